@@ -0,0 +1,150 @@
+package accesslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+// Logger writes one JSON line per proxied call.
+// Each line is written directly to the underlying writer, so nothing is
+// buffered in memory between calls.
+type Logger struct {
+	mu   sync.Mutex
+	w    io.Writer
+	path string   // destination path; empty when not file-backed
+	file *os.File // non-nil when the logger owns the destination file
+}
+
+type entry struct {
+	Time          string  `json:"time"`
+	ID            string  `json:"id"`
+	Method        string  `json:"method"`
+	CallType      string  `json:"call_type"`
+	Protocol      string  `json:"protocol"`
+	Status        int32   `json:"status"`
+	StatusName    string  `json:"status_name"`
+	Error         string  `json:"error,omitempty"`
+	DurationMs    float64 `json:"duration_ms"`
+	TTFBMs        float64 `json:"ttfb_ms,omitempty"`
+	RequestBytes  int64   `json:"request_bytes"`
+	ResponseBytes int64   `json:"response_bytes"`
+	Peer          string  `json:"peer,omitempty"`
+	Authority     string  `json:"authority,omitempty"`
+	CorrelationID string  `json:"correlation_id,omitempty"`
+}
+
+// New creates a Logger that writes to w.
+func New(w io.Writer) *Logger {
+	return &Logger{w: w}
+}
+
+// Open creates a Logger that appends to the file at path.
+// A path of "-" writes to stdout.
+func Open(path string) (*Logger, error) {
+	if path == "-" {
+		return New(os.Stdout), nil
+	}
+	f, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Logger{w: f, path: path, file: f}, nil
+}
+
+func openFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600) //nolint:gosec // G304: path is operator-configured
+	if err != nil {
+		return nil, fmt.Errorf("accesslog: open %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Log writes a single access log line for ev.
+func (l *Logger) Log(ev proxy.Event) error {
+	b, err := json.Marshal(entry{
+		Time:          ev.StartTime.UTC().Format(time.RFC3339Nano),
+		ID:            ev.ID,
+		Method:        ev.Method,
+		CallType:      ev.CallType.String(),
+		Protocol:      ev.Protocol.String(),
+		Status:        ev.Status,
+		StatusName:    proxy.FormatStatus(ev.Status),
+		Error:         ev.Error,
+		DurationMs:    float64(ev.Duration.Microseconds()) / 1000,
+		TTFBMs:        float64(ev.TimeToFirstByte.Microseconds()) / 1000,
+		RequestBytes:  ev.RequestSize,
+		ResponseBytes: ev.ResponseSize,
+		Peer:          ev.PeerAddr,
+		Authority:     ev.Authority,
+		CorrelationID: ev.CorrelationID,
+	})
+	if err != nil {
+		return fmt.Errorf("accesslog: marshal: %w", err)
+	}
+	b = append(b, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.w.Write(b); err != nil {
+		return fmt.Errorf("accesslog: write: %w", err)
+	}
+	return nil
+}
+
+// Run logs every completed call received from ch until ch is closed; progress
+// events of in-flight streams are skipped so each call is logged once.
+// Write errors are reported via onError (if non-nil) and do not stop the loop.
+func (l *Logger) Run(ch <-chan proxy.Event, onError func(error)) {
+	for ev := range ch {
+		if ev.Phase != proxy.PhaseComplete {
+			continue
+		}
+		if err := l.Log(ev); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// Reopen closes and reopens the destination file so that writes go to a
+// fresh file handle after external rotation (e.g. logrotate on SIGHUP).
+// It is a no-op for loggers that are not file-backed.
+func (l *Logger) Reopen() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.path == "" {
+		return nil
+	}
+	f, err := openFile(l.path)
+	if err != nil {
+		return err
+	}
+	if l.file != nil {
+		_ = l.file.Close()
+	}
+	l.w = f
+	l.file = f
+	return nil
+}
+
+// Close closes the destination file if the logger owns one.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("accesslog: close: %w", err)
+	}
+	l.file = nil
+	return nil
+}
@@ -0,0 +1,163 @@
+package accesslog_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mickamy/grpc-tap/accesslog"
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+func TestLogger_Log(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	l := accesslog.New(&buf)
+
+	ev := proxy.Event{
+		ID:           "ev-1",
+		Method:       "/test.Service/Hello",
+		CallType:     proxy.Unary,
+		Protocol:     proxy.ProtocolGRPC,
+		StartTime:    time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Duration:     1500 * time.Microsecond,
+		Status:       5,
+		Error:        "not found",
+		RequestSize:  12,
+		ResponseSize: 34,
+	}
+	if err := l.Log(ev); err != nil {
+		t.Fatal(err)
+	}
+
+	line := buf.String()
+	if !strings.HasSuffix(line, "\n") || strings.Count(line, "\n") != 1 {
+		t.Fatalf("want exactly one line, got %q", line)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal([]byte(line), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	want := map[string]any{
+		"time":           "2025-01-02T03:04:05Z",
+		"id":             "ev-1",
+		"method":         "/test.Service/Hello",
+		"call_type":      "Unary",
+		"protocol":       "gRPC",
+		"status":         float64(5),
+		"status_name":    "NotFound(5)",
+		"error":          "not found",
+		"duration_ms":    1.5,
+		"request_bytes":  float64(12),
+		"response_bytes": float64(34),
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestLogger_Run(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "access.log")
+	l, err := accesslog.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan proxy.Event, 4)
+	ch <- proxy.Event{ID: "a", Method: "/test.Service/Hello"}
+	ch <- proxy.Event{ID: "b", Method: "/test.Service/Stream", Phase: proxy.PhaseProgress}
+	ch <- proxy.Event{ID: "b", Method: "/test.Service/Stream"}
+	ch <- proxy.Event{ID: "c", Method: "/test.Service/Hello"}
+	close(ch)
+
+	l.Run(ch, func(err error) { t.Errorf("log: %v", err) })
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var ids []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("unmarshal %q: %v", sc.Text(), err)
+		}
+		ids = append(ids, e.ID)
+	}
+	if strings.Join(ids, ",") != "a,b,c" {
+		t.Errorf("ids = %v, want [a b c]", ids)
+	}
+}
+
+func TestLogger_Reopen(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	rotated := filepath.Join(dir, "access.log.1")
+
+	l, err := accesslog.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+
+	if err := l.Log(proxy.Event{ID: "before"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate logrotate: move the file away, then signal a reopen.
+	if err := os.Rename(path, rotated); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Log(proxy.Event{ID: "after"}); err != nil {
+		t.Fatal(err)
+	}
+
+	old, err := os.ReadFile(rotated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(old), `"before"`) || strings.Contains(string(old), `"after"`) {
+		t.Errorf("rotated file = %q, want only the event written before reopen", old)
+	}
+
+	cur, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(cur), `"after"`) || strings.Contains(string(cur), `"before"`) {
+		t.Errorf("new file = %q, want only the event written after reopen", cur)
+	}
+}
+
+func TestLogger_ReopenStdout(t *testing.T) {
+	t.Parallel()
+
+	l := accesslog.New(&bytes.Buffer{})
+	if err := l.Reopen(); err != nil {
+		t.Errorf("Reopen on non-file logger: %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/mickamy/grpc-tap/compare"
+	"github.com/mickamy/grpc-tap/filter"
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+	"github.com/mickamy/grpc-tap/proxy"
+	"github.com/mickamy/grpc-tap/web"
+)
+
+// runAssert replays every completed call in the capture file at path through
+// the daemon at target and compares each response with the captured one. The
+// file holds a JSON array of events as served by the web UI's
+// /api/events/history. Only calls matching f are replayed. Calls whose bodies
+// were not captured in full are skipped, as their replay would send a
+// different request. It reports per call to out and returns how many calls
+// did not match.
+func runAssert(
+	ctx context.Context, target, path string, f *filter.Filter, mode compare.Mode, out io.Writer,
+) (int, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is user-provided
+	if err != nil {
+		return 0, fmt.Errorf("assert: %w", err)
+	}
+	var events []web.EventJSON
+	if err := json.Unmarshal(data, &events); err != nil {
+		return 0, fmt.Errorf("assert: parse %s: %w", path, err)
+	}
+
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return 0, fmt.Errorf("assert: dial %s: %w", target, err)
+	}
+	defer func() { _ = conn.Close() }()
+	client := tapv1.NewTapServiceClient(conn)
+
+	passed, failed, skipped := 0, 0, 0
+	for _, ev := range events {
+		if ev.Phase != proxy.PhaseComplete.String() || ev.Method == "" {
+			continue
+		}
+		if !f.Empty() && !f.Evaluate(filterEvent(ev)) {
+			continue
+		}
+		if reason := skipReason(ev); reason != "" {
+			skipped++
+			_, _ = fmt.Fprintf(out, "SKIP  %s (%s): %s\n", ev.Method, ev.ID, reason)
+			continue
+		}
+		result, err := assertCall(ctx, client, ev, mode)
+		if err != nil {
+			return failed, fmt.Errorf("assert: %s (%s): %w", ev.Method, ev.ID, err)
+		}
+		if result.Match {
+			passed++
+			_, _ = fmt.Fprintf(out, "PASS  %s (%s)\n", ev.Method, ev.ID)
+			continue
+		}
+		failed++
+		_, _ = fmt.Fprintf(out, "FAIL  %s (%s): %s\n", ev.Method, ev.ID, result.Reason)
+		for _, line := range result.Diff {
+			_, _ = fmt.Fprintf(out, "      %s\n", line)
+		}
+	}
+	_, _ = fmt.Fprintf(out, "%d passed, %d failed, %d skipped\n", passed, failed, skipped)
+	return failed, nil
+}
+
+// skipReason tells why ev cannot be replayed faithfully, or returns "" if it
+// can.
+func skipReason(ev web.EventJSON) string {
+	switch {
+	case ev.BodyCaptureDisabled:
+		return "body capture was disabled"
+	case ev.BodyTruncated:
+		return "body was truncated at the capture size"
+	}
+	return ""
+}
+
+// assertCall replays ev with its original protocol and content type and
+// compares the response with the captured one.
+func assertCall(ctx context.Context, client tapv1.TapServiceClient, ev web.EventJSON, mode compare.Mode) (compare.Result, error) {
+	reqBody, err := base64.StdEncoding.DecodeString(ev.RequestBody)
+	if err != nil {
+		return compare.Result{}, fmt.Errorf("decode request body: %w", err)
+	}
+	respBody, err := base64.StdEncoding.DecodeString(ev.ResponseBody)
+	if err != nil {
+		return compare.Result{}, fmt.Errorf("decode response body: %w", err)
+	}
+	p, err := proxy.ParseProtocol(ev.Protocol)
+	if err != nil {
+		return compare.Result{}, err //nolint:wrapcheck // already names the protocol
+	}
+
+	resp, err := client.Replay(ctx, &tapv1.ReplayRequest{
+		Method:      ev.Method,
+		RequestBody: reqBody,
+		Protocol:    protocolToProto(p),
+		ContentType: ev.RequestHeaders["Content-Type"],
+	})
+	if err != nil {
+		return compare.Result{}, fmt.Errorf("replay: %w", err)
+	}
+	got := resp.GetEvent()
+	return compare.Compare(mode,
+		compare.Response{Status: ev.Status, Body: respBody},
+		compare.Response{Status: got.GetStatus(), Body: got.GetResponseBody()},
+	), nil
+}
+
+func protocolToProto(p proxy.Protocol) tapv1.Protocol {
+	switch p {
+	case proxy.ProtocolGRPCWeb:
+		return tapv1.Protocol_PROTOCOL_GRPC_WEB
+	case proxy.ProtocolConnect:
+		return tapv1.Protocol_PROTOCOL_CONNECT
+	default:
+		return tapv1.Protocol_PROTOCOL_GRPC
+	}
+}
+
+// filterEvent returns the filter fields of a captured call. Bodies that are
+// not valid base64 are left out.
+func filterEvent(ev web.EventJSON) filter.Event {
+	headers := make(map[string][]string)
+	for _, h := range []map[string]string{ev.RequestHeaders, ev.ResponseHeaders, ev.ResponseTrailers} {
+		for k, v := range h {
+			k = strings.ToLower(k)
+			headers[k] = append(headers[k], v)
+		}
+	}
+	reqBody, _ := base64.StdEncoding.DecodeString(ev.RequestBody)
+	respBody, _ := base64.StdEncoding.DecodeString(ev.ResponseBody)
+	return filter.Event{
+		Method:        ev.Method,
+		Protocol:      ev.Protocol,
+		CallType:      ev.CallType,
+		Status:        ev.Status,
+		Duration:      time.Duration(ev.DurationMs * float64(time.Millisecond)),
+		Peer:          ev.PeerAddr,
+		Authority:     ev.Authority,
+		CorrelationID: ev.CorrelationID,
+		Headers:       headers,
+		RequestBody:   reqBody,
+		ResponseBody:  respBody,
+	}
+}
@@ -2,6 +2,7 @@ package broker
 
 import (
 	"sync"
+	"time"
 
 	"github.com/mickamy/grpc-tap/proxy"
 )
@@ -13,13 +14,48 @@ type Broker struct {
 	subscribers map[int]chan proxy.Event
 	nextID      int
 	bufSize     int
+
+	// The backlog is a ring of the last completed events. Publish appends
+	// to it under mu.RLock, so it has its own lock; holding mu.Lock keeps
+	// it stable.
+	backlogMu   sync.Mutex
+	backlogSize int
+	backlog     []Entry
+	backlogNext int    // index of the oldest event once the ring is full
+	nextSeq     uint64 // sequence number of the next retained event
+
+	dedup *deduper // drops repeated calls; nil to publish every call
+}
+
+// Entry is an event in the backlog. Sequence numbers start at 1 and grow with
+// every retained event; they are never reused, not even after Clear, so they
+// order events and page through the backlog reliably.
+type Entry struct {
+	Seq   uint64
+	Event proxy.Event
+}
+
+// Option configures a Broker.
+type Option func(*Broker)
+
+// WithBacklog keeps the last n completed events, so that new subscribers can
+// catch up on recent traffic with SubscribeWithBacklog. Zero, the default,
+// keeps nothing.
+func WithBacklog(n int) Option {
+	return func(b *Broker) {
+		b.backlogSize = max(n, 0)
+	}
 }
 
-func New(bufSize int) *Broker {
-	return &Broker{
+func New(bufSize int, opts ...Option) *Broker {
+	b := &Broker{
 		subscribers: make(map[int]chan proxy.Event),
 		bufSize:     bufSize,
 	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
 }
 
 // Subscribe returns a channel that receives published events
@@ -28,6 +64,22 @@ func (b *Broker) Subscribe() (<-chan proxy.Event, func()) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	return b.subscribe()
+}
+
+// SubscribeWithBacklog is Subscribe, but also returns the backlog at the time
+// of subscribing, oldest first. Every event published afterwards goes to the
+// channel, so nothing falls between the two.
+func (b *Broker) SubscribeWithBacklog() ([]proxy.Event, <-chan proxy.Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch, unsub := b.subscribe()
+	return b.Backlog(), ch, unsub
+}
+
+// subscribe registers a subscriber. b.mu must be held.
+func (b *Broker) subscribe() (<-chan proxy.Event, func()) {
 	id := b.nextID
 	b.nextID++
 
@@ -47,10 +99,28 @@ func (b *Broker) Subscribe() (<-chan proxy.Event, func()) {
 
 // Publish sends an event to all subscribers.
 // If a subscriber's buffer is full, the event is dropped for that subscriber.
+// With WithDedup, repeats of a recent call are dropped for all of them.
 func (b *Broker) Publish(ev proxy.Event) {
+	if b.dedup != nil && !b.dedup.allow(ev, time.Now()) {
+		return
+	}
+
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
+	if b.backlogSize > 0 && ev.Phase == proxy.PhaseComplete {
+		b.backlogMu.Lock()
+		b.nextSeq++
+		e := Entry{Seq: b.nextSeq, Event: ev}
+		if len(b.backlog) < b.backlogSize {
+			b.backlog = append(b.backlog, e)
+		} else {
+			b.backlog[b.backlogNext] = e
+			b.backlogNext = (b.backlogNext + 1) % b.backlogSize
+		}
+		b.backlogMu.Unlock()
+	}
+
 	for _, ch := range b.subscribers {
 		select {
 		case ch <- ev:
@@ -60,6 +130,39 @@ func (b *Broker) Publish(ev proxy.Event) {
 	}
 }
 
+// Backlog returns the retained events, oldest first.
+func (b *Broker) Backlog() []proxy.Event {
+	entries := b.History()
+	events := make([]proxy.Event, len(entries))
+	for i, e := range entries {
+		events[i] = e.Event
+	}
+	return events
+}
+
+// History returns the retained events with their sequence numbers, oldest
+// first.
+func (b *Broker) History() []Entry {
+	b.backlogMu.Lock()
+	defer b.backlogMu.Unlock()
+
+	entries := make([]Entry, 0, len(b.backlog))
+	entries = append(entries, b.backlog[b.backlogNext:]...)
+	return append(entries, b.backlog[:b.backlogNext]...)
+}
+
+// Clear drops the backlog and returns how many events it held. Subscribers
+// are not affected.
+func (b *Broker) Clear() int {
+	b.backlogMu.Lock()
+	defer b.backlogMu.Unlock()
+
+	n := len(b.backlog)
+	b.backlog = nil
+	b.backlogNext = 0
+	return n
+}
+
 // SubscriberCount returns the number of active subscribers.
 func (b *Broker) SubscriberCount() int {
 	b.mu.RLock()
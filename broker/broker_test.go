@@ -111,3 +111,173 @@ func TestBroker_DropOnFullBuffer(t *testing.T) {
 		// expected: buffer was full, second event dropped
 	}
 }
+
+func TestBroker_Backlog(t *testing.T) {
+	t.Parallel()
+
+	ids := func(events []proxy.Event) string {
+		var s string
+		for _, ev := range events {
+			s += ev.ID
+		}
+		return s
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		t.Parallel()
+		b := broker.New(8)
+		b.Publish(proxy.Event{ID: "1"})
+		if got := b.Backlog(); len(got) != 0 {
+			t.Errorf("Backlog() = %d events, want 0", len(got))
+		}
+	})
+
+	t.Run("keeps the last n completed events", func(t *testing.T) {
+		t.Parallel()
+		b := broker.New(8, broker.WithBacklog(3))
+		for _, id := range []string{"1", "2", "3", "4", "5"} {
+			b.Publish(proxy.Event{ID: id})
+		}
+		b.Publish(proxy.Event{ID: "6", Phase: proxy.PhaseStart})
+		b.Publish(proxy.Event{ID: "6", Phase: proxy.PhaseProgress})
+		if got := ids(b.Backlog()); got != "345" {
+			t.Errorf("Backlog() = %s, want 345", got)
+		}
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		t.Parallel()
+		b := broker.New(8, broker.WithBacklog(3))
+		for _, id := range []string{"1", "2", "3", "4"} {
+			b.Publish(proxy.Event{ID: id})
+		}
+		if n := b.Clear(); n != 3 {
+			t.Errorf("Clear() = %d, want 3", n)
+		}
+		if got := b.Backlog(); len(got) != 0 {
+			t.Errorf("Backlog() after Clear = %d events, want 0", len(got))
+		}
+		b.Publish(proxy.Event{ID: "5"})
+		if got := ids(b.Backlog()); got != "5" {
+			t.Errorf("Backlog() = %s, want 5", got)
+		}
+	})
+
+	t.Run("history sequence numbers", func(t *testing.T) {
+		t.Parallel()
+		b := broker.New(8, broker.WithBacklog(2))
+		for _, id := range []string{"1", "2", "3"} {
+			b.Publish(proxy.Event{ID: id})
+		}
+		b.Publish(proxy.Event{ID: "4", Phase: proxy.PhaseStart})
+		h := b.History()
+		if len(h) != 2 || h[0].Seq != 2 || h[0].Event.ID != "2" || h[1].Seq != 3 || h[1].Event.ID != "3" {
+			t.Errorf("History() = %+v, want 2:2 and 3:3", h)
+		}
+
+		b.Clear()
+		b.Publish(proxy.Event{ID: "5"})
+		if h := b.History(); len(h) != 1 || h[0].Seq != 4 {
+			t.Errorf("History() after Clear = %+v, want seq 4 (never reused)", h)
+		}
+	})
+
+	t.Run("subscribe with backlog", func(t *testing.T) {
+		t.Parallel()
+		b := broker.New(8, broker.WithBacklog(3))
+		b.Publish(proxy.Event{ID: "1"})
+		backlog, ch, unsub := b.SubscribeWithBacklog()
+		defer unsub()
+		b.Publish(proxy.Event{ID: "2"})
+
+		if got := ids(backlog); got != "1" {
+			t.Errorf("backlog = %s, want 1", got)
+		}
+		select {
+		case ev := <-ch:
+			if ev.ID != "2" {
+				t.Errorf("got ID %q, want 2", ev.ID)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+	})
+}
+
+func TestBroker_Dedup(t *testing.T) {
+	t.Parallel()
+
+	call := func(id, method string, status int32, resp string) proxy.Event {
+		return proxy.Event{ID: id, Method: method, Status: status, RequestBody: []byte("req"), ResponseBody: []byte(resp)}
+	}
+	published := func(b *broker.Broker, events ...proxy.Event) string {
+		ch, unsub := b.Subscribe()
+		defer unsub()
+		for _, ev := range events {
+			b.Publish(ev)
+		}
+		var s string
+		for range len(events) {
+			select {
+			case ev := <-ch:
+				s += ev.ID
+			default:
+				return s
+			}
+		}
+		return s
+	}
+
+	t.Run("drops duplicates within the window", func(t *testing.T) {
+		t.Parallel()
+		b := broker.New(16, broker.WithDedup(time.Hour), broker.WithBacklog(16))
+		got := published(b,
+			call("1", "/pkg.Svc/Get", 14, "a"),
+			call("2", "/pkg.Svc/Get", 14, "a"),  // duplicate
+			call("3", "/pkg.Svc/Get", 14, "b"),  // other response
+			call("4", "/pkg.Svc/Get", 0, "a"),   // other status
+			call("5", "/pkg.Svc/List", 14, "a"), // other method
+			call("6", "/pkg.Svc/List", 14, "a"), // duplicate
+		)
+		if got != "1345" {
+			t.Errorf("published %s, want 1345", got)
+		}
+		if n := len(b.Backlog()); n != 4 {
+			t.Errorf("backlog holds %d events, want the 4 published", n)
+		}
+	})
+
+	t.Run("keeps calls published in flight", func(t *testing.T) {
+		t.Parallel()
+		b := broker.New(16, broker.WithDedup(time.Hour))
+		start := call("2", "/pkg.Svc/Get", 0, "")
+		start.Phase = proxy.PhaseStart
+		got := published(b,
+			call("1", "/pkg.Svc/Get", 0, "a"),
+			start,
+			call("2", "/pkg.Svc/Get", 0, "a"),
+		)
+		if got != "122" {
+			t.Errorf("published %s, want 122", got)
+		}
+	})
+
+	t.Run("passes repeats after the window", func(t *testing.T) {
+		t.Parallel()
+		const window = 20 * time.Millisecond
+		b := broker.New(16, broker.WithDedup(window))
+		first := published(b, call("1", "/pkg.Svc/Get", 0, "a"))
+		time.Sleep(2 * window)
+		if got := first + published(b, call("2", "/pkg.Svc/Get", 0, "a")); got != "12" {
+			t.Errorf("published %s, want 12", got)
+		}
+	})
+
+	t.Run("off by default", func(t *testing.T) {
+		t.Parallel()
+		b := broker.New(16)
+		if got := published(b, call("1", "/pkg.Svc/Get", 0, "a"), call("2", "/pkg.Svc/Get", 0, "a")); got != "12" {
+			t.Errorf("published %s, want 12", got)
+		}
+	})
+}
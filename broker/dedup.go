@@ -0,0 +1,82 @@
+package broker
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+// WithDedup drops calls identical to one published less than window before:
+// same method, status, request body and response body, as retries of a
+// flaky call tend to be. Only short calls are dropped, whole; a call that
+// already published start or progress events is always completed. Zero, the
+// default, publishes every call.
+func WithDedup(window time.Duration) Option {
+	return func(b *Broker) {
+		b.dedup = nil
+		if window > 0 {
+			b.dedup = &deduper{
+				window: window,
+				seen:   make(map[uint64]time.Time),
+				open:   make(map[string]bool),
+			}
+		}
+	}
+}
+
+// deduper remembers the calls published within its window.
+type deduper struct {
+	mu     sync.Mutex
+	window time.Duration
+	seen   map[uint64]time.Time // callKey → when a call with it was last published
+	expiry []seenCall           // entries of seen, oldest first
+	open   map[string]bool      // IDs of calls whose start or progress was published
+}
+
+// seenCall is a call published at a time, queued to expire from seen.
+type seenCall struct {
+	key uint64
+	at  time.Time
+}
+
+// allow reports whether ev, arriving at now, is to be published.
+func (d *deduper) allow(ev proxy.Event, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if ev.Phase != proxy.PhaseComplete {
+		d.open[ev.ID] = true
+		return true
+	}
+	for len(d.expiry) > 0 && now.Sub(d.expiry[0].at) >= d.window {
+		// A key published again since is queued again, and expires then.
+		if e := d.expiry[0]; d.seen[e.key].Equal(e.at) {
+			delete(d.seen, e.key)
+		}
+		d.expiry = d.expiry[1:]
+	}
+	key := callKey(ev)
+	if d.open[ev.ID] {
+		// Dropping the final event would leave the call in flight forever.
+		delete(d.open, ev.ID)
+	} else if _, ok := d.seen[key]; ok {
+		return false
+	}
+	d.seen[key] = now
+	d.expiry = append(d.expiry, seenCall{key: key, at: now})
+	return true
+}
+
+// callKey hashes what makes two calls identical for dedup.
+func callKey(ev proxy.Event) uint64 {
+	h := fnv.New64a()
+	for _, b := range [][]byte{[]byte(ev.Method), ev.RequestBody, ev.ResponseBody} {
+		_ = binary.Write(h, binary.BigEndian, uint64(len(b)))
+		_, _ = h.Write(b)
+	}
+	_ = binary.Write(h, binary.BigEndian, ev.Status)
+	return h.Sum64()
+}
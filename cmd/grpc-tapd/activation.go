@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// listenFDsStart is the first file descriptor systemd passes to a
+// socket-activated service.
+const listenFDsStart = 3
+
+// listenerNames are the FileDescriptorName= values grpc-tapd recognizes, one
+// per listening flag.
+var listenerNames = []string{"proxy", "grpc", "http", "combined"}
+
+// activationListeners returns the listeners systemd passed to the process by
+// socket activation, keyed by name: proxy, grpc, http or combined. It returns
+// nil when the process was not socket-activated, so the flags' addresses are
+// used instead. The activation variables are unset so that child processes
+// do not take the listeners for their own.
+func activationListeners() (map[string]net.Listener, error) {
+	names, err := parseListenEnv(os.Getenv, os.Getpid())
+	if err != nil || names == nil {
+		return nil, err
+	}
+	for _, v := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+		_ = os.Unsetenv(v)
+	}
+
+	files := make([]*os.File, len(names))
+	for i, name := range names {
+		fd := listenFDsStart + i
+		syscall.CloseOnExec(fd)
+		files[i] = os.NewFile(uintptr(fd), name)
+	}
+	return listenersFromFiles(names, files)
+}
+
+// parseListenEnv reads the socket activation variables of the sd_listen_fds
+// protocol and returns the name of each passed descriptor, or nil if they are
+// unset or meant for another process.
+func parseListenEnv(getenv func(string) string, pid int) ([]string, error) {
+	if getenv("LISTEN_PID") != strconv.Itoa(pid) {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(getenv("LISTEN_FDS"))
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("socket activation: invalid LISTEN_FDS %q", getenv("LISTEN_FDS"))
+	}
+	names := make([]string, n)
+	if v := getenv("LISTEN_FDNAMES"); v != "" {
+		copy(names, strings.Split(v, ":"))
+	}
+	return names, nil
+}
+
+// listenersFromFiles turns the passed descriptors into listeners keyed by
+// name. A single descriptor without a recognized name, as passed by a socket
+// unit with one ListenStream= and no FileDescriptorName=, is the proxy's.
+func listenersFromFiles(names []string, files []*os.File) (map[string]net.Listener, error) {
+	listeners := make(map[string]net.Listener, len(files))
+	fail := func(err error) (map[string]net.Listener, error) {
+		for _, lis := range listeners {
+			_ = lis.Close()
+		}
+		return nil, err
+	}
+	for i, f := range files {
+		name := names[i]
+		switch {
+		case slices.Contains(listenerNames, name):
+		case len(files) == 1:
+			name = "proxy"
+		default:
+			return fail(fmt.Errorf("socket activation: descriptor %d is named %q, want one of %s",
+				listenFDsStart+i, name, strings.Join(listenerNames, ", ")))
+		}
+		if _, ok := listeners[name]; ok {
+			return fail(fmt.Errorf("socket activation: more than one %s descriptor", name))
+		}
+		lis, err := net.FileListener(f)
+		if err != nil {
+			return fail(fmt.Errorf("socket activation: %s descriptor: %w", name, err))
+		}
+		// FileListener duplicates the descriptor; the original is not needed.
+		_ = f.Close()
+		listeners[name] = lis
+	}
+	return listeners, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestParseListenEnv(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    []string
+		wantErr bool
+	}{
+		{name: "not activated", env: map[string]string{}},
+		{name: "other process", env: map[string]string{"LISTEN_PID": "1", "LISTEN_FDS": "1"}},
+		{name: "unnamed", env: map[string]string{"LISTEN_PID": "42", "LISTEN_FDS": "1"}, want: []string{""}},
+		{
+			name: "named",
+			env:  map[string]string{"LISTEN_PID": "42", "LISTEN_FDS": "2", "LISTEN_FDNAMES": "proxy:http"},
+			want: []string{"proxy", "http"},
+		},
+		{name: "invalid count", env: map[string]string{"LISTEN_PID": "42", "LISTEN_FDS": "x"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseListenEnv(func(k string) string { return tt.env[k] }, 42)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("names = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// passedListener opens a listener and returns a duplicate of its descriptor,
+// as systemd would pass it.
+func passedListener(t *testing.T) (*os.File, string) {
+	t.Helper()
+	var lc net.ListenConfig
+	lis, err := lc.Listen(t.Context(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lis.Close() }()
+	f, err := lis.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, lis.Addr().String()
+}
+
+func TestListenersFromFiles(t *testing.T) {
+	t.Parallel()
+
+	t.Run("named", func(t *testing.T) {
+		t.Parallel()
+		proxyFile, proxyAddr := passedListener(t)
+		httpFile, httpAddr := passedListener(t)
+		listeners, err := listenersFromFiles([]string{"proxy", "http"}, []*os.File{proxyFile, httpFile})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, addr := range map[string]string{"proxy": proxyAddr, "http": httpAddr} {
+			lis := listeners[name]
+			if lis == nil {
+				t.Fatalf("no %s listener", name)
+			}
+			t.Cleanup(func() { _ = lis.Close() })
+			if lis.Addr().String() != addr {
+				t.Errorf("%s listener on %s, want %s", name, lis.Addr(), addr)
+			}
+			var d net.Dialer
+			conn, err := d.DialContext(t.Context(), "tcp", addr)
+			if err != nil {
+				t.Fatalf("dial %s listener: %v", name, err)
+			}
+			_ = conn.Close()
+		}
+	})
+
+	t.Run("single unnamed is the proxy", func(t *testing.T) {
+		t.Parallel()
+		f, _ := passedListener(t)
+		listeners, err := listenersFromFiles([]string{"grpc-tapd.socket"}, []*os.File{f})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if listeners["proxy"] == nil {
+			t.Fatalf("listeners = %v, want a proxy listener", listeners)
+		}
+		_ = listeners["proxy"].Close()
+	})
+
+	t.Run("unknown names", func(t *testing.T) {
+		t.Parallel()
+		a, _ := passedListener(t)
+		b, _ := passedListener(t)
+		if _, err := listenersFromFiles([]string{"", ""}, []*os.File{a, b}); err == nil {
+			t.Error("accepted two unnamed descriptors")
+		}
+	})
+
+	t.Run("duplicate names", func(t *testing.T) {
+		t.Parallel()
+		a, _ := passedListener(t)
+		b, _ := passedListener(t)
+		if _, err := listenersFromFiles([]string{"http", "http"}, []*os.File{a, b}); err == nil {
+			t.Error("accepted two http descriptors")
+		}
+	})
+}
@@ -4,16 +4,21 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/mickamy/grpc-tap/accesslog"
 	"github.com/mickamy/grpc-tap/broker"
+	"github.com/mickamy/grpc-tap/forward"
 	"github.com/mickamy/grpc-tap/proxy"
 	"github.com/mickamy/grpc-tap/server"
+	"github.com/mickamy/grpc-tap/stats"
+	"github.com/mickamy/grpc-tap/tracing"
 	"github.com/mickamy/grpc-tap/web"
 )
 
@@ -30,6 +35,62 @@ func main() {
 	upstream := fs.String("upstream", "", "upstream gRPC server address (required)")
 	grpcAddr := fs.String("grpc", ":9092", "gRPC server address for TUI")
 	httpAddr := fs.String("http", "", "HTTP server address for web UI (e.g. :8080)")
+	combinedAddr := fs.String("combined", "", "serve both the gRPC API for TUI and the web UI on this address (e.g. :8081)")
+	accessLog := fs.String("access-log", "", "write a JSON access log line per call to this file (\"-\" for stdout)")
+	upstreamHTTP1 := fs.Bool("upstream-http1", false, "talk HTTP/1.1 to the upstream (e.g. grpc-gateway) instead of h2c")
+	connectTimeout := fs.Duration("connect-timeout", 10*time.Second, "timeout for establishing upstream connections (0 for the OS default)")
+	upstreamMaxConns := fs.Int("upstream-max-conns", 0, "max open upstream connections (0 for no limit)")
+	upstreamIdleTimeout := fs.Duration("upstream-idle-timeout", 0, "close upstream connections idle for this long (0 for the transport default)")
+	replayUpstreams := map[string]string{}
+	fs.Func("replay-upstream", "allow replays to be sent to `name=url` instead of -upstream (repeatable)", func(s string) error {
+		name, addr, ok := strings.Cut(s, "=")
+		if !ok || name == "" || addr == "" {
+			return fmt.Errorf("want name=url, got %q", s)
+		}
+		replayUpstreams[name] = addr
+		return nil
+	})
+	upstreamHeaders := map[string]string{}
+	fs.Func("upstream-header", "set the header `name:value` on every call sent upstream (repeatable)", func(s string) error {
+		name, value, ok := strings.Cut(s, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("want name:value, got %q", s)
+		}
+		upstreamHeaders[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		return nil
+	})
+	upstreamAuthority := fs.String("upstream-authority", "",
+		"send calls upstream with this Host/:authority, e.g. for an upstream addressed by IP behind a virtual-hosting ingress")
+	var pathRewrites []pathRewrite
+	fs.Func("strip-prefix", "strip this path `prefix` (e.g. /internal) from calls before sending them upstream (repeatable)", func(s string) error {
+		pathRewrites = append(pathRewrites, pathRewrite{from: s})
+		return nil
+	})
+	fs.Func("rewrite-path", "replace the path prefix `from=to` on calls sent upstream (repeatable; the first match applies)", func(s string) error {
+		from, to, ok := strings.Cut(s, "=")
+		if !ok || from == "" {
+			return fmt.Errorf("want from=to, got %q", s)
+		}
+		pathRewrites = append(pathRewrites, pathRewrite{from: from, to: to})
+		return nil
+	})
+	replayTimeout := fs.Duration("replay-timeout", 0, "abort replays the upstream has not answered within this long (0 for no timeout)")
+	maxCaptureSize := fs.Int("max-capture-size", proxy.MaxCaptureSize, "bytes retained per request/response body")
+	noBodyCapture := fs.Bool("no-body-capture", false, "capture timing, status and headers only; never retain request/response bodies")
+	retryIdempotent := fs.String("retry-idempotent", "", "comma-separated idempotent methods (or /pkg.Service/ prefixes) to retry once when the upstream resets them (unary calls only)")
+	trustForwarded := fs.Bool("trust-forwarded", false, "take client addresses from Forwarded/X-Forwarded-For headers (only behind a load balancer that sets them)")
+	correlationHeader := fs.String("correlation-header", proxy.DefaultCorrelationHeader,
+		"request header whose value identifies calls across services, e.g. x-request-id (empty to disable)")
+	backlog := fs.Int("backlog", 500, "number of recent calls kept for clients that connect later (0 to disable)")
+	dedup := fs.Duration("dedup", 0, "drop calls identical (method, status and bodies) to one seen within this long, e.g. 200ms (0 to disable)")
+	readOnly := fs.Bool("read-only", false, "reject replay and clear requests from clients")
+	statsWindow := fs.Duration("stats-window", stats.DefaultWindow, "how far back the GetStats RPC aggregates calls")
+	webhook := fs.String("webhook", "", "POST events as JSON batches to this URL")
+	otlpEndpoint := fs.String("otlp-endpoint", "", "export a span per call to this OTLP/gRPC collector (e.g. http://localhost:4317)")
+	webhookErrorsOnly := fs.Bool("webhook-errors-only", false, "only forward events with a non-OK status to -webhook")
+	readyFile := fs.String("ready-file", "", "write the proxy's listen address to this file once it accepts connections, for scripts to wait on")
+	logLevel := fs.String("log-level", "info", "log level (debug, info, warn, error)")
+	quiet := fs.Bool("quiet", false, "only log errors (same as -log-level=error)")
 	showVersion := fs.Bool("version", false, "show version and exit")
 
 	_ = fs.Parse(os.Args[1:])
@@ -39,54 +100,308 @@ func main() {
 		return
 	}
 
-	if *listen == "" || *upstream == "" {
+	listeners, err := activationListeners()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if (*listen == "" && listeners["proxy"] == nil) || *upstream == "" {
 		fs.Usage()
 		os.Exit(1)
 	}
 
-	if err := run(*listen, *upstream, *grpcAddr, *httpAddr); err != nil {
-		log.Fatal(err)
+	// -combined replaces the default gRPC listener; an explicit -grpc keeps it.
+	if *combinedAddr != "" || listeners["combined"] != nil {
+		grpcSet := false
+		fs.Visit(func(f *flag.Flag) {
+			grpcSet = grpcSet || f.Name == "grpc"
+		})
+		if !grpcSet {
+			*grpcAddr = ""
+		}
+	}
+
+	level, err := parseLogLevel(*logLevel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *quiet {
+		level = slog.LevelError
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+	cfg := config{
+		listen:            *listen,
+		upstream:          *upstream,
+		upstreamHTTP1:     *upstreamHTTP1,
+		connectTimeout:    *connectTimeout,
+		maxConns:          *upstreamMaxConns,
+		idleTimeout:       *upstreamIdleTimeout,
+		grpcAddr:          *grpcAddr,
+		httpAddr:          *httpAddr,
+		combinedAddr:      *combinedAddr,
+		accessLog:         *accessLog,
+		noBodyCapture:     *noBodyCapture,
+		trustForwarded:    *trustForwarded,
+		retryIdempotent:   splitList(*retryIdempotent),
+		correlationHeader: *correlationHeader,
+		replayUpstreams:   replayUpstreams,
+		upstreamHeaders:   upstreamHeaders,
+		upstreamAuthority: *upstreamAuthority,
+		pathRewrites:      pathRewrites,
+		replayTimeout:     *replayTimeout,
+		maxCaptureSize:    *maxCaptureSize,
+		statsWindow:       *statsWindow,
+		backlog:           *backlog,
+		dedup:             *dedup,
+		readOnly:          *readOnly,
+		webhook:           *webhook,
+		webhookErrorsOnly: *webhookErrorsOnly,
+		otlpEndpoint:      *otlpEndpoint,
+		readyFile:         *readyFile,
+		listeners:         listeners,
+	}
+	if err := run(cfg); err != nil {
+		slog.Error("grpc-tapd", "error", err)
+		os.Exit(1)
 	}
 }
 
-func run(listen, upstream, grpcAddr, httpAddr string) error {
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var items []string
+	for item := range strings.SplitSeq(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseLogLevel converts a -log-level flag value into a slog.Level.
+func parseLogLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q: must be one of debug, info, warn, error", s)
+	}
+	return level, nil
+}
+
+// config holds the parsed command-line flags.
+type config struct {
+	listen            string
+	upstream          string
+	upstreamHTTP1     bool
+	connectTimeout    time.Duration
+	maxConns          int
+	idleTimeout       time.Duration
+	grpcAddr          string
+	httpAddr          string
+	combinedAddr      string
+	accessLog         string
+	noBodyCapture     bool
+	trustForwarded    bool
+	retryIdempotent   []string
+	correlationHeader string
+	replayUpstreams   map[string]string // name → address
+	upstreamHeaders   map[string]string // name → value
+	upstreamAuthority string
+	pathRewrites      []pathRewrite // in flag order
+	replayTimeout     time.Duration
+	maxCaptureSize    int
+	statsWindow       time.Duration
+	backlog           int
+	dedup             time.Duration
+	readOnly          bool
+	webhook           string
+	webhookErrorsOnly bool
+	otlpEndpoint      string
+	readyFile         string
+	listeners         map[string]net.Listener // inherited by socket activation, by name
+}
+
+// openListener returns the listener passed by socket activation under name,
+// or else listens on addr. Both missing means the listener is disabled, and
+// it returns nil.
+func (cfg config) openListener(ctx context.Context, name, addr string) (net.Listener, error) {
+	if lis := cfg.listeners[name]; lis != nil {
+		return lis, nil
+	}
+	if addr == "" {
+		return nil, nil //nolint:nilnil // a disabled listener is not an error
+	}
+	lis, err := proxy.Listen(ctx, addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen %s: %w", name, err)
+	}
+	return lis, nil
+}
+
+// pathRewrite is a -strip-prefix or -rewrite-path flag.
+type pathRewrite struct {
+	from, to string
+}
+
+func run(cfg config) error {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
 	// Broker
-	b := broker.New(256)
+	b := broker.New(256, broker.WithBacklog(cfg.backlog), broker.WithDedup(cfg.dedup))
+
+	// Access log (optional)
+	if cfg.accessLog != "" {
+		al, err := accesslog.Open(cfg.accessLog)
+		if err != nil {
+			return fmt.Errorf("access log: %w", err)
+		}
+		ch, unsub := b.Subscribe()
+		go al.Run(ch, func(err error) {
+			slog.Error("access log", "error", err)
+		})
+		defer func() {
+			unsub()
+			_ = al.Close()
+		}()
+
+		// Reopen the access log on SIGHUP for logrotate compatibility.
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-hup:
+					if err := al.Reopen(); err != nil {
+						slog.Error("access log reopen", "error", err)
+						continue
+					}
+					slog.Info("access log reopened", "path", cfg.accessLog)
+				}
+			}
+		}()
+	}
+
+	// Webhook forwarding (optional)
+	if cfg.webhook != "" {
+		fw := forward.New(forward.Config{URL: cfg.webhook, ErrorsOnly: cfg.webhookErrorsOnly})
+		ch, unsub := b.Subscribe()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			fw.Run(ctx, ch)
+		}()
+		defer func() {
+			unsub()
+			<-done
+			if n := fw.Dropped(); n > 0 {
+				slog.Warn("webhook dropped events", "count", n)
+			}
+		}()
+	}
+
+	// OpenTelemetry spans (optional)
+	if cfg.otlpEndpoint != "" {
+		tp, err := tracing.NewOTLPProvider(ctx, cfg.otlpEndpoint, "grpc-tapd")
+		if err != nil {
+			return fmt.Errorf("otlp: %w", err)
+		}
+		ch, unsub := b.Subscribe()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			tracing.New(tp).Run(ch)
+		}()
+		defer func() {
+			unsub()
+			<-done
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := tp.Shutdown(shutdownCtx); err != nil {
+				slog.Warn("tracing shutdown", "error", err)
+			}
+		}()
+	}
+
+	// Per-method stats for GetStats
+	st := stats.New(cfg.statsWindow)
+	statsCh, statsUnsub := b.Subscribe()
+	go st.Run(statsCh)
+	defer statsUnsub()
 
 	// Reverse proxy
-	p, err := proxy.New(listen, upstream)
+	proxyOpts := []proxy.Option{
+		proxy.WithConnectTimeout(cfg.connectTimeout),
+		proxy.WithUpstreamMaxConns(cfg.maxConns),
+		proxy.WithUpstreamIdleTimeout(cfg.idleTimeout),
+		proxy.WithReplayTimeout(cfg.replayTimeout),
+		proxy.WithMaxCaptureSize(cfg.maxCaptureSize),
+		proxy.WithCorrelationHeader(cfg.correlationHeader),
+	}
+	if cfg.upstreamHTTP1 {
+		proxyOpts = append(proxyOpts, proxy.WithUpstreamHTTP1())
+	}
+	if cfg.noBodyCapture {
+		proxyOpts = append(proxyOpts, proxy.WithoutBodyCapture())
+	}
+	if cfg.trustForwarded {
+		proxyOpts = append(proxyOpts, proxy.WithTrustForwarded())
+	}
+	if len(cfg.retryIdempotent) > 0 {
+		proxyOpts = append(proxyOpts, proxy.WithIdempotentRetry(cfg.retryIdempotent...))
+	}
+	for name, value := range cfg.upstreamHeaders {
+		proxyOpts = append(proxyOpts, proxy.WithHeader(name, value))
+	}
+	if cfg.upstreamAuthority != "" {
+		proxyOpts = append(proxyOpts, proxy.WithUpstreamAuthority(cfg.upstreamAuthority))
+	}
+	for _, rw := range cfg.pathRewrites {
+		proxyOpts = append(proxyOpts, proxy.WithPathRewrite(rw.from, rw.to))
+	}
+	for name, addr := range cfg.replayUpstreams {
+		proxyOpts = append(proxyOpts, proxy.WithReplayUpstream(name, addr))
+	}
+	p, err := proxy.New(cfg.listen, cfg.upstream, proxyOpts...)
 	if err != nil {
 		return fmt.Errorf("proxy: %w", err)
 	}
 
 	// gRPC server for TUI clients
-	var lc net.ListenConfig
-	grpcLis, err := lc.Listen(ctx, "tcp", grpcAddr)
+	serverOpts := []server.Option{server.WithStats(st)}
+	webOpts := []web.Option{web.WithStats(st), web.WithReadiness(p.CheckReady), web.WithMaxCaptureSize(cfg.maxCaptureSize)}
+	if cfg.readOnly {
+		serverOpts = append(serverOpts, server.WithReadOnly())
+		webOpts = append(webOpts, web.WithReadOnly())
+	}
+	srv := server.New(b, p, serverOpts...)
+	grpcLis, err := cfg.openListener(ctx, "grpc", cfg.grpcAddr)
 	if err != nil {
-		return fmt.Errorf("listen grpc %s: %w", grpcAddr, err)
+		return err
+	}
+	if grpcLis != nil {
+		go func() {
+			slog.Info("gRPC server listening", "addr", grpcLis.Addr().String())
+			if err := srv.Serve(grpcLis); err != nil {
+				slog.Error("grpc serve", "error", err)
+			}
+		}()
 	}
-	srv := server.New(b, p)
-	go func() {
-		log.Printf("gRPC server listening on %s", grpcAddr)
-		if err := srv.Serve(grpcLis); err != nil {
-			log.Printf("grpc serve: %v", err)
-		}
-	}()
 
 	// HTTP server for web UI (optional)
-	if httpAddr != "" {
-		httpLis, err := lc.Listen(ctx, "tcp", httpAddr)
-		if err != nil {
-			return fmt.Errorf("listen http %s: %w", httpAddr, err)
-		}
-		webSrv := web.New(b, p)
+	httpLis, err := cfg.openListener(ctx, "http", cfg.httpAddr)
+	if err != nil {
+		return err
+	}
+	if httpLis != nil {
+		webSrv := web.New(b, p, webOpts...)
 		go func() {
-			log.Printf("HTTP server listening on %s", httpAddr)
+			slog.Info("HTTP server listening", "addr", httpLis.Addr().String())
 			if err := webSrv.Serve(httpLis); err != nil {
-				log.Printf("http serve: %v", err)
+				slog.Error("http serve", "error", err)
 			}
 		}()
 		defer func() {
@@ -96,17 +411,74 @@ func run(listen, upstream, grpcAddr, httpAddr string) error {
 		}()
 	}
 
+	// gRPC and web UI on one port (optional)
+	combinedLis, err := cfg.openListener(ctx, "combined", cfg.combinedAddr)
+	if err != nil {
+		return err
+	}
+	if combinedLis != nil {
+		combinedSrv := web.New(b, p, append(webOpts, web.WithGRPC(srv.Handler()))...)
+		go func() {
+			slog.Info("combined gRPC and HTTP server listening", "addr", combinedLis.Addr().String())
+			if err := combinedSrv.Serve(combinedLis); err != nil {
+				slog.Error("combined serve", "error", err)
+			}
+		}()
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = combinedSrv.Shutdown(shutdownCtx)
+		}()
+	}
+
 	go func() {
 		for ev := range p.Events() {
+			if ev.Phase == proxy.PhaseComplete {
+				slog.Debug("call",
+					"method", ev.Method,
+					"protocol", ev.Protocol.String(),
+					"status", proxy.FormatStatus(ev.Status),
+					"duration", ev.Duration,
+				)
+			}
 			b.Publish(ev)
 		}
 	}()
 
-	log.Printf("proxying %s -> %s", listen, upstream)
-	if err := p.ListenAndServe(ctx); err != nil {
+	if cfg.readyFile != "" {
+		// A file left by an earlier run must not pass as readiness.
+		_ = os.Remove(cfg.readyFile)
+		defer func() { _ = os.Remove(cfg.readyFile) }()
+	}
+	go func() {
+		select {
+		case <-p.Ready():
+		case <-ctx.Done():
+			return
+		}
+		slog.Info("proxying", "listen", p.Addr().String(), "upstream", cfg.upstream)
+		if cfg.readyFile != "" {
+			if err := os.WriteFile(cfg.readyFile, []byte(p.Addr().String()+"\n"), 0o600); err != nil {
+				slog.Error("write ready file", "path", cfg.readyFile, "error", err)
+			}
+		}
+	}()
+
+	if proxyLis := cfg.listeners["proxy"]; proxyLis != nil {
+		err = p.Serve(ctx, proxyLis)
+	} else {
+		err = p.ListenAndServe(ctx)
+	}
+	if err != nil {
 		return fmt.Errorf("proxy: %w", err)
 	}
 
+	if combinedLis != nil {
+		// gRPC streams served through the combined port cannot be drained
+		// gracefully, so they are closed instead.
+		srv.Stop()
+		return nil
+	}
 	srv.GracefulStop()
 	return nil
 }
@@ -0,0 +1,247 @@
+// Package compare checks a replayed call against the call it repeats, for
+// asserting that a replay still gets the captured response.
+package compare
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+// Mode selects when two responses match.
+type Mode int
+
+const (
+	// Fields matches responses that decode to the same fields, regardless of
+	// the order they were encoded in. Protobuf and JSON bodies are decoded;
+	// anything else is compared byte for byte.
+	Fields Mode = iota
+	// Bytes matches byte-identical responses only.
+	Bytes
+)
+
+func (m Mode) String() string {
+	if m == Bytes {
+		return "bytes"
+	}
+	return "fields"
+}
+
+// ParseMode parses "fields" or "bytes".
+func ParseMode(s string) (Mode, error) {
+	switch s {
+	case "fields":
+		return Fields, nil
+	case "bytes":
+		return Bytes, nil
+	default:
+		return Fields, fmt.Errorf("compare: unknown mode %q (want fields or bytes)", s)
+	}
+}
+
+// Response is the outcome of a call as far as an assertion is concerned.
+type Response struct {
+	Status int32
+	Body   []byte
+}
+
+// Result is the outcome of comparing a replayed response against the
+// expected one.
+type Result struct {
+	Match bool
+	// Reason says why the responses do not match; empty when they do.
+	Reason string
+	// Diff is a line diff of the decoded bodies: lines prefixed "- " are only
+	// expected, "+ " only got, and "  " in both. It is nil when the bodies
+	// decode the same.
+	Diff []string
+}
+
+// Compare reports whether got matches want under mode. The status codes
+// must be equal in either mode.
+func Compare(mode Mode, want, got Response) Result {
+	wantLines, wantOK := decode(want.Body)
+	gotLines, gotOK := decode(got.Body)
+
+	var r Result
+	if !slices.Equal(wantLines, gotLines) {
+		r.Diff = Diff(wantLines, gotLines)
+	}
+	switch {
+	case want.Status != got.Status:
+		r.Reason = fmt.Sprintf("status %s, want %s", proxy.FormatStatus(got.Status), proxy.FormatStatus(want.Status))
+	case mode == Bytes || !wantOK || !gotOK:
+		if !bytes.Equal(want.Body, got.Body) {
+			r.Reason = "response bytes differ"
+			if r.Diff == nil {
+				r.Reason += " (same fields, different encoding)"
+			}
+		}
+	case r.Diff != nil:
+		r.Reason = "response fields differ"
+	}
+	r.Match = r.Reason == ""
+	return r
+}
+
+// decode renders body as canonical lines: protobuf fields sorted by number,
+// or JSON with sorted keys. ok is false if body is neither, in which case the
+// lines are a hex dump.
+func decode(body []byte) ([]string, bool) {
+	if len(body) == 0 {
+		return nil, true
+	}
+	if lines, ok := decodeProto(body, ""); ok {
+		return lines, true
+	}
+	var v any
+	if json.Unmarshal(body, &v) == nil {
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err == nil {
+			return strings.Split(string(b), "\n"), true
+		}
+	}
+	return strings.Split(strings.TrimSuffix(hex.Dump(body), "\n"), "\n"), false
+}
+
+// field is a decoded protobuf field, rendered as lines.
+type field struct {
+	num   protowire.Number
+	lines []string
+}
+
+// decodeProto renders the protobuf message in data with its fields sorted by
+// number. Repeated fields keep their order.
+func decodeProto(data []byte, indent string) ([]string, bool) {
+	var fields []field
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 || num <= 0 {
+			return nil, false
+		}
+		data = data[n:]
+
+		var line string
+		var nested []string
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return nil, false
+			}
+			data = data[n:]
+			line = fmt.Sprintf("%s%d: %d", indent, num, v)
+		case protowire.Fixed32Type:
+			v, n := protowire.ConsumeFixed32(data)
+			if n < 0 {
+				return nil, false
+			}
+			data = data[n:]
+			line = fmt.Sprintf("%s%d: 0x%08x", indent, num, v)
+		case protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(data)
+			if n < 0 {
+				return nil, false
+			}
+			data = data[n:]
+			line = fmt.Sprintf("%s%d: 0x%016x", indent, num, v)
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return nil, false
+			}
+			data = data[n:]
+			if lines, ok := decodeProto(v, indent+"  "); ok && len(v) > 0 {
+				nested = lines
+				line = fmt.Sprintf("%s%d: {", indent, num)
+			} else if utf8.Valid(v) {
+				line = fmt.Sprintf("%s%d: %q", indent, num, v)
+			} else {
+				line = fmt.Sprintf("%s%d: 0x%x", indent, num, v)
+			}
+		default:
+			return nil, false
+		}
+
+		lines := []string{line}
+		if nested != nil {
+			lines = append(lines, nested...)
+			lines = append(lines, indent+"}")
+		}
+		fields = append(fields, field{num: num, lines: lines})
+	}
+
+	slices.SortStableFunc(fields, func(a, b field) int { return int(a.num) - int(b.num) })
+	var lines []string
+	for _, f := range fields {
+		lines = append(lines, f.lines...)
+	}
+	return lines, true
+}
+
+// maxDiffCells bounds the work of Diff; larger inputs are diffed as a
+// wholesale replacement.
+const maxDiffCells = 1 << 20
+
+// Diff returns a line diff turning want into got, based on their longest
+// common subsequence.
+func Diff(want, got []string) []string {
+	if len(want)*len(got) > maxDiffCells {
+		out := make([]string, 0, len(want)+len(got))
+		for _, l := range want {
+			out = append(out, "- "+l)
+		}
+		for _, l := range got {
+			out = append(out, "+ "+l)
+		}
+		return out
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// want[i:] and got[j:].
+	lcs := make([][]int, len(want)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(got)+1)
+	}
+	for i := len(want) - 1; i >= 0; i-- {
+		for j := len(got) - 1; j >= 0; j-- {
+			if want[i] == got[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	out := make([]string, 0, len(want)+len(got))
+	i, j := 0, 0
+	for i < len(want) && j < len(got) {
+		switch {
+		case want[i] == got[j]:
+			out = append(out, "  "+want[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, "- "+want[i])
+			i++
+		default:
+			out = append(out, "+ "+got[j])
+			j++
+		}
+	}
+	for ; i < len(want); i++ {
+		out = append(out, "- "+want[i])
+	}
+	for ; j < len(got); j++ {
+		out = append(out, "+ "+got[j])
+	}
+	return out
+}
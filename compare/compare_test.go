@@ -0,0 +1,138 @@
+package compare_test
+
+import (
+	"slices"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/mickamy/grpc-tap/compare"
+)
+
+// message encodes a string field 1 and a varint field 2, in the given order.
+func message(name string, id uint64, idFirst bool) []byte {
+	var nameField, idField []byte
+	nameField = protowire.AppendTag(nameField, 1, protowire.BytesType)
+	nameField = protowire.AppendString(nameField, name)
+	idField = protowire.AppendTag(idField, 2, protowire.VarintType)
+	idField = protowire.AppendVarint(idField, id)
+	if idFirst {
+		return append(idField, nameField...)
+	}
+	return append(nameField, idField...)
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	alice := message("alice", 1, false)
+	tests := []struct {
+		name       string
+		mode       compare.Mode
+		want, got  compare.Response
+		wantMatch  bool
+		wantReason string
+		wantDiff   bool
+	}{
+		{
+			name:      "identical",
+			mode:      compare.Bytes,
+			want:      compare.Response{Body: alice},
+			got:       compare.Response{Body: alice},
+			wantMatch: true,
+		},
+		{
+			name:      "field order in fields mode",
+			mode:      compare.Fields,
+			want:      compare.Response{Body: alice},
+			got:       compare.Response{Body: message("alice", 1, true)},
+			wantMatch: true,
+		},
+		{
+			name:       "field order in bytes mode",
+			mode:       compare.Bytes,
+			want:       compare.Response{Body: alice},
+			got:        compare.Response{Body: message("alice", 1, true)},
+			wantReason: "response bytes differ (same fields, different encoding)",
+		},
+		{
+			name:       "changed field",
+			mode:       compare.Fields,
+			want:       compare.Response{Body: alice},
+			got:        compare.Response{Body: message("bob", 1, false)},
+			wantReason: "response fields differ",
+			wantDiff:   true,
+		},
+		{
+			name:       "status",
+			mode:       compare.Fields,
+			want:       compare.Response{Body: alice},
+			got:        compare.Response{Status: 5},
+			wantReason: "status NotFound(5), want OK",
+			wantDiff:   true,
+		},
+		{
+			name:      "JSON key order",
+			mode:      compare.Fields,
+			want:      compare.Response{Body: []byte(`{"name":"alice","id":1}`)},
+			got:       compare.Response{Body: []byte(`{"id":1, "name":"alice"}`)},
+			wantMatch: true,
+		},
+		{
+			name:       "undecodable bodies compare as bytes",
+			mode:       compare.Fields,
+			want:       compare.Response{Body: []byte{0xff, 0xfe}},
+			got:        compare.Response{Body: []byte{0xff, 0xfd}},
+			wantReason: "response bytes differ",
+			wantDiff:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := compare.Compare(tt.mode, tt.want, tt.got)
+			if r.Match != tt.wantMatch || r.Reason != tt.wantReason {
+				t.Errorf("Compare = %v %q, want %v %q", r.Match, r.Reason, tt.wantMatch, tt.wantReason)
+			}
+			if (r.Diff != nil) != tt.wantDiff {
+				t.Errorf("diff = %q, want diff: %v", r.Diff, tt.wantDiff)
+			}
+		})
+	}
+}
+
+func TestCompare_Diff(t *testing.T) {
+	t.Parallel()
+
+	r := compare.Compare(compare.Fields,
+		compare.Response{Body: message("alice", 1, false)},
+		compare.Response{Body: message("bob", 1, false)},
+	)
+	want := []string{`- 1: "alice"`, `+ 1: "bob"`, "  2: 1"}
+	if !slices.Equal(r.Diff, want) {
+		t.Errorf("diff = %q, want %q", r.Diff, want)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	got := compare.Diff([]string{"a", "b", "c", "d"}, []string{"a", "c", "d", "e"})
+	want := []string{"  a", "- b", "  c", "  d", "+ e"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Diff = %q, want %q", got, want)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	t.Parallel()
+
+	for _, m := range []compare.Mode{compare.Fields, compare.Bytes} {
+		if got, err := compare.ParseMode(m.String()); err != nil || got != m {
+			t.Errorf("ParseMode(%q) = %v, %v", m, got, err)
+		}
+	}
+	if _, err := compare.ParseMode("exact"); err == nil {
+		t.Error("ParseMode(exact) succeeded")
+	}
+}
@@ -0,0 +1,90 @@
+package filter
+
+import (
+	"net/http"
+	"strings"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+// FromEvent returns the filter fields of a captured call.
+func FromEvent(ev proxy.Event) Event {
+	headers := make(map[string][]string, len(ev.RequestHeaders)+len(ev.ResponseHeaders)+len(ev.ResponseTrailers))
+	for _, h := range []http.Header{ev.RequestHeaders, ev.ResponseHeaders, ev.ResponseTrailers} {
+		for k, vs := range h {
+			k = strings.ToLower(k)
+			headers[k] = append(headers[k], vs...)
+		}
+	}
+	return Event{
+		Method:        ev.Method,
+		Protocol:      ev.Protocol.String(),
+		CallType:      ev.CallType.String(),
+		Status:        ev.Status,
+		InFlight:      ev.Phase == proxy.PhaseStart || ev.Phase == proxy.PhaseProgress,
+		Duration:      ev.Duration,
+		Peer:          ev.PeerAddr,
+		Authority:     ev.Authority,
+		CorrelationID: ev.CorrelationID,
+		Headers:       headers,
+		RequestBody:   ev.RequestBody,
+		ResponseBody:  ev.ResponseBody,
+	}
+}
+
+// FromProto returns the filter fields of a call streamed by the daemon's
+// TapService.
+func FromProto(ev *tapv1.GRPCEvent) Event {
+	headers := make(map[string][]string,
+		len(ev.GetRequestHeaders())+len(ev.GetResponseHeaders())+len(ev.GetResponseTrailers()))
+	for _, h := range []map[string]string{ev.GetRequestHeaders(), ev.GetResponseHeaders(), ev.GetResponseTrailers()} {
+		for k, v := range h {
+			k = strings.ToLower(k)
+			headers[k] = append(headers[k], v)
+		}
+	}
+	phase := ev.GetPhase()
+	return Event{
+		Method:        ev.GetMethod(),
+		Protocol:      protocolName(ev.GetProtocol()),
+		CallType:      callTypeName(ev.GetCallType()),
+		Status:        ev.GetStatus(),
+		InFlight:      phase == tapv1.EventPhase_EVENT_PHASE_START || phase == tapv1.EventPhase_EVENT_PHASE_PROGRESS,
+		Duration:      ev.GetDuration().AsDuration(),
+		Peer:          ev.GetPeerAddr(),
+		Authority:     ev.GetAuthority(),
+		CorrelationID: ev.GetCorrelationId(),
+		Headers:       headers,
+		RequestBody:   ev.GetRequestBody(),
+		ResponseBody:  ev.GetResponseBody(),
+	}
+}
+
+func protocolName(p tapv1.Protocol) string {
+	switch p {
+	case tapv1.Protocol_PROTOCOL_GRPC:
+		return proxy.ProtocolGRPC.String()
+	case tapv1.Protocol_PROTOCOL_GRPC_WEB:
+		return proxy.ProtocolGRPCWeb.String()
+	case tapv1.Protocol_PROTOCOL_CONNECT:
+		return proxy.ProtocolConnect.String()
+	default:
+		return ""
+	}
+}
+
+func callTypeName(ct tapv1.CallType) string {
+	switch ct {
+	case tapv1.CallType_CALL_TYPE_UNARY:
+		return proxy.Unary.String()
+	case tapv1.CallType_CALL_TYPE_SERVER_STREAM:
+		return proxy.ServerStream.String()
+	case tapv1.CallType_CALL_TYPE_CLIENT_STREAM:
+		return proxy.ClientStream.String()
+	case tapv1.CallType_CALL_TYPE_BIDI_STREAM:
+		return proxy.BidiStream.String()
+	default:
+		return ""
+	}
+}
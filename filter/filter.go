@@ -0,0 +1,312 @@
+// Package filter implements the filter expressions used to narrow captured
+// calls, e.g. "GetUser code:unavailable protocol:grpc". An expression is
+// parsed once into a Filter whose Evaluate reports whether a call matches,
+// so the TUI, the CLI and the daemon's API all filter the same way.
+//
+// An expression is a whitespace-separated list of terms, all of which must
+// match. A term is either a word, matched as a part of the method, or
+// key:value:
+//
+//	method:<text>         part of the method
+//	peer:<addr>           part of the client address
+//	host:<authority>      part of the authority the client addressed
+//	trace:<id>            part of the correlation ID
+//	code:<code>           the status, by name (unavailable, deadline_exceeded)
+//	                      or number (14); "ok" and "error" match any success
+//	                      or failure. status: is the same.
+//	protocol:<name>       the protocol: grpc, grpc-web or connect
+//	type:<name>           the call type: unary, server_stream,
+//	                      client_stream or bidi_stream; "stream" matches
+//	                      any of the streams
+//	header:<name>         a request or response header or trailer named name;
+//	                      header:<name>=<text> also matches part of its value
+//	body:<text>           part of the captured request or response body
+//	dur><duration>        calls that took longer than duration, e.g. dur>100ms;
+//	                      dur>=, dur< and dur<= compare the other ways
+//
+// Matching ignores case. A term starting with "-" matches calls the rest of
+// the term does not match, e.g. -method:Health. A key with an empty value,
+// as while a term is being typed, matches every call.
+package filter
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+// Event holds the fields of a call that filters look at. FromEvent and
+// FromProto build one from the daemon's and the API's event types.
+type Event struct {
+	Method        string
+	Protocol      string // as returned by proxy.Protocol.String, e.g. "gRPC-Web"
+	CallType      string // as returned by proxy.CallType.String, e.g. "ServerStream"
+	Status        int32
+	InFlight      bool // a start or progress event: the call has no status yet
+	Duration      time.Duration
+	Peer          string
+	Authority     string
+	CorrelationID string
+
+	// Headers holds the values of the request headers, response headers
+	// and trailers, keyed by lower-case name.
+	Headers      map[string][]string
+	RequestBody  []byte
+	ResponseBody []byte
+}
+
+// Filter is a parsed filter expression. The zero Filter matches every call.
+type Filter struct {
+	terms []term
+}
+
+// term is one term of an expression.
+type term struct {
+	negate bool
+	match  func(ev *Event) bool
+	text   string // the text looked for in the method or body, if any
+}
+
+// Parse parses a filter expression. It fails on terms with an unknown key.
+func Parse(expr string) (*Filter, error) {
+	f := &Filter{}
+	for word := range strings.FieldsSeq(expr) {
+		t, err := parseTerm(word)
+		if err != nil {
+			return nil, err
+		}
+		if t.match != nil {
+			f.terms = append(f.terms, t)
+		}
+	}
+	return f, nil
+}
+
+// Empty reports whether f has no terms, so it matches every call.
+func (f *Filter) Empty() bool {
+	return f == nil || len(f.terms) == 0
+}
+
+// Evaluate reports whether ev matches every term of f. A nil Filter matches
+// every call.
+func (f *Filter) Evaluate(ev Event) bool {
+	if f == nil {
+		return true
+	}
+	for _, t := range f.terms {
+		if t.match(&ev) == t.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// Texts returns the texts f looks for in the method or body, from plain
+// words and method: and body: terms that are not negated, for highlighting
+// matches.
+func (f *Filter) Texts() []string {
+	if f == nil {
+		return nil
+	}
+	var texts []string
+	for _, t := range f.terms {
+		if t.text != "" && !t.negate {
+			texts = append(texts, t.text)
+		}
+	}
+	return texts
+}
+
+// parseTerm parses one term. A term that constrains nothing, such as a key
+// without a value, is returned without a match func.
+func parseTerm(word string) (term, error) {
+	var t term
+	if rest, ok := strings.CutPrefix(word, "-"); ok && rest != "" {
+		t.negate = true
+		word = rest
+	}
+	if rest, ok := cutFold(word, "dur"); ok && (strings.HasPrefix(rest, ">") || strings.HasPrefix(rest, "<")) {
+		match, err := matchDuration(rest)
+		if err != nil {
+			return t, err
+		}
+		t.match = match
+		return t, nil
+	}
+	key, value, ok := strings.Cut(word, ":")
+	if !ok {
+		t.match = contains(func(ev *Event) string { return ev.Method }, word)
+		t.text = word
+		return t, nil
+	}
+	build, known := keys[strings.ToLower(key)]
+	if !known {
+		return t, fmt.Errorf("filter: unknown key %q", key)
+	}
+	if value == "" {
+		return term{}, nil
+	}
+	t.match = build(value)
+	if k := strings.ToLower(key); k == "method" || k == "body" {
+		t.text = value
+	}
+	return t, nil
+}
+
+// cutFold is strings.CutPrefix ignoring ASCII case.
+func cutFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
+
+// matchDuration parses a comparison such as ">100ms" or "<=1s" and matches
+// finished calls whose duration compares so. An operator without a duration,
+// as while a term is being typed, matches every call.
+func matchDuration(cmp string) (func(ev *Event) bool, error) {
+	op := cmp[:1]
+	if strings.HasPrefix(cmp[1:], "=") {
+		op = cmp[:2]
+	}
+	value := cmp[len(op):]
+	if value == "" {
+		return nil, nil //nolint:nilnil // no duration yet: the term constrains nothing
+	}
+	limit, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, fmt.Errorf("filter: dur%s: %w", op, err)
+	}
+	var want func(d time.Duration) bool
+	switch op {
+	case ">":
+		want = func(d time.Duration) bool { return d > limit }
+	case ">=":
+		want = func(d time.Duration) bool { return d >= limit }
+	case "<":
+		want = func(d time.Duration) bool { return d < limit }
+	default:
+		want = func(d time.Duration) bool { return d <= limit }
+	}
+	return func(ev *Event) bool {
+		return !ev.InFlight && want(ev.Duration)
+	}, nil
+}
+
+// keys maps each key to the builder of its match func.
+var keys = map[string]func(value string) func(ev *Event) bool{
+	"method":   containsIn(func(ev *Event) string { return ev.Method }),
+	"peer":     containsIn(func(ev *Event) string { return ev.Peer }),
+	"host":     containsIn(func(ev *Event) string { return ev.Authority }),
+	"trace":    containsIn(func(ev *Event) string { return ev.CorrelationID }),
+	"code":     matchCode,
+	"status":   matchCode,
+	"protocol": equalNameIn(func(ev *Event) string { return ev.Protocol }),
+	"type":     matchCallType,
+	"header":   matchHeader,
+	"body":     matchBody,
+}
+
+// containsIn returns a builder of contains matches on field.
+func containsIn(field func(ev *Event) string) func(value string) func(ev *Event) bool {
+	return func(value string) func(ev *Event) bool { return contains(field, value) }
+}
+
+// equalNameIn returns a builder of equalName matches on field.
+func equalNameIn(field func(ev *Event) string) func(value string) func(ev *Event) bool {
+	return func(value string) func(ev *Event) bool { return equalName(field, value) }
+}
+
+// contains matches calls whose field contains value, ignoring case.
+func contains(field func(ev *Event) string, value string) func(ev *Event) bool {
+	value = strings.ToLower(value)
+	return func(ev *Event) bool {
+		return strings.Contains(strings.ToLower(field(ev)), value)
+	}
+}
+
+// equalName matches calls whose field equals value, ignoring case and the
+// separators "-" and "_", so grpc-web matches gRPC-Web and server_stream
+// matches ServerStream.
+func equalName(field func(ev *Event) string, value string) func(ev *Event) bool {
+	value = normalizeName(value)
+	return func(ev *Event) bool {
+		return normalizeName(field(ev)) == value
+	}
+}
+
+// nameSeparators drops the separators equalName ignores. It is built once as
+// normalizeName runs for every event a term is evaluated against.
+var nameSeparators = strings.NewReplacer("-", "", "_", "")
+
+func normalizeName(s string) string {
+	return nameSeparators.Replace(strings.ToLower(s))
+}
+
+// matchCallType matches calls of the call type given by name, or any
+// streaming call for "stream".
+func matchCallType(value string) func(ev *Event) bool {
+	if normalizeName(value) != "stream" {
+		return equalName(func(ev *Event) string { return ev.CallType }, value)
+	}
+	return func(ev *Event) bool {
+		switch ev.CallType {
+		case proxy.ServerStream.String(), proxy.ClientStream.String(), proxy.BidiStream.String():
+			return true
+		}
+		return false
+	}
+}
+
+// matchCode matches calls that finished with the status code given by name
+// or number, or with any success (ok) or failure (error). Calls still in
+// flight have no status yet and match no code.
+func matchCode(value string) func(ev *Event) bool {
+	value = strings.ToLower(value)
+	var want func(status int32) bool
+	switch n, err := strconv.Atoi(value); {
+	case value == "error":
+		want = func(status int32) bool { return status != 0 }
+	case err == nil:
+		want = func(status int32) bool { return int(status) == n }
+	default:
+		name := normalizeName(value)
+		want = func(status int32) bool { return normalizeName(proxy.StatusName(status)) == name }
+	}
+	return func(ev *Event) bool {
+		return !ev.InFlight && want(ev.Status)
+	}
+}
+
+// matchHeader matches calls with a header named as in value, and for
+// name=text a value containing text.
+func matchHeader(value string) func(ev *Event) bool {
+	name, text, withText := strings.Cut(value, "=")
+	name, text = strings.ToLower(name), strings.ToLower(text)
+	return func(ev *Event) bool {
+		vs, ok := ev.Headers[name]
+		if !ok || !withText {
+			return ok
+		}
+		for _, v := range vs {
+			if strings.Contains(strings.ToLower(v), text) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// matchBody matches calls whose captured request or response body contains
+// value, ignoring ASCII case.
+func matchBody(value string) func(ev *Event) bool {
+	want := bytes.ToLower([]byte(value))
+	return func(ev *Event) bool {
+		return bytes.Contains(bytes.ToLower(ev.RequestBody), want) ||
+			bytes.Contains(bytes.ToLower(ev.ResponseBody), want)
+	}
+}
@@ -0,0 +1,255 @@
+package filter_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/mickamy/grpc-tap/filter"
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expr      string
+		wantErr   bool
+		wantEmpty bool
+	}{
+		{expr: "", wantEmpty: true},
+		{expr: "   ", wantEmpty: true},
+		{expr: "GetUser"},
+		{expr: "method:GetUser code:error protocol:grpc"},
+		{expr: "-method:Health"},
+		{expr: "METHOD:getuser Code:14"},
+		{expr: "code:", wantEmpty: true},
+		{expr: "header:x-env=staging body:alice"},
+		{expr: "nope:x", wantErr: true},
+		{expr: "GetUser nope:", wantErr: true},
+		{expr: "-nope:x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			t.Parallel()
+			f, err := filter.Parse(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) err = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			}
+			if err == nil && f.Empty() != tt.wantEmpty {
+				t.Errorf("Parse(%q).Empty() = %v, want %v", tt.expr, f.Empty(), tt.wantEmpty)
+			}
+		})
+	}
+}
+
+func TestFilter_Evaluate(t *testing.T) {
+	t.Parallel()
+
+	ev := filter.Event{
+		Method:        "/pkg.UserService/GetUser",
+		Protocol:      "gRPC-Web",
+		CallType:      "ServerStream",
+		Status:        14,
+		Duration:      120 * time.Millisecond,
+		Peer:          "10.0.0.7:51234",
+		Authority:     "users.internal",
+		CorrelationID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		Headers:       map[string][]string{"x-env": {"Staging"}, "grpc-status": {"14"}},
+		RequestBody:   []byte("\n\x05Alice"),
+		ResponseBody:  []byte("{}"),
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{expr: "", want: true},
+		{expr: "getuser", want: true},
+		{expr: "ListUsers", want: false},
+		{expr: "method:UserService", want: true},
+		{expr: "peer:10.0.0.7", want: true},
+		{expr: "peer:10.0.0.8", want: false},
+		{expr: "host:Users.Internal", want: true},
+		{expr: "trace:4BF92F35", want: true},
+		{expr: "trace:0af7", want: false},
+		{expr: "code:unavailable", want: true},
+		{expr: "code:14", want: true},
+		{expr: "status:error", want: true},
+		{expr: "code:ok", want: false},
+		{expr: "code:deadline_exceeded", want: false},
+		{expr: "protocol:grpc-web", want: true},
+		{expr: "protocol:grpcweb", want: true},
+		{expr: "protocol:grpc", want: false},
+		{expr: "type:server_stream", want: true},
+		{expr: "type:unary", want: false},
+		{expr: "type:stream", want: true},
+		{expr: "-type:stream", want: false},
+		{expr: "header:x-env", want: true},
+		{expr: "header:X-Env=stag", want: true},
+		{expr: "header:x-env=prod", want: false},
+		{expr: "header:authorization", want: false},
+		{expr: "body:alice", want: true},
+		{expr: "body:bob", want: false},
+		{expr: "-method:Health", want: true},
+		{expr: "-code:error", want: false},
+		{expr: "getuser code:unavailable protocol:grpc-web", want: true},
+		{expr: "getuser code:unavailable protocol:connect", want: false},
+		{expr: "code:", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			t.Parallel()
+			f, err := filter.Parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := f.Evaluate(ev); got != tt.want {
+				t.Errorf("Evaluate(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilter_InFlight(t *testing.T) {
+	t.Parallel()
+
+	ev := filter.Event{Method: "/pkg.Svc/Watch", InFlight: true}
+	for _, expr := range []string{"code:ok", "code:0", "code:error"} {
+		if f, _ := filter.Parse(expr); f.Evaluate(ev) {
+			t.Errorf("%s matched a call in flight", expr)
+		}
+	}
+	if f, _ := filter.Parse("-code:error"); !f.Evaluate(ev) {
+		t.Error("-code:error did not match a call in flight")
+	}
+}
+
+func TestFilter_Nil(t *testing.T) {
+	t.Parallel()
+
+	var f *filter.Filter
+	if !f.Empty() || !f.Evaluate(filter.Event{}) {
+		t.Error("a nil Filter does not match every call")
+	}
+}
+
+func TestFromEvent(t *testing.T) {
+	t.Parallel()
+
+	ev := filter.FromEvent(proxy.Event{
+		Method:           "/pkg.Svc/Method",
+		Phase:            proxy.PhaseProgress,
+		Protocol:         proxy.ProtocolConnect,
+		CallType:         proxy.BidiStream,
+		Duration:         time.Second,
+		RequestHeaders:   http.Header{"X-Env": {"staging"}},
+		ResponseTrailers: http.Header{"Grpc-Status": {"0"}},
+	})
+	if ev.Protocol != "Connect" || ev.CallType != "BidiStream" || !ev.InFlight || ev.Duration != time.Second {
+		t.Errorf("event = %+v", ev)
+	}
+	if got := ev.Headers["x-env"]; len(got) != 1 || got[0] != "staging" {
+		t.Errorf("x-env = %v, want [staging]", got)
+	}
+	if _, ok := ev.Headers["grpc-status"]; !ok {
+		t.Error("trailers are not among the headers")
+	}
+}
+
+func TestFromProto(t *testing.T) {
+	t.Parallel()
+
+	ev := filter.FromProto(&tapv1.GRPCEvent{
+		Method:          "/pkg.Svc/Method",
+		Phase:           tapv1.EventPhase_EVENT_PHASE_COMPLETE,
+		Protocol:        tapv1.Protocol_PROTOCOL_GRPC_WEB,
+		CallType:        tapv1.CallType_CALL_TYPE_SERVER_STREAM,
+		Status:          5,
+		Duration:        durationpb.New(time.Millisecond),
+		ResponseHeaders: map[string]string{"Content-Type": "application/grpc-web"},
+	})
+	if ev.Protocol != "gRPC-Web" || ev.CallType != "ServerStream" || ev.InFlight || ev.Status != 5 {
+		t.Errorf("event = %+v", ev)
+	}
+	f, err := filter.Parse("protocol:grpc-web type:server_stream code:not_found header:content-type=grpc-web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.Evaluate(ev) {
+		t.Error("filter does not match the converted event")
+	}
+}
+
+func TestFilter_Duration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expr    string
+		dur     time.Duration
+		want    bool
+		wantErr bool
+	}{
+		{expr: "dur>100ms", dur: 101 * time.Millisecond, want: true},
+		{expr: "dur>100ms", dur: 100 * time.Millisecond, want: false},
+		{expr: "dur>=100ms", dur: 100 * time.Millisecond, want: true},
+		{expr: "dur<100ms", dur: 100 * time.Millisecond, want: false},
+		{expr: "dur<100ms", dur: 99 * time.Millisecond, want: true},
+		{expr: "dur<=100ms", dur: 100 * time.Millisecond, want: true},
+		{expr: "dur>1.5s", dur: 2 * time.Second, want: true},
+		{expr: "DUR>250us", dur: 300 * time.Microsecond, want: true},
+		{expr: "-dur>1s", dur: 2 * time.Second, want: false},
+		{expr: "dur>100ms dur<1s", dur: 500 * time.Millisecond, want: true},
+		{expr: "dur>100ms dur<1s", dur: time.Second, want: false},
+		{expr: "dur>", dur: 0, want: true},
+		{expr: "dur>=", dur: 0, want: true},
+		{expr: "dur>100", wantErr: true},
+		{expr: "dur>fast", wantErr: true},
+		{expr: "dur>=>1s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr+"/"+tt.dur.String(), func(t *testing.T) {
+			t.Parallel()
+			f, err := filter.Parse(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) err = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := f.Evaluate(filter.Event{Duration: tt.dur}); got != tt.want {
+				t.Errorf("Evaluate(%q, %v) = %v, want %v", tt.expr, tt.dur, got, tt.want)
+			}
+		})
+	}
+
+	f, err := filter.Parse("dur<1s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Evaluate(filter.Event{Duration: time.Millisecond, InFlight: true}) {
+		t.Error("dur<1s matched a call in flight")
+	}
+}
+
+func TestFilter_Texts(t *testing.T) {
+	t.Parallel()
+
+	f, err := filter.Parse("GetUser method:Users body:alice -body:bob code:error header:x-env=alice dur>1s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := f.Texts()
+	want := []string{"GetUser", "Users", "alice"}
+	if len(got) != len(want) {
+		t.Fatalf("Texts() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Texts()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
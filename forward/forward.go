@@ -0,0 +1,197 @@
+package forward
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/mickamy/grpc-tap/proxy"
+	"github.com/mickamy/grpc-tap/web"
+)
+
+// Config configures a Forwarder. Zero values fall back to sensible defaults.
+type Config struct {
+	URL           string        // webhook endpoint (required)
+	ErrorsOnly    bool          // forward only events with a non-zero status
+	BatchSize     int           // max events per POST (default 50)
+	FlushInterval time.Duration // max time an event waits for a batch to fill (default 1s)
+	QueueSize     int           // events buffered before dropping (default 1024)
+	MaxRetries    int           // retries per batch after the first attempt (default 3, negative for none)
+	RetryBackoff  time.Duration // initial backoff, doubled per retry (default 500ms)
+	Client        *http.Client  // HTTP client (default: 10s timeout)
+}
+
+// Payload is the JSON body POSTed to the webhook.
+type Payload struct {
+	Events []web.EventJSON `json:"events"`
+}
+
+// Forwarder POSTs batches of events to a webhook URL.
+// Events are queued without blocking the caller; when the queue is full,
+// new events are dropped.
+type Forwarder struct {
+	cfg     Config
+	queue   chan proxy.Event
+	dropped atomic.Int64
+}
+
+// New creates a Forwarder for cfg.
+func New(cfg Config) *Forwarder {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1024
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	} else if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 500 * time.Millisecond
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Forwarder{
+		cfg:   cfg,
+		queue: make(chan proxy.Event, cfg.QueueSize),
+	}
+}
+
+// Dropped returns the number of events dropped because the queue was full
+// or delivery failed after all retries.
+func (f *Forwarder) Dropped() int64 {
+	return f.dropped.Load()
+}
+
+// Run forwards events received from ch until ch is closed or ctx is done.
+// Only completed calls are forwarded, not progress events of in-flight
+// streams. Pending events are flushed before Run returns.
+func (f *Forwarder) Run(ctx context.Context, ch <-chan proxy.Event) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f.deliver(ctx)
+	}()
+
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case ev, ok := <-ch:
+			if !ok {
+				break loop
+			}
+			f.enqueue(ev)
+		}
+	}
+	close(f.queue)
+	<-done
+}
+
+func (f *Forwarder) enqueue(ev proxy.Event) {
+	if ev.Phase != proxy.PhaseComplete || f.cfg.ErrorsOnly && ev.Status == 0 {
+		return
+	}
+	select {
+	case f.queue <- ev:
+	default:
+		f.dropped.Add(1)
+	}
+}
+
+func (f *Forwarder) deliver(ctx context.Context) {
+	ticker := time.NewTicker(f.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]web.EventJSON, 0, f.cfg.BatchSize)
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if err := f.post(ctx, batch); err != nil {
+			f.dropped.Add(int64(len(batch)))
+			slog.Warn("forward: deliver batch", "url", f.cfg.URL, "events", len(batch), "error", err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case ev, ok := <-f.queue:
+			if !ok {
+				// Give the final batch a chance even if ctx is already canceled.
+				finalCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+				flush(finalCtx)
+				cancel()
+				return
+			}
+			batch = append(batch, web.EventToJSON(ev))
+			if len(batch) >= f.cfg.BatchSize {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		}
+	}
+}
+
+// post sends one batch, retrying server errors and network failures with
+// exponential backoff. Client errors (4xx other than 429) are not retried.
+func (f *Forwarder) post(ctx context.Context, batch []web.EventJSON) error {
+	body, err := json.Marshal(Payload{Events: batch})
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	backoff := f.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := f.postOnce(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= f.cfg.MaxRetries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (gave up: %w)", err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (f *Forwarder) postOnce(ctx context.Context, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := f.cfg.Client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("post: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("post: status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("post: status %d", resp.StatusCode)
+	}
+}
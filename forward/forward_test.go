@@ -0,0 +1,162 @@
+package forward_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mickamy/grpc-tap/forward"
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+type receiver struct {
+	mu       sync.Mutex
+	payloads []forward.Payload
+	raw      []map[string]any
+	fail     int // number of requests to reject with 500 before accepting
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.fail > 0 {
+		rc.fail--
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var raw map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	data, _ := json.Marshal(raw)
+	var p forward.Payload
+	_ = json.Unmarshal(data, &p)
+	rc.raw = append(rc.raw, raw)
+	rc.payloads = append(rc.payloads, p)
+}
+
+func (rc *receiver) ids() []string {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	var ids []string
+	for _, p := range rc.payloads {
+		for _, ev := range p.Events {
+			ids = append(ids, ev.ID)
+		}
+	}
+	return ids
+}
+
+func runForwarder(t *testing.T, cfg forward.Config, events ...proxy.Event) *forward.Forwarder {
+	t.Helper()
+
+	f := forward.New(cfg)
+	ch := make(chan proxy.Event, len(events))
+	for _, ev := range events {
+		ch <- ev
+	}
+	close(ch)
+	f.Run(t.Context(), ch)
+	return f
+}
+
+func TestForwarder_Payload(t *testing.T) {
+	t.Parallel()
+
+	rc := &receiver{}
+	srv := httptest.NewServer(rc)
+	t.Cleanup(srv.Close)
+
+	runForwarder(t, forward.Config{URL: srv.URL, BatchSize: 2},
+		proxy.Event{ID: "a", Method: "/test.Service/A", CallType: proxy.Unary, Protocol: proxy.ProtocolGRPC, Duration: time.Millisecond},
+		proxy.Event{ID: "b", Method: "/test.Service/B", Status: 5, Error: "not found"},
+		proxy.Event{ID: "c", Method: "/test.Service/C"},
+	)
+
+	if got := rc.ids(); len(got) != 3 || got[0] != "a" || got[1] != "b" || got[2] != "c" {
+		t.Fatalf("ids = %v, want [a b c]", got)
+	}
+	if len(rc.payloads) != 2 {
+		t.Errorf("batches = %d, want 2", len(rc.payloads))
+	}
+
+	events, ok := rc.raw[0]["events"].([]any)
+	if !ok || len(events) == 0 {
+		t.Fatalf("payload = %v, want events array", rc.raw[0])
+	}
+	first, _ := events[0].(map[string]any)
+	for k, want := range map[string]any{
+		"id":          "a",
+		"method":      "/test.Service/A",
+		"call_type":   "Unary",
+		"protocol":    "gRPC",
+		"status":      float64(0),
+		"duration_ms": float64(1),
+	} {
+		if first[k] != want {
+			t.Errorf("%s = %v, want %v", k, first[k], want)
+		}
+	}
+}
+
+func TestForwarder_ErrorsOnly(t *testing.T) {
+	t.Parallel()
+
+	rc := &receiver{}
+	srv := httptest.NewServer(rc)
+	t.Cleanup(srv.Close)
+
+	runForwarder(t, forward.Config{URL: srv.URL, ErrorsOnly: true},
+		proxy.Event{ID: "ok-1"},
+		proxy.Event{ID: "err-1", Status: 13},
+		proxy.Event{ID: "ok-2"},
+		proxy.Event{ID: "err-2", Status: 2},
+	)
+
+	if got := rc.ids(); len(got) != 2 || got[0] != "err-1" || got[1] != "err-2" {
+		t.Errorf("ids = %v, want [err-1 err-2]", got)
+	}
+}
+
+func TestForwarder_Retry(t *testing.T) {
+	t.Parallel()
+
+	rc := &receiver{fail: 2}
+	srv := httptest.NewServer(rc)
+	t.Cleanup(srv.Close)
+
+	f := runForwarder(t, forward.Config{URL: srv.URL, RetryBackoff: time.Millisecond},
+		proxy.Event{ID: "a"},
+	)
+
+	if got := rc.ids(); len(got) != 1 || got[0] != "a" {
+		t.Errorf("ids = %v, want [a]", got)
+	}
+	if f.Dropped() != 0 {
+		t.Errorf("dropped = %d, want 0", f.Dropped())
+	}
+}
+
+func TestForwarder_GivesUp(t *testing.T) {
+	t.Parallel()
+
+	rc := &receiver{fail: 100}
+	srv := httptest.NewServer(rc)
+	t.Cleanup(srv.Close)
+
+	f := runForwarder(t, forward.Config{URL: srv.URL, MaxRetries: 1, RetryBackoff: time.Millisecond},
+		proxy.Event{ID: "a"},
+		proxy.Event{ID: "b"},
+	)
+
+	if f.Dropped() != 2 {
+		t.Errorf("dropped = %d, want 2", f.Dropped())
+	}
+}
@@ -23,6 +23,58 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type EventPhase int32
+
+const (
+	EventPhase_EVENT_PHASE_UNSPECIFIED EventPhase = 0
+	EventPhase_EVENT_PHASE_COMPLETE    EventPhase = 1
+	EventPhase_EVENT_PHASE_PROGRESS    EventPhase = 2
+	EventPhase_EVENT_PHASE_START       EventPhase = 3
+)
+
+// Enum value maps for EventPhase.
+var (
+	EventPhase_name = map[int32]string{
+		0: "EVENT_PHASE_UNSPECIFIED",
+		1: "EVENT_PHASE_COMPLETE",
+		2: "EVENT_PHASE_PROGRESS",
+		3: "EVENT_PHASE_START",
+	}
+	EventPhase_value = map[string]int32{
+		"EVENT_PHASE_UNSPECIFIED": 0,
+		"EVENT_PHASE_COMPLETE":    1,
+		"EVENT_PHASE_PROGRESS":    2,
+		"EVENT_PHASE_START":       3,
+	}
+)
+
+func (x EventPhase) Enum() *EventPhase {
+	p := new(EventPhase)
+	*p = x
+	return p
+}
+
+func (x EventPhase) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventPhase) Descriptor() protoreflect.EnumDescriptor {
+	return file_tap_v1_tap_proto_enumTypes[0].Descriptor()
+}
+
+func (EventPhase) Type() protoreflect.EnumType {
+	return &file_tap_v1_tap_proto_enumTypes[0]
+}
+
+func (x EventPhase) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventPhase.Descriptor instead.
+func (EventPhase) EnumDescriptor() ([]byte, []int) {
+	return file_tap_v1_tap_proto_rawDescGZIP(), []int{0}
+}
+
 type CallType int32
 
 const (
@@ -62,11 +114,11 @@ func (x CallType) String() string {
 }
 
 func (CallType) Descriptor() protoreflect.EnumDescriptor {
-	return file_tap_v1_tap_proto_enumTypes[0].Descriptor()
+	return file_tap_v1_tap_proto_enumTypes[1].Descriptor()
 }
 
 func (CallType) Type() protoreflect.EnumType {
-	return &file_tap_v1_tap_proto_enumTypes[0]
+	return &file_tap_v1_tap_proto_enumTypes[1]
 }
 
 func (x CallType) Number() protoreflect.EnumNumber {
@@ -75,7 +127,7 @@ func (x CallType) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use CallType.Descriptor instead.
 func (CallType) EnumDescriptor() ([]byte, []int) {
-	return file_tap_v1_tap_proto_rawDescGZIP(), []int{0}
+	return file_tap_v1_tap_proto_rawDescGZIP(), []int{1}
 }
 
 type Protocol int32
@@ -114,11 +166,11 @@ func (x Protocol) String() string {
 }
 
 func (Protocol) Descriptor() protoreflect.EnumDescriptor {
-	return file_tap_v1_tap_proto_enumTypes[1].Descriptor()
+	return file_tap_v1_tap_proto_enumTypes[2].Descriptor()
 }
 
 func (Protocol) Type() protoreflect.EnumType {
-	return &file_tap_v1_tap_proto_enumTypes[1]
+	return &file_tap_v1_tap_proto_enumTypes[2]
 }
 
 func (x Protocol) Number() protoreflect.EnumNumber {
@@ -127,25 +179,42 @@ func (x Protocol) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use Protocol.Descriptor instead.
 func (Protocol) EnumDescriptor() ([]byte, []int) {
-	return file_tap_v1_tap_proto_rawDescGZIP(), []int{1}
+	return file_tap_v1_tap_proto_rawDescGZIP(), []int{2}
 }
 
 type GRPCEvent struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Method          string                 `protobuf:"bytes,2,opt,name=method,proto3" json:"method,omitempty"`
-	CallType        CallType               `protobuf:"varint,3,opt,name=call_type,json=callType,proto3,enum=tap.v1.CallType" json:"call_type,omitempty"`
-	StartTime       *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
-	Duration        *durationpb.Duration   `protobuf:"bytes,5,opt,name=duration,proto3" json:"duration,omitempty"`
-	Status          int32                  `protobuf:"varint,6,opt,name=status,proto3" json:"status,omitempty"`
-	Error           string                 `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
-	Protocol        Protocol               `protobuf:"varint,8,opt,name=protocol,proto3,enum=tap.v1.Protocol" json:"protocol,omitempty"`
-	RequestBody     []byte                 `protobuf:"bytes,9,opt,name=request_body,json=requestBody,proto3" json:"request_body,omitempty"`
-	ResponseBody    []byte                 `protobuf:"bytes,10,opt,name=response_body,json=responseBody,proto3" json:"response_body,omitempty"`
-	RequestHeaders  map[string]string      `protobuf:"bytes,11,rep,name=request_headers,json=requestHeaders,proto3" json:"request_headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	ResponseHeaders map[string]string      `protobuf:"bytes,12,rep,name=response_headers,json=responseHeaders,proto3" json:"response_headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	Id                     string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Method                 string                 `protobuf:"bytes,2,opt,name=method,proto3" json:"method,omitempty"`
+	CallType               CallType               `protobuf:"varint,3,opt,name=call_type,json=callType,proto3,enum=tap.v1.CallType" json:"call_type,omitempty"`
+	StartTime              *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	Duration               *durationpb.Duration   `protobuf:"bytes,5,opt,name=duration,proto3" json:"duration,omitempty"`
+	Status                 int32                  `protobuf:"varint,6,opt,name=status,proto3" json:"status,omitempty"`
+	Error                  string                 `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	Protocol               Protocol               `protobuf:"varint,8,opt,name=protocol,proto3,enum=tap.v1.Protocol" json:"protocol,omitempty"`
+	RequestBody            []byte                 `protobuf:"bytes,9,opt,name=request_body,json=requestBody,proto3" json:"request_body,omitempty"`
+	ResponseBody           []byte                 `protobuf:"bytes,10,opt,name=response_body,json=responseBody,proto3" json:"response_body,omitempty"`
+	RequestHeaders         map[string]string      `protobuf:"bytes,11,rep,name=request_headers,json=requestHeaders,proto3" json:"request_headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	ResponseHeaders        map[string]string      `protobuf:"bytes,12,rep,name=response_headers,json=responseHeaders,proto3" json:"response_headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	ResponseTrailers       map[string]string      `protobuf:"bytes,13,rep,name=response_trailers,json=responseTrailers,proto3" json:"response_trailers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	RequestEncoding        string                 `protobuf:"bytes,14,opt,name=request_encoding,json=requestEncoding,proto3" json:"request_encoding,omitempty"`                         // grpc-encoding of the captured request message, empty if uncompressed
+	ResponseEncoding       string                 `protobuf:"bytes,15,opt,name=response_encoding,json=responseEncoding,proto3" json:"response_encoding,omitempty"`                      // grpc-encoding of the captured response message, empty if uncompressed
+	RequestCompressedSize  int64                  `protobuf:"varint,16,opt,name=request_compressed_size,json=requestCompressedSize,proto3" json:"request_compressed_size,omitempty"`    // compressed size of the captured request message
+	ResponseCompressedSize int64                  `protobuf:"varint,17,opt,name=response_compressed_size,json=responseCompressedSize,proto3" json:"response_compressed_size,omitempty"` // compressed size of the captured response message
+	Phase                  EventPhase             `protobuf:"varint,18,opt,name=phase,proto3,enum=tap.v1.EventPhase" json:"phase,omitempty"`                                            // start/progress events are superseded by a later event with the same id
+	BodyCaptureDisabled    bool                   `protobuf:"varint,19,opt,name=body_capture_disabled,json=bodyCaptureDisabled,proto3" json:"body_capture_disabled,omitempty"`          // the proxy does not retain payloads; bodies are always empty
+	Upstream               string                 `protobuf:"bytes,20,opt,name=upstream,proto3" json:"upstream,omitempty"`                                                              // replay upstream a replayed call was sent to; empty for the proxied upstream
+	Deadline               *durationpb.Duration   `protobuf:"bytes,21,opt,name=deadline,proto3" json:"deadline,omitempty"`                                                              // client-set timeout (grpc-timeout, Connect-Timeout-Ms); unset if none
+	PeerAddr               string                 `protobuf:"bytes,22,opt,name=peer_addr,json=peerAddr,proto3" json:"peer_addr,omitempty"`                                              // address of the calling client; empty for replayed calls
+	Authority              string                 `protobuf:"bytes,23,opt,name=authority,proto3" json:"authority,omitempty"`                                                            // :authority / Host the client addressed
+	CorrelationId          string                 `protobuf:"bytes,24,opt,name=correlation_id,json=correlationId,proto3" json:"correlation_id,omitempty"`                               // correlation header value, e.g. the traceparent trace ID; empty if none
+	TimeToFirstByte        *durationpb.Duration   `protobuf:"bytes,25,opt,name=time_to_first_byte,json=timeToFirstByte,proto3" json:"time_to_first_byte,omitempty"`                     // until the first response bytes arrived; unset if none did
+	ResponseMessageRate    float64                `protobuf:"fixed64,26,opt,name=response_message_rate,json=responseMessageRate,proto3" json:"response_message_rate,omitempty"`         // response messages per second of a stream; 0 for unary calls
+	ContentType            string                 `protobuf:"bytes,27,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`                                     // Content-Type of the request, e.g. application/connect+json
+	RequestFrames          []*Frame               `protobuf:"bytes,28,rep,name=request_frames,json=requestFrames,proto3" json:"request_frames,omitempty"`                               // captured request messages of a bidi stream, with their timing
+	ResponseFrames         []*Frame               `protobuf:"bytes,29,rep,name=response_frames,json=responseFrames,proto3" json:"response_frames,omitempty"`                            // captured response messages of a bidi stream, with their timing
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
 }
 
 func (x *GRPCEvent) Reset() {
@@ -262,6 +331,178 @@ func (x *GRPCEvent) GetResponseHeaders() map[string]string {
 	return nil
 }
 
+func (x *GRPCEvent) GetResponseTrailers() map[string]string {
+	if x != nil {
+		return x.ResponseTrailers
+	}
+	return nil
+}
+
+func (x *GRPCEvent) GetRequestEncoding() string {
+	if x != nil {
+		return x.RequestEncoding
+	}
+	return ""
+}
+
+func (x *GRPCEvent) GetResponseEncoding() string {
+	if x != nil {
+		return x.ResponseEncoding
+	}
+	return ""
+}
+
+func (x *GRPCEvent) GetRequestCompressedSize() int64 {
+	if x != nil {
+		return x.RequestCompressedSize
+	}
+	return 0
+}
+
+func (x *GRPCEvent) GetResponseCompressedSize() int64 {
+	if x != nil {
+		return x.ResponseCompressedSize
+	}
+	return 0
+}
+
+func (x *GRPCEvent) GetPhase() EventPhase {
+	if x != nil {
+		return x.Phase
+	}
+	return EventPhase_EVENT_PHASE_UNSPECIFIED
+}
+
+func (x *GRPCEvent) GetBodyCaptureDisabled() bool {
+	if x != nil {
+		return x.BodyCaptureDisabled
+	}
+	return false
+}
+
+func (x *GRPCEvent) GetUpstream() string {
+	if x != nil {
+		return x.Upstream
+	}
+	return ""
+}
+
+func (x *GRPCEvent) GetDeadline() *durationpb.Duration {
+	if x != nil {
+		return x.Deadline
+	}
+	return nil
+}
+
+func (x *GRPCEvent) GetPeerAddr() string {
+	if x != nil {
+		return x.PeerAddr
+	}
+	return ""
+}
+
+func (x *GRPCEvent) GetAuthority() string {
+	if x != nil {
+		return x.Authority
+	}
+	return ""
+}
+
+func (x *GRPCEvent) GetCorrelationId() string {
+	if x != nil {
+		return x.CorrelationId
+	}
+	return ""
+}
+
+func (x *GRPCEvent) GetTimeToFirstByte() *durationpb.Duration {
+	if x != nil {
+		return x.TimeToFirstByte
+	}
+	return nil
+}
+
+func (x *GRPCEvent) GetResponseMessageRate() float64 {
+	if x != nil {
+		return x.ResponseMessageRate
+	}
+	return 0
+}
+
+func (x *GRPCEvent) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *GRPCEvent) GetRequestFrames() []*Frame {
+	if x != nil {
+		return x.RequestFrames
+	}
+	return nil
+}
+
+func (x *GRPCEvent) GetResponseFrames() []*Frame {
+	if x != nil {
+		return x.ResponseFrames
+	}
+	return nil
+}
+
+// Frame is one message of a stream.
+type Frame struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Offset        *durationpb.Duration   `protobuf:"bytes,1,opt,name=offset,proto3" json:"offset,omitempty"` // when the message passed the proxy, after start_time
+	Body          []byte                 `protobuf:"bytes,2,opt,name=body,proto3" json:"body,omitempty"`     // the message, decompressed
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Frame) Reset() {
+	*x = Frame{}
+	mi := &file_tap_v1_tap_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Frame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Frame) ProtoMessage() {}
+
+func (x *Frame) ProtoReflect() protoreflect.Message {
+	mi := &file_tap_v1_tap_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Frame.ProtoReflect.Descriptor instead.
+func (*Frame) Descriptor() ([]byte, []int) {
+	return file_tap_v1_tap_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Frame) GetOffset() *durationpb.Duration {
+	if x != nil {
+		return x.Offset
+	}
+	return nil
+}
+
+func (x *Frame) GetBody() []byte {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
 type WatchRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -270,7 +511,7 @@ type WatchRequest struct {
 
 func (x *WatchRequest) Reset() {
 	*x = WatchRequest{}
-	mi := &file_tap_v1_tap_proto_msgTypes[1]
+	mi := &file_tap_v1_tap_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -282,7 +523,7 @@ func (x *WatchRequest) String() string {
 func (*WatchRequest) ProtoMessage() {}
 
 func (x *WatchRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_tap_v1_tap_proto_msgTypes[1]
+	mi := &file_tap_v1_tap_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -295,7 +536,7 @@ func (x *WatchRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
 func (*WatchRequest) Descriptor() ([]byte, []int) {
-	return file_tap_v1_tap_proto_rawDescGZIP(), []int{1}
+	return file_tap_v1_tap_proto_rawDescGZIP(), []int{2}
 }
 
 type WatchResponse struct {
@@ -307,7 +548,7 @@ type WatchResponse struct {
 
 func (x *WatchResponse) Reset() {
 	*x = WatchResponse{}
-	mi := &file_tap_v1_tap_proto_msgTypes[2]
+	mi := &file_tap_v1_tap_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -319,7 +560,7 @@ func (x *WatchResponse) String() string {
 func (*WatchResponse) ProtoMessage() {}
 
 func (x *WatchResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_tap_v1_tap_proto_msgTypes[2]
+	mi := &file_tap_v1_tap_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -332,7 +573,7 @@ func (x *WatchResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WatchResponse.ProtoReflect.Descriptor instead.
 func (*WatchResponse) Descriptor() ([]byte, []int) {
-	return file_tap_v1_tap_proto_rawDescGZIP(), []int{2}
+	return file_tap_v1_tap_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *WatchResponse) GetEvent() *GRPCEvent {
@@ -346,13 +587,16 @@ type ReplayRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Method        string                 `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"`                              // e.g. "/echo.v1.EchoService/Echo"
 	RequestBody   []byte                 `protobuf:"bytes,2,opt,name=request_body,json=requestBody,proto3" json:"request_body,omitempty"` // protobuf wire format (without gRPC framing)
+	Upstream      string                 `protobuf:"bytes,3,opt,name=upstream,proto3" json:"upstream,omitempty"`                          // name of an allow-listed replay upstream; empty for the proxied upstream
+	Protocol      Protocol               `protobuf:"varint,4,opt,name=protocol,proto3,enum=tap.v1.Protocol" json:"protocol,omitempty"`    // protocol to replay with, e.g. that of the original call; unspecified for gRPC
+	ContentType   string                 `protobuf:"bytes,5,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"` // Content-Type to send, e.g. application/json for Connect JSON; empty for the protocol's protobuf type
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ReplayRequest) Reset() {
 	*x = ReplayRequest{}
-	mi := &file_tap_v1_tap_proto_msgTypes[3]
+	mi := &file_tap_v1_tap_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -364,7 +608,7 @@ func (x *ReplayRequest) String() string {
 func (*ReplayRequest) ProtoMessage() {}
 
 func (x *ReplayRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_tap_v1_tap_proto_msgTypes[3]
+	mi := &file_tap_v1_tap_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -377,7 +621,7 @@ func (x *ReplayRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReplayRequest.ProtoReflect.Descriptor instead.
 func (*ReplayRequest) Descriptor() ([]byte, []int) {
-	return file_tap_v1_tap_proto_rawDescGZIP(), []int{3}
+	return file_tap_v1_tap_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *ReplayRequest) GetMethod() string {
@@ -394,6 +638,27 @@ func (x *ReplayRequest) GetRequestBody() []byte {
 	return nil
 }
 
+func (x *ReplayRequest) GetUpstream() string {
+	if x != nil {
+		return x.Upstream
+	}
+	return ""
+}
+
+func (x *ReplayRequest) GetProtocol() Protocol {
+	if x != nil {
+		return x.Protocol
+	}
+	return Protocol_PROTOCOL_UNSPECIFIED
+}
+
+func (x *ReplayRequest) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
 type ReplayResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Event         *GRPCEvent             `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"` // resulting event from the replayed call
@@ -403,7 +668,7 @@ type ReplayResponse struct {
 
 func (x *ReplayResponse) Reset() {
 	*x = ReplayResponse{}
-	mi := &file_tap_v1_tap_proto_msgTypes[4]
+	mi := &file_tap_v1_tap_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -415,7 +680,7 @@ func (x *ReplayResponse) String() string {
 func (*ReplayResponse) ProtoMessage() {}
 
 func (x *ReplayResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_tap_v1_tap_proto_msgTypes[4]
+	mi := &file_tap_v1_tap_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -428,7 +693,7 @@ func (x *ReplayResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReplayResponse.ProtoReflect.Descriptor instead.
 func (*ReplayResponse) Descriptor() ([]byte, []int) {
-	return file_tap_v1_tap_proto_rawDescGZIP(), []int{4}
+	return file_tap_v1_tap_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *ReplayResponse) GetEvent() *GRPCEvent {
@@ -438,11 +703,287 @@ func (x *ReplayResponse) GetEvent() *GRPCEvent {
 	return nil
 }
 
+type GetStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Window        *durationpb.Duration   `protobuf:"bytes,1,opt,name=window,proto3" json:"window,omitempty"` // only count calls completed within this window; unset for the server's full window
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsRequest) Reset() {
+	*x = GetStatsRequest{}
+	mi := &file_tap_v1_tap_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsRequest) ProtoMessage() {}
+
+func (x *GetStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tap_v1_tap_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetStatsRequest) Descriptor() ([]byte, []int) {
+	return file_tap_v1_tap_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetStatsRequest) GetWindow() *durationpb.Duration {
+	if x != nil {
+		return x.Window
+	}
+	return nil
+}
+
+type GetStatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Methods       []*MethodStats         `protobuf:"bytes,1,rep,name=methods,proto3" json:"methods,omitempty"` // ordered by method
+	Window        *durationpb.Duration   `protobuf:"bytes,2,opt,name=window,proto3" json:"window,omitempty"`   // window the stats were computed over
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsResponse) Reset() {
+	*x = GetStatsResponse{}
+	mi := &file_tap_v1_tap_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsResponse) ProtoMessage() {}
+
+func (x *GetStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tap_v1_tap_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetStatsResponse) Descriptor() ([]byte, []int) {
+	return file_tap_v1_tap_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetStatsResponse) GetMethods() []*MethodStats {
+	if x != nil {
+		return x.Methods
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetWindow() *durationpb.Duration {
+	if x != nil {
+		return x.Window
+	}
+	return nil
+}
+
+type MethodStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Method        string                 `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Errors        int64                  `protobuf:"varint,3,opt,name=errors,proto3" json:"errors,omitempty"`
+	ErrorRate     float64                `protobuf:"fixed64,4,opt,name=error_rate,json=errorRate,proto3" json:"error_rate,omitempty"` // percent of calls with a non-OK status
+	Total         *durationpb.Duration   `protobuf:"bytes,5,opt,name=total,proto3" json:"total,omitempty"`
+	P50           *durationpb.Duration   `protobuf:"bytes,6,opt,name=p50,proto3" json:"p50,omitempty"`
+	P95           *durationpb.Duration   `protobuf:"bytes,7,opt,name=p95,proto3" json:"p95,omitempty"`
+	P99           *durationpb.Duration   `protobuf:"bytes,8,opt,name=p99,proto3" json:"p99,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MethodStats) Reset() {
+	*x = MethodStats{}
+	mi := &file_tap_v1_tap_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MethodStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MethodStats) ProtoMessage() {}
+
+func (x *MethodStats) ProtoReflect() protoreflect.Message {
+	mi := &file_tap_v1_tap_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MethodStats.ProtoReflect.Descriptor instead.
+func (*MethodStats) Descriptor() ([]byte, []int) {
+	return file_tap_v1_tap_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *MethodStats) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *MethodStats) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *MethodStats) GetErrors() int64 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *MethodStats) GetErrorRate() float64 {
+	if x != nil {
+		return x.ErrorRate
+	}
+	return 0
+}
+
+func (x *MethodStats) GetTotal() *durationpb.Duration {
+	if x != nil {
+		return x.Total
+	}
+	return nil
+}
+
+func (x *MethodStats) GetP50() *durationpb.Duration {
+	if x != nil {
+		return x.P50
+	}
+	return nil
+}
+
+func (x *MethodStats) GetP95() *durationpb.Duration {
+	if x != nil {
+		return x.P95
+	}
+	return nil
+}
+
+func (x *MethodStats) GetP99() *durationpb.Duration {
+	if x != nil {
+		return x.P99
+	}
+	return nil
+}
+
+type ClearRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClearRequest) Reset() {
+	*x = ClearRequest{}
+	mi := &file_tap_v1_tap_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClearRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClearRequest) ProtoMessage() {}
+
+func (x *ClearRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tap_v1_tap_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClearRequest.ProtoReflect.Descriptor instead.
+func (*ClearRequest) Descriptor() ([]byte, []int) {
+	return file_tap_v1_tap_proto_rawDescGZIP(), []int{9}
+}
+
+type ClearResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cleared       int64                  `protobuf:"varint,1,opt,name=cleared,proto3" json:"cleared,omitempty"` // number of backlog events dropped
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClearResponse) Reset() {
+	*x = ClearResponse{}
+	mi := &file_tap_v1_tap_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClearResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClearResponse) ProtoMessage() {}
+
+func (x *ClearResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tap_v1_tap_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClearResponse.ProtoReflect.Descriptor instead.
+func (*ClearResponse) Descriptor() ([]byte, []int) {
+	return file_tap_v1_tap_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ClearResponse) GetCleared() int64 {
+	if x != nil {
+		return x.Cleared
+	}
+	return 0
+}
+
 var File_tap_v1_tap_proto protoreflect.FileDescriptor
 
 const file_tap_v1_tap_proto_rawDesc = "" +
 	"\n" +
-	"\x10tap/v1/tap.proto\x12\x06tap.v1\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1egoogle/protobuf/duration.proto\"\xa2\x05\n" +
+	"\x10tap/v1/tap.proto\x12\x06tap.v1\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1egoogle/protobuf/duration.proto\"\xa7\f\n" +
 	"\tGRPCEvent\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
 	"\x06method\x18\x02 \x01(\tR\x06method\x12-\n" +
@@ -457,21 +998,71 @@ const file_tap_v1_tap_proto_rawDesc = "" +
 	"\rresponse_body\x18\n" +
 	" \x01(\fR\fresponseBody\x12N\n" +
 	"\x0frequest_headers\x18\v \x03(\v2%.tap.v1.GRPCEvent.RequestHeadersEntryR\x0erequestHeaders\x12Q\n" +
-	"\x10response_headers\x18\f \x03(\v2&.tap.v1.GRPCEvent.ResponseHeadersEntryR\x0fresponseHeaders\x1aA\n" +
+	"\x10response_headers\x18\f \x03(\v2&.tap.v1.GRPCEvent.ResponseHeadersEntryR\x0fresponseHeaders\x12T\n" +
+	"\x11response_trailers\x18\r \x03(\v2'.tap.v1.GRPCEvent.ResponseTrailersEntryR\x10responseTrailers\x12)\n" +
+	"\x10request_encoding\x18\x0e \x01(\tR\x0frequestEncoding\x12+\n" +
+	"\x11response_encoding\x18\x0f \x01(\tR\x10responseEncoding\x126\n" +
+	"\x17request_compressed_size\x18\x10 \x01(\x03R\x15requestCompressedSize\x128\n" +
+	"\x18response_compressed_size\x18\x11 \x01(\x03R\x16responseCompressedSize\x12(\n" +
+	"\x05phase\x18\x12 \x01(\x0e2\x12.tap.v1.EventPhaseR\x05phase\x122\n" +
+	"\x15body_capture_disabled\x18\x13 \x01(\bR\x13bodyCaptureDisabled\x12\x1a\n" +
+	"\bupstream\x18\x14 \x01(\tR\bupstream\x125\n" +
+	"\bdeadline\x18\x15 \x01(\v2\x19.google.protobuf.DurationR\bdeadline\x12\x1b\n" +
+	"\tpeer_addr\x18\x16 \x01(\tR\bpeerAddr\x12\x1c\n" +
+	"\tauthority\x18\x17 \x01(\tR\tauthority\x12%\n" +
+	"\x0ecorrelation_id\x18\x18 \x01(\tR\rcorrelationId\x12F\n" +
+	"\x12time_to_first_byte\x18\x19 \x01(\v2\x19.google.protobuf.DurationR\x0ftimeToFirstByte\x122\n" +
+	"\x15response_message_rate\x18\x1a \x01(\x01R\x13responseMessageRate\x12!\n" +
+	"\fcontent_type\x18\x1b \x01(\tR\vcontentType\x124\n" +
+	"\x0erequest_frames\x18\x1c \x03(\v2\r.tap.v1.FrameR\rrequestFrames\x126\n" +
+	"\x0fresponse_frames\x18\x1d \x03(\v2\r.tap.v1.FrameR\x0eresponseFrames\x1aA\n" +
 	"\x13RequestHeadersEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1aB\n" +
 	"\x14ResponseHeadersEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x0e\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1aC\n" +
+	"\x15ResponseTrailersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"N\n" +
+	"\x05Frame\x121\n" +
+	"\x06offset\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\x06offset\x12\x12\n" +
+	"\x04body\x18\x02 \x01(\fR\x04body\"\x0e\n" +
 	"\fWatchRequest\"8\n" +
 	"\rWatchResponse\x12'\n" +
-	"\x05event\x18\x01 \x01(\v2\x11.tap.v1.GRPCEventR\x05event\"J\n" +
+	"\x05event\x18\x01 \x01(\v2\x11.tap.v1.GRPCEventR\x05event\"\xb7\x01\n" +
 	"\rReplayRequest\x12\x16\n" +
 	"\x06method\x18\x01 \x01(\tR\x06method\x12!\n" +
-	"\frequest_body\x18\x02 \x01(\fR\vrequestBody\"9\n" +
+	"\frequest_body\x18\x02 \x01(\fR\vrequestBody\x12\x1a\n" +
+	"\bupstream\x18\x03 \x01(\tR\bupstream\x12,\n" +
+	"\bprotocol\x18\x04 \x01(\x0e2\x10.tap.v1.ProtocolR\bprotocol\x12!\n" +
+	"\fcontent_type\x18\x05 \x01(\tR\vcontentType\"9\n" +
 	"\x0eReplayResponse\x12'\n" +
-	"\x05event\x18\x01 \x01(\v2\x11.tap.v1.GRPCEventR\x05event*\x8f\x01\n" +
+	"\x05event\x18\x01 \x01(\v2\x11.tap.v1.GRPCEventR\x05event\"D\n" +
+	"\x0fGetStatsRequest\x121\n" +
+	"\x06window\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\x06window\"t\n" +
+	"\x10GetStatsResponse\x12-\n" +
+	"\amethods\x18\x01 \x03(\v2\x13.tap.v1.MethodStatsR\amethods\x121\n" +
+	"\x06window\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x06window\"\xaa\x02\n" +
+	"\vMethodStats\x12\x16\n" +
+	"\x06method\x18\x01 \x01(\tR\x06method\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\x12\x16\n" +
+	"\x06errors\x18\x03 \x01(\x03R\x06errors\x12\x1d\n" +
+	"\n" +
+	"error_rate\x18\x04 \x01(\x01R\terrorRate\x12/\n" +
+	"\x05total\x18\x05 \x01(\v2\x19.google.protobuf.DurationR\x05total\x12+\n" +
+	"\x03p50\x18\x06 \x01(\v2\x19.google.protobuf.DurationR\x03p50\x12+\n" +
+	"\x03p95\x18\a \x01(\v2\x19.google.protobuf.DurationR\x03p95\x12+\n" +
+	"\x03p99\x18\b \x01(\v2\x19.google.protobuf.DurationR\x03p99\"\x0e\n" +
+	"\fClearRequest\")\n" +
+	"\rClearResponse\x12\x18\n" +
+	"\acleared\x18\x01 \x01(\x03R\acleared*t\n" +
+	"\n" +
+	"EventPhase\x12\x1b\n" +
+	"\x17EVENT_PHASE_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14EVENT_PHASE_COMPLETE\x10\x01\x12\x18\n" +
+	"\x14EVENT_PHASE_PROGRESS\x10\x02\x12\x15\n" +
+	"\x11EVENT_PHASE_START\x10\x03*\x8f\x01\n" +
 	"\bCallType\x12\x19\n" +
 	"\x15CALL_TYPE_UNSPECIFIED\x10\x00\x12\x13\n" +
 	"\x0fCALL_TYPE_UNARY\x10\x01\x12\x1b\n" +
@@ -482,11 +1073,13 @@ const file_tap_v1_tap_proto_rawDesc = "" +
 	"\x14PROTOCOL_UNSPECIFIED\x10\x00\x12\x11\n" +
 	"\rPROTOCOL_GRPC\x10\x01\x12\x15\n" +
 	"\x11PROTOCOL_GRPC_WEB\x10\x02\x12\x14\n" +
-	"\x10PROTOCOL_CONNECT\x10\x032}\n" +
+	"\x10PROTOCOL_CONNECT\x10\x032\xf2\x01\n" +
 	"\n" +
 	"TapService\x126\n" +
 	"\x05Watch\x12\x14.tap.v1.WatchRequest\x1a\x15.tap.v1.WatchResponse0\x01\x127\n" +
-	"\x06Replay\x12\x15.tap.v1.ReplayRequest\x1a\x16.tap.v1.ReplayResponseB}\n" +
+	"\x06Replay\x12\x15.tap.v1.ReplayRequest\x1a\x16.tap.v1.ReplayResponse\x12=\n" +
+	"\bGetStats\x12\x17.tap.v1.GetStatsRequest\x1a\x18.tap.v1.GetStatsResponse\x124\n" +
+	"\x05Clear\x12\x14.tap.v1.ClearRequest\x1a\x15.tap.v1.ClearResponseB}\n" +
 	"\n" +
 	"com.tap.v1B\bTapProtoP\x01Z,github.com/mickamy/grpc-tap/gen/tap/v1;tapv1\xa2\x02\x03TXX\xaa\x02\x06Tap.V1\xca\x02\x06Tap\\V1\xe2\x02\x12Tap\\V1\\GPBMetadata\xea\x02\aTap::V1b\x06proto3"
 
@@ -502,39 +1095,66 @@ func file_tap_v1_tap_proto_rawDescGZIP() []byte {
 	return file_tap_v1_tap_proto_rawDescData
 }
 
-var file_tap_v1_tap_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_tap_v1_tap_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_tap_v1_tap_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_tap_v1_tap_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_tap_v1_tap_proto_goTypes = []any{
-	(CallType)(0),                 // 0: tap.v1.CallType
-	(Protocol)(0),                 // 1: tap.v1.Protocol
-	(*GRPCEvent)(nil),             // 2: tap.v1.GRPCEvent
-	(*WatchRequest)(nil),          // 3: tap.v1.WatchRequest
-	(*WatchResponse)(nil),         // 4: tap.v1.WatchResponse
-	(*ReplayRequest)(nil),         // 5: tap.v1.ReplayRequest
-	(*ReplayResponse)(nil),        // 6: tap.v1.ReplayResponse
-	nil,                           // 7: tap.v1.GRPCEvent.RequestHeadersEntry
-	nil,                           // 8: tap.v1.GRPCEvent.ResponseHeadersEntry
-	(*timestamppb.Timestamp)(nil), // 9: google.protobuf.Timestamp
-	(*durationpb.Duration)(nil),   // 10: google.protobuf.Duration
+	(EventPhase)(0),               // 0: tap.v1.EventPhase
+	(CallType)(0),                 // 1: tap.v1.CallType
+	(Protocol)(0),                 // 2: tap.v1.Protocol
+	(*GRPCEvent)(nil),             // 3: tap.v1.GRPCEvent
+	(*Frame)(nil),                 // 4: tap.v1.Frame
+	(*WatchRequest)(nil),          // 5: tap.v1.WatchRequest
+	(*WatchResponse)(nil),         // 6: tap.v1.WatchResponse
+	(*ReplayRequest)(nil),         // 7: tap.v1.ReplayRequest
+	(*ReplayResponse)(nil),        // 8: tap.v1.ReplayResponse
+	(*GetStatsRequest)(nil),       // 9: tap.v1.GetStatsRequest
+	(*GetStatsResponse)(nil),      // 10: tap.v1.GetStatsResponse
+	(*MethodStats)(nil),           // 11: tap.v1.MethodStats
+	(*ClearRequest)(nil),          // 12: tap.v1.ClearRequest
+	(*ClearResponse)(nil),         // 13: tap.v1.ClearResponse
+	nil,                           // 14: tap.v1.GRPCEvent.RequestHeadersEntry
+	nil,                           // 15: tap.v1.GRPCEvent.ResponseHeadersEntry
+	nil,                           // 16: tap.v1.GRPCEvent.ResponseTrailersEntry
+	(*timestamppb.Timestamp)(nil), // 17: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),   // 18: google.protobuf.Duration
 }
 var file_tap_v1_tap_proto_depIdxs = []int32{
-	0,  // 0: tap.v1.GRPCEvent.call_type:type_name -> tap.v1.CallType
-	9,  // 1: tap.v1.GRPCEvent.start_time:type_name -> google.protobuf.Timestamp
-	10, // 2: tap.v1.GRPCEvent.duration:type_name -> google.protobuf.Duration
-	1,  // 3: tap.v1.GRPCEvent.protocol:type_name -> tap.v1.Protocol
-	7,  // 4: tap.v1.GRPCEvent.request_headers:type_name -> tap.v1.GRPCEvent.RequestHeadersEntry
-	8,  // 5: tap.v1.GRPCEvent.response_headers:type_name -> tap.v1.GRPCEvent.ResponseHeadersEntry
-	2,  // 6: tap.v1.WatchResponse.event:type_name -> tap.v1.GRPCEvent
-	2,  // 7: tap.v1.ReplayResponse.event:type_name -> tap.v1.GRPCEvent
-	3,  // 8: tap.v1.TapService.Watch:input_type -> tap.v1.WatchRequest
-	5,  // 9: tap.v1.TapService.Replay:input_type -> tap.v1.ReplayRequest
-	4,  // 10: tap.v1.TapService.Watch:output_type -> tap.v1.WatchResponse
-	6,  // 11: tap.v1.TapService.Replay:output_type -> tap.v1.ReplayResponse
-	10, // [10:12] is the sub-list for method output_type
-	8,  // [8:10] is the sub-list for method input_type
-	8,  // [8:8] is the sub-list for extension type_name
-	8,  // [8:8] is the sub-list for extension extendee
-	0,  // [0:8] is the sub-list for field type_name
+	1,  // 0: tap.v1.GRPCEvent.call_type:type_name -> tap.v1.CallType
+	17, // 1: tap.v1.GRPCEvent.start_time:type_name -> google.protobuf.Timestamp
+	18, // 2: tap.v1.GRPCEvent.duration:type_name -> google.protobuf.Duration
+	2,  // 3: tap.v1.GRPCEvent.protocol:type_name -> tap.v1.Protocol
+	14, // 4: tap.v1.GRPCEvent.request_headers:type_name -> tap.v1.GRPCEvent.RequestHeadersEntry
+	15, // 5: tap.v1.GRPCEvent.response_headers:type_name -> tap.v1.GRPCEvent.ResponseHeadersEntry
+	16, // 6: tap.v1.GRPCEvent.response_trailers:type_name -> tap.v1.GRPCEvent.ResponseTrailersEntry
+	0,  // 7: tap.v1.GRPCEvent.phase:type_name -> tap.v1.EventPhase
+	18, // 8: tap.v1.GRPCEvent.deadline:type_name -> google.protobuf.Duration
+	18, // 9: tap.v1.GRPCEvent.time_to_first_byte:type_name -> google.protobuf.Duration
+	4,  // 10: tap.v1.GRPCEvent.request_frames:type_name -> tap.v1.Frame
+	4,  // 11: tap.v1.GRPCEvent.response_frames:type_name -> tap.v1.Frame
+	18, // 12: tap.v1.Frame.offset:type_name -> google.protobuf.Duration
+	3,  // 13: tap.v1.WatchResponse.event:type_name -> tap.v1.GRPCEvent
+	2,  // 14: tap.v1.ReplayRequest.protocol:type_name -> tap.v1.Protocol
+	3,  // 15: tap.v1.ReplayResponse.event:type_name -> tap.v1.GRPCEvent
+	18, // 16: tap.v1.GetStatsRequest.window:type_name -> google.protobuf.Duration
+	11, // 17: tap.v1.GetStatsResponse.methods:type_name -> tap.v1.MethodStats
+	18, // 18: tap.v1.GetStatsResponse.window:type_name -> google.protobuf.Duration
+	18, // 19: tap.v1.MethodStats.total:type_name -> google.protobuf.Duration
+	18, // 20: tap.v1.MethodStats.p50:type_name -> google.protobuf.Duration
+	18, // 21: tap.v1.MethodStats.p95:type_name -> google.protobuf.Duration
+	18, // 22: tap.v1.MethodStats.p99:type_name -> google.protobuf.Duration
+	5,  // 23: tap.v1.TapService.Watch:input_type -> tap.v1.WatchRequest
+	7,  // 24: tap.v1.TapService.Replay:input_type -> tap.v1.ReplayRequest
+	9,  // 25: tap.v1.TapService.GetStats:input_type -> tap.v1.GetStatsRequest
+	12, // 26: tap.v1.TapService.Clear:input_type -> tap.v1.ClearRequest
+	6,  // 27: tap.v1.TapService.Watch:output_type -> tap.v1.WatchResponse
+	8,  // 28: tap.v1.TapService.Replay:output_type -> tap.v1.ReplayResponse
+	10, // 29: tap.v1.TapService.GetStats:output_type -> tap.v1.GetStatsResponse
+	13, // 30: tap.v1.TapService.Clear:output_type -> tap.v1.ClearResponse
+	27, // [27:31] is the sub-list for method output_type
+	23, // [23:27] is the sub-list for method input_type
+	23, // [23:23] is the sub-list for extension type_name
+	23, // [23:23] is the sub-list for extension extendee
+	0,  // [0:23] is the sub-list for field type_name
 }
 
 func init() { file_tap_v1_tap_proto_init() }
@@ -547,8 +1167,8 @@ func file_tap_v1_tap_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_tap_v1_tap_proto_rawDesc), len(file_tap_v1_tap_proto_rawDesc)),
-			NumEnums:      2,
-			NumMessages:   7,
+			NumEnums:      3,
+			NumMessages:   14,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,8 +19,10 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	TapService_Watch_FullMethodName  = "/tap.v1.TapService/Watch"
-	TapService_Replay_FullMethodName = "/tap.v1.TapService/Replay"
+	TapService_Watch_FullMethodName    = "/tap.v1.TapService/Watch"
+	TapService_Replay_FullMethodName   = "/tap.v1.TapService/Replay"
+	TapService_GetStats_FullMethodName = "/tap.v1.TapService/GetStats"
+	TapService_Clear_FullMethodName    = "/tap.v1.TapService/Clear"
 )
 
 // TapServiceClient is the client API for TapService service.
@@ -29,6 +31,9 @@ const (
 type TapServiceClient interface {
 	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchResponse], error)
 	Replay(ctx context.Context, in *ReplayRequest, opts ...grpc.CallOption) (*ReplayResponse, error)
+	GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error)
+	// Clear drops the daemon's event backlog and stats for every client.
+	Clear(ctx context.Context, in *ClearRequest, opts ...grpc.CallOption) (*ClearResponse, error)
 }
 
 type tapServiceClient struct {
@@ -68,12 +73,35 @@ func (c *tapServiceClient) Replay(ctx context.Context, in *ReplayRequest, opts .
 	return out, nil
 }
 
+func (c *tapServiceClient) GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetStatsResponse)
+	err := c.cc.Invoke(ctx, TapService_GetStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tapServiceClient) Clear(ctx context.Context, in *ClearRequest, opts ...grpc.CallOption) (*ClearResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClearResponse)
+	err := c.cc.Invoke(ctx, TapService_Clear_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TapServiceServer is the server API for TapService service.
 // All implementations must embed UnimplementedTapServiceServer
 // for forward compatibility.
 type TapServiceServer interface {
 	Watch(*WatchRequest, grpc.ServerStreamingServer[WatchResponse]) error
 	Replay(context.Context, *ReplayRequest) (*ReplayResponse, error)
+	GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error)
+	// Clear drops the daemon's event backlog and stats for every client.
+	Clear(context.Context, *ClearRequest) (*ClearResponse, error)
 	mustEmbedUnimplementedTapServiceServer()
 }
 
@@ -90,6 +118,12 @@ func (UnimplementedTapServiceServer) Watch(*WatchRequest, grpc.ServerStreamingSe
 func (UnimplementedTapServiceServer) Replay(context.Context, *ReplayRequest) (*ReplayResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Replay not implemented")
 }
+func (UnimplementedTapServiceServer) GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetStats not implemented")
+}
+func (UnimplementedTapServiceServer) Clear(context.Context, *ClearRequest) (*ClearResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Clear not implemented")
+}
 func (UnimplementedTapServiceServer) mustEmbedUnimplementedTapServiceServer() {}
 func (UnimplementedTapServiceServer) testEmbeddedByValue()                    {}
 
@@ -140,6 +174,42 @@ func _TapService_Replay_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TapService_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TapServiceServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TapService_GetStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TapServiceServer).GetStats(ctx, req.(*GetStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TapService_Clear_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClearRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TapServiceServer).Clear(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TapService_Clear_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TapServiceServer).Clear(ctx, req.(*ClearRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // TapService_ServiceDesc is the grpc.ServiceDesc for TapService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -151,6 +221,14 @@ var TapService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Replay",
 			Handler:    _TapService_Replay_Handler,
 		},
+		{
+			MethodName: "GetStats",
+			Handler:    _TapService_GetStats_Handler,
+		},
+		{
+			MethodName: "Clear",
+			Handler:    _TapService_Clear_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -1,24 +1,64 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/mickamy/grpc-tap/compare"
+	"github.com/mickamy/grpc-tap/filter"
+	"github.com/mickamy/grpc-tap/proxy"
 	"github.com/mickamy/grpc-tap/tui"
 )
 
 var version = "dev"
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "table" {
+		tableMain(os.Args[2:])
+		return
+	}
+
 	fs := flag.NewFlagSet("grpc-tap", flag.ExitOnError)
 	fs.Usage = func() {
-		fmt.Fprintf(os.Stderr, "grpc-tap — Watch gRPC traffic in real-time\n\nUsage:\n  grpc-tap [flags] <addr>\n\nFlags:\n")
+		fmt.Fprintf(os.Stderr, "grpc-tap — Watch gRPC traffic in real-time\n\nUsage:\n  grpc-tap [flags] <addr>\n"+
+			"  grpc-tap table [flags] <addr>\n\nFlags:\n")
 		fs.PrintDefaults()
 	}
 
+	keymapPath := fs.String("keymap", "", "path to a JSON keymap file overriding default keybindings")
+	onError := fs.String("on-error", "off", "react to new error events: off, alert, or inspect (open when following)")
+	bellOnError := fs.Bool("bell-on-error", false, "ring the terminal bell when an error event arrives")
+	notifyOnError := fs.Bool("notify-on-error", false, "send a desktop notification when an error event arrives")
+	errorRateAlert := fs.Float64("error-rate-alert", 0,
+		"show a banner while a method's error rate over the last minute exceeds this share, e.g. 0.05 for 5% (0 to disable)")
+	exportWindow := fs.Duration("export-window", 0, "only export events started within this long before the export, e.g. 5m (0 for all)")
+	hideInternal := fs.Bool("hide-internal", true, "hide health check and reflection calls from the list, analytics and exports")
+	internalMethods := fs.String("internal-methods", strings.Join(tui.DefaultInternalMethods, ","),
+		"comma-separated method prefixes treated as internal by -hide-internal")
+	traceColumn := fs.Bool("trace-column", false, "show the correlation ID (trace ID) of each call as a list column")
+	columns := fs.String("columns", "proto,method,type,status,duration,time",
+		"list columns in order, from proto, method, host, trace, type, content-type, status, duration and time (method is required)")
+	hostColumn := fs.Bool("host-column", false, "show the authority (host) each call addressed as a list column")
+	maxEvents := fs.Int("max-events", 0, "keep at most this many calls, dropping the oldest that are not bookmarked (0 for no limit)")
+	assertReplays := fs.Bool("assert-replays", false, "compare the response of each replay with the original call's and show PASS/FAIL with a diff")
+	replayConcurrency := fs.Int("replay-concurrency", 1, "how many replays of a batch replay (R) are in flight at once")
+	replayPacing := fs.String("replay-pacing", "fast",
+		"space the replays of a batch replay (R): fast, original (the gaps between the original calls) or a fixed delay such as 200ms")
+	assertPath := fs.String("assert", "", "replay the calls in this capture file (JSON from /api/events/history) and exit 1 if a response differs")
+	assertMatch := fs.String("assert-match", "fields", "when responses match for -assert and -assert-replays: fields (decoded fields equal) or bytes (identical bytes)")
+	filterExpr := fs.String("filter", "", "start with this filter expression as the search (e.g. \"code:error -method:Health\"); with -assert, only replay matching calls")
+	statePath := fs.String("state", tui.DefaultStatePath(), "file saved views are kept in (empty to keep them for the session only)")
+	durationUnit := fs.String("duration-unit", "auto", "show durations in one unit: auto (ns, µs, ms or s by magnitude), us, ms or s")
+	descriptorSet := fs.String("descriptor-set", "", "decode bodies with the message types in this FileDescriptorSet (from buf build -o or protoc --include_imports --descriptor_set_out)")
+	noHighlight := fs.Bool("no-highlight", false, "disable syntax highlighting of decoded bodies")
+	noPreview := fs.Bool("no-preview", false, "start with the preview pane below the list hidden (toggle it with P)")
+	noAutoFollow := fs.Bool("no-auto-follow", false, "only follow new events when toggled with F, not when the cursor reaches the last row")
+	summaryOnExit := fs.Bool("summary-on-exit", false, "print a summary of the session (calls, errors, slowest methods) on quit")
 	showVersion := fs.Bool("version", false, "show version and exit")
 
 	_ = fs.Parse(os.Args[1:])
@@ -33,10 +73,135 @@ func main() {
 		os.Exit(1)
 	}
 
-	m := tui.New(fs.Arg(0))
+	matchMode, err := compare.ParseMode(*assertMatch)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	search, err := filter.Parse(*filterExpr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *assertPath != "" {
+		failed, err := runAssert(context.Background(), fs.Arg(0), *assertPath, search, matchMode, os.Stdout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	errorMode, err := tui.ParseErrorMode(*onError)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	unit, err := tui.ParseDurationUnit(*durationUnit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	cols, err := tui.ParseColumns(*columns)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *errorRateAlert < 0 || *errorRateAlert >= 1 {
+		fmt.Fprintf(os.Stderr, "Error: -error-rate-alert %v is not a share between 0 and 1\n", *errorRateAlert)
+		os.Exit(1)
+	}
+	pacing, err := tui.ParseReplayPacing(*replayPacing)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *replayConcurrency < 1 {
+		fmt.Fprintf(os.Stderr, "Error: -replay-concurrency %d is below 1\n", *replayConcurrency)
+		os.Exit(1)
+	}
+	opts := []tui.Option{
+		tui.WithErrorMode(errorMode),
+		tui.WithBellOnError(*bellOnError),
+		tui.WithNotifyOnError(*notifyOnError),
+		tui.WithErrorRateAlert(*errorRateAlert),
+		tui.WithSyntaxHighlight(!*noHighlight),
+		tui.WithPreview(!*noPreview),
+		tui.WithAutoFollow(!*noAutoFollow),
+		tui.WithExportWindow(*exportWindow),
+		tui.WithHideInternal(*hideInternal),
+		tui.WithInternalMethods(splitPrefixes(*internalMethods)),
+		tui.WithColumns(cols),
+		tui.WithHostColumn(*hostColumn),
+		tui.WithTraceColumn(*traceColumn),
+		tui.WithMaxEvents(*maxEvents),
+		tui.WithDurationUnit(unit),
+		tui.WithSearch(*filterExpr),
+		tui.WithSummaryOnExit(*summaryOnExit),
+		tui.WithBatchConcurrency(*replayConcurrency),
+		tui.WithReplayPacing(pacing),
+	}
+	if *assertReplays {
+		opts = append(opts, tui.WithReplayAssert(matchMode))
+	}
+	if *statePath != "" {
+		st, err := tui.LoadState(*statePath)
+		switch {
+		case err != nil && *statePath == tui.DefaultStatePath():
+			// A broken default state file should not keep the TUI from
+			// starting; saving a view replaces it.
+			fmt.Fprintf(os.Stderr, "Warning: %v; starting without saved views\n", err)
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, tui.WithStateFile(*statePath, st))
+	}
+	if *descriptorSet != "" {
+		d, err := proxy.LoadDescriptorSet(*descriptorSet)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, tui.WithDescriptors(d))
+	}
+	if *keymapPath != "" {
+		km, err := tui.LoadKeyMap(*keymapPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, tui.WithKeyMap(km))
+	}
+
+	if err := tui.CheckTerminal(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	m := tui.New(fs.Arg(0), opts...)
 	p := tea.NewProgram(m, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+	// Printed only now, as the alternate screen is gone.
+	if fm, ok := final.(tui.Model); ok {
+		fmt.Print(fm.ExitSummary())
+	}
+}
+
+// splitPrefixes splits a comma-separated list, dropping empty entries.
+func splitPrefixes(s string) []string {
+	var prefixes []string
+	for p := range strings.SplitSeq(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			prefixes = append(prefixes, p)
+		}
+	}
+	return prefixes
 }
@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Send shows a desktop notification.
+// It uses terminal-notifier or osascript on macOS and notify-send on Linux.
+func Send(ctx context.Context, title, body string) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("terminal-notifier"); err == nil {
+			cmd = exec.CommandContext(ctx, "terminal-notifier", "-title", title, "-message", body)
+		} else {
+			script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))
+			cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+		}
+	case "linux":
+		if _, err := exec.LookPath("notify-send"); err != nil {
+			return errors.New("notify-send is required on Linux")
+		}
+		cmd = exec.CommandContext(ctx, "notify-send", "--", title, body)
+	}
+
+	if cmd == nil {
+		return fmt.Errorf("notifications not supported on %s", runtime.GOOS)
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(s) + `"`
+}
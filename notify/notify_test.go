@@ -0,0 +1,22 @@
+package notify
+
+import "testing"
+
+func TestAppleScriptString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"hello", `"hello"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`C:\path`, `"C:\\path"`},
+		{`" & do shell script "rm`, `"\" & do shell script \"rm"`},
+	}
+	for _, tt := range tests {
+		if got := appleScriptString(tt.in); got != tt.want {
+			t.Errorf("appleScriptString(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package proxy_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+// drainEvents discards events so ServeHTTP never blocks on a full channel.
+func drainEvents(rp *proxy.ReverseProxy) {
+	go func() {
+		for range rp.Events() {
+		}
+	}()
+}
+
+func BenchmarkServeHTTP_Concurrent(b *testing.B) {
+	respFrame := buildGRPCFrame(bytes.Repeat([]byte("r"), 512))
+	reqFrame := buildGRPCFrame(bytes.Repeat([]byte("q"), 128))
+
+	for _, maxConns := range []int{0, 1, 4} {
+		b.Run(fmt.Sprintf("max-conns=%d", maxConns), func(b *testing.B) {
+			upstream := newUpstream(b, nil, respFrame)
+			rp, err := proxy.New(":0", upstream.URL, proxy.WithUpstreamMaxConns(maxConns))
+			if err != nil {
+				b.Fatal(err)
+			}
+			drainEvents(rp)
+
+			b.ReportAllocs()
+			b.SetBytes(int64(len(reqFrame) + len(respFrame)))
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					req := httptest.NewRequest(http.MethodPost, "/bench.Service/Call", bytes.NewReader(reqFrame))
+					req.Header.Set("Content-Type", "application/grpc")
+					rp.ServeHTTP(httptest.NewRecorder(), req)
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkServeHTTP(b *testing.B) {
+	for _, size := range []int{128, 16 * 1024, 256 * 1024} {
+		b.Run(fmt.Sprintf("resp=%dB", size), func(b *testing.B) {
+			respFrame := buildGRPCFrame(bytes.Repeat([]byte("r"), size))
+			reqFrame := buildGRPCFrame(bytes.Repeat([]byte("q"), 128))
+			upstream := newUpstream(b, nil, respFrame)
+			rp, err := proxy.New(":0", upstream.URL)
+			if err != nil {
+				b.Fatal(err)
+			}
+			drainEvents(rp)
+
+			b.ReportAllocs()
+			b.SetBytes(int64(len(reqFrame) + len(respFrame)))
+			b.ResetTimer()
+			for b.Loop() {
+				req := httptest.NewRequest(http.MethodPost, "/bench.Service/Call", bytes.NewReader(reqFrame))
+				req.Header.Set("Content-Type", "application/grpc")
+				rp.ServeHTTP(httptest.NewRecorder(), req)
+			}
+		})
+	}
+}
+
+func BenchmarkCaptureReader(b *testing.B) {
+	data := bytes.Repeat([]byte("x"), 256*1024)
+	buf := make([]byte, 32*1024)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for b.Loop() {
+		cr := proxy.NewCaptureReader(bytes.NewReader(data), proxy.MaxCaptureSize)
+		for {
+			if _, err := cr.Read(buf); err != nil {
+				break
+			}
+		}
+	}
+}
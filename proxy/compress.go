@@ -0,0 +1,185 @@
+package proxy
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// decompress decodes a compressed gRPC message payload according to the
+// grpc-encoding negotiated for the call. An empty encoding is treated as
+// gzip, the only codec every gRPC implementation ships. The payload comes
+// from the client or upstream, so at most limit bytes are decoded: gzip and
+// deflate output is cut off there, and a snappy block declaring more is
+// rejected.
+func decompress(payload []byte, encoding string, limit int) ([]byte, error) {
+	switch encoding {
+	case "", "gzip":
+		r, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("gzip: %w", err)
+		}
+		return readAllClose(r, limit)
+	case "deflate":
+		// Most implementations send zlib-wrapped deflate; fall back to raw.
+		if r, err := zlib.NewReader(bytes.NewReader(payload)); err == nil {
+			if decoded, err := readAllClose(r, limit); err == nil {
+				return decoded, nil
+			}
+		}
+		return readAllClose(flate.NewReader(bytes.NewReader(payload)), limit)
+	case "snappy":
+		return decodeSnappy(payload, limit)
+	default:
+		return nil, fmt.Errorf("unsupported grpc-encoding %q", encoding)
+	}
+}
+
+// readAllClose reads up to limit bytes from r and closes it.
+func readAllClose(r io.ReadCloser, limit int) ([]byte, error) {
+	decoded, err := io.ReadAll(io.LimitReader(r, int64(limit)))
+	_ = r.Close()
+	if err != nil {
+		return nil, fmt.Errorf("decompress: %w", err)
+	}
+	return decoded, nil
+}
+
+// snappyStreamID is the stream identifier chunk that starts the snappy
+// framing format.
+var snappyStreamID = []byte("\xff\x06\x00\x00sNaPpY")
+
+// decodeSnappy decodes either the snappy framing format or a bare snappy
+// block of at most limit decoded bytes. Checksums in the framing format are
+// not verified.
+func decodeSnappy(data []byte, limit int) ([]byte, error) {
+	if !bytes.HasPrefix(data, snappyStreamID) {
+		return decodeSnappyBlock(data, limit)
+	}
+
+	var out []byte
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, errors.New("snappy: truncated chunk header")
+		}
+		typ := data[0]
+		n := int(data[1]) | int(data[2])<<8 | int(data[3])<<16
+		data = data[4:]
+		if len(data) < n {
+			return nil, errors.New("snappy: truncated chunk")
+		}
+		chunk := data[:n]
+		data = data[n:]
+
+		switch {
+		case typ == 0x00 || typ == 0x01:
+			if len(chunk) < 4 {
+				return nil, errors.New("snappy: chunk too short")
+			}
+			body := chunk[4:] // skip masked CRC-32C
+			if typ == 0x00 {
+				decoded, err := decodeSnappyBlock(body, limit-len(out))
+				if err != nil {
+					return nil, err
+				}
+				body = decoded
+			}
+			if len(out)+len(body) > limit {
+				return nil, errors.New("snappy: stream too long")
+			}
+			out = append(out, body...)
+		case typ == 0xff || typ >= 0x80:
+			// Stream identifier, padding, or skippable chunk.
+		default:
+			return nil, fmt.Errorf("snappy: reserved chunk type %#x", typ)
+		}
+	}
+	return out, nil
+}
+
+// decodeSnappyBlock decodes a single snappy-compressed block. The decoded
+// length is declared up front by the sender, so blocks declaring more than
+// limit bytes are rejected before anything is allocated.
+func decodeSnappyBlock(src []byte, limit int) ([]byte, error) {
+	dlen, n := binary.Uvarint(src)
+	if n <= 0 || dlen > 1<<32 {
+		return nil, errors.New("snappy: invalid block length")
+	}
+	if dlen > uint64(max(limit, 0)) {
+		return nil, fmt.Errorf("snappy: block length %d exceeds limit %d", dlen, limit)
+	}
+	src = src[n:]
+	dst := make([]byte, 0, dlen)
+
+	for len(src) > 0 {
+		tag := src[0]
+		var length, offset int
+		switch tag & 0x03 {
+		case 0x00: // literal
+			length = int(tag >> 2)
+			src = src[1:]
+			if length >= 60 {
+				extra := length - 59
+				if len(src) < extra {
+					return nil, errors.New("snappy: truncated literal length")
+				}
+				length = 0
+				for i := range extra {
+					length |= int(src[i]) << (8 * i)
+				}
+				src = src[extra:]
+			}
+			length++
+			if len(src) < length {
+				return nil, errors.New("snappy: truncated literal")
+			}
+			if uint64(len(dst)+length) > dlen {
+				return nil, errors.New("snappy: length mismatch")
+			}
+			dst = append(dst, src[:length]...)
+			src = src[length:]
+			continue
+		case 0x01: // copy with 1-byte offset
+			if len(src) < 2 {
+				return nil, errors.New("snappy: truncated copy")
+			}
+			length = 4 + int(tag>>2&0x07)
+			offset = int(tag&0xe0)<<3 | int(src[1])
+			src = src[2:]
+		case 0x02: // copy with 2-byte offset
+			if len(src) < 3 {
+				return nil, errors.New("snappy: truncated copy")
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src[1:3]))
+			src = src[3:]
+		case 0x03: // copy with 4-byte offset
+			if len(src) < 5 {
+				return nil, errors.New("snappy: truncated copy")
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src[1:5]))
+			src = src[5:]
+		}
+		if offset <= 0 || offset > len(dst) {
+			return nil, errors.New("snappy: invalid copy offset")
+		}
+		if uint64(len(dst)+length) > dlen {
+			return nil, errors.New("snappy: length mismatch")
+		}
+		// Copies may overlap their own output, so go byte by byte.
+		start := len(dst) - offset
+		for i := range length {
+			dst = append(dst, dst[start+i])
+		}
+	}
+	if uint64(len(dst)) != dlen {
+		return nil, errors.New("snappy: length mismatch")
+	}
+	return dst, nil
+}
@@ -0,0 +1,45 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+)
+
+// DefaultCorrelationHeader is the header events take their correlation ID
+// from, unless changed with WithCorrelationHeader.
+const DefaultCorrelationHeader = "traceparent"
+
+// correlationID returns the value of the header name in h, which ties the
+// call to logs and traces elsewhere. For a W3C traceparent it is the trace
+// ID alone, since the parent span differs from hop to hop.
+func correlationID(h http.Header, name string) string {
+	if name == "" {
+		return ""
+	}
+	v := strings.TrimSpace(h.Get(name))
+	if strings.EqualFold(name, "traceparent") {
+		if id, ok := traceID(v); ok {
+			return id
+		}
+	}
+	return v
+}
+
+// traceID extracts the trace ID from a traceparent value of the form
+// "00-<32 hex trace ID>-<16 hex parent ID>-<2 hex flags>".
+func traceID(traceparent string) (string, bool) {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) < 4 || len(parts[1]) != 32 || !isHex(parts[1]) || strings.Trim(parts[1], "0") == "" {
+		return "", false
+	}
+	return strings.ToLower(parts[1]), true
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package proxy_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+func TestServeHTTP_CorrelationID(t *testing.T) {
+	t.Parallel()
+
+	upstream := newUpstream(t, nil, buildGRPCFrame([]byte("ok")))
+
+	tests := []struct {
+		name   string
+		opts   []proxy.Option
+		header http.Header
+		want   string
+	}{
+		{
+			name:   "traceparent trace ID",
+			header: http.Header{"Traceparent": {"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"}},
+			want:   "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{name: "absent", header: http.Header{}, want: ""},
+		{
+			name:   "malformed traceparent kept as is",
+			header: http.Header{"Traceparent": {"not-a-trace"}},
+			want:   "not-a-trace",
+		},
+		{
+			name:   "all-zero trace ID kept as is",
+			header: http.Header{"Traceparent": {"00-00000000000000000000000000000000-00f067aa0ba902b7-01"}},
+			want:   "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		},
+		{
+			name:   "custom header",
+			opts:   []proxy.Option{proxy.WithCorrelationHeader("x-request-id")},
+			header: http.Header{"X-Request-Id": {"req-42"}, "Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}},
+			want:   "req-42",
+		},
+		{
+			name:   "custom header absent",
+			opts:   []proxy.Option{proxy.WithCorrelationHeader("x-request-id")},
+			header: http.Header{"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}},
+			want:   "",
+		},
+		{
+			name:   "disabled",
+			opts:   []proxy.Option{proxy.WithCorrelationHeader("")},
+			header: http.Header{"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}},
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rp, err := proxy.New(":0", upstream.URL, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/test.Service/Method",
+				bytes.NewReader(buildGRPCFrame([]byte("req"))))
+			req.Header = tt.header
+			req.Header.Set("Content-Type", "application/grpc")
+
+			if ev := serveOnce(t, rp, req); ev.CorrelationID != tt.want {
+				t.Errorf("correlation ID = %q, want %q", ev.CorrelationID, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// ErrUnknownMethod is returned when a descriptor set does not describe a
+// method, so its messages can only be decoded without a schema.
+var ErrUnknownMethod = errors.New("method not in descriptor set")
+
+// Descriptors resolves the request and response message types of gRPC
+// methods from a compiled FileDescriptorSet, as written by
+// `buf build -o image.pb` or `protoc --include_imports --descriptor_set_out`.
+// A nil *Descriptors knows no methods.
+type Descriptors struct {
+	methods map[string]protoreflect.MethodDescriptor // by full method, e.g. "/pkg.Service/Method"
+	types   *dynamicpb.Types                         // resolves Any and extensions
+}
+
+// LoadDescriptorSet reads a binary FileDescriptorSet from path.
+func LoadDescriptorSet(path string) (*Descriptors, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is user-provided config
+	if err != nil {
+		return nil, fmt.Errorf("read descriptor set: %w", err)
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse descriptor set %s: %w", path, err)
+	}
+	d, err := NewDescriptors(&set)
+	if err != nil {
+		return nil, fmt.Errorf("descriptor set %s: %w", path, err)
+	}
+	return d, nil
+}
+
+// NewDescriptors indexes the methods of every service in set. The set must
+// include the files its files import.
+func NewDescriptors(set *descriptorpb.FileDescriptorSet) (*Descriptors, error) {
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("build descriptors: %w", err)
+	}
+	d := &Descriptors{
+		methods: make(map[string]protoreflect.MethodDescriptor),
+		types:   dynamicpb.NewTypes(files),
+	}
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		services := fd.Services()
+		for i := range services.Len() {
+			methods := services.Get(i).Methods()
+			for j := range methods.Len() {
+				md := methods.Get(j)
+				d.methods["/"+string(md.Parent().FullName())+"/"+string(md.Name())] = md
+			}
+		}
+		return true
+	})
+	return d, nil
+}
+
+// Method returns the descriptor of method, e.g. "/pkg.Service/Method".
+func (d *Descriptors) Method(method string) (protoreflect.MethodDescriptor, bool) {
+	if d == nil {
+		return nil, false
+	}
+	md, ok := d.methods[method]
+	return md, ok
+}
+
+// Len returns how many methods d describes.
+func (d *Descriptors) Len() int {
+	if d == nil {
+		return 0
+	}
+	return len(d.methods)
+}
+
+// message returns the request message type of method, or its response
+// message type when response is set.
+func (d *Descriptors) message(method string, response bool) (protoreflect.MessageDescriptor, error) {
+	md, ok := d.Method(method)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMethod, method)
+	}
+	if response {
+		return md.Output(), nil
+	}
+	return md.Input(), nil
+}
+
+// Decode unmarshals payload, protobuf wire format without gRPC framing, as
+// the request message of method, or its response message when response is
+// set. It returns ErrUnknownMethod when d does not describe method.
+func (d *Descriptors) Decode(method string, response bool, payload []byte) (*dynamicpb.Message, error) {
+	desc, err := d.message(method, response)
+	if err != nil {
+		return nil, err
+	}
+	msg := dynamicpb.NewMessage(desc)
+	opts := proto.UnmarshalOptions{Resolver: d.types}
+	if err := opts.Unmarshal(payload, msg); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", desc.FullName(), err)
+	}
+	return msg, nil
+}
+
+// DecodeJSON is Decode rendered as indented JSON with the field names of the
+// .proto files and enum values by name: the schema-aware counterpart of
+// ProtoWireToJSON.
+func (d *Descriptors) DecodeJSON(method string, response bool, payload []byte) ([]byte, error) {
+	msg, err := d.Decode(method, response, payload)
+	if err != nil {
+		return nil, err
+	}
+	opts := protojson.MarshalOptions{UseProtoNames: true, Resolver: d.types}
+	compact, err := opts.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s: %w", msg.Descriptor().FullName(), err)
+	}
+	// protojson varies its whitespace from build to build on purpose;
+	// re-indenting makes the output stable.
+	var out bytes.Buffer
+	if err := json.Indent(&out, compact, "", "  "); err != nil {
+		return nil, fmt.Errorf("indent %s: %w", msg.Descriptor().FullName(), err)
+	}
+	return out.Bytes(), nil
+}
+
+// EncodeJSON is the inverse of DecodeJSON: it encodes JSON of method's
+// request message, or its response message when response is set, to
+// protobuf wire format. Enum values may be given by name, by number, or as
+// FormatEnum writes them.
+func (d *Descriptors) EncodeJSON(method string, response bool, data []byte) ([]byte, error) {
+	desc, err := d.message(method, response)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("json unmarshal: %w", err)
+	}
+	normalized, err := json.Marshal(normalizeEnums(desc, doc))
+	if err != nil {
+		return nil, fmt.Errorf("encode %s: %w", desc.FullName(), err)
+	}
+	msg := dynamicpb.NewMessage(desc)
+	if err := (protojson.UnmarshalOptions{Resolver: d.types}).Unmarshal(normalized, msg); err != nil {
+		return nil, fmt.Errorf("encode %s: %w", desc.FullName(), err)
+	}
+	wire, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s: %w", desc.FullName(), err)
+	}
+	return wire, nil
+}
+
+// normalizeEnums rewrites the enum values in v, JSON of a desc message, that
+// protojson does not read, such as "1" or "ACTIVE (1)", as numbers. Values
+// that are no enum value are left for protojson to reject.
+func normalizeEnums(desc protoreflect.MessageDescriptor, v any) any {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return v
+	}
+	fields := desc.Fields()
+	for key, val := range obj {
+		fd := fields.ByJSONName(key)
+		if fd == nil {
+			fd = fields.ByTextName(key)
+		}
+		if fd == nil {
+			continue
+		}
+		if fd.IsMap() {
+			fd = fd.MapValue()
+			if entries, ok := val.(map[string]any); ok {
+				for k, e := range entries {
+					entries[k] = normalizeValue(fd, e)
+				}
+			}
+			continue
+		}
+		if list, ok := val.([]any); ok && fd.IsList() {
+			for i, e := range list {
+				list[i] = normalizeValue(fd, e)
+			}
+			continue
+		}
+		obj[key] = normalizeValue(fd, val)
+	}
+	return obj
+}
+
+// normalizeValue is normalizeEnums for a single value of field fd.
+func normalizeValue(fd protoreflect.FieldDescriptor, v any) any {
+	switch {
+	case fd.Message() != nil:
+		return normalizeEnums(fd.Message(), v)
+	case fd.Enum() != nil:
+		s, ok := v.(string)
+		if !ok {
+			return v
+		}
+		n, err := ParseEnum(fd.Enum(), s)
+		if err != nil {
+			return v
+		}
+		return json.Number(strconv.Itoa(int(n)))
+	}
+	return v
+}
+
+// FormatEnum renders value n of the enum ed as its name and number, e.g.
+// "ACTIVE (1)", or as just the number when ed has no value n.
+func FormatEnum(ed protoreflect.EnumDescriptor, n protoreflect.EnumNumber) string {
+	if v := ed.Values().ByNumber(n); v != nil {
+		return fmt.Sprintf("%s (%d)", v.Name(), n)
+	}
+	return strconv.Itoa(int(n))
+}
+
+// ParseEnum reads a value of the enum ed given by name ("ACTIVE"), by number
+// ("1"), or as FormatEnum writes it ("ACTIVE (1)"). Any int32 number is
+// accepted, as open enums may hold numbers they do not name.
+func ParseEnum(ed protoreflect.EnumDescriptor, s string) (protoreflect.EnumNumber, error) {
+	s = strings.TrimSpace(s)
+	if name, num, ok := strings.Cut(s, " ("); ok && strings.HasSuffix(num, ")") {
+		n, err := ParseEnum(ed, strings.TrimSuffix(num, ")"))
+		if err != nil {
+			return 0, err
+		}
+		if v := ed.Values().ByName(protoreflect.Name(name)); v == nil || v.Number() != n {
+			return 0, fmt.Errorf("%s: %q does not match its number", ed.FullName(), s)
+		}
+		return n, nil
+	}
+	if v := ed.Values().ByName(protoreflect.Name(s)); v != nil {
+		return v.Number(), nil
+	}
+	n, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%s has no value %q", ed.FullName(), s)
+	}
+	return protoreflect.EnumNumber(n), nil
+}
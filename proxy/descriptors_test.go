@@ -0,0 +1,214 @@
+package proxy_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+// writeTapDescriptorSet writes the descriptor set of tap.proto and its
+// imports, as buf build would, and returns its path.
+func writeTapDescriptorSet(t *testing.T) string {
+	t.Helper()
+
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(timestamppb.File_google_protobuf_timestamp_proto),
+		protodesc.ToFileDescriptorProto(durationpb.File_google_protobuf_duration_proto),
+		protodesc.ToFileDescriptorProto(tapv1.File_tap_v1_tap_proto),
+	}}
+	data, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "image.pb")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDescriptorSet(t *testing.T) {
+	t.Parallel()
+
+	d, err := proxy.LoadDescriptorSet(writeTapDescriptorSet(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Len(); got != 4 {
+		t.Errorf("Len = %d, want the 4 TapService methods", got)
+	}
+	md, ok := d.Method("/tap.v1.TapService/Replay")
+	if !ok {
+		t.Fatal("Replay not found")
+	}
+	if got := md.Output().FullName(); got != "tap.v1.ReplayResponse" {
+		t.Errorf("output = %s, want tap.v1.ReplayResponse", got)
+	}
+}
+
+func TestLoadDescriptorSet_Invalid(t *testing.T) {
+	t.Parallel()
+
+	if _, err := proxy.LoadDescriptorSet(filepath.Join(t.TempDir(), "missing.pb")); err == nil {
+		t.Error("missing file: no error")
+	}
+
+	// tap.proto without the well-known types it imports.
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(tapv1.File_tap_v1_tap_proto),
+	}}
+	if _, err := proxy.NewDescriptors(set); err == nil {
+		t.Error("unresolved imports: no error")
+	}
+}
+
+func TestDescriptors_DecodeJSON(t *testing.T) {
+	t.Parallel()
+
+	d, err := proxy.LoadDescriptorSet(writeTapDescriptorSet(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := proto.Marshal(&tapv1.ReplayRequest{
+		Method:   "/echo.v1.EchoService/Echo",
+		Protocol: tapv1.Protocol_PROTOCOL_CONNECT,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := d.DecodeJSON("/tap.v1.TapService/Replay", false, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if got["method"] != "/echo.v1.EchoService/Echo" || got["protocol"] != "PROTOCOL_CONNECT" {
+		t.Errorf("decoded request = %v, want field names and the enum by name", got)
+	}
+
+	resp, err := proto.Marshal(&tapv1.ReplayResponse{Event: &tapv1.GRPCEvent{Id: "1", Status: 5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = d.DecodeJSON("/tap.v1.TapService/Replay", true, resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"event\": {\n    \"id\": \"1\",\n    \"status\": 5\n  }\n}"
+	if string(out) != want {
+		t.Errorf("decoded response =\n%s\nwant\n%s", out, want)
+	}
+
+	if _, err := d.DecodeJSON("/other.Svc/Call", false, req); !errors.Is(err, proxy.ErrUnknownMethod) {
+		t.Errorf("unknown method: err = %v, want ErrUnknownMethod", err)
+	}
+	if _, err := d.DecodeJSON("/tap.v1.TapService/Replay", false, []byte{0xff}); err == nil {
+		t.Error("invalid payload: no error")
+	}
+
+	var none *proxy.Descriptors
+	if _, err := none.DecodeJSON("/tap.v1.TapService/Replay", false, req); !errors.Is(err, proxy.ErrUnknownMethod) {
+		t.Errorf("nil descriptors: err = %v, want ErrUnknownMethod", err)
+	}
+}
+
+func TestFormatEnum(t *testing.T) {
+	t.Parallel()
+
+	ed := tapv1.Protocol(0).Descriptor()
+	if got, want := proxy.FormatEnum(ed, 3), "PROTOCOL_CONNECT (3)"; got != want {
+		t.Errorf("FormatEnum(3) = %q, want %q", got, want)
+	}
+	if got, want := proxy.FormatEnum(ed, 42), "42"; got != want {
+		t.Errorf("FormatEnum(42) = %q, want %q", got, want)
+	}
+}
+
+func TestParseEnum(t *testing.T) {
+	t.Parallel()
+
+	ed := tapv1.Protocol(0).Descriptor()
+	tests := []struct {
+		in      string
+		want    int32
+		wantErr bool
+	}{
+		{in: "PROTOCOL_CONNECT", want: 3},
+		{in: "3", want: 3},
+		{in: " PROTOCOL_CONNECT (3) ", want: 3},
+		{in: "42", want: 42}, // open enums hold unnamed numbers
+		{in: "PROTOCOL_CONNECT (2)", wantErr: true},
+		{in: "CONNECT", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := proxy.ParseEnum(ed, tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseEnum(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && int32(got) != tt.want {
+			t.Errorf("ParseEnum(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDescriptors_EncodeJSON(t *testing.T) {
+	t.Parallel()
+
+	d, err := proxy.LoadDescriptorSet(writeTapDescriptorSet(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &tapv1.ReplayRequest{Method: "/pkg.Svc/Get", Protocol: tapv1.Protocol_PROTOCOL_CONNECT}
+	for _, protocol := range []string{`"PROTOCOL_CONNECT"`, `3`, `"3"`, `"PROTOCOL_CONNECT (3)"`} {
+		wire, err := d.EncodeJSON("/tap.v1.TapService/Replay", false,
+			[]byte(`{"method": "/pkg.Svc/Get", "protocol": `+protocol+`}`))
+		if err != nil {
+			t.Errorf("protocol %s: %v", protocol, err)
+			continue
+		}
+		var got tapv1.ReplayRequest
+		if err := proto.Unmarshal(wire, &got); err != nil {
+			t.Fatal(err)
+		}
+		if !proto.Equal(&got, want) {
+			t.Errorf("protocol %s: encoded %v, want %v", protocol, &got, want)
+		}
+	}
+
+	// Nested messages and the JSON field names work too.
+	wire, err := d.EncodeJSON("/tap.v1.TapService/Replay", true,
+		[]byte(`{"event": {"id": "1", "callType": "CALL_TYPE_UNARY (1)", "phase": "2"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp tapv1.ReplayResponse
+	if err := proto.Unmarshal(wire, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if ev := resp.GetEvent(); ev.GetCallType() != tapv1.CallType_CALL_TYPE_UNARY || ev.GetPhase() != tapv1.EventPhase_EVENT_PHASE_PROGRESS {
+		t.Errorf("nested enums = %v, %v", ev.GetCallType(), ev.GetPhase())
+	}
+
+	if _, err := d.EncodeJSON("/tap.v1.TapService/Replay", false, []byte(`{"protocol": "NOPE"}`)); err == nil {
+		t.Error("unknown enum name: no error")
+	}
+	if _, err := d.EncodeJSON("/other.Svc/Call", false, []byte(`{}`)); !errors.Is(err, proxy.ErrUnknownMethod) {
+		t.Errorf("unknown method: err = %v, want ErrUnknownMethod", err)
+	}
+}
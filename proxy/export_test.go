@@ -0,0 +1,13 @@
+package proxy
+
+import (
+	"context"
+	"net"
+)
+
+// WithDialer replaces the dialer of the upstream transport.
+func WithDialer(dial func(ctx context.Context, network, addr string) (net.Conn, error)) Option {
+	return func(rp *ReverseProxy) {
+		rp.dialContext = dial
+	}
+}
@@ -3,6 +3,7 @@ package proxy
 import (
 	"bytes"
 	"compress/gzip"
+	"encoding/base64"
 	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
@@ -10,8 +11,12 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"net/http"
 	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 	"unicode/utf8"
 
 	"google.golang.org/protobuf/encoding/protowire"
@@ -28,8 +33,14 @@ type FrameCounter struct {
 	hdrBuf [5]byte
 	hdrN   int
 	remain uint32
+	first  time.Time   // when the first frame arrived
+	last   time.Time   // when the latest frame arrived
+	times  []time.Time // when each frame arrived, up to maxFrameTimes
 }
 
+// maxFrameTimes bounds how many frame arrival times a FrameCounter keeps.
+const maxFrameTimes = 1024
+
 // NewFrameCounter creates a FrameCounter wrapping the given reader.
 func NewFrameCounter(r io.Reader) *FrameCounter {
 	return &FrameCounter{r: r}
@@ -52,6 +63,13 @@ func (fc *FrameCounter) scan(data []byte) {
 			if fc.hdrN == 5 {
 				fc.remain = binary.BigEndian.Uint32(fc.hdrBuf[1:5])
 				fc.Count++
+				fc.last = time.Now()
+				if fc.Count == 1 {
+					fc.first = fc.last
+				}
+				if len(fc.times) < maxFrameTimes {
+					fc.times = append(fc.times, fc.last)
+				}
 				fc.hdrN = 0
 				if fc.remain > 0 {
 					fc.state = 1
@@ -68,6 +86,19 @@ func (fc *FrameCounter) scan(data []byte) {
 	}
 }
 
+// Rate returns how many frames per second arrived between the first frame
+// and the latest one, or 0 before a second frame arrived.
+func (fc *FrameCounter) Rate() float64 {
+	if fc.Count < 2 {
+		return 0
+	}
+	elapsed := fc.last.Sub(fc.first)
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(fc.Count-1) / elapsed.Seconds()
+}
+
 // DetectCallType determines the CallType based on protocol, content type,
 // and observed frame counts.
 func DetectCallType(protocol Protocol, contentType string, reqFrames, respFrames *FrameCounter) CallType {
@@ -102,11 +133,16 @@ func DetectCallType(protocol Protocol, contentType string, reqFrames, respFrames
 }
 
 // CaptureReader wraps an io.Reader and stores the first maxSize bytes
-// that pass through it.
+// that pass through it. Bytes and Total may be called while another goroutine
+// is reading, e.g. to snapshot a stream that is still in flight.
 type CaptureReader struct {
 	r       io.Reader
-	buf     []byte
 	maxSize int
+
+	mu    sync.Mutex
+	buf   []byte
+	total int64
+	first time.Time // when the first bytes were read
 }
 
 // NewCaptureReader creates a CaptureReader that captures up to maxSize bytes.
@@ -116,38 +152,82 @@ func NewCaptureReader(r io.Reader, maxSize int) *CaptureReader {
 
 func (cr *CaptureReader) Read(p []byte) (int, error) {
 	n, err := cr.r.Read(p)
+	cr.mu.Lock()
+	if n > 0 && cr.first.IsZero() {
+		cr.first = time.Now()
+	}
+	cr.total += int64(n)
 	if remaining := cr.maxSize - len(cr.buf); remaining > 0 && n > 0 {
 		take := min(n, remaining)
+		cr.grow(take)
 		cr.buf = append(cr.buf, p[:take]...)
 	}
+	cr.mu.Unlock()
 	return n, err //nolint:wrapcheck // pass-through reader
 }
 
-// Bytes returns the captured data.
+// grow makes room for n more bytes without letting the capacity exceed
+// maxSize, so nothing past the limit is ever allocated or retained.
+func (cr *CaptureReader) grow(n int) {
+	if len(cr.buf)+n <= cap(cr.buf) {
+		return
+	}
+	// Size the buffer from the first read: small bodies stay small, large
+	// ones reach maxSize after a few doublings.
+	size := min(cr.maxSize, max(2*cap(cr.buf), 2*n, 512))
+	buf := make([]byte, len(cr.buf), size)
+	copy(buf, cr.buf)
+	cr.buf = buf
+}
+
+// Bytes returns the data captured so far. The returned slice is never
+// modified by later reads.
 func (cr *CaptureReader) Bytes() []byte {
-	return cr.buf
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	return cr.buf[:len(cr.buf):len(cr.buf)]
+}
+
+// FirstRead returns when the first bytes were read, or the zero time if none
+// have been.
+func (cr *CaptureReader) FirstRead() time.Time {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	return cr.first
 }
 
+// Total returns the total number of bytes read, including bytes beyond maxSize.
+func (cr *CaptureReader) Total() int64 {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	return cr.total
+}
+
+// grpcWebTrailerFlag is set in the flags byte of a gRPC-Web frame that
+// carries trailers instead of a message.
+const grpcWebTrailerFlag = 0x80
+
 // ExtractPayload parses the first gRPC length-prefixed frame and returns the
-// decompressed payload. If the data is not valid gRPC framing, it is returned
-// as-is.
-func ExtractPayload(data []byte) []byte {
+// decompressed payload, up to limit bytes of it (see WithMaxCaptureSize).
+// encoding is the call's grpc-encoding header value (gzip, deflate or snappy;
+// empty means gzip). If the data is not valid gRPC framing or the codec is
+// unknown, the bytes are returned as-is. A gRPC-Web trailer frame carries no
+// message, so it yields nil.
+func ExtractPayload(data []byte, encoding string, limit int) []byte {
 	if len(data) < 5 {
 		return data
 	}
 	compressed := data[0]
+	if compressed&grpcWebTrailerFlag != 0 {
+		return nil
+	}
 	length := binary.BigEndian.Uint32(data[1:5])
 	if uint32(len(data)-5) < length { //nolint:gosec // len-5 is non-negative (checked above)
 		return data
 	}
 	payload := data[5 : 5+length]
 	if compressed == 1 {
-		r, err := gzip.NewReader(bytes.NewReader(payload))
-		if err != nil {
-			return payload
-		}
-		decoded, err := io.ReadAll(r)
-		_ = r.Close()
+		decoded, err := decompress(payload, encoding, limit)
 		if err != nil {
 			return payload
 		}
@@ -156,9 +236,137 @@ func ExtractPayload(data []byte) []byte {
 	return payload
 }
 
-// DecompressGzip decompresses data if it starts with a gzip magic header.
-// Returns the original data unchanged if it is not gzip.
-func DecompressGzip(data []byte) []byte {
+// FrameCompression reports how the first gRPC frame in data is compressed.
+// It returns the codec name (grpc-encoding, defaulting to gzip) and the
+// compressed payload size, or an empty codec if the frame is uncompressed or
+// data is not gRPC framing.
+func FrameCompression(data []byte, encoding string) (string, int64) {
+	if len(data) < 5 || data[0]&grpcWebTrailerFlag != 0 || data[0]&1 == 0 {
+		return "", 0
+	}
+	if encoding == "" {
+		encoding = "gzip"
+	}
+	return encoding, int64(binary.BigEndian.Uint32(data[1:5]))
+}
+
+// SplitGRPCWebTrailers separates the gRPC-Web trailer frame from the message
+// frames in body. It returns the bytes preceding the trailer frame and the
+// parsed trailers, or body unchanged and nil trailers when no complete trailer
+// frame is found.
+func SplitGRPCWebTrailers(body []byte) ([]byte, http.Header) {
+	rest := body
+	for len(rest) >= 5 {
+		length := binary.BigEndian.Uint32(rest[1:5])
+		if uint32(len(rest)-5) < length { //nolint:gosec // len-5 is non-negative (checked above)
+			break
+		}
+		if rest[0]&grpcWebTrailerFlag != 0 {
+			return body[:len(body)-len(rest)], parseGRPCWebTrailers(rest[5 : 5+length])
+		}
+		rest = rest[5+length:]
+	}
+	return body, nil
+}
+
+// parseGRPCWebTrailers parses an HTTP/1-style header block ("key: value"
+// lines separated by CRLF) as carried in a gRPC-Web trailer frame.
+func parseGRPCWebTrailers(block []byte) http.Header {
+	h := http.Header{}
+	for line := range strings.SplitSeq(string(block), "\n") {
+		k, v, ok := strings.Cut(line, ":")
+		if k = strings.TrimSpace(k); !ok || k == "" {
+			continue
+		}
+		h.Add(k, strings.TrimSpace(v))
+	}
+	return h
+}
+
+// DecodeGRPCWebText decodes a gRPC-Web text (base64) body. Each message may
+// be encoded separately, so the body can contain several padded base64
+// chunks. A trailing partial quantum (e.g. from capture truncation) is
+// dropped. If the data is not valid base64, it is returned as-is.
+func DecodeGRPCWebText(data []byte) []byte {
+	clean := bytes.Map(func(r rune) rune {
+		if r == '\r' || r == '\n' || r == ' ' || r == '\t' {
+			return -1
+		}
+		return r
+	}, data)
+
+	var out []byte
+	for len(clean) > 0 {
+		// A chunk ends after its padding, or at the end of the data.
+		end := len(clean)
+		if i := bytes.IndexByte(clean, '='); i >= 0 {
+			end = i
+			for end < len(clean) && clean[end] == '=' {
+				end++
+			}
+		}
+		chunk := clean[:end]
+		clean = clean[end:]
+
+		enc := base64.StdEncoding
+		if !bytes.HasSuffix(chunk, []byte("=")) {
+			enc = base64.RawStdEncoding
+			if len(chunk)%4 == 1 {
+				// A single leftover character cannot encode a full byte.
+				chunk = chunk[:len(chunk)-1]
+			}
+		}
+		decoded := make([]byte, enc.DecodedLen(len(chunk)))
+		n, err := enc.Decode(decoded, chunk)
+		if err != nil {
+			return data
+		}
+		out = append(out, decoded[:n]...)
+	}
+	return out
+}
+
+// timedFrames splits the complete gRPC frames of a captured body into
+// Frames, pairing the nth message with the nth time in times. Trailer frames
+// are skipped, and messages without a recorded time are left out. The
+// messages share a budget of limit decompressed bytes; those beyond it are
+// kept compressed, as the captured body itself is bounded.
+func timedFrames(data []byte, encoding string, limit int, times []time.Time, start time.Time) []Frame {
+	var frames []Frame
+	budget := limit
+	for n := 0; len(data) >= 5 && n < len(times); n++ {
+		flags := data[0]
+		length := binary.BigEndian.Uint32(data[1:5])
+		if uint32(len(data)-5) < length { //nolint:gosec // len-5 is non-negative (checked above)
+			break
+		}
+		payload := data[5 : 5+length]
+		data = data[5+length:]
+		if flags&grpcWebTrailerFlag != 0 {
+			continue
+		}
+		if flags&1 != 0 && budget > 0 {
+			if decoded, err := decompress(payload, encoding, budget); err == nil {
+				payload = decoded
+				budget -= len(decoded)
+			}
+		}
+		frames = append(frames, Frame{Offset: times[n].Sub(start), Body: payload})
+	}
+	return frames
+}
+
+// countCapturedFrames counts the gRPC frames in an already-captured body.
+func countCapturedFrames(data []byte) *FrameCounter {
+	fc := NewFrameCounter(bytes.NewReader(data))
+	_, _ = io.Copy(io.Discard, fc)
+	return fc
+}
+
+// DecompressGzip decompresses data, up to limit bytes of it, if it starts
+// with a gzip magic header. Returns the original data unchanged if it is not
+// gzip.
+func DecompressGzip(data []byte, limit int) []byte {
 	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
 		return data
 	}
@@ -166,8 +374,7 @@ func DecompressGzip(data []byte) []byte {
 	if err != nil {
 		return data
 	}
-	decoded, err := io.ReadAll(r)
-	_ = r.Close()
+	decoded, err := readAllClose(r, limit)
 	if err != nil {
 		return data
 	}
@@ -2,11 +2,16 @@ package proxy_test
 
 import (
 	"bytes"
+	"compress/flate"
 	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
 	"encoding/binary"
 	"encoding/json"
 	"io"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"google.golang.org/protobuf/encoding/protowire"
 
@@ -23,6 +28,12 @@ func buildGRPCFrame(payload []byte) []byte {
 	return buf.Bytes()
 }
 
+func buildCompressedFrame(compressed []byte) []byte {
+	frame := buildGRPCFrame(compressed)
+	frame[0] = 1 // compressed
+	return frame
+}
+
 func TestFrameCounter(t *testing.T) {
 	t.Parallel()
 
@@ -125,6 +136,24 @@ func TestCaptureReader(t *testing.T) {
 		if len(cr.Bytes()) != 50 {
 			t.Errorf("captured: got %d bytes, want 50", len(cr.Bytes()))
 		}
+		if cr.Total() != 200 {
+			t.Errorf("total: got %d bytes, want 200", cr.Total())
+		}
+	})
+
+	t.Run("never allocates past max size", func(t *testing.T) {
+		t.Parallel()
+
+		const maxSize = 1000
+		data := bytes.Repeat([]byte("c"), 10*maxSize)
+		cr := proxy.NewCaptureReader(iotest.OneByteReader(bytes.NewReader(data)), maxSize)
+		if _, err := io.ReadAll(cr); err != nil {
+			t.Fatalf("ReadAll: %v", err)
+		}
+		got := cr.Bytes()
+		if len(got) != maxSize || cap(got) > maxSize {
+			t.Errorf("captured len/cap = %d/%d, want %d/<=%d", len(got), cap(got), maxSize, maxSize)
+		}
 	})
 
 	t.Run("empty reader", func(t *testing.T) {
@@ -148,7 +177,7 @@ func TestExtractPayload(t *testing.T) {
 
 		payload := []byte("hello world")
 		frame := buildGRPCFrame(payload)
-		got := proxy.ExtractPayload(frame)
+		got := proxy.ExtractPayload(frame, "", proxy.MaxCaptureSize)
 		if !bytes.Equal(got, payload) {
 			t.Errorf("got %q, want %q", got, payload)
 		}
@@ -171,17 +200,114 @@ func TestExtractPayload(t *testing.T) {
 		frame.Write(length)
 		frame.Write(compressed.Bytes())
 
-		got := proxy.ExtractPayload(frame.Bytes())
+		got := proxy.ExtractPayload(frame.Bytes(), "", proxy.MaxCaptureSize)
 		if !bytes.Equal(got, payload) {
 			t.Errorf("got %q, want %q", got, payload)
 		}
 	})
 
+	t.Run("deflate compressed (zlib)", func(t *testing.T) {
+		t.Parallel()
+
+		payload := []byte("deflated payload")
+		var compressed bytes.Buffer
+		w := zlib.NewWriter(&compressed)
+		_, _ = w.Write(payload)
+		_ = w.Close()
+
+		got := proxy.ExtractPayload(buildCompressedFrame(compressed.Bytes()), "deflate", proxy.MaxCaptureSize)
+		if !bytes.Equal(got, payload) {
+			t.Errorf("got %q, want %q", got, payload)
+		}
+	})
+
+	t.Run("deflate compressed (raw)", func(t *testing.T) {
+		t.Parallel()
+
+		payload := []byte("raw deflated payload")
+		var compressed bytes.Buffer
+		w, _ := flate.NewWriter(&compressed, flate.DefaultCompression)
+		_, _ = w.Write(payload)
+		_ = w.Close()
+
+		got := proxy.ExtractPayload(buildCompressedFrame(compressed.Bytes()), "deflate", proxy.MaxCaptureSize)
+		if !bytes.Equal(got, payload) {
+			t.Errorf("got %q, want %q", got, payload)
+		}
+	})
+
+	t.Run("snappy block", func(t *testing.T) {
+		t.Parallel()
+
+		// len=10, literal "a", copy length 9 offset 1
+		block := []byte{0x0a, 0x00, 'a', 0x15, 0x01}
+		got := proxy.ExtractPayload(buildCompressedFrame(block), "snappy", proxy.MaxCaptureSize)
+		if string(got) != "aaaaaaaaaa" {
+			t.Errorf("got %q, want %q", got, "aaaaaaaaaa")
+		}
+	})
+
+	t.Run("snappy framed", func(t *testing.T) {
+		t.Parallel()
+
+		stream := []byte("\xff\x06\x00\x00sNaPpY")
+		stream = append(stream, 0x00, 0x09, 0x00, 0x00, 0, 0, 0, 0) // compressed chunk, zero CRC
+		stream = append(stream, 0x0a, 0x00, 'a', 0x15, 0x01)
+		stream = append(stream, 0x01, 0x07, 0x00, 0x00, 0, 0, 0, 0) // uncompressed chunk
+		stream = append(stream, "bcd"...)
+
+		got := proxy.ExtractPayload(buildCompressedFrame(stream), "snappy", proxy.MaxCaptureSize)
+		if string(got) != "aaaaaaaaaabcd" {
+			t.Errorf("got %q, want %q", got, "aaaaaaaaaabcd")
+		}
+	})
+
+	t.Run("snappy block declaring a huge length", func(t *testing.T) {
+		t.Parallel()
+
+		// Declares a 4 GiB block: rejected before allocating, raw payload kept.
+		frame := []byte{1, 0, 0, 0, 5, 0xff, 0xff, 0xff, 0xff, 0x0f}
+		got := proxy.ExtractPayload(frame, "snappy", proxy.MaxCaptureSize)
+		if !bytes.Equal(got, frame[5:]) {
+			t.Errorf("got %x, want %x", got, frame[5:])
+		}
+	})
+
+	t.Run("decompression stops at the limit", func(t *testing.T) {
+		t.Parallel()
+
+		const limit = 1000
+		for _, encoding := range []string{"gzip", "deflate"} {
+			var compressed bytes.Buffer
+			var w io.WriteCloser = gzip.NewWriter(&compressed)
+			if encoding == "deflate" {
+				w = zlib.NewWriter(&compressed)
+			}
+			_, _ = w.Write(make([]byte, 4*limit))
+			_ = w.Close()
+
+			got := proxy.ExtractPayload(buildCompressedFrame(compressed.Bytes()), encoding, limit)
+			if len(got) != limit {
+				t.Errorf("%s: got %d bytes, want %d", encoding, len(got), limit)
+			}
+		}
+	})
+
+	t.Run("unknown encoding returns raw payload", func(t *testing.T) {
+		t.Parallel()
+
+		raw := []byte("zstd bytes")
+		got := proxy.ExtractPayload(buildCompressedFrame(raw), "zstd", proxy.MaxCaptureSize)
+		if !bytes.Equal(got, raw) {
+			t.Errorf("got %q, want %q", got, raw)
+		}
+	})
+
 	t.Run("too short", func(t *testing.T) {
 		t.Parallel()
 
 		data := []byte{0, 1, 2}
-		got := proxy.ExtractPayload(data)
+		got := proxy.ExtractPayload(data, "", proxy.MaxCaptureSize)
 		if !bytes.Equal(got, data) {
 			t.Errorf("got %q, want %q", got, data)
 		}
@@ -191,11 +317,89 @@ func TestExtractPayload(t *testing.T) {
 		t.Parallel()
 
 		frame := buildGRPCFrame(nil)
-		got := proxy.ExtractPayload(frame)
+		got := proxy.ExtractPayload(frame, "", proxy.MaxCaptureSize)
 		if len(got) != 0 {
 			t.Errorf("got %d bytes, want 0", len(got))
 		}
 	})
+
+	t.Run("gRPC-Web trailer frame only", func(t *testing.T) {
+		t.Parallel()
+
+		got := proxy.ExtractPayload(buildGRPCWebTrailerFrame("grpc-status:5\r\n"), "", proxy.MaxCaptureSize)
+		if got != nil {
+			t.Errorf("got %q, want nil", got)
+		}
+	})
+}
+
+func buildGRPCWebTrailerFrame(block string) []byte {
+	frame := buildGRPCFrame([]byte(block))
+	frame[0] = 0x80
+	return frame
+}
+
+func TestSplitGRPCWebTrailers(t *testing.T) {
+	t.Parallel()
+
+	t.Run("message then trailers", func(t *testing.T) {
+		t.Parallel()
+
+		msg := buildGRPCFrame([]byte("hello"))
+		body := append(append([]byte{}, msg...),
+			buildGRPCWebTrailerFrame("grpc-status: 3\r\ngrpc-message: bad arg\r\nx-custom: a\r\n")...)
+
+		data, trailers := proxy.SplitGRPCWebTrailers(body)
+		if !bytes.Equal(data, msg) {
+			t.Errorf("data = %q, want %q", data, msg)
+		}
+		if got := proxy.ExtractPayload(data, "", proxy.MaxCaptureSize); string(got) != "hello" {
+			t.Errorf("payload = %q, want %q", got, "hello")
+		}
+		if trailers.Get("Grpc-Status") != "3" {
+			t.Errorf("grpc-status = %q, want 3", trailers.Get("Grpc-Status"))
+		}
+		if trailers.Get("Grpc-Message") != "bad arg" {
+			t.Errorf("grpc-message = %q, want %q", trailers.Get("Grpc-Message"), "bad arg")
+		}
+		if trailers.Get("X-Custom") != "a" {
+			t.Errorf("x-custom = %q, want a", trailers.Get("X-Custom"))
+		}
+	})
+
+	t.Run("trailers only", func(t *testing.T) {
+		t.Parallel()
+
+		data, trailers := proxy.SplitGRPCWebTrailers(buildGRPCWebTrailerFrame("grpc-status:16\r\n"))
+		if len(data) != 0 {
+			t.Errorf("data = %q, want empty", data)
+		}
+		if trailers.Get("Grpc-Status") != "16" {
+			t.Errorf("grpc-status = %q, want 16", trailers.Get("Grpc-Status"))
+		}
+	})
+
+	t.Run("no trailer frame", func(t *testing.T) {
+		t.Parallel()
+
+		body := buildGRPCFrame([]byte("hello"))
+		data, trailers := proxy.SplitGRPCWebTrailers(body)
+		if !bytes.Equal(data, body) || trailers != nil {
+			t.Errorf("got (%q, %v), want body unchanged and nil trailers", data, trailers)
+		}
+	})
+
+	t.Run("truncated trailer frame", func(t *testing.T) {
+		t.Parallel()
+
+		msg := buildGRPCFrame([]byte("hello"))
+		trailer := buildGRPCWebTrailerFrame("grpc-status:0\r\n")
+		body := append(append([]byte{}, msg...), trailer[:len(trailer)-3]...)
+		data, trailers := proxy.SplitGRPCWebTrailers(body)
+		if !bytes.Equal(data, body) || trailers != nil {
+			t.Errorf("got (%q, %v), want body unchanged and nil trailers", data, trailers)
+		}
+	})
 }
 
 func TestDetectCallType(t *testing.T) {
@@ -436,3 +640,113 @@ func TestJSONToProtoWire(t *testing.T) {
 		}
 	})
 }
+
+func TestDecodeGRPCWebText(t *testing.T) {
+	t.Parallel()
+
+	msg := buildGRPCFrame([]byte("hello"))
+	trailer := buildGRPCWebTrailerFrame("grpc-status:0\r\n")
+
+	t.Run("single chunk", func(t *testing.T) {
+		t.Parallel()
+
+		encoded := []byte(base64.StdEncoding.EncodeToString(msg))
+		got := proxy.DecodeGRPCWebText(encoded)
+		if !bytes.Equal(got, msg) {
+			t.Errorf("got %q, want %q", got, msg)
+		}
+		if payload := proxy.ExtractPayload(got, "", proxy.MaxCaptureSize); string(payload) != "hello" {
+			t.Errorf("payload = %q, want %q", payload, "hello")
+		}
+	})
+
+	t.Run("separately encoded frames", func(t *testing.T) {
+		t.Parallel()
+
+		encoded := base64.StdEncoding.EncodeToString(msg) + base64.StdEncoding.EncodeToString(trailer)
+		got := proxy.DecodeGRPCWebText([]byte(encoded))
+		want := append(append([]byte{}, msg...), trailer...)
+		if !bytes.Equal(got, want) {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+		data, trailers := proxy.SplitGRPCWebTrailers(got)
+		if !bytes.Equal(data, msg) || trailers.Get("Grpc-Status") != "0" {
+			t.Errorf("split = (%q, %v), want message and grpc-status 0", data, trailers)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		t.Parallel()
+
+		encoded := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("x"), 10))
+		got := proxy.DecodeGRPCWebText([]byte(encoded[:9]))
+		if !bytes.Equal(got, bytes.Repeat([]byte("x"), 6)) {
+			t.Errorf("got %q, want the 6 complete bytes", got)
+		}
+	})
+
+	t.Run("not base64", func(t *testing.T) {
+		t.Parallel()
+
+		data := []byte{0x00, 0x01, 0x02, 0xff}
+		if got := proxy.DecodeGRPCWebText(data); !bytes.Equal(got, data) {
+			t.Errorf("got %q, want input unchanged", got)
+		}
+	})
+}
+
+func TestFrameCounter_Rate(t *testing.T) {
+	t.Parallel()
+
+	const (
+		frames   = 6
+		interval = 20 * time.Millisecond // 50 frames per second
+	)
+	pr, pw := io.Pipe()
+	go func() {
+		for i := range frames {
+			if i > 0 {
+				time.Sleep(interval)
+			}
+			_, _ = pw.Write(buildGRPCFrame([]byte("msg")))
+		}
+		_ = pw.Close()
+	}()
+
+	fc := proxy.NewFrameCounter(pr)
+	if got := fc.Rate(); got != 0 {
+		t.Errorf("Rate before any frame = %v, want 0", got)
+	}
+	if _, err := io.ReadAll(fc); err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	// Sleeps only ever run long, so the rate can only come out lower.
+	if got := fc.Rate(); got < 10 || got > 50 {
+		t.Errorf("Rate = %.1f frames/s, want about 50", got)
+	}
+
+	var single bytes.Buffer
+	single.Write(buildGRPCFrame([]byte("msg")))
+	one := proxy.NewFrameCounter(&single)
+	_, _ = io.ReadAll(one)
+	if got := one.Rate(); got != 0 {
+		t.Errorf("Rate of one frame = %v, want 0", got)
+	}
+}
+
+func TestDecompressGzip(t *testing.T) {
+	t.Parallel()
+
+	if got := proxy.DecompressGzip([]byte("plain"), proxy.MaxCaptureSize); string(got) != "plain" {
+		t.Errorf("plain data = %q, want it unchanged", got)
+	}
+
+	const limit = 1000
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	_, _ = w.Write(make([]byte, 4*limit))
+	_ = w.Close()
+	if got := proxy.DecompressGzip(compressed.Bytes(), limit); len(got) != limit {
+		t.Errorf("got %d bytes, want %d", len(got), limit)
+	}
+}
@@ -0,0 +1,68 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// unixPrefix marks a listen address as a Unix domain socket path.
+const unixPrefix = "unix:"
+
+// Listen listens on addr: a TCP address such as ":8080", or a Unix domain
+// socket such as "unix:/tmp/grpc-tap.sock". A socket file left behind by an
+// earlier run is removed first; the file is removed again when the listener
+// is closed. A socket something still listens on, and any other file at the
+// path, is never removed.
+func Listen(ctx context.Context, addr string) (net.Listener, error) {
+	var lc net.ListenConfig
+	path, ok := strings.CutPrefix(addr, unixPrefix)
+	if !ok {
+		lis, err := lc.Listen(ctx, "tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("proxy: listen %s: %w", addr, err)
+		}
+		return lis, nil
+	}
+	if err := removeStaleSocket(ctx, path); err != nil {
+		return nil, err
+	}
+	lis, err := lc.Listen(ctx, "unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("proxy: listen %s: %w", addr, err)
+	}
+	return lis, nil
+}
+
+// removeStaleSocket removes the socket file at path, if there is one and
+// nothing listens on it anymore: only a refused connection shows that the
+// socket is stale.
+func removeStaleSocket(ctx context.Context, path string) error {
+	fi, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("proxy: stat %s: %w", path, err)
+	}
+	if fi.Mode().Type() != fs.ModeSocket {
+		return fmt.Errorf("proxy: listen %s: file exists and is not a socket", path)
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", path)
+	if err == nil {
+		_ = conn.Close()
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("proxy: listen %s: %w", path, syscall.EADDRINUSE)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("proxy: remove stale socket: %w", err)
+	}
+	return nil
+}
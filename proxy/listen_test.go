@@ -0,0 +1,133 @@
+package proxy_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+func TestListen_Unix(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "tap.sock")
+
+	// A socket left behind by a crashed run is replaced.
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.SetUnlinkOnClose(false)
+	_ = stale.Close()
+
+	lis, err := proxy.Listen(t.Context(), "unix:"+path)
+	if err != nil {
+		t.Fatalf("Listen over a stale socket: %v", err)
+	}
+	if lis.Addr().Network() != "unix" {
+		t.Errorf("network = %q, want unix", lis.Addr().Network())
+	}
+	_ = lis.Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("socket file still exists after Close: %v", err)
+	}
+}
+
+func TestListen_UnixRefusesLiveSocket(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "tap.sock")
+	live, err := proxy.Listen(t.Context(), "unix:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = live.Close() })
+
+	if lis, err := proxy.Listen(t.Context(), "unix:"+path); !errors.Is(err, syscall.EADDRINUSE) {
+		if err == nil {
+			_ = lis.Close()
+		}
+		t.Fatalf("Listen over a live socket: err = %v, want address in use", err)
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(t.Context(), "unix", path)
+	if err != nil {
+		t.Fatalf("live socket no longer reachable: %v", err)
+	}
+	_ = conn.Close()
+}
+
+func TestListen_UnixRefusesOtherFiles(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if lis, err := proxy.Listen(t.Context(), "unix:"+path); err == nil {
+		_ = lis.Close()
+		t.Fatal("Listen replaced a regular file")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("regular file was removed: %v", err)
+	}
+}
+
+func TestListenAndServe_Unix(t *testing.T) {
+	t.Parallel()
+
+	upstream := newUpstream(t, nil, buildGRPCFrame([]byte("resp")))
+	path := filepath.Join(t.TempDir(), "tap.sock")
+	rp, err := proxy.New("unix:"+path, upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+	go func() { done <- rp.ListenAndServe(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	<-rp.Ready()
+
+	// An h2c client dialing the socket, as a gRPC client would.
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, _, _ string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, "http://unix/test.Service/Method",
+		bytes.NewReader(buildGRPCFrame([]byte("req"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	select {
+	case ev := <-rp.Events():
+		if ev.Method != "/test.Service/Method" || string(ev.ResponseBody) != "resp" {
+			t.Errorf("event = %s %q, want /test.Service/Method resp", ev.Method, ev.ResponseBody)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event emitted")
+	}
+}
@@ -0,0 +1,57 @@
+package proxy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidateMethod checks that method has the "/package.Service/Method" shape of
+// a gRPC method path. The package is optional, but the service and method
+// names must be non-empty identifiers, so the path can't traverse
+// directories, carry a query or name another host. Errors wrap
+// ErrInvalidMethod.
+func ValidateMethod(method string) error {
+	service, name, ok := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	switch {
+	case method == "":
+		return fmt.Errorf("%w: empty", ErrInvalidMethod)
+	case !strings.HasPrefix(method, "/"):
+		return fmt.Errorf("%w %q: must start with '/'", ErrInvalidMethod, method)
+	case !ok || !isServiceName(service) || !isIdent(name):
+		return fmt.Errorf("%w %q: want /package.Service/Method", ErrInvalidMethod, method)
+	}
+	return nil
+}
+
+// ValidateReplayMethod checks method like ValidateMethod, after applying the
+// path rewrites of p set with WithPathRewrite, so that captured methods still
+// carrying the prefix the client called pass. Replay checks the same, but
+// entry points call it to reject a method before doing any work.
+func ValidateReplayMethod(p Proxy, method string) error {
+	if rp, ok := p.(*ReverseProxy); ok {
+		method = rp.rewritePath(method)
+	}
+	return ValidateMethod(method)
+}
+
+// isServiceName reports whether s is a dot-separated list of identifiers.
+func isServiceName(s string) bool {
+	for part := range strings.SplitSeq(s, ".") {
+		if !isIdent(part) {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c != '_' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
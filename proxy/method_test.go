@@ -0,0 +1,71 @@
+package proxy_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+func TestValidateMethod(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		method string
+		valid  bool
+	}{
+		{"/echo.v1.EchoService/Echo", true},
+		{"/Service/Method", true},
+		{"/grpc.health.v1.Health/Check", true},
+		{"", false},
+		{"echo.v1.EchoService/Echo", false},
+		{"/", false},
+		{"/echo.v1.EchoService", false},
+		{"/echo.v1.EchoService/", false},
+		{"//Echo", false},
+		{"/echo.v1.EchoService/Echo/extra", false},
+		{"/../admin", false},
+		{"/echo.v1..EchoService/Echo", false},
+		{"/echo.v1.EchoService/../../admin", false},
+		{"/.hidden/Echo", false},
+		{"/echo.v1.EchoService/Echo?x=1", false},
+		{"/%2e%2e/admin", false},
+		{"/http://example.com/x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			t.Parallel()
+			err := proxy.ValidateMethod(tt.method)
+			if tt.valid && err != nil {
+				t.Errorf("ValidateMethod(%q) = %v, want nil", tt.method, err)
+			}
+			if !tt.valid && !errors.Is(err, proxy.ErrInvalidMethod) {
+				t.Errorf("ValidateMethod(%q) = %v, want ErrInvalidMethod", tt.method, err)
+			}
+		})
+	}
+}
+
+func TestValidateReplayMethod(t *testing.T) {
+	t.Parallel()
+
+	rp, err := proxy.New(":0", "http://upstream.invalid", proxy.WithPathRewrite("/internal", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for method, valid := range map[string]bool{
+		"/internal/pkg.Svc/Method": true, // a captured method, rewritten before the check
+		"/pkg.Svc/Method":          true,
+		"/internal/../pkg.Svc/X":   false,
+		"/other/pkg.Svc/Method":    false,
+	} {
+		err := proxy.ValidateReplayMethod(rp, method)
+		if valid && err != nil {
+			t.Errorf("ValidateReplayMethod(%q) = %v, want nil", method, err)
+		}
+		if !valid && !errors.Is(err, proxy.ErrInvalidMethod) {
+			t.Errorf("ValidateReplayMethod(%q) = %v, want ErrInvalidMethod", method, err)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package proxy
+
+import (
+	"net/http"
+	"time"
+)
+
+// Option configures a ReverseProxy.
+type Option func(*ReverseProxy)
+
+// WithUpstreamHTTP1 talks HTTP/1.1 to the upstream instead of h2c. Use it for
+// plain HTTP/JSON upstreams such as grpc-gateway; native gRPC requires HTTP/2.
+func WithUpstreamHTTP1() Option {
+	return func(rp *ReverseProxy) {
+		rp.upstreamHTTP1 = true
+	}
+}
+
+// WithTransport sends upstream calls through rt instead of the transport the
+// proxy builds itself, e.g. to wrap it with retries or tracing, or to answer
+// calls with canned responses in tests. rt must speak HTTP/2 for native gRPC.
+// The upstream connection options (WithUpstreamHTTP1, WithConnectTimeout,
+// WithUpstreamMaxConns and WithUpstreamIdleTimeout) configure the built-in
+// transport only and have no effect on rt.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(rp *ReverseProxy) {
+		rp.transport = rt
+	}
+}
+
+// WithConnectTimeout bounds how long dialing the upstream may take. Zero
+// leaves it to the operating system's TCP connect timeout.
+func WithConnectTimeout(d time.Duration) Option {
+	return func(rp *ReverseProxy) {
+		rp.connectTimeout = d
+	}
+}
+
+// WithUpstreamMaxConns caps the number of open upstream connections. Zero
+// means no limit.
+//
+// Over HTTP/2 every connection multiplexes many concurrent calls, so a small
+// cap is usually enough: calls are spread round robin over n connections,
+// and queue for a free stream on theirs instead of dialing another. Over
+// HTTP/1.1 each in-flight call needs its own connection, so the cap directly
+// bounds concurrency.
+func WithUpstreamMaxConns(n int) Option {
+	return func(rp *ReverseProxy) {
+		rp.upstreamMaxConns = n
+	}
+}
+
+// WithUpstreamIdleTimeout closes upstream connections that have been idle for
+// d. Zero keeps idle connections open (HTTP/2) or uses the net/http default
+// of 90s (HTTP/1.1).
+func WithUpstreamIdleTimeout(d time.Duration) Option {
+	return func(rp *ReverseProxy) {
+		rp.upstreamIdleTimeout = d
+	}
+}
+
+// WithoutBodyCapture stops the proxy from retaining request and response
+// payloads: events carry timing, status and headers only. Frames are still
+// counted, so call types are detected as usual, but anything derived from the
+// captured bytes is lost, including the message encoding and the status of
+// gRPC-Web calls whose trailers travel in the body.
+func WithoutBodyCapture() Option {
+	return func(rp *ReverseProxy) {
+		rp.noBodyCapture = true
+	}
+}
+
+// WithMaxCaptureSize sets how many bytes of each request and response body
+// are retained in events. Larger bodies are still proxied in full and counted
+// in RequestSize and ResponseSize. Values below one keep the default,
+// MaxCaptureSize; use WithoutBodyCapture to retain nothing.
+func WithMaxCaptureSize(n int) Option {
+	return func(rp *ReverseProxy) {
+		if n > 0 {
+			rp.maxCaptureSize = n
+		}
+	}
+}
+
+// WithHeader sets the header key to value on every call sent upstream,
+// including replays, replacing any value the client sent. Replays to the
+// upstreams added with WithReplayUpstream go without it. Use it for headers
+// the upstream requires but clients do not send, such as credentials. Events
+// record the headers the client sent, so injected values are not captured.
+// An invalid header name or value makes New fail.
+func WithHeader(key, value string) Option {
+	return func(rp *ReverseProxy) {
+		if rp.headers == nil {
+			rp.headers = make(http.Header)
+		}
+		rp.headers.Set(key, value)
+	}
+}
+
+// WithUpstreamAuthority sends calls upstream with authority as their Host
+// (HTTP/1.1) or :authority (HTTP/2), for upstreams addressed by IP behind a
+// virtual-hosting ingress. By default the authority is the host of the
+// upstream address. Replays to the upstream use it too, but not replays to
+// the upstreams added with WithReplayUpstream. Events record the authority
+// the client addressed. An invalid authority makes New fail.
+func WithUpstreamAuthority(authority string) Option {
+	return func(rp *ReverseProxy) {
+		rp.upstreamAuthority = authority
+	}
+}
+
+// WithPathRewrite replaces the path prefix from with to on calls sent
+// upstream, for services a gateway exposes under a prefix, e.g.
+// WithPathRewrite("/internal", "") forwards /internal/pkg.Service/Method as
+// /pkg.Service/Method. Prefixes match whole path segments; the first matching
+// rewrite applies. Events keep the path the client called, and Replay applies
+// the same rewrites, so replaying a captured call works as is. A prefix that
+// does not start with "/" makes New fail.
+func WithPathRewrite(from, to string) Option {
+	return func(rp *ReverseProxy) {
+		rp.pathRewrites = append(rp.pathRewrites, pathRewrite{from: from, to: to})
+	}
+}
+
+// WithReplayTimeout bounds how long Replay waits for the upstream, on top of
+// any deadline of the context passed to it. The timeout is also sent to the
+// upstream as grpc-timeout. Zero, the default, sets no timeout.
+func WithReplayTimeout(d time.Duration) Option {
+	return func(rp *ReverseProxy) {
+		rp.replayTimeout = d
+	}
+}
+
+// WithIdempotentRetry declares methods idempotent: when the upstream resets
+// a call to one of them or goes away (HTTP/2 RST_STREAM or GOAWAY) before
+// responding, the call is sent once more. An entry ending in "/", such as
+// "/pkg.Service/", covers every method of the service. Only declare methods
+// that are safe to run twice. Only unary calls are retried: the first request
+// message is buffered before the call is sent, and a call whose request turns
+// out to hold more is sent once.
+func WithIdempotentRetry(methods ...string) Option {
+	return func(rp *ReverseProxy) {
+		rp.idempotentMethods = append(rp.idempotentMethods, methods...)
+	}
+}
+
+// WithTrustForwarded takes the client address of events from the Forwarded
+// or X-Forwarded-For request header when present, for proxies behind a load
+// balancer. Clients can set these headers themselves, so only enable it when
+// the load balancer overwrites them.
+func WithTrustForwarded() Option {
+	return func(rp *ReverseProxy) {
+		rp.trustForwarded = true
+	}
+}
+
+// WithCorrelationHeader sets the request header events take their
+// CorrelationID from, e.g. "x-request-id". For "traceparent", the default,
+// only the trace ID is taken. An empty name captures no correlation ID.
+func WithCorrelationHeader(name string) Option {
+	return func(rp *ReverseProxy) {
+		rp.correlationHeader = name
+	}
+}
+
+// WithReplayUpstream adds addr (e.g. "http://staging:9000") to the upstreams
+// Replay may target, under the given name. Replay never sends requests to an
+// address that is not on this allow-list, so callers can only pick a name.
+// https addresses are reached over HTTP/2 with TLS, verified against the
+// system roots. Invalid addresses make New fail.
+func WithReplayUpstream(name, addr string) Option {
+	return func(rp *ReverseProxy) {
+		if rp.replayUpstreamAddrs == nil {
+			rp.replayUpstreamAddrs = make(map[string]string)
+		}
+		rp.replayUpstreamAddrs[name] = addr
+	}
+}
+
+// WithStreamUpdateInterval sets how long a call may run before it emits a
+// PhaseStart event, and from then on how often a streaming response emits a
+// PhaseProgress event with what has been captured so far. Zero disables
+// interim events. The default is DefaultStreamUpdateInterval.
+func WithStreamUpdateInterval(d time.Duration) Option {
+	return func(rp *ReverseProxy) {
+		rp.streamUpdateInterval = d
+	}
+}
@@ -0,0 +1,229 @@
+package proxy_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+func TestWithMaxCaptureSize(t *testing.T) {
+	t.Parallel()
+
+	payload := bytes.Repeat([]byte("x"), 100)
+	upstream := newUpstream(t, nil, buildGRPCFrame(payload))
+
+	tests := []struct {
+		name      string
+		size      int
+		want      int
+		truncated bool
+	}{
+		{name: "smaller", size: 32, want: 32, truncated: true},
+		{name: "default", size: 0, want: len(payload)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rp, err := proxy.New(":0", upstream.URL, proxy.WithMaxCaptureSize(tt.size))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/test.Service/Method",
+				bytes.NewReader(buildGRPCFrame(payload)))
+			req.Header.Set("Content-Type", "application/grpc")
+			rec := httptest.NewRecorder()
+			rp.ServeHTTP(rec, req)
+			ev := <-rp.Events()
+
+			if !bytes.Equal(rec.Body.Bytes(), buildGRPCFrame(payload)) {
+				t.Errorf("client got %d bytes, want the full response", rec.Body.Len())
+			}
+			if len(ev.ResponseBody) != tt.want || len(ev.RequestBody) != tt.want {
+				t.Errorf("captured %d/%d bytes, want %d", len(ev.RequestBody), len(ev.ResponseBody), tt.want)
+			}
+			if ev.RequestSize != int64(len(payload)+5) || ev.ResponseSize != int64(len(payload)+5) {
+				t.Errorf("sizes = %d/%d, want %d", ev.RequestSize, ev.ResponseSize, len(payload)+5)
+			}
+			if ev.BodyTruncated != tt.truncated {
+				t.Errorf("BodyTruncated = %v, want %v", ev.BodyTruncated, tt.truncated)
+			}
+		})
+	}
+}
+
+func TestWithHeader(t *testing.T) {
+	t.Parallel()
+
+	sent := make(chan http.Header, 1)
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		sent <- r.Header.Clone()
+		_, _ = io.Copy(io.Discard, r.Body)
+		return okResponse("ok"), nil
+	})
+	rp, err := proxy.New(":0", "http://upstream.invalid", proxy.WithTransport(rt),
+		proxy.WithHeader("Authorization", "Bearer upstream"), proxy.WithHeader("x-env", "staging"),
+		proxy.WithReplayUpstream("staging", "http://staging:9000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(t *testing.T, ev proxy.Event, clientAuth string) {
+		t.Helper()
+		h := <-sent
+		if h.Get("Authorization") != "Bearer upstream" || h.Get("X-Env") != "staging" {
+			t.Errorf("upstream headers = %v, want the injected ones", h)
+		}
+		if len(h.Values("Authorization")) != 1 {
+			t.Errorf("authorization = %v, want the client's value replaced", h.Values("Authorization"))
+		}
+		if got := ev.RequestHeaders.Get("Authorization"); got != clientAuth {
+			t.Errorf("event authorization = %q, want %q", got, clientAuth)
+		}
+		if ev.RequestHeaders.Get("X-Env") != "" {
+			t.Error("event captured the injected x-env header")
+		}
+	}
+
+	t.Run("proxied", func(t *testing.T) {
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/test.Service/Method",
+			bytes.NewReader(buildGRPCFrame([]byte("req"))))
+		req.Header.Set("Content-Type", "application/grpc")
+		req.Header.Set("Authorization", "Bearer client")
+		check(t, serveOnce(t, rp, req), "Bearer client")
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		ev, err := rp.Replay(t.Context(), proxy.ReplayRequest{Method: "/test.Service/Method", Body: []byte("req")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, ev, "")
+	})
+
+	t.Run("replay upstream", func(t *testing.T) {
+		_, err := rp.Replay(t.Context(), proxy.ReplayRequest{Method: "/test.Service/Method", Body: []byte("req"), Upstream: "staging"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h := <-sent; h.Get("Authorization") != "" || h.Get("X-Env") != "" {
+			t.Errorf("replay upstream headers = %v, want none injected", h)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		for _, opt := range []proxy.Option{
+			proxy.WithHeader("bad header", "x"),
+			proxy.WithHeader("X-Ok", "line\nbreak"),
+		} {
+			if _, err := proxy.New(":0", "http://upstream.invalid", opt); err == nil {
+				t.Error("New succeeded with an invalid header")
+			}
+		}
+	})
+}
+
+func TestWithUpstreamAuthority(t *testing.T) {
+	t.Parallel()
+
+	sent := make(chan string, 1)
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		sent <- r.Host
+		_, _ = io.Copy(io.Discard, r.Body)
+		return okResponse("ok"), nil
+	})
+	rp, err := proxy.New(":0", "http://10.0.3.7:80", proxy.WithTransport(rt),
+		proxy.WithUpstreamAuthority("users.internal.example.com"),
+		proxy.WithReplayUpstream("staging", "http://staging:9000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("proxied", func(t *testing.T) {
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/test.Service/Method",
+			bytes.NewReader(buildGRPCFrame([]byte("req"))))
+		req.Host = "proxy.local:8080"
+		req.Header.Set("Content-Type", "application/grpc")
+		ev := serveOnce(t, rp, req)
+		if got := <-sent; got != "users.internal.example.com" {
+			t.Errorf("upstream authority = %q, want the override", got)
+		}
+		if ev.Authority != "proxy.local:8080" {
+			t.Errorf("event authority = %q, want the one the client addressed", ev.Authority)
+		}
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		if _, err := rp.Replay(t.Context(), proxy.ReplayRequest{Method: "/test.Service/Method"}); err != nil {
+			t.Fatal(err)
+		}
+		if got := <-sent; got != "users.internal.example.com" {
+			t.Errorf("upstream authority = %q, want the override", got)
+		}
+	})
+
+	t.Run("replay upstream", func(t *testing.T) {
+		_, err := rp.Replay(t.Context(), proxy.ReplayRequest{Method: "/test.Service/Method", Upstream: "staging"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := <-sent; got != "staging:9000" {
+			t.Errorf("upstream authority = %q, want the replay upstream's own", got)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		if _, err := proxy.New(":0", "http://10.0.3.7:80", proxy.WithUpstreamAuthority("bad host")); err == nil {
+			t.Error("New succeeded with an invalid authority")
+		}
+	})
+}
+
+func TestWithReplayTimeout(t *testing.T) {
+	t.Parallel()
+
+	sent := make(chan http.Header, 1)
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		sent <- r.Header.Clone()
+		if strings.HasSuffix(r.URL.Path, "/Slow") {
+			<-r.Context().Done()
+			return nil, r.Context().Err()
+		}
+		return okResponse("ok"), nil
+	})
+	const timeout = 50 * time.Millisecond
+	rp, err := proxy.New(":0", "http://upstream.invalid", proxy.WithTransport(rt), proxy.WithReplayTimeout(timeout))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ev, err := rp.Replay(t.Context(), proxy.ReplayRequest{Method: "/test.Service/Fast", Body: []byte("req")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := (<-sent).Get("Grpc-Timeout"); got != proxy.FormatTimeout(timeout) {
+		t.Errorf("grpc-timeout = %q, want %q", got, proxy.FormatTimeout(timeout))
+	}
+	if ev.Deadline != timeout {
+		t.Errorf("deadline = %v, want %v", ev.Deadline, timeout)
+	}
+
+	start := time.Now()
+	_, err = rp.Replay(t.Context(), proxy.ReplayRequest{Method: "/test.Service/Slow", Body: []byte("req")})
+	<-sent
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > timeout+2*time.Second {
+		t.Errorf("replay took %v, want it aborted around %v", elapsed, timeout)
+	}
+}
@@ -0,0 +1,48 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+)
+
+// peerAddr returns the address of the client that made r. With
+// trustForwarded, the client named by a Forwarded or X-Forwarded-For header
+// takes precedence over the connection's remote address; only enable it when
+// every request passes through a load balancer that sets those headers, since
+// clients can send them too.
+func peerAddr(r *http.Request, trustForwarded bool) string {
+	if trustForwarded {
+		if addr := ForwardedFor(r.Header); addr != "" {
+			return addr
+		}
+	}
+	return r.RemoteAddr
+}
+
+// ForwardedFor returns the original client address in h: the for= parameter
+// of the first element of the Forwarded header (RFC 7239), falling back to
+// the first entry of X-Forwarded-For. It returns "" when neither names one.
+func ForwardedFor(h http.Header) string {
+	if v := h.Get("Forwarded"); v != "" {
+		first, _, _ := strings.Cut(v, ",")
+		for pair := range strings.SplitSeq(first, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || !strings.EqualFold(key, "for") {
+				continue
+			}
+			value = strings.Trim(value, `"`)
+			// IPv6 addresses are bracketed: for="[2001:db8::1]:4711".
+			if host, ok := strings.CutPrefix(value, "["); ok && !strings.Contains(host, "]:") {
+				value = strings.TrimSuffix(host, "]")
+			}
+			if value != "" && value != "unknown" {
+				return value
+			}
+		}
+	}
+	if v := h.Get("X-Forwarded-For"); v != "" {
+		first, _, _ := strings.Cut(v, ",")
+		return strings.TrimSpace(first)
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+package proxy_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+func TestForwardedFor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   string
+	}{
+		{name: "none", header: http.Header{}, want: ""},
+		{name: "x-forwarded-for", header: http.Header{"X-Forwarded-For": {"203.0.113.7"}}, want: "203.0.113.7"},
+		{name: "x-forwarded-for chain", header: http.Header{"X-Forwarded-For": {" 203.0.113.7 , 10.0.0.1"}}, want: "203.0.113.7"},
+		{name: "forwarded", header: http.Header{"Forwarded": {"for=192.0.2.60;proto=http;by=203.0.113.43"}}, want: "192.0.2.60"},
+		{name: "forwarded case", header: http.Header{"Forwarded": {"For=192.0.2.60"}}, want: "192.0.2.60"},
+		{name: "forwarded chain", header: http.Header{"Forwarded": {"for=192.0.2.43, for=198.51.100.17"}}, want: "192.0.2.43"},
+		{name: "forwarded quoted port", header: http.Header{"Forwarded": {`for="192.0.2.43:4711"`}}, want: "192.0.2.43:4711"},
+		{name: "forwarded ipv6", header: http.Header{"Forwarded": {`for="[2001:db8:cafe::17]"`}}, want: "2001:db8:cafe::17"},
+		{name: "forwarded ipv6 port", header: http.Header{"Forwarded": {`for="[2001:db8:cafe::17]:4711"`}}, want: "[2001:db8:cafe::17]:4711"},
+		{
+			name:   "forwarded wins",
+			header: http.Header{"Forwarded": {"for=192.0.2.60"}, "X-Forwarded-For": {"203.0.113.7"}},
+			want:   "192.0.2.60",
+		},
+		{
+			name:   "forwarded unknown falls back",
+			header: http.Header{"Forwarded": {"for=unknown"}, "X-Forwarded-For": {"203.0.113.7"}},
+			want:   "203.0.113.7",
+		},
+		{name: "forwarded without for", header: http.Header{"Forwarded": {"proto=https"}}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := proxy.ForwardedFor(tt.header); got != tt.want {
+				t.Errorf("ForwardedFor = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServeHTTP_PeerAddr(t *testing.T) {
+	t.Parallel()
+
+	upstream := newUpstream(t, nil, buildGRPCFrame([]byte("ok")))
+
+	tests := []struct {
+		name string
+		opts []proxy.Option
+		want string
+	}{
+		{name: "remote address", want: "192.0.2.1:1234"},
+		{name: "trust forwarded", opts: []proxy.Option{proxy.WithTrustForwarded()}, want: "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rp, err := proxy.New(":0", upstream.URL, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/test.Service/Method",
+				bytes.NewReader(buildGRPCFrame([]byte("req"))))
+			req.RemoteAddr = "192.0.2.1:1234"
+			req.Header.Set("Content-Type", "application/grpc")
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+			if ev := serveOnce(t, rp, req); ev.PeerAddr != tt.want {
+				t.Errorf("peer = %q, want %q", ev.PeerAddr, tt.want)
+			}
+		})
+	}
+}
@@ -2,8 +2,10 @@ package proxy
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -52,33 +54,163 @@ func (p Protocol) String() string {
 	return fmt.Sprintf("UnknownProtocol(%d)", p)
 }
 
-// MaxCaptureSize is the maximum number of bytes captured per body.
+// ParseProtocol parses a protocol name as returned by Protocol.String, e.g.
+// "gRPC-Web", ignoring case.
+func ParseProtocol(s string) (Protocol, error) {
+	for _, p := range []Protocol{ProtocolGRPC, ProtocolGRPCWeb, ProtocolConnect} {
+		if strings.EqualFold(s, p.String()) {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("proxy: unknown protocol %q", s)
+}
+
+// Phase tells whether an event describes a finished call or a snapshot of one
+// that is still running.
+type Phase int32
+
+const (
+	PhaseComplete Phase = iota // The call has finished; this is its final event
+	PhaseProgress              // Interim snapshot of a long-running stream
+	PhaseStart                 // A long-running call has started; its status is not known yet
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseComplete:
+		return "complete"
+	case PhaseProgress:
+		return "progress"
+	case PhaseStart:
+		return "start"
+	}
+	return fmt.Sprintf("UnknownPhase(%d)", p)
+}
+
+// MaxCaptureSize is the default maximum number of bytes captured per body,
+// unless changed with WithMaxCaptureSize.
 const MaxCaptureSize = 64 * 1024
 
 // Event represents a captured gRPC call event.
+//
+// A call that outlives the stream update interval first emits a PhaseStart
+// event, then PhaseProgress events as its response streams in, and finally a
+// PhaseComplete event. All events of one call share the same ID, and each
+// supersedes the previous one.
 type Event struct {
-	ID              string
-	Method          string // Full method name, e.g. "/package.Service/Method"
-	CallType        CallType
-	Protocol        Protocol
-	StartTime       time.Time
-	Duration        time.Duration
-	Status          int32  // gRPC status code (codes.Code)
-	Error           string // Error message, empty on success
-	RequestHeaders  http.Header
-	ResponseHeaders http.Header
-	RequestBody     []byte // Captured request body (up to MaxCaptureSize)
-	ResponseBody    []byte // Captured response body (up to MaxCaptureSize)
+	ID               string
+	Phase            Phase
+	Method           string // Full method name, e.g. "/package.Service/Method"
+	CallType         CallType
+	Protocol         Protocol
+	StartTime        time.Time
+	Duration         time.Duration
+	Status           int32  // gRPC status code (codes.Code)
+	Error            string // Error message, empty on success
+	RequestHeaders   http.Header
+	ResponseHeaders  http.Header
+	ResponseTrailers http.Header // gRPC trailers, including those carried in a gRPC-Web trailer frame
+	RequestBody      []byte      // Captured request body (up to the capture size)
+	ResponseBody     []byte      // Captured response body (up to the capture size)
+	RequestSize      int64       // Total request body bytes on the wire
+	ResponseSize     int64       // Total response body bytes on the wire
+
+	// Compression of the captured (first) message in each direction. The
+	// encoding is empty when the message was sent uncompressed; the
+	// decompressed size is len(RequestBody) / len(ResponseBody).
+	RequestEncoding        string
+	ResponseEncoding       string
+	RequestCompressedSize  int64
+	ResponseCompressedSize int64
+
+	// BodyCaptureDisabled is set when the proxy was configured not to retain
+	// payloads, so empty bodies mean "not captured" rather than "empty".
+	BodyCaptureDisabled bool
+
+	// BodyTruncated is set when a body was longer than the capture size, so
+	// RequestBody or ResponseBody holds only its beginning.
+	BodyTruncated bool
+
+	// Upstream is the name of the replay upstream a replayed call was sent
+	// to, or empty when it went to the proxied upstream.
+	Upstream string
+
+	// Deadline is the timeout the client set for the call (grpc-timeout or
+	// Connect-Timeout-Ms), or 0 when it set none.
+	Deadline time.Duration
+
+	// PeerAddr is the address of the client that made the call, as seen by
+	// the proxy or, with WithTrustForwarded, as named by a load balancer.
+	// It is empty for replayed calls.
+	PeerAddr string
+
+	// Authority is the :authority (HTTP/2) or Host (HTTP/1.1) the client
+	// addressed, which tells apart virtual hosts behind one proxy.
+	Authority string
+
+	// CorrelationID is the value of the correlation header of the request
+	// (see WithCorrelationHeader), e.g. the trace ID of its traceparent. It
+	// is empty when the client sent none.
+	CorrelationID string
+
+	// TimeToFirstByte is how long after StartTime the first bytes of the
+	// response body arrived from the upstream, or 0 when none did. Next to
+	// Duration, it tells a slow server apart from a slow transfer or a long
+	// stream.
+	TimeToFirstByte time.Duration
+
+	// ContentType is the Content-Type of the request, which names the codec
+	// of the call next to its Protocol: application/json and
+	// application/proto are both Connect, for instance.
+	ContentType string
+
+	// ResponseMessageRate is how many response messages per second a server
+	// stream or bidi stream has delivered so far, between its first message
+	// and its latest one. It is 0 for other calls and before the second
+	// message, and for protocols whose messages the proxy does not count.
+	ResponseMessageRate float64
+
+	// RequestFrames and ResponseFrames are the captured messages of a bidi
+	// stream, each with when it passed the proxy, so that the interleaving
+	// of the two directions can be followed. They are set on the final
+	// event of gRPC and gRPC-Web bidi streams only, and hold the messages
+	// that fit in the captured bodies.
+	RequestFrames  []Frame
+	ResponseFrames []Frame
+}
+
+// Frame is one message of a stream.
+type Frame struct {
+	Offset time.Duration // when the message passed the proxy, after StartTime
+	Body   []byte        // the message, decompressed
 }
 
+var (
+	// ErrUnknownUpstream is returned by Replay when the requested upstream is
+	// not on the proxy's replay allow-list.
+	ErrUnknownUpstream = errors.New("unknown replay upstream")
+	// ErrInvalidMethod is returned by Replay for method paths that could point
+	// the request somewhere other than a method on the upstream, such as ones
+	// containing ".." or a full URL.
+	ErrInvalidMethod = errors.New("invalid method")
+	// ErrRedirect is returned by Replay when the upstream answers with a
+	// redirect. Redirects are never followed.
+	ErrRedirect = errors.New("upstream redirected")
+	// ErrInvalidContentType is returned by Replay when the content type to
+	// send does not belong to the protocol to replay with.
+	ErrInvalidContentType = errors.New("content type does not match protocol")
+)
+
 // Proxy is the interface for gRPC reverse proxies.
 type Proxy interface {
 	// ListenAndServe accepts client connections and relays them to the upstream gRPC server.
 	ListenAndServe(ctx context.Context) error
 	// Events returns the channel of captured events.
 	Events() <-chan Event
-	// Replay sends a request to the upstream server and returns the resulting event.
-	Replay(ctx context.Context, method string, body []byte) (Event, error)
+	// Replay sends a request to the upstream server and returns the resulting
+	// event. A non-empty req.Upstream names an allow-listed replay upstream to
+	// send it to instead of the proxied one.
+	Replay(ctx context.Context, req ReplayRequest) (Event, error)
 	// Close stops the proxy.
 	Close() error
 }
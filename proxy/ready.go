@@ -0,0 +1,56 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// errNotListening is reported by CheckReady until the proxy accepts
+// connections.
+var errNotListening = errors.New("proxy: not listening yet")
+
+// Ready returns a channel that is closed once ListenAndServe is listening, so
+// client connections are accepted from then on.
+func (rp *ReverseProxy) Ready() <-chan struct{} {
+	return rp.ready
+}
+
+// Addr returns the address the proxy listens on, e.g. to learn the port
+// picked for a listen address of ":0". It is nil until Ready is closed.
+func (rp *ReverseProxy) Addr() net.Addr {
+	select {
+	case <-rp.ready:
+		return rp.addr
+	default:
+		return nil
+	}
+}
+
+// CheckReady reports whether the proxy can serve calls: it must be listening,
+// and the upstream must accept a TCP connection within the connect timeout
+// (see WithConnectTimeout). The connection is closed right away.
+func (rp *ReverseProxy) CheckReady(ctx context.Context) error {
+	if rp.Addr() == nil {
+		return errNotListening
+	}
+	d := net.Dialer{Timeout: rp.connectTimeout}
+	conn, err := d.DialContext(ctx, "tcp", upstreamHostPort(rp.upstream.Scheme, rp.upstream.Host))
+	if err != nil {
+		return fmt.Errorf("proxy: upstream unreachable: %w", err)
+	}
+	_ = conn.Close()
+	return nil
+}
+
+// upstreamHostPort adds the default port of scheme to host if it has none.
+func upstreamHostPort(scheme, host string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	if scheme == "https" {
+		return net.JoinHostPort(host, "443")
+	}
+	return net.JoinHostPort(host, "80")
+}
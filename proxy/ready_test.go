@@ -0,0 +1,75 @@
+package proxy_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+func TestReady(t *testing.T) {
+	t.Parallel()
+
+	rp, err := proxy.New("127.0.0.1:0", "http://upstream.invalid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rp.Addr() != nil {
+		t.Errorf("Addr() = %v before listening, want nil", rp.Addr())
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+	go func() { done <- rp.ListenAndServe(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	select {
+	case <-rp.Ready():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Ready was never closed")
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(t.Context(), "tcp", rp.Addr().String())
+	if err != nil {
+		t.Fatalf("dial %v after Ready: %v", rp.Addr(), err)
+	}
+	_ = conn.Close()
+}
+
+func TestServe_Twice(t *testing.T) {
+	t.Parallel()
+
+	rp, err := proxy.New("127.0.0.1:0", "http://upstream.invalid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+	go func() { done <- rp.ListenAndServe(ctx) }()
+	<-rp.Ready()
+
+	var lc net.ListenConfig
+	lis, err := lc.Listen(t.Context(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rp.Serve(ctx, lis); err == nil {
+		t.Error("second Serve succeeded, want an error")
+	}
+	if _, err := lis.Accept(); err == nil {
+		t.Error("second Serve left its listener open")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("first Serve = %v", err)
+	}
+	if err := rp.ListenAndServe(t.Context()); err == nil {
+		t.Error("ListenAndServe after Serve returned succeeded, want an error")
+	}
+}
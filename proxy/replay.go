@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReplayRequest describes a call for Replay to send.
+type ReplayRequest struct {
+	Method   string // e.g. "/echo.v1.EchoService/Echo"
+	Body     []byte // the request message, without framing
+	Upstream string // name of an allow-listed replay upstream; empty for the proxied upstream
+
+	// Protocol is the wire protocol to replay with; the zero value is gRPC.
+	Protocol Protocol
+	// ContentType is the Content-Type to send, typically that of the original
+	// call, e.g. "application/json" to replay a Connect JSON call. It must
+	// belong to Protocol. Empty selects the protocol's binary protobuf type.
+	ContentType string
+}
+
+// replayContentType returns the Content-Type a replay with protocol p sends.
+func replayContentType(p Protocol, ct string) (string, error) {
+	if ct == "" {
+		switch p {
+		case ProtocolGRPCWeb:
+			return "application/grpc-web+proto", nil
+		case ProtocolConnect:
+			return "application/proto", nil
+		default:
+			return "application/grpc", nil
+		}
+	}
+	if protocolOf(ct) != p {
+		return "", fmt.Errorf("%w: %q is not %s", ErrInvalidContentType, ct, p)
+	}
+	return ct, nil
+}
+
+// isConnectStream reports whether ct is a Connect streaming content type,
+// whose messages are enveloped like gRPC frames.
+func isConnectStream(ct string) bool {
+	return strings.HasPrefix(ct, "application/connect+")
+}
+
+// encodeReplayBody frames body for the protocol: one length-prefixed message
+// for gRPC, gRPC-Web and Connect streaming, base64-encoded for gRPC-Web text,
+// and the bare message for unary Connect.
+func encodeReplayBody(p Protocol, ct string, body []byte) []byte {
+	if p == ProtocolConnect && !isConnectStream(ct) {
+		return body
+	}
+	frame := make([]byte, 5+len(body))
+	frame[0] = 0                                              // no compression
+	binary.BigEndian.PutUint32(frame[1:5], uint32(len(body))) //nolint:gosec // replay bodies are capped far below 4GiB by the web and gRPC APIs
+	copy(frame[5:], body)
+	if strings.HasPrefix(ct, "application/grpc-web-text") {
+		return []byte(base64.StdEncoding.EncodeToString(frame))
+	}
+	return frame
+}
+
+// setReplayHeaders sets the headers the protocol requires on a replay.
+func setReplayHeaders(h http.Header, p Protocol, ct string) {
+	h.Set("Content-Type", ct)
+	switch {
+	case p == ProtocolGRPC:
+		h.Set("TE", "trailers")
+	case p == ProtocolGRPCWeb:
+		h.Set("X-Grpc-Web", "1")
+	case !isConnectStream(ct):
+		h.Set("Connect-Protocol-Version", "1")
+	}
+}
+
+// setReplayTimeout announces the replay timeout d to the upstream in the
+// protocol's timeout header.
+func setReplayTimeout(h http.Header, p Protocol, d time.Duration) {
+	if p == ProtocolConnect {
+		ms := min(ceilDiv(d, time.Millisecond), 9999999999) // at most ten digits
+		h.Set("Connect-Timeout-Ms", strconv.FormatInt(ms, 10))
+		return
+	}
+	h.Set("Grpc-Timeout", FormatTimeout(d))
+}
+
+// replayResult is what a replayed call's response decodes to.
+type replayResult struct {
+	status         int32
+	errMsg         string
+	trailers       http.Header
+	payload        []byte
+	encoding       string
+	compressedSize int64
+}
+
+// decodeReplayResponse extracts the status and response message of a
+// replayed call from resp and its body, data, the same way ServeHTTP does for
+// proxied calls of protocol p, decompressing at most limit bytes.
+func decodeReplayResponse(p Protocol, resp *http.Response, data []byte, limit int) replayResult {
+	status, errMsg := ExtractStatus(p, resp)
+	r := replayResult{status: status, errMsg: errMsg, trailers: resp.Trailer.Clone()}
+	if p == ProtocolConnect {
+		r.payload = DecompressGzip(data, limit)
+		return r
+	}
+
+	if p == ProtocolGRPCWeb {
+		if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/grpc-web-text") {
+			data = DecodeGRPCWebText(data)
+		}
+		// gRPC-Web carries trailers in-band as the last body frame.
+		var webTrailers http.Header
+		data, webTrailers = SplitGRPCWebTrailers(data)
+		if webTrailers != nil {
+			r.trailers = webTrailers
+			if code, msg, ok := statusFromHeader(webTrailers); ok {
+				r.status, r.errMsg = code, msg
+			}
+		}
+	}
+	encoding := resp.Header.Get("Grpc-Encoding")
+	r.encoding, r.compressedSize = FrameCompression(data, encoding)
+	r.payload = ExtractPayload(data, encoding, limit)
+	return r
+}
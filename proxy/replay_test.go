@@ -0,0 +1,188 @@
+package proxy_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+func TestReplay_Protocols(t *testing.T) {
+	t.Parallel()
+
+	framed := buildGRPCFrame([]byte("req"))
+	tests := []struct {
+		name        string
+		protocol    proxy.Protocol
+		contentType string
+		wantCT      string
+		wantBody    []byte
+		wantHeader  string // header the upstream must receive set to a non-empty value
+		respond     func(w http.ResponseWriter)
+		wantStatus  int32
+		wantResp    string // defaults to "resp" for successful calls
+	}{
+		{
+			name: "grpc", protocol: proxy.ProtocolGRPC,
+			wantCT: "application/grpc", wantBody: framed, wantHeader: "Te",
+			respond: func(w http.ResponseWriter) {
+				w.Header().Set("Content-Type", "application/grpc")
+				w.Header().Set("Trailer", "Grpc-Status")
+				_, _ = w.Write(buildGRPCFrame([]byte("resp")))
+				w.Header().Set("Grpc-Status", "5")
+			},
+			wantStatus: 5,
+		},
+		{
+			name: "grpc-web", protocol: proxy.ProtocolGRPCWeb,
+			wantCT: "application/grpc-web+proto", wantBody: framed, wantHeader: "X-Grpc-Web",
+			respond: func(w http.ResponseWriter) {
+				w.Header().Set("Content-Type", "application/grpc-web+proto")
+				_, _ = w.Write(append(buildGRPCFrame([]byte("resp")), buildGRPCWebTrailerFrame("grpc-status: 7\r\n")...))
+			},
+			wantStatus: 7,
+		},
+		{
+			name: "grpc-web text", protocol: proxy.ProtocolGRPCWeb, contentType: "application/grpc-web-text",
+			wantCT: "application/grpc-web-text", wantBody: []byte(base64.StdEncoding.EncodeToString(framed)),
+			respond: func(w http.ResponseWriter) {
+				w.Header().Set("Content-Type", "application/grpc-web-text+proto")
+				body := append(buildGRPCFrame([]byte("resp")), buildGRPCWebTrailerFrame("grpc-status: 0\r\n")...)
+				_, _ = io.WriteString(w, base64.StdEncoding.EncodeToString(body))
+			},
+		},
+		{
+			name: "connect proto", protocol: proxy.ProtocolConnect,
+			wantCT: "application/proto", wantBody: []byte("req"), wantHeader: "Connect-Protocol-Version",
+			respond: func(w http.ResponseWriter) {
+				w.Header().Set("Content-Type", "application/proto")
+				_, _ = io.WriteString(w, "resp")
+			},
+		},
+		{
+			name: "connect json", protocol: proxy.ProtocolConnect, contentType: "application/json",
+			wantCT: "application/json", wantBody: []byte("req"), wantHeader: "Connect-Protocol-Version",
+			respond: func(w http.ResponseWriter) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = io.WriteString(w, "resp")
+			},
+		},
+		{
+			name: "connect error", protocol: proxy.ProtocolConnect,
+			wantCT: "application/proto", wantBody: []byte("req"),
+			respond: func(w http.ResponseWriter) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = io.WriteString(w, `{"code":"unauthenticated"}`)
+			},
+			wantStatus: 16,
+		},
+		{
+			name: "connect streaming", protocol: proxy.ProtocolConnect, contentType: "application/connect+proto",
+			wantCT: "application/connect+proto", wantBody: framed,
+			respond: func(w http.ResponseWriter) {
+				w.Header().Set("Content-Type", "application/connect+proto")
+				_, _ = w.Write(buildGRPCFrame([]byte("resp")))
+			},
+			// Like proxied Connect streams, the response is captured as sent.
+			wantResp: string(buildGRPCFrame([]byte("resp"))),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			type received struct {
+				header http.Header
+				body   []byte
+			}
+			got := make(chan received, 1)
+			h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				got <- received{header: r.Header.Clone(), body: body}
+				tt.respond(w)
+			})
+			upstream := httptest.NewServer(h2c.NewHandler(h, &http2.Server{}))
+			t.Cleanup(upstream.Close)
+			rp, err := proxy.New(":0", upstream.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ev, err := rp.Replay(t.Context(), proxy.ReplayRequest{
+				Method:      "/test.Service/Method",
+				Body:        []byte("req"),
+				Protocol:    tt.protocol,
+				ContentType: tt.contentType,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := <-got
+
+			if ct := req.header.Get("Content-Type"); ct != tt.wantCT {
+				t.Errorf("upstream content type = %q, want %q", ct, tt.wantCT)
+			}
+			if string(req.body) != string(tt.wantBody) {
+				t.Errorf("upstream body = %q, want %q", req.body, tt.wantBody)
+			}
+			if tt.wantHeader != "" && req.header.Get(tt.wantHeader) == "" {
+				t.Errorf("upstream header %s missing", tt.wantHeader)
+			}
+			if ev.Protocol != tt.protocol || ev.Status != tt.wantStatus {
+				t.Errorf("event protocol/status = %v/%d, want %v/%d", ev.Protocol, ev.Status, tt.protocol, tt.wantStatus)
+			}
+			wantResp := tt.wantResp
+			if wantResp == "" && tt.wantStatus == 0 {
+				wantResp = "resp"
+			}
+			if wantResp != "" && string(ev.ResponseBody) != wantResp {
+				t.Errorf("event response = %q, want %q", ev.ResponseBody, wantResp)
+			}
+			if string(ev.RequestBody) != "req" || ev.RequestHeaders.Get("Content-Type") != tt.wantCT {
+				t.Errorf("event request = %q (%s)", ev.RequestBody, ev.RequestHeaders.Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestReplay_ContentTypeMismatch(t *testing.T) {
+	t.Parallel()
+
+	rp, err := proxy.New(":0", "http://upstream.invalid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, req := range []proxy.ReplayRequest{
+		{Method: "/test.Service/Method", Protocol: proxy.ProtocolGRPC, ContentType: "application/json"},
+		{Method: "/test.Service/Method", Protocol: proxy.ProtocolConnect, ContentType: "application/grpc"},
+		{Method: "/test.Service/Method", Protocol: proxy.ProtocolGRPCWeb, ContentType: "application/grpc+proto"},
+	} {
+		if _, err := rp.Replay(t.Context(), req); !errors.Is(err, proxy.ErrInvalidContentType) {
+			t.Errorf("Replay(%v, %q) err = %v, want ErrInvalidContentType", req.Protocol, req.ContentType, err)
+		}
+	}
+}
+
+func TestParseProtocol(t *testing.T) {
+	t.Parallel()
+
+	for _, p := range []proxy.Protocol{proxy.ProtocolGRPC, proxy.ProtocolGRPCWeb, proxy.ProtocolConnect} {
+		if got, err := proxy.ParseProtocol(p.String()); err != nil || got != p {
+			t.Errorf("ParseProtocol(%q) = %v, %v", p.String(), got, err)
+		}
+	}
+	if got, err := proxy.ParseProtocol("grpc-web"); err != nil || got != proxy.ProtocolGRPCWeb {
+		t.Errorf("ParseProtocol ignores case: got %v, %v", got, err)
+	}
+	if _, err := proxy.ParseProtocol("thrift"); err == nil {
+		t.Error("ParseProtocol accepted an unknown protocol")
+	}
+}
@@ -0,0 +1,186 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"connectrpc.com/connect"
+	"golang.org/x/net/http2"
+)
+
+// maxRetryBodySize bounds the request bodies buffered so that idempotent
+// calls can be retried; larger calls are sent once. It matches gRPC's default
+// maximum message size.
+const maxRetryBodySize = 4 << 20
+
+// retryBody buffers the request of a call to an idempotent method so that it
+// can be sent again. Streaming clients may wait for responses before sending
+// more, so nothing past the first message is read ahead of the upstream, and
+// only a body that turns out to hold that message alone is sent again.
+type retryBody struct {
+	first []byte // the body up to the end of its first message
+	r     io.Reader
+	more  atomic.Bool // bytes past the first message were read, or buffering failed
+	done  atomic.Bool // the body was read to its end
+}
+
+// bufferRetryBody reads body up to the end of its first message: a gRPC
+// frame when framed is set, and the whole body, for unary Connect and
+// gRPC-Web text calls, otherwise. It returns the buffer and a reader
+// yielding the whole body.
+func bufferRetryBody(body io.Reader, framed bool) (*retryBody, io.Reader) {
+	rb := &retryBody{r: body}
+	var err error
+	if framed {
+		rb.first, err = readFrame(body)
+	} else {
+		rb.first, err = io.ReadAll(io.LimitReader(body, maxRetryBodySize+1))
+		if err == nil && len(rb.first) <= maxRetryBodySize {
+			err = io.EOF
+		}
+	}
+	switch {
+	case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+		rb.done.Store(true)
+	case err != nil || !framed:
+		rb.more.Store(true)
+	}
+	return rb, io.MultiReader(bytes.NewReader(rb.first), rb)
+}
+
+// errFrameTooLarge stops a frame from being buffered for a retry.
+var errFrameTooLarge = errors.New("frame too large to buffer")
+
+// readFrame reads one gRPC frame from r, returning as much of it as was read
+// along with any error.
+func readFrame(r io.Reader) ([]byte, error) {
+	hdr := make([]byte, 5)
+	n, err := io.ReadFull(r, hdr)
+	if err != nil {
+		return hdr[:n], err //nolint:wrapcheck // inspected by the caller
+	}
+	length := binary.BigEndian.Uint32(hdr[1:5])
+	if length > maxRetryBodySize {
+		return hdr, errFrameTooLarge
+	}
+	frame := append(hdr, make([]byte, length)...)
+	n, err = io.ReadFull(r, frame[5:])
+	return frame[:5+n], err //nolint:wrapcheck // inspected by the caller
+}
+
+func (rb *retryBody) Read(p []byte) (int, error) {
+	n, err := rb.r.Read(p)
+	if n > 0 {
+		rb.more.Store(true)
+	}
+	if errors.Is(err, io.EOF) {
+		rb.done.Store(true)
+	}
+	return n, err //nolint:wrapcheck // pass-through reader
+}
+
+// replayable reports whether the body is known to end with its first
+// message. The transport may not have read it to the end yet when a call
+// fails, and such calls are not retried.
+func (rb *retryBody) replayable() bool {
+	return rb.done.Load() && !rb.more.Load()
+}
+
+// upstreamError translates an error from the upstream round trip into the
+// gRPC status of the call and a description of what happened. Stream resets
+// map to codes as in the gRPC HTTP/2 protocol spec; a GOAWAY or any other
+// failure to reach the upstream is Unavailable.
+func upstreamError(err error) (connect.Code, string) {
+	var se http2.StreamError
+	var ga http2.GoAwayError
+	switch {
+	case errors.As(err, &se):
+		return resetCode(se.Code), fmt.Sprintf("upstream reset the stream (%s)", se.Code)
+	case errors.As(err, &ga):
+		msg := fmt.Sprintf("upstream sent GOAWAY (%s)", ga.ErrCode)
+		if ga.DebugData != "" {
+			msg += ": " + ga.DebugData
+		}
+		return connect.CodeUnavailable, msg
+	case errors.Is(err, context.Canceled):
+		return connect.CodeCanceled, "client canceled the call"
+	case errors.Is(err, context.DeadlineExceeded):
+		return connect.CodeDeadlineExceeded, "deadline exceeded before the upstream responded"
+	case strings.Contains(err.Error(), "GOAWAY"):
+		// A graceful GOAWAY refusing a stream surfaces as an unexported error.
+		return connect.CodeUnavailable, "upstream sent GOAWAY: " + err.Error()
+	default:
+		return connect.CodeUnavailable, "upstream: " + err.Error()
+	}
+}
+
+// resetCode maps an HTTP/2 RST_STREAM error code to a gRPC status code.
+func resetCode(code http2.ErrCode) connect.Code {
+	switch code {
+	case http2.ErrCodeRefusedStream:
+		return connect.CodeUnavailable
+	case http2.ErrCodeCancel:
+		return connect.CodeCanceled
+	case http2.ErrCodeEnhanceYourCalm:
+		return connect.CodeResourceExhausted
+	case http2.ErrCodeInadequateSecurity:
+		return connect.CodePermissionDenied
+	default:
+		return connect.CodeInternal
+	}
+}
+
+// isReset reports whether err means the upstream reset the stream or went
+// away, the failures a retry may get past.
+func isReset(err error) bool {
+	var se http2.StreamError
+	var ga http2.GoAwayError
+	return errors.As(err, &se) || errors.As(err, &ga) || strings.Contains(err.Error(), "GOAWAY")
+}
+
+// idempotent reports whether calls to method may be retried, i.e. method, or
+// its service for entries ending in "/", was passed to WithIdempotentRetry.
+func (rp *ReverseProxy) idempotent(method string) bool {
+	for _, m := range rp.idempotentMethods {
+		if m == method || strings.HasSuffix(m, "/") && strings.HasPrefix(method, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeUpstreamError answers a call the upstream failed. gRPC and gRPC-Web
+// clients get a trailers-only response carrying the status, so they see it
+// instead of a bare HTTP error; other protocols get 502 Bad Gateway.
+func writeUpstreamError(w http.ResponseWriter, r *http.Request, protocol Protocol, code connect.Code, msg string) {
+	if protocol != ProtocolGRPC && protocol != ProtocolGRPCWeb {
+		http.Error(w, msg, http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+	w.Header().Set("Grpc-Status", strconv.Itoa(int(code)))
+	w.Header().Set("Grpc-Message", encodeGRPCMessage(msg))
+	w.WriteHeader(http.StatusOK)
+}
+
+// encodeGRPCMessage percent-encodes msg for the grpc-message header.
+func encodeGRPCMessage(msg string) string {
+	var sb strings.Builder
+	for i := range len(msg) {
+		c := msg[i]
+		if c >= ' ' && c <= '~' && c != '%' {
+			sb.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&sb, "%%%02X", c)
+	}
+	return sb.String()
+}
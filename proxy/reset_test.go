@@ -0,0 +1,266 @@
+package proxy_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// okResponse is a successful unary gRPC response with payload.
+func okResponse(payload string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/grpc"}},
+		Body:       io.NopCloser(bytes.NewReader(buildGRPCFrame([]byte(payload)))),
+		Trailer:    http.Header{"Grpc-Status": {"0"}},
+	}
+}
+
+// newFailingProxy returns a proxy whose upstream round trips are answered by
+// rt.
+func newFailingProxy(t *testing.T, rt http.RoundTripper, opts ...proxy.Option) *proxy.ReverseProxy {
+	t.Helper()
+
+	rp, err := proxy.New(":0", "http://upstream.invalid", append(opts, proxy.WithTransport(rt))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rp
+}
+
+func grpcRequest(t *testing.T, contentType string) *http.Request {
+	t.Helper()
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/test.Service/Get",
+		bytes.NewReader(buildGRPCFrame([]byte("req"))))
+	req.Header.Set("Content-Type", contentType)
+	return req
+}
+
+func TestServeHTTP_UpstreamReset(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		err     error
+		code    connect.Code
+		message string
+	}{
+		{
+			name: "refused stream", err: http2.StreamError{StreamID: 1, Code: http2.ErrCodeRefusedStream},
+			code: connect.CodeUnavailable, message: "upstream reset the stream (REFUSED_STREAM)",
+		},
+		{
+			name: "cancel", err: http2.StreamError{StreamID: 1, Code: http2.ErrCodeCancel},
+			code: connect.CodeCanceled, message: "upstream reset the stream (CANCEL)",
+		},
+		{
+			name: "internal error", err: http2.StreamError{StreamID: 1, Code: http2.ErrCodeInternal},
+			code: connect.CodeInternal, message: "upstream reset the stream (INTERNAL_ERROR)",
+		},
+		{
+			name: "enhance your calm", err: http2.StreamError{StreamID: 1, Code: http2.ErrCodeEnhanceYourCalm},
+			code: connect.CodeResourceExhausted, message: "upstream reset the stream (ENHANCE_YOUR_CALM)",
+		},
+		{
+			name: "wrapped reset", err: fmt.Errorf("roundtrip: %w", http2.StreamError{Code: http2.ErrCodeRefusedStream}),
+			code: connect.CodeUnavailable, message: "upstream reset the stream (REFUSED_STREAM)",
+		},
+		{
+			name: "goaway", err: http2.GoAwayError{ErrCode: http2.ErrCodeNo, DebugData: "shutting down"},
+			code: connect.CodeUnavailable, message: "upstream sent GOAWAY (NO_ERROR): shutting down",
+		},
+		{
+			name: "graceful goaway", err: errors.New("http2: Transport received Server's graceful shutdown GOAWAY"),
+			code: connect.CodeUnavailable, message: "upstream sent GOAWAY",
+		},
+		{
+			name: "connection refused", err: errors.New("dial tcp: connection refused"),
+			code: connect.CodeUnavailable, message: "upstream: dial tcp: connection refused",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rp := newFailingProxy(t, roundTripFunc(func(*http.Request) (*http.Response, error) {
+				return nil, tt.err
+			}))
+			rec := httptest.NewRecorder()
+			rp.ServeHTTP(rec, grpcRequest(t, "application/grpc"))
+			ev := <-rp.Events()
+
+			if ev.Status != int32(tt.code) || !strings.HasPrefix(ev.Error, tt.message) {
+				t.Errorf("event status/error = %d/%q, want %d/%q", ev.Status, ev.Error, tt.code, tt.message)
+			}
+			if rec.Code != http.StatusOK {
+				t.Errorf("client got HTTP %d, want a trailers-only 200", rec.Code)
+			}
+			if got := rec.Header().Get("Grpc-Status"); got != fmt.Sprint(int(tt.code)) {
+				t.Errorf("client grpc-status = %q, want %d", got, tt.code)
+			}
+			if got := rec.Header().Get("Grpc-Message"); !strings.HasPrefix(got, tt.message) {
+				t.Errorf("client grpc-message = %q, want %q", got, tt.message)
+			}
+		})
+	}
+
+	t.Run("connect gets bad gateway", func(t *testing.T) {
+		t.Parallel()
+		rp := newFailingProxy(t, roundTripFunc(func(*http.Request) (*http.Response, error) {
+			return nil, http2.StreamError{Code: http2.ErrCodeRefusedStream}
+		}))
+		rec := httptest.NewRecorder()
+		rp.ServeHTTP(rec, grpcRequest(t, "application/proto"))
+		ev := <-rp.Events()
+		if rec.Code != http.StatusBadGateway || ev.Status != int32(connect.CodeUnavailable) {
+			t.Errorf("HTTP/event status = %d/%d, want 502/Unavailable", rec.Code, ev.Status)
+		}
+	})
+
+	t.Run("real upstream reset", func(t *testing.T) {
+		t.Parallel()
+		h := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic(http.ErrAbortHandler) // resets the stream with INTERNAL_ERROR
+		})
+		upstream := httptest.NewServer(h2c.NewHandler(h, &http2.Server{}))
+		t.Cleanup(upstream.Close)
+		rp, err := proxy.New(":0", upstream.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ev := serveOnce(t, rp, grpcRequest(t, "application/grpc"))
+		if ev.Status != int32(connect.CodeInternal) || !strings.Contains(ev.Error, "INTERNAL_ERROR") {
+			t.Errorf("event status/error = %d/%q, want Internal with INTERNAL_ERROR", ev.Status, ev.Error)
+		}
+	})
+}
+
+func TestServeHTTP_IdempotentRetry(t *testing.T) {
+	t.Parallel()
+
+	reset := http2.StreamError{StreamID: 1, Code: http2.ErrCodeRefusedStream}
+	tests := []struct {
+		name       string
+		opts       []proxy.Option
+		firstErr   error
+		wantTries  int32
+		wantStatus connect.Code
+	}{
+		{name: "not idempotent", firstErr: reset, wantTries: 1, wantStatus: connect.CodeUnavailable},
+		{
+			name: "idempotent method", opts: []proxy.Option{proxy.WithIdempotentRetry("/test.Service/Get")},
+			firstErr: reset, wantTries: 2, wantStatus: 0,
+		},
+		{
+			name: "idempotent service", opts: []proxy.Option{proxy.WithIdempotentRetry("/test.Service/")},
+			firstErr: http2.GoAwayError{ErrCode: http2.ErrCodeNo}, wantTries: 2, wantStatus: 0,
+		},
+		{
+			name: "other method", opts: []proxy.Option{proxy.WithIdempotentRetry("/test.Service/List")},
+			firstErr: reset, wantTries: 1, wantStatus: connect.CodeUnavailable,
+		},
+		{
+			name: "not a reset", opts: []proxy.Option{proxy.WithIdempotentRetry("/test.Service/Get")},
+			firstErr: errors.New("dial tcp: connection refused"), wantTries: 1, wantStatus: connect.CodeUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var tries atomic.Int32
+			rp := newFailingProxy(t, roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(r.Body)
+				if !bytes.Equal(body, buildGRPCFrame([]byte("req"))) {
+					t.Errorf("attempt %d sent body %q, want the full request", tries.Load()+1, body)
+				}
+				if tries.Add(1) == 1 {
+					return nil, tt.firstErr
+				}
+				return okResponse("ok"), nil
+			}), tt.opts...)
+
+			ev := serveOnce(t, rp, grpcRequest(t, "application/grpc"))
+			if n := tries.Load(); n != tt.wantTries {
+				t.Errorf("round trips = %d, want %d", n, tt.wantTries)
+			}
+			if ev.Status != int32(tt.wantStatus) {
+				t.Errorf("status = %d (%s), want %d", ev.Status, ev.Error, tt.wantStatus)
+			}
+			if !bytes.Equal(ev.RequestBody, []byte("req")) {
+				t.Errorf("captured request = %q, want req", ev.RequestBody)
+			}
+		})
+	}
+}
+
+func TestServeHTTP_IdempotentRetryStreams(t *testing.T) {
+	t.Parallel()
+
+	t.Run("stream is forwarded before it ends", func(t *testing.T) {
+		t.Parallel()
+		frame := buildGRPCFrame([]byte("req"))
+		forwarded := make(chan struct{})
+		rp := newFailingProxy(t, roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			got := make([]byte, len(frame))
+			if _, err := io.ReadFull(r.Body, got); err != nil || !bytes.Equal(got, frame) {
+				t.Errorf("upstream got %q (%v), want the first message", got, err)
+			}
+			close(forwarded)
+			return okResponse("ok"), nil
+		}), proxy.WithIdempotentRetry("/test.Service/"))
+
+		pr, pw := io.Pipe()
+		go func() {
+			_, _ = pw.Write(frame)
+			// Like a bidi client, send more only after the call went out.
+			select {
+			case <-forwarded:
+			case <-time.After(5 * time.Second):
+				t.Error("call not forwarded while the request stream was open")
+			}
+			_ = pw.Close()
+		}()
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/test.Service/Chat", pr)
+		req.Header.Set("Content-Type", "application/grpc")
+		if ev := serveOnce(t, rp, req); ev.Status != 0 {
+			t.Errorf("status = %d (%s), want OK", ev.Status, ev.Error)
+		}
+	})
+
+	t.Run("several messages are not retried", func(t *testing.T) {
+		t.Parallel()
+		var tries atomic.Int32
+		rp := newFailingProxy(t, roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			_, _ = io.ReadAll(r.Body)
+			if tries.Add(1) == 1 {
+				return nil, http2.StreamError{StreamID: 1, Code: http2.ErrCodeRefusedStream}
+			}
+			return okResponse("ok"), nil
+		}), proxy.WithIdempotentRetry("/test.Service/"))
+
+		body := append(buildGRPCFrame([]byte("a")), buildGRPCFrame([]byte("b"))...)
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/test.Service/Upload", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/grpc")
+		ev := serveOnce(t, rp, req)
+		if n := tries.Load(); n != 1 || ev.Status != int32(connect.CodeUnavailable) {
+			t.Errorf("round trips/status = %d/%d, want 1/Unavailable", n, ev.Status)
+		}
+	})
+}
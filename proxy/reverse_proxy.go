@@ -3,20 +3,22 @@ package proxy
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
-	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"connectrpc.com/connect"
 	"github.com/google/uuid"
+	"golang.org/x/net/http/httpguts"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 )
@@ -28,30 +30,74 @@ type ReverseProxy struct {
 	upstream   *url.URL
 	events     chan Event
 	server     *http.Server
-	transport  http.RoundTripper
+	transport  http.RoundTripper // built by newTransport unless set by WithTransport
+	ready      chan struct{}     // closed once listening on addr
+	serveOnce  sync.Once         // lets only the first Serve run
+	addr       net.Addr
+
+	upstreamHTTP1       bool
+	connectTimeout      time.Duration
+	dialContext         func(ctx context.Context, network, addr string) (net.Conn, error) // net.Dialer unless replaced in tests
+	upstreamMaxConns    int
+	upstreamIdleTimeout time.Duration
+
+	streamUpdateInterval time.Duration
+	maxCaptureSize       int
+	noBodyCapture        bool
+	headers              http.Header // set by WithHeader
+	upstreamAuthority    string      // set by WithUpstreamAuthority
+	replayTimeout        time.Duration
+	trustForwarded       bool
+	correlationHeader    string
+	idempotentMethods    []string      // set by WithIdempotentRetry
+	pathRewrites         []pathRewrite // set by WithPathRewrite
+
+	replayUpstreamAddrs map[string]string   // set by WithReplayUpstream
+	replayUpstreams     map[string]*url.URL // parsed from replayUpstreamAddrs
 }
 
+// DefaultStreamUpdateInterval is how long a call runs before it emits a
+// PhaseStart event, and how often an in-flight stream emits a PhaseProgress
+// event, unless changed with WithStreamUpdateInterval.
+const DefaultStreamUpdateInterval = time.Second
+
 // New creates a new ReverseProxy.
-// listenAddr is the address to listen on (e.g. ":8080").
+// listenAddr is the address to listen on (e.g. ":8080", or
+// "unix:/tmp/grpc-tap.sock" for a Unix domain socket; see Listen).
 // upstreamAddr is the upstream server address (e.g. "http://localhost:9090").
-func New(listenAddr, upstreamAddr string) (*ReverseProxy, error) {
+func New(listenAddr, upstreamAddr string, opts ...Option) (*ReverseProxy, error) {
 	u, err := url.Parse(upstreamAddr)
 	if err != nil {
 		return nil, fmt.Errorf("proxy: parse upstream: %w", err)
 	}
 
-	transport := &http2.Transport{
-		AllowHTTP: true,
-		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
-			return (&net.Dialer{}).DialContext(ctx, network, addr)
-		},
-	}
-
 	rp := &ReverseProxy{
 		listenAddr: listenAddr,
 		upstream:   u,
 		events:     make(chan Event, 256),
-		transport:  transport,
+		ready:      make(chan struct{}),
+
+		streamUpdateInterval: DefaultStreamUpdateInterval,
+		maxCaptureSize:       MaxCaptureSize,
+		correlationHeader:    DefaultCorrelationHeader,
+	}
+	for _, opt := range opts {
+		opt(rp)
+	}
+	if err := validateHeaders(rp.headers); err != nil {
+		return nil, err
+	}
+	if rp.upstreamAuthority != "" && !httpguts.ValidHostHeader(rp.upstreamAuthority) {
+		return nil, fmt.Errorf("proxy: invalid upstream authority %q", rp.upstreamAuthority)
+	}
+	if err := validatePathRewrites(rp.pathRewrites); err != nil {
+		return nil, err
+	}
+	if rp.replayUpstreams, err = parseReplayUpstreams(rp.replayUpstreamAddrs); err != nil {
+		return nil, err
+	}
+	if rp.transport == nil {
+		rp.transport = rp.newTransport()
 	}
 
 	h2s := &http2.Server{}
@@ -63,12 +109,30 @@ func New(listenAddr, upstreamAddr string) (*ReverseProxy, error) {
 	return rp, nil
 }
 
+// errServed is returned by Serve and ListenAndServe once the proxy has served.
+var errServed = errors.New("proxy: already serving")
+
 // ListenAndServe starts the proxy and blocks until ctx is cancelled.
 func (rp *ReverseProxy) ListenAndServe(ctx context.Context) error {
-	lis, err := net.Listen("tcp", rp.listenAddr) //nolint:noctx // uses ctx for shutdown
+	lis, err := Listen(ctx, rp.listenAddr)
 	if err != nil {
-		return fmt.Errorf("proxy: listen %s: %w", rp.listenAddr, err)
+		return err
+	}
+	return rp.Serve(ctx, lis)
+}
+
+// Serve is ListenAndServe on a listener the caller opened, e.g. one inherited
+// by socket activation. The listen address passed to New is not used. lis is
+// closed when Serve returns. A proxy serves once: later calls fail.
+func (rp *ReverseProxy) Serve(ctx context.Context, lis net.Listener) error {
+	first := false
+	rp.serveOnce.Do(func() { first = true })
+	if !first {
+		_ = lis.Close()
+		return errServed
 	}
+	rp.addr = lis.Addr()
+	close(rp.ready)
 
 	go func() {
 		<-ctx.Done()
@@ -92,28 +156,94 @@ func (rp *ReverseProxy) Close() error {
 	return rp.server.Close() //nolint:wrapcheck // pass-through
 }
 
-// Replay sends a gRPC unary request to the upstream server and returns the
-// resulting event. The body should be raw protobuf bytes (without gRPC framing).
-// The event is also published to the events channel.
-func (rp *ReverseProxy) Replay(ctx context.Context, method string, body []byte) (Event, error) {
+// parseReplayUpstreams validates the WithReplayUpstream addresses.
+func parseReplayUpstreams(addrs map[string]string) (map[string]*url.URL, error) {
+	upstreams := make(map[string]*url.URL, len(addrs))
+	for name, addr := range addrs {
+		if name == "" {
+			return nil, fmt.Errorf("proxy: replay upstream %q: empty name", addr)
+		}
+		u, err := url.Parse(addr)
+		if err != nil {
+			return nil, fmt.Errorf("proxy: parse replay upstream %s: %w", name, err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("proxy: replay upstream %s: %q is not an http(s) URL", name, addr)
+		}
+		upstreams[name] = u
+	}
+	return upstreams, nil
+}
+
+// Replay sends a unary request to the upstream server and returns the
+// resulting event. req.Body holds the request message, without framing; it is
+// sent with req.Protocol and req.ContentType (see ReplayRequest), so a call is
+// replayed the way it was originally made.
+// A non-empty req.Upstream sends the request to the replay upstream registered
+// under that name with WithReplayUpstream instead; unknown names fail with
+// ErrUnknownUpstream. Methods rejected by ValidateMethod fail with
+// ErrInvalidMethod, content types of another protocol with
+// ErrInvalidContentType, and redirects are not followed but fail with
+// ErrRedirect. The event is also published to the events channel.
+func (rp *ReverseProxy) Replay(ctx context.Context, rr ReplayRequest) (Event, error) {
 	start := time.Now()
+	method, body, upstream := rr.Method, rr.Body, rr.Upstream
+	// Captured methods are the paths clients called, so they are rewritten
+	// like proxied calls before being checked and sent.
+	path := rp.rewritePath(method)
+
+	if err := ValidateMethod(path); err != nil {
+		return Event{}, fmt.Errorf("replay: %w", err)
+	}
+	contentType, err := replayContentType(rr.Protocol, rr.ContentType)
+	if err != nil {
+		return Event{}, fmt.Errorf("replay: %w", err)
+	}
 
-	// Wrap body in gRPC length-prefixed frame.
-	frame := make([]byte, 5+len(body))
-	frame[0] = 0                                              // no compression
-	binary.BigEndian.PutUint32(frame[1:5], uint32(len(body))) //nolint:gosec // body is bounded by MaxCaptureSize (64KB)
-	copy(frame[5:], body)
+	target := rp.upstream
+	if upstream != "" {
+		u, ok := rp.replayUpstreams[upstream]
+		if !ok {
+			return Event{}, fmt.Errorf("replay: %w: %q", ErrUnknownUpstream, upstream)
+		}
+		target = u
+	}
 
-	upstreamURL := *rp.upstream
-	upstreamURL.Path = method
+	wire := encodeReplayBody(rr.Protocol, contentType, body)
+
+	upstreamURL := *target
+	upstreamURL.Path = path
 
-	reqBody := io.NopCloser(bytes.NewReader(frame))
+	if rp.replayTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rp.replayTimeout)
+		defer cancel()
+	}
+
+	reqBody := io.NopCloser(bytes.NewReader(wire))
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, upstreamURL.String(), reqBody)
 	if err != nil {
 		return Event{}, fmt.Errorf("replay: build request: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/grpc")
-	req.Header.Set("TE", "trailers")
+	// The method only ever changes the path; refuse anything that would
+	// send the request to a different host.
+	if req.URL.Scheme != target.Scheme || req.URL.Host != target.Host {
+		return Event{}, fmt.Errorf("replay: %w %q: target %s is not the upstream", ErrInvalidMethod, method, req.URL.Host)
+	}
+	if target == rp.upstream && rp.upstreamAuthority != "" {
+		req.Host = rp.upstreamAuthority
+	}
+	setReplayHeaders(req.Header, rr.Protocol, contentType)
+	if rp.replayTimeout > 0 {
+		setReplayTimeout(req.Header, rr.Protocol, rp.replayTimeout)
+	}
+	// Like ServeHTTP, record the call's own headers, not the injected ones.
+	// Injected headers may carry credentials for the upstream, so they are
+	// not sent to the replay upstreams.
+	reqHeaders := req.Header.Clone()
+	if target == rp.upstream {
+		setHeaders(req.Header, rp.headers)
+	}
 
 	resp, err := rp.transport.RoundTrip(req)
 	if err != nil {
@@ -121,27 +251,45 @@ func (rp *ReverseProxy) Replay(ctx context.Context, method string, body []byte)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	respData, err := io.ReadAll(resp.Body)
+	// RoundTrip never follows redirects; make sure a redirect doesn't pass
+	// as a response either.
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		return Event{}, fmt.Errorf("replay: %w (%d) to %q", ErrRedirect, resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	respRead := NewCaptureReader(resp.Body, 0) // only times the first byte
+	respData, err := io.ReadAll(respRead)
 	if err != nil {
 		return Event{}, fmt.Errorf("replay: read response: %w", err)
 	}
 
-	status, errMsg := extractGRPCStatus(resp)
-	respPayload := ExtractPayload(respData)
+	r := decodeReplayResponse(rr.Protocol, resp, respData, rp.decodeLimit())
 
 	ev := Event{
-		ID:              uuid.New().String(),
-		Method:          method,
-		CallType:        Unary,
-		Protocol:        ProtocolGRPC,
-		StartTime:       start,
-		Duration:        time.Since(start),
-		Status:          status,
-		Error:           errMsg,
-		RequestHeaders:  req.Header.Clone(),
-		ResponseHeaders: resp.Header.Clone(),
-		RequestBody:     body,
-		ResponseBody:    respPayload,
+		ID:               uuid.New().String(),
+		Method:           method,
+		CallType:         Unary,
+		Protocol:         rr.Protocol,
+		StartTime:        start,
+		Duration:         time.Since(start),
+		Status:           r.status,
+		Error:            r.errMsg,
+		RequestHeaders:   reqHeaders,
+		ResponseHeaders:  resp.Header.Clone(),
+		ResponseTrailers: r.trailers,
+		RequestBody:      body,
+		ResponseBody:     r.payload,
+		RequestSize:      int64(len(wire)),
+		ResponseSize:     int64(len(respData)),
+
+		ResponseEncoding:       r.encoding,
+		ResponseCompressedSize: r.compressedSize,
+
+		Upstream:        upstream,
+		Deadline:        rp.replayTimeout,
+		Authority:       req.Host,
+		ContentType:     contentType,
+		TimeToFirstByte: sinceStart(start, respRead.FirstRead()),
 	}
 
 	// Publish to event channel (non-blocking).
@@ -153,39 +301,133 @@ func (rp *ReverseProxy) Replay(ctx context.Context, method string, body []byte)
 	return ev, nil
 }
 
+// decodeLimit returns how many bytes of a compressed message are decoded: the
+// capture limit, falling back to MaxCaptureSize.
+func (rp *ReverseProxy) decodeLimit() int {
+	if rp.maxCaptureSize > 0 {
+		return rp.maxCaptureSize
+	}
+	return MaxCaptureSize
+}
+
+// copyBufPool recycles the buffers used to stream response bodies.
+var copyBufPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 32*1024)
+		return &b
+	},
+}
+
 // ServeHTTP handles each proxied request.
 func (rp *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
+	id := uuid.New().String()
 	protocol := DetectProtocol(r)
-	contentType := r.Header.Get("Content-Type")
 	method := r.URL.Path
+	// gRPC-Web text bodies are base64, so frames can only be counted after
+	// decoding the captured bytes.
+	webText := IsGRPCWebText(r)
+	countFrames := (protocol == ProtocolGRPC || protocol == ProtocolGRPCWeb) && !webText
+
+	// A zero-size capture still counts the bytes on the wire but never
+	// retains any of them.
+	captureSize := rp.maxCaptureSize
+	if rp.noBodyCapture {
+		captureSize = 0
+	}
 
 	// Wrap request body for capture and frame counting.
-	reqCapture := NewCaptureReader(r.Body, MaxCaptureSize)
+	reqCapture := NewCaptureReader(r.Body, captureSize)
 	var reqFrames *FrameCounter
 	body := io.Reader(reqCapture)
-	if protocol == ProtocolGRPC || protocol == ProtocolGRPCWeb {
+	if countFrames {
 		reqFrames = NewFrameCounter(reqCapture)
 		body = reqFrames
 	}
 
+	c := &call{
+		id:         id,
+		method:     method,
+		protocol:   protocol,
+		webText:    webText,
+		start:      start,
+		deadline:   requestDeadline(r.Header),
+		peer:       peerAddr(r, rp.trustForwarded),
+		authority:  r.Host,
+		corrID:     correlationID(r.Header, rp.correlationHeader),
+		noBody:     rp.noBodyCapture,
+		maxDecoded: rp.decodeLimit(),
+		req:        r,
+		reqCapture: reqCapture,
+		reqFrames:  reqFrames,
+	}
+	if rp.streamUpdateInterval > 0 {
+		timer := time.AfterFunc(rp.streamUpdateInterval, func() { rp.announce(c) })
+		defer timer.Stop()
+	}
+
 	// Build upstream request.
 	upstreamURL := *rp.upstream
-	upstreamURL.Path = r.URL.Path
+	upstreamURL.Path = rp.rewritePath(r.URL.Path)
 	upstreamURL.RawQuery = r.URL.RawQuery
 
+	// Unary calls to idempotent methods are buffered so that they can be sent
+	// again if the upstream resets them.
+	var retry *retryBody
+	if rp.idempotent(method) && DetectCallType(protocol, r.Header.Get("Content-Type"), nil, nil) == Unary {
+		retry, body = bufferRetryBody(body, countFrames)
+	}
+
 	outReq, err := http.NewRequestWithContext(r.Context(), r.Method, upstreamURL.String(), io.NopCloser(body))
 	if err != nil {
+		slog.Error("proxy: build upstream request", "method", method, "error", err)
 		http.Error(w, err.Error(), http.StatusBadGateway)
 		return
 	}
 	copyHeaders(outReq.Header, r.Header)
+	setHeaders(outReq.Header, rp.headers)
+	if rp.upstreamAuthority != "" {
+		outReq.Host = rp.upstreamAuthority
+	}
 	// Announce trailers so the upstream response trailers are forwarded.
 	outReq.Trailer = r.Trailer
 
 	resp, err := rp.transport.RoundTrip(outReq)
+	if err != nil && retry != nil && retry.replayable() && isReset(err) && r.Context().Err() == nil {
+		slog.Info("proxy: retrying idempotent call", "method", method, "error", err)
+		retryReq := outReq.Clone(r.Context())
+		retryReq.Body = io.NopCloser(bytes.NewReader(retry.first))
+		resp, err = rp.transport.RoundTrip(retryReq)
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadGateway)
+		slog.Warn("proxy: upstream roundtrip", "method", method, "error", err)
+		code, msg := upstreamError(err)
+		writeUpstreamError(w, r, protocol, code, msg)
+		// Surface the failed call so it is visible alongside successful ones.
+		reqBody := reqCapture.Bytes()
+		if countFrames {
+			reqBody = ExtractPayload(reqBody, r.Header.Get("Grpc-Encoding"), c.maxDecoded)
+		}
+		rp.finish(c, Event{
+			ID:             id,
+			Method:         method,
+			CallType:       Unary,
+			Protocol:       protocol,
+			StartTime:      start,
+			Duration:       time.Since(start),
+			Status:         int32(code),
+			Error:          msg,
+			RequestHeaders: r.Header.Clone(),
+			RequestBody:    reqBody,
+			RequestSize:    reqCapture.Total(),
+
+			BodyCaptureDisabled: rp.noBodyCapture,
+			Deadline:            c.deadline,
+			PeerAddr:            c.peer,
+			Authority:           c.authority,
+			CorrelationID:       c.corrID,
+			ContentType:         r.Header.Get("Content-Type"),
+		})
 		return
 	}
 	defer func() { _ = resp.Body.Close() }()
@@ -199,29 +441,44 @@ func (rp *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(resp.StatusCode)
 
 	// Wrap response body for capture and frame counting.
-	respCapture := NewCaptureReader(resp.Body, MaxCaptureSize)
+	respCapture := NewCaptureReader(resp.Body, captureSize)
 	var respFrames *FrameCounter
 	respBody := io.Reader(respCapture)
-	if protocol == ProtocolGRPC || protocol == ProtocolGRPCWeb {
+	if countFrames {
 		respFrames = NewFrameCounter(respCapture)
 		respBody = respFrames
 	}
 
+	c.mu.Lock()
+	c.resp = resp
+	c.respCapture = respCapture
+	c.respFrames = respFrames
+	c.mu.Unlock()
+
 	// Copy body (streaming).
 	if f, ok := w.(http.Flusher); ok {
-		buf := make([]byte, 32*1024)
+		bufp := copyBufPool.Get().(*[]byte) //nolint:forcetypeassert // pool only holds *[]byte
+		defer copyBufPool.Put(bufp)
+		buf := *bufp
+		lastUpdate := start
 		for {
 			n, readErr := respBody.Read(buf)
 			if n > 0 {
 				_, _ = w.Write(buf[:n])
 				f.Flush()
+				if rp.streamUpdateInterval > 0 && time.Since(lastUpdate) >= rp.streamUpdateInterval {
+					lastUpdate = time.Now()
+					rp.progress(c)
+				}
 			}
 			if readErr != nil {
 				break
 			}
 		}
 	} else {
-		_, _ = io.Copy(w, respBody)
+		bufp := copyBufPool.Get().(*[]byte) //nolint:forcetypeassert // pool only holds *[]byte
+		defer copyBufPool.Put(bufp)
+		_, _ = io.CopyBuffer(w, respBody, *bufp)
 	}
 
 	// Copy trailers.
@@ -231,37 +488,219 @@ func (rp *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Emit event.
-	status, errMsg := ExtractStatus(protocol, resp)
-	capturedReq := reqCapture.Bytes()
-	capturedResp := respCapture.Bytes()
-	if protocol == ProtocolGRPC || protocol == ProtocolGRPCWeb {
-		capturedReq = ExtractPayload(capturedReq)
-		capturedResp = ExtractPayload(capturedResp)
+	rp.finish(c, c.event(PhaseComplete))
+}
+
+// announce publishes the start event of c unless the call has already
+// finished. It runs once the call has outlived the stream update interval.
+func (rp *ReverseProxy) announce(c *call) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.done {
+		return
+	}
+	c.started = true
+	// Interim events are best-effort: never stall the call.
+	select {
+	case rp.events <- c.startEvent():
+	default:
+	}
+}
+
+// progress publishes a snapshot of c if it has been announced and its
+// response has shown itself to be a stream.
+func (rp *ReverseProxy) progress(c *call) {
+	c.mu.Lock()
+	started := c.started
+	c.mu.Unlock()
+	if !started || !c.streaming() {
+		return
+	}
+	select {
+	case rp.events <- c.event(PhaseProgress):
+	default:
+	}
+}
+
+// finish publishes the final event of c. Marking the call done first
+// guarantees no start event can follow it.
+func (rp *ReverseProxy) finish(c *call, ev Event) {
+	c.mu.Lock()
+	c.done = true
+	c.mu.Unlock()
+	rp.events <- ev
+}
+
+// call holds the capture state of one proxied request, from which its start,
+// progress and final events are built.
+type call struct {
+	id         string
+	method     string
+	protocol   Protocol
+	webText    bool
+	start      time.Time
+	deadline   time.Duration
+	peer       string
+	authority  string
+	corrID     string
+	noBody     bool
+	maxDecoded int // bytes to decompress per message
+	req        *http.Request
+	reqCapture *CaptureReader
+	reqFrames  *FrameCounter
+
+	// mu guards the fields below: the start event is built on a timer
+	// goroutine while the response is being set up.
+	mu          sync.Mutex
+	resp        *http.Response
+	respCapture *CaptureReader
+	respFrames  *FrameCounter
+	started     bool // the start event has been published
+	done        bool // the final event is being published
+}
+
+// startEvent builds the start event of c. It must be called with c.mu held.
+func (c *call) startEvent() Event {
+	ev := Event{
+		ID:             c.id,
+		Phase:          PhaseStart,
+		Method:         c.method,
+		CallType:       DetectCallType(c.protocol, c.req.Header.Get("Content-Type"), nil, nil),
+		Protocol:       c.protocol,
+		StartTime:      c.start,
+		Duration:       time.Since(c.start),
+		RequestHeaders: c.req.Header,
+		Deadline:       c.deadline,
+		PeerAddr:       c.peer,
+		Authority:      c.authority,
+		CorrelationID:  c.corrID,
+		ContentType:    c.req.Header.Get("Content-Type"),
+	}
+	if c.resp != nil {
+		ev.ResponseHeaders = c.resp.Header
+	}
+	return ev
+}
+
+// streaming reports whether the response has shown itself to be a stream.
+func (c *call) streaming() bool {
+	return DetectCallType(c.protocol, c.req.Header.Get("Content-Type"), nil, c.respFrames) != Unary
+}
+
+// event builds an event from what has been captured so far. It must be called
+// from the goroutine copying the response body, after the response is set.
+func (c *call) event(phase Phase) Event {
+	capturedReq := c.reqCapture.Bytes()
+	capturedResp := c.respCapture.Bytes()
+	truncated := !c.noBody &&
+		(int64(len(capturedReq)) < c.reqCapture.Total() || int64(len(capturedResp)) < c.respCapture.Total())
+	reqFrames, respFrames := c.reqFrames, c.respFrames
+
+	var status int32
+	var errMsg string
+	var trailers http.Header
+	if phase == PhaseComplete {
+		status, errMsg = ExtractStatus(c.protocol, c.resp)
+		trailers = c.resp.Trailer.Clone()
+	} else {
+		// The transport may still be reading the request body, so its frame
+		// count is not safe to look at until the call is done.
+		reqFrames = nil
+	}
+
+	if c.webText {
+		capturedReq = DecodeGRPCWebText(capturedReq)
+		capturedResp = DecodeGRPCWebText(capturedResp)
+		reqFrames = countCapturedFrames(capturedReq)
+		respFrames = countCapturedFrames(capturedResp)
+	}
+	if c.protocol == ProtocolGRPCWeb {
+		// gRPC-Web carries trailers in-band as the last body frame.
+		var webTrailers http.Header
+		capturedResp, webTrailers = SplitGRPCWebTrailers(capturedResp)
+		if webTrailers != nil {
+			trailers = webTrailers
+			if code, msg, ok := statusFromHeader(webTrailers); ok {
+				status, errMsg = code, msg
+			}
+		}
+	}
+	callType := DetectCallType(c.protocol, c.req.Header.Get("Content-Type"), reqFrames, respFrames)
+	var reqMessages, respMessages []Frame
+	if callType == BidiStream && phase == PhaseComplete && !c.webText && c.reqFrames != nil {
+		reqMessages = timedFrames(capturedReq, c.req.Header.Get("Grpc-Encoding"), c.maxDecoded, c.reqFrames.times, c.start)
+		respMessages = timedFrames(capturedResp, c.resp.Header.Get("Grpc-Encoding"), c.maxDecoded, c.respFrames.times, c.start)
+	}
+	var reqEncoding, respEncoding string
+	var reqCompressed, respCompressed int64
+	if c.protocol == ProtocolGRPC || c.protocol == ProtocolGRPCWeb {
+		reqEncoding, reqCompressed = FrameCompression(capturedReq, c.req.Header.Get("Grpc-Encoding"))
+		respEncoding, respCompressed = FrameCompression(capturedResp, c.resp.Header.Get("Grpc-Encoding"))
+		capturedReq = ExtractPayload(capturedReq, c.req.Header.Get("Grpc-Encoding"), c.maxDecoded)
+		capturedResp = ExtractPayload(capturedResp, c.resp.Header.Get("Grpc-Encoding"), c.maxDecoded)
 	} else {
-		capturedReq = DecompressGzip(capturedReq)
-		capturedResp = DecompressGzip(capturedResp)
-	}
-
-	rp.events <- Event{
-		ID:              uuid.New().String(),
-		Method:          method,
-		CallType:        DetectCallType(protocol, contentType, reqFrames, respFrames),
-		Protocol:        protocol,
-		StartTime:       start,
-		Duration:        time.Since(start),
-		Status:          status,
-		Error:           errMsg,
-		RequestHeaders:  r.Header.Clone(),
-		ResponseHeaders: resp.Header.Clone(),
-		RequestBody:     capturedReq,
-		ResponseBody:    capturedResp,
+		capturedReq = DecompressGzip(capturedReq, c.maxDecoded)
+		capturedResp = DecompressGzip(capturedResp, c.maxDecoded)
+	}
+	var rate float64
+	if (callType == ServerStream || callType == BidiStream) && c.respFrames != nil {
+		rate = c.respFrames.Rate()
+	}
+
+	return Event{
+		ID:        c.id,
+		Phase:     phase,
+		Method:    c.method,
+		CallType:  callType,
+		Protocol:  c.protocol,
+		StartTime: c.start,
+		Duration:  time.Since(c.start),
+		Status:    status,
+		Error:     errMsg,
+		// Neither header map is modified after the response headers are
+		// copied, so the event can share them instead of cloning.
+		RequestHeaders:   c.req.Header,
+		ResponseHeaders:  c.resp.Header,
+		ResponseTrailers: trailers,
+		RequestBody:      capturedReq,
+		ResponseBody:     capturedResp,
+		RequestSize:      c.reqCapture.Total(),
+		ResponseSize:     c.respCapture.Total(),
+
+		RequestEncoding:        reqEncoding,
+		ResponseEncoding:       respEncoding,
+		RequestCompressedSize:  reqCompressed,
+		ResponseCompressedSize: respCompressed,
+
+		BodyCaptureDisabled: c.noBody,
+		BodyTruncated:       truncated,
+		Deadline:            c.deadline,
+		PeerAddr:            c.peer,
+		Authority:           c.authority,
+		CorrelationID:       c.corrID,
+		ContentType:         c.req.Header.Get("Content-Type"),
+		TimeToFirstByte:     sinceStart(c.start, c.respCapture.FirstRead()),
+		ResponseMessageRate: rate,
+		RequestFrames:       reqMessages,
+		ResponseFrames:      respMessages,
+	}
+}
+
+// sinceStart returns how long after start t was, or 0 when t is zero.
+func sinceStart(start, t time.Time) time.Duration {
+	if t.IsZero() {
+		return 0
 	}
+	return t.Sub(start)
 }
 
 // DetectProtocol determines the wire protocol from the Content-Type header.
 func DetectProtocol(r *http.Request) Protocol {
-	ct := r.Header.Get("Content-Type")
+	return protocolOf(r.Header.Get("Content-Type"))
+}
+
+// protocolOf returns the wire protocol a Content-Type belongs to.
+func protocolOf(ct string) Protocol {
 	switch {
 	case strings.HasPrefix(ct, "application/grpc-web"):
 		return ProtocolGRPCWeb
@@ -272,6 +711,12 @@ func DetectProtocol(r *http.Request) Protocol {
 	}
 }
 
+// IsGRPCWebText reports whether r uses the base64 text variant of gRPC-Web
+// (application/grpc-web-text), whose bodies must be decoded before framing.
+func IsGRPCWebText(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc-web-text")
+}
+
 // ExtractStatus extracts the gRPC status code from the response
 // based on the wire protocol.
 func ExtractStatus(p Protocol, resp *http.Response) (int32, string) {
@@ -288,18 +733,26 @@ func ExtractStatus(p Protocol, resp *http.Response) (int32, string) {
 // extractGRPCStatus reads grpc-status from response trailers or headers.
 func extractGRPCStatus(resp *http.Response) (int32, string) {
 	// Trailers (populated after body is fully read).
-	if s := resp.Trailer.Get("Grpc-Status"); s != "" {
-		code, _ := strconv.ParseInt(s, 10, 32)
-		return int32(code), resp.Trailer.Get("Grpc-Message")
+	if code, msg, ok := statusFromHeader(resp.Trailer); ok {
+		return code, msg
 	}
 	// Some implementations send grpc-status in headers (e.g. immediate errors).
-	if s := resp.Header.Get("Grpc-Status"); s != "" {
-		code, _ := strconv.ParseInt(s, 10, 32)
-		return int32(code), resp.Header.Get("Grpc-Message")
+	if code, msg, ok := statusFromHeader(resp.Header); ok {
+		return code, msg
 	}
 	return 0, ""
 }
 
+// statusFromHeader reads grpc-status and grpc-message from h.
+func statusFromHeader(h http.Header) (int32, string, bool) {
+	s := h.Get("Grpc-Status")
+	if s == "" {
+		return 0, "", false
+	}
+	code, _ := strconv.ParseInt(s, 10, 32)
+	return int32(code), h.Get("Grpc-Message"), true
+}
+
 // extractConnectStatus maps HTTP status to a gRPC-compatible status code.
 // Connect uses HTTP status codes; 200 = OK, others map to gRPC codes.
 func extractConnectStatus(resp *http.Response) (int32, string) {
@@ -337,3 +790,25 @@ func copyHeaders(dst, src http.Header) {
 		}
 	}
 }
+
+// setHeaders replaces the headers of dst that are in src.
+func setHeaders(dst, src http.Header) {
+	for k, vs := range src {
+		dst[k] = slices.Clone(vs)
+	}
+}
+
+// validateHeaders checks the WithHeader headers.
+func validateHeaders(h http.Header) error {
+	for k, vs := range h {
+		if !httpguts.ValidHeaderFieldName(k) {
+			return fmt.Errorf("proxy: invalid header name %q", k)
+		}
+		for _, v := range vs {
+			if !httpguts.ValidHeaderFieldValue(v) {
+				return fmt.Errorf("proxy: invalid value for header %s", k)
+			}
+		}
+	}
+	return nil
+}
@@ -1,11 +1,27 @@
 package proxy_test
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"connectrpc.com/connect"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 
 	"github.com/mickamy/grpc-tap/proxy"
 )
@@ -41,6 +57,35 @@ func TestDetectProtocol(t *testing.T) {
 	}
 }
 
+func TestIsGRPCWebText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{"application/grpc-web-text", true},
+		{"application/grpc-web-text+proto", true},
+		{"application/grpc-web", false},
+		{"application/grpc-web+proto", false},
+		{"application/grpc", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			t.Parallel()
+			r, _ := http.NewRequest(http.MethodPost, "/test.Service/Method", nil) //nolint:noctx // test code
+			r.Header.Set("Content-Type", tt.contentType)
+			if got := proxy.IsGRPCWebText(r); got != tt.want {
+				t.Errorf("IsGRPCWebText(%q) = %v, want %v", tt.contentType, got, tt.want)
+			}
+			if got := proxy.DetectProtocol(r); tt.want && got != proxy.ProtocolGRPCWeb {
+				t.Errorf("DetectProtocol(%q) = %v, want gRPC-Web", tt.contentType, got)
+			}
+		})
+	}
+}
+
 func TestExtractStatus_Connect(t *testing.T) {
 	t.Parallel()
 
@@ -137,3 +182,801 @@ func TestExtractStatus_GRPC(t *testing.T) {
 		}
 	})
 }
+
+func gzipFrame(t testing.TB, payload []byte) []byte {
+	t.Helper()
+
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	_, _ = w.Write(payload)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buildCompressedFrame(compressed.Bytes())
+}
+
+// newUpstream starts an h2c server that answers every call with body.
+func newUpstream(t testing.TB, header http.Header, body []byte) *httptest.Server {
+	t.Helper()
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		for k, vs := range header {
+			w.Header()[k] = vs
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		_, _ = w.Write(body)
+		w.Header().Set("Grpc-Status", "0")
+	})
+	srv := httptest.NewServer(h2c.NewHandler(h, &http2.Server{}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func serveOnce(t *testing.T, rp *proxy.ReverseProxy, req *http.Request) proxy.Event {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	rp.ServeHTTP(rec, req)
+
+	select {
+	case ev := <-rp.Events():
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event emitted")
+		return proxy.Event{}
+	}
+}
+
+func TestServeHTTP_Compression(t *testing.T) {
+	t.Parallel()
+
+	respPayload := bytes.Repeat([]byte("response "), 100)
+	upstream := newUpstream(t, http.Header{"Grpc-Encoding": {"gzip"}}, gzipFrame(t, respPayload))
+
+	rp, err := proxy.New(":0", upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reqPayload := []byte("plain request")
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/test.Service/Method",
+		bytes.NewReader(buildGRPCFrame(reqPayload)))
+	req.Header.Set("Content-Type", "application/grpc")
+
+	ev := serveOnce(t, rp, req)
+
+	if ev.RequestEncoding != "" || ev.RequestCompressedSize != 0 {
+		t.Errorf("request encoding = %q (%d), want uncompressed", ev.RequestEncoding, ev.RequestCompressedSize)
+	}
+	if !bytes.Equal(ev.RequestBody, reqPayload) {
+		t.Errorf("request body = %q, want %q", ev.RequestBody, reqPayload)
+	}
+
+	if ev.ResponseEncoding != "gzip" {
+		t.Errorf("response encoding = %q, want gzip", ev.ResponseEncoding)
+	}
+	if want := ev.ResponseSize - 5; ev.ResponseCompressedSize != want {
+		t.Errorf("response compressed size = %d, want %d", ev.ResponseCompressedSize, want)
+	}
+	if !bytes.Equal(ev.ResponseBody, respPayload) {
+		t.Errorf("response body not decompressed: got %d bytes, want %d", len(ev.ResponseBody), len(respPayload))
+	}
+	if ev.ResponseCompressedSize >= int64(len(ev.ResponseBody)) {
+		t.Errorf("compressed size %d not smaller than decompressed %d", ev.ResponseCompressedSize, len(ev.ResponseBody))
+	}
+}
+
+func TestServeHTTP_HTTP1Upstream(t *testing.T) {
+	t.Parallel()
+
+	upstreamProto := make(chan string, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamProto <- r.Proto
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"echo":%s}`, body)
+	}))
+	t.Cleanup(upstream.Close)
+
+	rp, err := proxy.New(":0", upstream.URL, proxy.WithUpstreamHTTP1())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/v1/echo", bytes.NewReader([]byte(`"hi"`)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	rp.ServeHTTP(rec, req)
+	ev := <-rp.Events()
+
+	if proto := <-upstreamProto; proto != "HTTP/1.1" {
+		t.Errorf("upstream proto = %q, want HTTP/1.1", proto)
+	}
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"echo":"hi"}` {
+		t.Errorf("response = %d %q, want 200 {\"echo\":\"hi\"}", rec.Code, rec.Body.String())
+	}
+	if ev.Protocol != proxy.ProtocolConnect || ev.Status != 0 {
+		t.Errorf("event protocol/status = %v/%d, want Connect/0", ev.Protocol, ev.Status)
+	}
+	if string(ev.RequestBody) != `"hi"` || string(ev.ResponseBody) != `{"echo":"hi"}` {
+		t.Errorf("captured bodies = %q / %q", ev.RequestBody, ev.ResponseBody)
+	}
+}
+
+func TestServeHTTP_WithTransport(t *testing.T) {
+	t.Parallel()
+
+	var sent *http.Request
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		sent = r
+		_, _ = io.Copy(io.Discard, r.Body)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/grpc"}, "X-Canned": {"yes"}},
+			Body:       io.NopCloser(bytes.NewReader(buildGRPCFrame([]byte("canned")))),
+			Trailer:    http.Header{"Grpc-Status": {"5"}, "Grpc-Message": {"no such user"}},
+		}, nil
+	})
+	rp, err := proxy.New(":0", "http://users.internal:9000", proxy.WithTransport(rt))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/users.v1.UserService/GetUser",
+		bytes.NewReader(buildGRPCFrame([]byte("id=1"))))
+	req.Header.Set("Content-Type", "application/grpc")
+	rec := httptest.NewRecorder()
+	rp.ServeHTTP(rec, req)
+	ev := <-rp.Events()
+
+	if sent == nil || sent.URL.Host != "users.internal:9000" || sent.URL.Path != "/users.v1.UserService/GetUser" {
+		t.Fatalf("transport got %v, want the call to the upstream", sent)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), buildGRPCFrame([]byte("canned"))) {
+		t.Errorf("client got body %q, want the canned response", rec.Body.Bytes())
+	}
+	if ev.Method != "/users.v1.UserService/GetUser" || ev.Protocol != proxy.ProtocolGRPC || ev.CallType != proxy.Unary {
+		t.Errorf("event method/protocol/call type = %s/%v/%v", ev.Method, ev.Protocol, ev.CallType)
+	}
+	if ev.Status != int32(connect.CodeNotFound) || ev.Error != "no such user" {
+		t.Errorf("event status/error = %d/%q, want NotFound/no such user", ev.Status, ev.Error)
+	}
+	if string(ev.RequestBody) != "id=1" || string(ev.ResponseBody) != "canned" {
+		t.Errorf("captured bodies = %q / %q", ev.RequestBody, ev.ResponseBody)
+	}
+	if ev.ResponseHeaders.Get("X-Canned") != "yes" {
+		t.Errorf("response headers = %v, want X-Canned", ev.ResponseHeaders)
+	}
+}
+
+func TestServeHTTP_ConnectTimeout(t *testing.T) {
+	t.Parallel()
+
+	// The dialer never connects; only the connect timeout can end the dial.
+	const timeout = 200 * time.Millisecond
+	hang := func(ctx context.Context, _, _ string) (net.Conn, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	rp, err := proxy.New(":0", "http://upstream.invalid:81",
+		proxy.WithConnectTimeout(timeout), proxy.WithDialer(hang))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/test.Service/Method",
+		bytes.NewReader(buildGRPCFrame([]byte("x"))))
+	req.Header.Set("Content-Type", "application/grpc")
+
+	start := time.Now()
+	ev := serveOnce(t, rp, req)
+	if elapsed := time.Since(start); elapsed > timeout+2*time.Second {
+		t.Errorf("dial took %v, want it aborted around %v", elapsed, timeout)
+	}
+	if ev.Status != int32(connect.CodeUnavailable) || ev.Error == "" {
+		t.Errorf("event status/error = %d/%q, want Unavailable with an error", ev.Status, ev.Error)
+	}
+}
+
+func TestServeHTTP_UpstreamMaxConns(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	conns := 0
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		time.Sleep(10 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/grpc")
+		_, _ = w.Write(buildGRPCFrame([]byte("ok")))
+	})
+	upstream := httptest.NewUnstartedServer(h2c.NewHandler(h, &http2.Server{}))
+	upstream.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			conns++
+			mu.Unlock()
+		}
+	}
+	upstream.Start()
+	t.Cleanup(upstream.Close)
+
+	const maxConns = 2
+	rp, err := proxy.New(":0", upstream.URL, proxy.WithUpstreamMaxConns(maxConns))
+	if err != nil {
+		t.Fatal(err)
+	}
+	drainEvents(rp)
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Go(func() {
+			req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/test.Service/Method",
+				bytes.NewReader(buildGRPCFrame([]byte("x"))))
+			req.Header.Set("Content-Type", "application/grpc")
+			rec := httptest.NewRecorder()
+			rp.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Errorf("status = %d, want 200", rec.Code)
+			}
+		})
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if conns != maxConns {
+		t.Errorf("upstream connections = %d, want %d", conns, maxConns)
+	}
+}
+
+func TestServeHTTP_StreamPhases(t *testing.T) {
+	t.Parallel()
+
+	const frames = 5
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		for i := range frames {
+			_, _ = w.Write(buildGRPCFrame(fmt.Appendf(nil, "message %d", i)))
+			w.(http.Flusher).Flush() //nolint:forcetypeassert // h2c ResponseWriter is a Flusher
+			time.Sleep(30 * time.Millisecond)
+		}
+		w.Header().Set("Grpc-Status", "0")
+	})
+	upstream := httptest.NewServer(h2c.NewHandler(h, &http2.Server{}))
+	t.Cleanup(upstream.Close)
+
+	rp, err := proxy.New(":0", upstream.URL, proxy.WithStreamUpdateInterval(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/test.Service/Watch",
+		bytes.NewReader(buildGRPCFrame([]byte("watch"))))
+	req.Header.Set("Content-Type", "application/grpc")
+	rec := httptest.NewRecorder()
+	rp.ServeHTTP(rec, req)
+
+	var events []proxy.Event
+	for len(events) == 0 || events[len(events)-1].Phase != proxy.PhaseComplete {
+		select {
+		case ev := <-rp.Events():
+			events = append(events, ev)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no final event after %d events", len(events))
+		}
+	}
+
+	final := events[len(events)-1]
+	if first := events[0]; first.Phase != proxy.PhaseStart || first.ID != final.ID || first.Status != 0 {
+		t.Fatalf("first event phase/id/status = %v/%s/%d, want start/%s/0", first.Phase, first.ID, first.Status, final.ID)
+	}
+	progress := events[1 : len(events)-1]
+	if len(progress) == 0 {
+		t.Fatal("no progress events emitted")
+	}
+	var lastSize int64
+	for i, ev := range progress {
+		if ev.Phase != proxy.PhaseProgress || ev.ID != final.ID {
+			t.Errorf("event %d: phase/id = %v/%s, want progress/%s", i, ev.Phase, ev.ID, final.ID)
+		}
+		if ev.ResponseSize < lastSize || ev.ResponseSize > final.ResponseSize {
+			t.Errorf("event %d: response size %d, want in [%d, %d]", i, ev.ResponseSize, lastSize, final.ResponseSize)
+		}
+		lastSize = ev.ResponseSize
+		if string(ev.RequestBody) != "watch" || string(ev.ResponseBody) != "message 0" {
+			t.Errorf("event %d: bodies = %q / %q", i, ev.RequestBody, ev.ResponseBody)
+		}
+	}
+	if final.CallType != proxy.ServerStream || final.Status != 0 {
+		t.Errorf("final call type/status = %v/%d, want ServerStream/0", final.CallType, final.Status)
+	}
+	if want := int64(frames * len(buildGRPCFrame([]byte("message 0")))); final.ResponseSize != want {
+		t.Errorf("final response size = %d, want %d", final.ResponseSize, want)
+	}
+}
+
+// newBidiUpstream returns an upstream answering each request message with
+// its upper-cased bytes as soon as it arrives.
+func newBidiUpstream(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		hdr := make([]byte, 5)
+		for {
+			if _, err := io.ReadFull(r.Body, hdr); err != nil {
+				break
+			}
+			msg := make([]byte, binary.BigEndian.Uint32(hdr[1:5]))
+			if _, err := io.ReadFull(r.Body, msg); err != nil {
+				break
+			}
+			_, _ = w.Write(buildGRPCFrame(bytes.ToUpper(msg)))
+			w.(http.Flusher).Flush() //nolint:forcetypeassert // h2c ResponseWriter is a Flusher
+		}
+		w.Header().Set("Grpc-Status", "0")
+	})
+	upstream := httptest.NewServer(h2c.NewHandler(h, &http2.Server{}))
+	t.Cleanup(upstream.Close)
+	return upstream
+}
+
+func TestServeHTTP_BidiFrames(t *testing.T) {
+	t.Parallel()
+
+	rp, err := proxy.New(":0", newBidiUpstream(t).URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		for _, msg := range []string{"a", "b"} {
+			_, _ = pw.Write(buildGRPCFrame([]byte(msg)))
+			time.Sleep(30 * time.Millisecond)
+		}
+		_ = pw.Close()
+	}()
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/test.Service/Chat", pr)
+	req.Header.Set("Content-Type", "application/grpc")
+	ev := serveOnce(t, rp, req)
+
+	if ev.CallType != proxy.BidiStream {
+		t.Fatalf("call type = %v, want BidiStream", ev.CallType)
+	}
+	bodies := func(frames []proxy.Frame) string {
+		var s []string
+		for _, f := range frames {
+			s = append(s, string(f.Body))
+		}
+		return strings.Join(s, ",")
+	}
+	if got := bodies(ev.RequestFrames); got != "a,b" {
+		t.Errorf("request frames = %s, want a,b", got)
+	}
+	if got := bodies(ev.ResponseFrames); got != "A,B" {
+		t.Fatalf("response frames = %s, want A,B", got)
+	}
+	// Each answer passed between its request and the next one.
+	req0, resp0 := ev.RequestFrames[0].Offset, ev.ResponseFrames[0].Offset
+	req1, resp1 := ev.RequestFrames[1].Offset, ev.ResponseFrames[1].Offset
+	if !(req0 < resp0 && resp0 < req1 && req1 < resp1 && resp1 <= ev.Duration) {
+		t.Errorf("offsets req/resp = %v/%v, %v/%v, want interleaved within %v", req0, resp0, req1, resp1, ev.Duration)
+	}
+}
+
+func TestServeHTTP_BidiFramesShareDecodeBudget(t *testing.T) {
+	t.Parallel()
+
+	const limit = 1000
+	rp, err := proxy.New(":0", newBidiUpstream(t).URL, proxy.WithMaxCaptureSize(limit))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, _ = zw.Write(make([]byte, 600))
+	_ = zw.Close()
+	frame := buildCompressedFrame(compressed.Bytes())
+
+	pr, pw := io.Pipe()
+	go func() {
+		for range 3 {
+			_, _ = pw.Write(frame)
+			time.Sleep(10 * time.Millisecond)
+		}
+		_ = pw.Close()
+	}()
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/test.Service/Chat", pr)
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("Grpc-Encoding", "gzip")
+	ev := serveOnce(t, rp, req)
+
+	var sizes []int
+	for _, f := range ev.RequestFrames {
+		sizes = append(sizes, len(f.Body))
+	}
+	// 600 bytes, the 400 left of the budget, then the message as captured.
+	if want := []int{600, 400, compressed.Len()}; !slices.Equal(sizes, want) {
+		t.Errorf("request frame sizes = %v, want %v", sizes, want)
+	}
+}
+
+func TestServeHTTP_NoStartEventForShortCalls(t *testing.T) {
+	t.Parallel()
+
+	upstream := newUpstream(t, nil, buildGRPCFrame([]byte("ok")))
+	rp, err := proxy.New(":0", upstream.URL, proxy.WithStreamUpdateInterval(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/test.Service/Method",
+		bytes.NewReader(buildGRPCFrame([]byte("x"))))
+	req.Header.Set("Content-Type", "application/grpc")
+	if ev := serveOnce(t, rp, req); ev.Phase != proxy.PhaseComplete {
+		t.Errorf("phase = %v, want complete", ev.Phase)
+	}
+	select {
+	case ev := <-rp.Events():
+		t.Errorf("unexpected extra event with phase %v", ev.Phase)
+	default:
+	}
+}
+
+func TestServeHTTP_NoBodyCapture(t *testing.T) {
+	t.Parallel()
+
+	respBody := append(buildGRPCFrame([]byte("first")), buildGRPCFrame([]byte("second"))...)
+	upstream := newUpstream(t, nil, respBody)
+	rp, err := proxy.New(":0", upstream.URL, proxy.WithoutBodyCapture())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reqBody := buildGRPCFrame([]byte("secret request"))
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/test.Service/Watch", bytes.NewReader(reqBody))
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("X-Request-Id", "abc")
+	rec := httptest.NewRecorder()
+	rp.ServeHTTP(rec, req)
+	ev := <-rp.Events()
+
+	if !bytes.Equal(rec.Body.Bytes(), respBody) {
+		t.Errorf("client got %q, want the upstream body passed through", rec.Body.Bytes())
+	}
+	if len(ev.RequestBody) != 0 || len(ev.ResponseBody) != 0 || !ev.BodyCaptureDisabled {
+		t.Errorf("bodies = %q / %q (disabled=%v), want empty and disabled", ev.RequestBody, ev.ResponseBody, ev.BodyCaptureDisabled)
+	}
+	if ev.Status != 0 || ev.Duration <= 0 || ev.StartTime.IsZero() {
+		t.Errorf("status/duration/start = %d/%v/%v, want OK with timing", ev.Status, ev.Duration, ev.StartTime)
+	}
+	if ev.RequestHeaders.Get("X-Request-Id") != "abc" {
+		t.Errorf("request headers = %v, want X-Request-Id", ev.RequestHeaders)
+	}
+	if ev.RequestSize != int64(len(reqBody)) || ev.ResponseSize != int64(len(respBody)) {
+		t.Errorf("sizes = %d/%d, want %d/%d", ev.RequestSize, ev.ResponseSize, len(reqBody), len(respBody))
+	}
+	if ev.CallType != proxy.ServerStream {
+		t.Errorf("call type = %v, want ServerStream", ev.CallType)
+	}
+}
+
+func TestReplay_Upstream(t *testing.T) {
+	t.Parallel()
+
+	proxied := newUpstream(t, nil, buildGRPCFrame([]byte("prod")))
+	staging := newUpstream(t, nil, buildGRPCFrame([]byte("staging")))
+
+	rp, err := proxy.New(":0", proxied.URL, proxy.WithReplayUpstream("staging", staging.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("proxied upstream by default", func(t *testing.T) {
+		t.Parallel()
+		ev, err := rp.Replay(t.Context(), proxy.ReplayRequest{Method: "/test.Service/Method", Body: []byte("req")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(ev.ResponseBody) != "prod" || ev.Upstream != "" {
+			t.Errorf("response/upstream = %q/%q, want prod/\"\"", ev.ResponseBody, ev.Upstream)
+		}
+	})
+
+	t.Run("allow-listed upstream", func(t *testing.T) {
+		t.Parallel()
+		ev, err := rp.Replay(t.Context(), proxy.ReplayRequest{Method: "/test.Service/Method", Body: []byte("req"), Upstream: "staging"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(ev.ResponseBody) != "staging" || ev.Upstream != "staging" {
+			t.Errorf("response/upstream = %q/%q, want staging/staging", ev.ResponseBody, ev.Upstream)
+		}
+	})
+
+	t.Run("unknown upstream", func(t *testing.T) {
+		t.Parallel()
+		_, err := rp.Replay(t.Context(), proxy.ReplayRequest{Method: "/test.Service/Method", Body: []byte("req"), Upstream: staging.URL})
+		if !errors.Is(err, proxy.ErrUnknownUpstream) {
+			t.Errorf("err = %v, want ErrUnknownUpstream", err)
+		}
+	})
+}
+
+func TestReplay_TLSUpstream(t *testing.T) {
+	t.Parallel()
+
+	staging := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Error("upstream reached without verifying its certificate")
+	}))
+	staging.EnableHTTP2 = true
+	staging.Config.ErrorLog = log.New(io.Discard, "", 0) // the failed handshake is expected
+	staging.StartTLS()
+	t.Cleanup(staging.Close)
+
+	rp, err := proxy.New(":0", "http://localhost:9000", proxy.WithReplayUpstream("staging", staging.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The test server's certificate is self-signed: failing to verify it
+	// shows that TLS was negotiated rather than cleartext HTTP/2.
+	_, err = rp.Replay(t.Context(), proxy.ReplayRequest{Method: "/test.Service/Method", Body: []byte("req"), Upstream: "staging"})
+	var certErr *tls.CertificateVerificationError
+	if !errors.As(err, &certErr) {
+		t.Errorf("err = %v, want a certificate verification error", err)
+	}
+}
+
+func TestNew_InvalidReplayUpstream(t *testing.T) {
+	t.Parallel()
+
+	for _, addr := range []string{"staging:9000", "ftp://staging", "http://", "://bad"} {
+		t.Run(addr, func(t *testing.T) {
+			t.Parallel()
+			if _, err := proxy.New(":0", "http://localhost:9000", proxy.WithReplayUpstream("staging", addr)); err == nil {
+				t.Errorf("New accepted replay upstream %q", addr)
+			}
+		})
+	}
+}
+
+func TestReplay_RejectsMaliciousMethods(t *testing.T) {
+	t.Parallel()
+
+	hit := make(chan string, 16)
+	upstream := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		hit <- r.URL.String()
+	}))
+	t.Cleanup(upstream.Close)
+
+	rp, err := proxy.New(":0", upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, method := range []string{
+		"",
+		"test.Service/Method",
+		"/../admin",
+		"/test.Service/../../admin",
+		"http://169.254.169.254/latest/meta-data",
+		"//169.254.169.254/latest",
+		"/test.Service/Method?x=1",
+		"/test.Service/Method#frag",
+		"/%2e%2e/admin",
+		"/\\evil.example.com/x",
+	} {
+		t.Run(method, func(t *testing.T) {
+			t.Parallel()
+			_, err := rp.Replay(t.Context(), proxy.ReplayRequest{Method: method})
+			if !errors.Is(err, proxy.ErrInvalidMethod) {
+				t.Errorf("Replay(%q) err = %v, want ErrInvalidMethod", method, err)
+			}
+		})
+	}
+
+	select {
+	case u := <-hit:
+		t.Errorf("upstream received %q", u)
+	default:
+	}
+}
+
+func TestReplay_DoesNotFollowRedirects(t *testing.T) {
+	t.Parallel()
+
+	followed := make(chan struct{}, 1)
+	target := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		followed <- struct{}{}
+	}))
+	t.Cleanup(target.Close)
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL+"/latest/meta-data", http.StatusTemporaryRedirect)
+	})
+	upstream := httptest.NewServer(h2c.NewHandler(h, &http2.Server{}))
+	t.Cleanup(upstream.Close)
+
+	rp, err := proxy.New(":0", upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = rp.Replay(t.Context(), proxy.ReplayRequest{Method: "/test.Service/Method", Body: []byte("req")})
+	if !errors.Is(err, proxy.ErrRedirect) {
+		t.Errorf("err = %v, want ErrRedirect", err)
+	}
+	select {
+	case <-followed:
+		t.Error("redirect was followed")
+	default:
+	}
+}
+
+func TestServeHTTP_PropagatesTraceContext(t *testing.T) {
+	t.Parallel()
+
+	trace := http.Header{
+		"Traceparent":    {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		"Tracestate":     {"vendor=value"},
+		"Grpc-Trace-Bin": {"AABL+S81d7NNpqPOkp0ODkc2AQDwZ6oLqQK3AgE"},
+	}
+	sent := make(chan http.Header, 1)
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		sent <- r.Header.Clone()
+		_, _ = io.Copy(io.Discard, r.Body)
+		return okResponse("ok"), nil
+	})
+	rp, err := proxy.New(":0", "http://upstream.invalid", proxy.WithTransport(rt))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/test.Service/Method",
+		bytes.NewReader(buildGRPCFrame([]byte("req"))))
+	req.Header = trace.Clone()
+	req.Header.Set("Content-Type", "application/grpc")
+	serveOnce(t, rp, req)
+
+	h := <-sent
+	for k, vs := range trace {
+		if got := h.Get(k); got != vs[0] {
+			t.Errorf("upstream %s = %q, want %q", k, got, vs[0])
+		}
+	}
+}
+
+func TestServeHTTP_Authority(t *testing.T) {
+	t.Parallel()
+
+	upstream := newUpstream(t, nil, buildGRPCFrame([]byte("ok")))
+
+	t.Run("h2c", func(t *testing.T) {
+		t.Parallel()
+		rp, err := proxy.New(":0", upstream.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv := httptest.NewServer(h2c.NewHandler(rp, &http2.Server{}))
+		t.Cleanup(srv.Close)
+
+		client := &http.Client{Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			},
+		}}
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, srv.URL+"/test.Service/Method",
+			bytes.NewReader(buildGRPCFrame([]byte("req"))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Host = "users.internal:443" // sent as :authority
+		req.Header.Set("Content-Type", "application/grpc")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		if resp.ProtoMajor != 2 {
+			t.Fatalf("proto = %s, want HTTP/2", resp.Proto)
+		}
+
+		select {
+		case ev := <-rp.Events():
+			if ev.Authority != "users.internal:443" {
+				t.Errorf("authority = %q, want users.internal:443", ev.Authority)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event emitted")
+		}
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		t.Parallel()
+		rp, err := proxy.New(":0", upstream.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ev, err := rp.Replay(t.Context(), proxy.ReplayRequest{Method: "/test.Service/Method", Body: []byte("req")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := strings.TrimPrefix(upstream.URL, "http://"); ev.Authority != want {
+			t.Errorf("authority = %q, want the upstream %q", ev.Authority, want)
+		}
+	})
+}
+
+func TestServeHTTP_ContentType(t *testing.T) {
+	t.Parallel()
+
+	upstream := newUpstream(t, nil, []byte(`{"ok":true}`))
+	rp, err := proxy.New(":0", upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/test.Service/Method",
+		strings.NewReader(`{"name":"x"}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	ev := serveOnce(t, rp, req)
+	if ev.Protocol != proxy.ProtocolConnect {
+		t.Errorf("protocol = %v, want Connect", ev.Protocol)
+	}
+	if ev.ContentType != "application/json; charset=utf-8" {
+		t.Errorf("content type = %q, want that of the request", ev.ContentType)
+	}
+}
+
+func TestServeHTTP_TimeToFirstByte(t *testing.T) {
+	t.Parallel()
+
+	const delay = 100 * time.Millisecond
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		// The first message arrives at once, the second one after delay.
+		_, _ = w.Write(buildGRPCFrame([]byte("first")))
+		w.(http.Flusher).Flush() //nolint:forcetypeassert // http2 writers flush
+		time.Sleep(delay)
+		_, _ = w.Write(buildGRPCFrame([]byte("second")))
+		w.Header().Set("Grpc-Status", "0")
+	})
+	upstream := httptest.NewServer(h2c.NewHandler(h, &http2.Server{}))
+	t.Cleanup(upstream.Close)
+
+	rp, err := proxy.New(":0", upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/test.Service/Method",
+		bytes.NewReader(buildGRPCFrame([]byte("req"))))
+	req.Header.Set("Content-Type", "application/grpc")
+	ev := serveOnce(t, rp, req)
+
+	if ev.TimeToFirstByte <= 0 || ev.TimeToFirstByte >= delay {
+		t.Errorf("TimeToFirstByte = %v, want under the %v the body took", ev.TimeToFirstByte, delay)
+	}
+	if ev.Duration < delay {
+		t.Errorf("Duration = %v, want at least %v", ev.Duration, delay)
+	}
+
+	replayed, err := rp.Replay(t.Context(), proxy.ReplayRequest{Method: "/test.Service/Method", Body: []byte("req")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.TimeToFirstByte <= 0 || replayed.TimeToFirstByte >= replayed.Duration {
+		t.Errorf("replay TimeToFirstByte = %v, want under the total %v", replayed.TimeToFirstByte, replayed.Duration)
+	}
+}
@@ -0,0 +1,37 @@
+package proxy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// pathRewrite replaces the leading from of a request path with to.
+type pathRewrite struct {
+	from, to string
+}
+
+// rewritePath applies the first rewrite whose prefix matches path, as set by
+// WithPathRewrite. A prefix only matches whole path segments, and the result
+// always starts with "/". Paths no rewrite matches are returned unchanged.
+func (rp *ReverseProxy) rewritePath(path string) string {
+	for _, rw := range rp.pathRewrites {
+		rest, ok := strings.CutPrefix(path, rw.from)
+		if !ok || (rest != "" && !strings.HasPrefix(rest, "/") && !strings.HasSuffix(rw.from, "/")) {
+			continue
+		}
+		return strings.TrimSuffix(rw.to, "/") + "/" + strings.TrimPrefix(rest, "/")
+	}
+	return path
+}
+
+func validatePathRewrites(rewrites []pathRewrite) error {
+	for _, rw := range rewrites {
+		if !strings.HasPrefix(rw.from, "/") || rw.from == "/" {
+			return fmt.Errorf("proxy: path rewrite %q: prefix must start with '/' and not be the root", rw.from)
+		}
+		if rw.to != "" && !strings.HasPrefix(rw.to, "/") {
+			return fmt.Errorf("proxy: path rewrite %q: replacement %q must be empty or start with '/'", rw.from, rw.to)
+		}
+	}
+	return nil
+}
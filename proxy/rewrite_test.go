@@ -0,0 +1,102 @@
+package proxy_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+func TestWithPathRewrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		rewrites [][2]string
+		path     string
+		want     string
+	}{
+		{name: "strip prefix", rewrites: [][2]string{{"/internal", ""}}, path: "/internal/pkg.Svc/Method", want: "/pkg.Svc/Method"},
+		{name: "strip prefix with slash", rewrites: [][2]string{{"/internal/", ""}}, path: "/internal/pkg.Svc/Method", want: "/pkg.Svc/Method"},
+		{name: "replace prefix", rewrites: [][2]string{{"/v1", "/api/v2"}}, path: "/v1/pkg.Svc/Method", want: "/api/v2/pkg.Svc/Method"},
+		{name: "whole segments only", rewrites: [][2]string{{"/internal", ""}}, path: "/internalx/pkg.Svc/Method", want: "/internalx/pkg.Svc/Method"},
+		{name: "no match", rewrites: [][2]string{{"/internal", ""}}, path: "/pkg.Svc/Method", want: "/pkg.Svc/Method"},
+		{
+			name:     "first match wins",
+			rewrites: [][2]string{{"/a/b", "/x"}, {"/a", "/y"}},
+			path:     "/a/b/pkg.Svc/Method",
+			want:     "/x/pkg.Svc/Method",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sent := make(chan string, 1)
+			rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				sent <- r.URL.Path
+				_, _ = io.Copy(io.Discard, r.Body)
+				return okResponse("ok"), nil
+			})
+			opts := []proxy.Option{proxy.WithTransport(rt)}
+			for _, rw := range tt.rewrites {
+				opts = append(opts, proxy.WithPathRewrite(rw[0], rw[1]))
+			}
+			rp, err := proxy.New(":0", "http://upstream.invalid", opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, tt.path,
+				bytes.NewReader(buildGRPCFrame([]byte("req"))))
+			req.Header.Set("Content-Type", "application/grpc")
+			ev := serveOnce(t, rp, req)
+			if got := <-sent; got != tt.want {
+				t.Errorf("upstream path = %q, want %q", got, tt.want)
+			}
+			if ev.Method != tt.path {
+				t.Errorf("event method = %q, want the client's path %q", ev.Method, tt.path)
+			}
+		})
+	}
+}
+
+func TestWithPathRewrite_Replay(t *testing.T) {
+	t.Parallel()
+
+	sent := make(chan string, 1)
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		sent <- r.URL.Path
+		_, _ = io.Copy(io.Discard, r.Body)
+		return okResponse("ok"), nil
+	})
+	rp, err := proxy.New(":0", "http://upstream.invalid", proxy.WithTransport(rt),
+		proxy.WithPathRewrite("/internal", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ev, err := rp.Replay(t.Context(), proxy.ReplayRequest{Method: "/internal/pkg.Svc/Method", Body: []byte("req")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := <-sent; got != "/pkg.Svc/Method" {
+		t.Errorf("upstream path = %q, want /pkg.Svc/Method", got)
+	}
+	if ev.Method != "/internal/pkg.Svc/Method" {
+		t.Errorf("event method = %q, want the captured path", ev.Method)
+	}
+}
+
+func TestWithPathRewrite_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, rw := range [][2]string{{"internal", ""}, {"/", ""}, {"/internal", "api"}} {
+		if _, err := proxy.New(":0", "http://upstream.invalid", proxy.WithPathRewrite(rw[0], rw[1])); err == nil {
+			t.Errorf("New accepted rewrite %q=%q", rw[0], rw[1])
+		}
+	}
+}
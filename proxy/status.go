@@ -0,0 +1,43 @@
+package proxy
+
+import "strconv"
+
+// statusNames are the canonical names of the gRPC status codes, indexed by
+// code, as in the gRPC status code spec.
+var statusNames = [...]string{
+	"OK",
+	"Canceled",
+	"Unknown",
+	"InvalidArgument",
+	"DeadlineExceeded",
+	"NotFound",
+	"AlreadyExists",
+	"PermissionDenied",
+	"ResourceExhausted",
+	"FailedPrecondition",
+	"Aborted",
+	"OutOfRange",
+	"Unimplemented",
+	"Internal",
+	"Unavailable",
+	"DataLoss",
+	"Unauthenticated",
+}
+
+// StatusName returns the canonical name of a gRPC status code, e.g.
+// "Unavailable" for 14. Codes outside the spec are named "Code(n)".
+func StatusName(code int32) string {
+	if code >= 0 && int(code) < len(statusNames) {
+		return statusNames[code]
+	}
+	return "Code(" + strconv.Itoa(int(code)) + ")"
+}
+
+// FormatStatus renders a gRPC status code by name, keeping the number for
+// failures: "OK", or e.g. "Internal(13)".
+func FormatStatus(code int32) string {
+	if code <= 0 || int(code) >= len(statusNames) {
+		return StatusName(code)
+	}
+	return statusNames[code] + "(" + strconv.Itoa(int(code)) + ")"
+}
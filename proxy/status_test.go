@@ -0,0 +1,55 @@
+package proxy_test
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+func TestStatusName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		code       int32
+		wantName   string
+		wantFormat string
+	}{
+		{0, "OK", "OK"},
+		{1, "Canceled", "Canceled(1)"},
+		{2, "Unknown", "Unknown(2)"},
+		{3, "InvalidArgument", "InvalidArgument(3)"},
+		{4, "DeadlineExceeded", "DeadlineExceeded(4)"},
+		{5, "NotFound", "NotFound(5)"},
+		{6, "AlreadyExists", "AlreadyExists(6)"},
+		{7, "PermissionDenied", "PermissionDenied(7)"},
+		{8, "ResourceExhausted", "ResourceExhausted(8)"},
+		{9, "FailedPrecondition", "FailedPrecondition(9)"},
+		{10, "Aborted", "Aborted(10)"},
+		{11, "OutOfRange", "OutOfRange(11)"},
+		{12, "Unimplemented", "Unimplemented(12)"},
+		{13, "Internal", "Internal(13)"},
+		{14, "Unavailable", "Unavailable(14)"},
+		{15, "DataLoss", "DataLoss(15)"},
+		{16, "Unauthenticated", "Unauthenticated(16)"},
+		{17, "Code(17)", "Code(17)"},
+		{-1, "Code(-1)", "Code(-1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wantName, func(t *testing.T) {
+			t.Parallel()
+			if got := proxy.StatusName(tt.code); got != tt.wantName {
+				t.Errorf("StatusName(%d) = %q, want %q", tt.code, got, tt.wantName)
+			}
+			if got := proxy.FormatStatus(tt.code); got != tt.wantFormat {
+				t.Errorf("FormatStatus(%d) = %q, want %q", tt.code, got, tt.wantFormat)
+			}
+			if tt.code >= 0 && tt.code <= 16 {
+				if grpcName := codes.Code(tt.code).String(); grpcName != tt.wantName { //nolint:gosec // G115: checked range
+					t.Errorf("grpc names code %d %q, want %q", tt.code, grpcName, tt.wantName)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package proxy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ProtoWireToText converts protobuf wire-format bytes into protobuf text
+// format using field numbers as names, as `protoc --decode_raw` does:
+//
+//	1: "hello"
+//	2 {
+//	  1: 42
+//	}
+//
+// Bytes fields that decode as nested protobuf messages are emitted as
+// blocks; other bytes are emitted as quoted strings. Unlike ProtoWireToJSON,
+// repeated fields keep every value, in wire order.
+func ProtoWireToText(data []byte) ([]byte, error) {
+	var w textWriter
+	if err := w.wire(data); err != nil {
+		return nil, err
+	}
+	return w.bytes(), nil
+}
+
+// DecodeText is Decode rendered as protobuf text format with the field names
+// of the .proto files and enum values by name, as grpcurl and evans accept
+// it: the schema-aware counterpart of ProtoWireToText. Fields the schema does
+// not know are left out.
+func (d *Descriptors) DecodeText(method string, response bool, payload []byte) ([]byte, error) {
+	msg, err := d.Decode(method, response, payload)
+	if err != nil {
+		return nil, err
+	}
+	var w textWriter
+	w.message(msg)
+	return w.bytes(), nil
+}
+
+// textWriter writes protobuf text format, one field per line. prototext is
+// not used as it varies its whitespace from build to build on purpose.
+type textWriter struct {
+	buf    bytes.Buffer
+	indent int
+}
+
+func (w *textWriter) bytes() []byte {
+	return bytes.TrimSuffix(w.buf.Bytes(), []byte("\n"))
+}
+
+// line writes one line at the current indentation.
+func (w *textWriter) line(s string) {
+	w.buf.WriteString(strings.Repeat("  ", w.indent))
+	w.buf.WriteString(s)
+	w.buf.WriteByte('\n')
+}
+
+// block writes `name {`, the lines written by body one level deeper, and `}`.
+func (w *textWriter) block(name string, body func()) {
+	w.line(name + " {")
+	w.indent++
+	body()
+	w.indent--
+	w.line("}")
+}
+
+// wire writes the fields of schema-less wire-format data by number.
+func (w *textWriter) wire(data []byte) error {
+	for len(data) > 0 {
+		num, wtype, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return errors.New("invalid protobuf tag")
+		}
+		data = data[n:]
+		name := strconv.FormatInt(int64(num), 10)
+
+		switch wtype {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return fmt.Errorf("invalid varint for field %d", num)
+			}
+			data = data[n:]
+			w.line(name + ": " + strconv.FormatUint(v, 10))
+		case protowire.Fixed32Type:
+			v, n := protowire.ConsumeFixed32(data)
+			if n < 0 {
+				return fmt.Errorf("invalid fixed32 for field %d", num)
+			}
+			data = data[n:]
+			w.line(name + ": " + strconv.FormatUint(uint64(v), 10))
+		case protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(data)
+			if n < 0 {
+				return fmt.Errorf("invalid fixed64 for field %d", num)
+			}
+			data = data[n:]
+			w.line(name + ": " + strconv.FormatUint(v, 10))
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return fmt.Errorf("invalid bytes for field %d", num)
+			}
+			data = data[n:]
+			// Try nested message, as ProtoWireToJSON does.
+			if nested, err := ProtoWireToText(v); err == nil && len(nested) > 0 {
+				w.block(name, func() {
+					for l := range strings.SplitSeq(string(nested), "\n") {
+						w.line(l)
+					}
+				})
+			} else {
+				w.line(name + ": " + quoteText(v))
+			}
+		default:
+			return fmt.Errorf("unsupported wire type %d for field %d", wtype, num)
+		}
+	}
+	return nil
+}
+
+// message writes the set fields of msg in declaration order.
+func (w *textWriter) message(msg protoreflect.Message) {
+	fields := msg.Descriptor().Fields()
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		if !msg.Has(fd) {
+			continue
+		}
+		name := string(fd.Name())
+		v := msg.Get(fd)
+		switch {
+		case fd.IsMap():
+			w.mapEntries(name, fd, v.Map())
+		case fd.IsList():
+			list := v.List()
+			for j := range list.Len() {
+				w.field(name, fd, list.Get(j))
+			}
+		default:
+			w.field(name, fd, v)
+		}
+	}
+}
+
+// mapEntries writes the entries of map field fd as repeated key/value
+// blocks, sorted by key.
+func (w *textWriter) mapEntries(name string, fd protoreflect.FieldDescriptor, m protoreflect.Map) {
+	keys := make([]protoreflect.MapKey, 0, m.Len())
+	m.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys = append(keys, k)
+		return true
+	})
+	sort.Slice(keys, func(i, j int) bool { return mapKeyLess(keys[i], keys[j]) })
+	for _, k := range keys {
+		w.block(name, func() {
+			w.field("key", fd.MapKey(), k.Value())
+			w.field("value", fd.MapValue(), m.Get(k))
+		})
+	}
+}
+
+// mapKeyLess orders map keys, which are all of one kind, naturally.
+func mapKeyLess(a, b protoreflect.MapKey) bool {
+	switch a.Interface().(type) {
+	case int32, int64:
+		return a.Int() < b.Int()
+	case uint32, uint64:
+		return a.Uint() < b.Uint()
+	case bool:
+		return !a.Bool() && b.Bool()
+	}
+	return a.String() < b.String()
+}
+
+// field writes a single value of field fd.
+func (w *textWriter) field(name string, fd protoreflect.FieldDescriptor, v protoreflect.Value) {
+	if fd.Message() != nil {
+		w.block(name, func() { w.message(v.Message()) })
+		return
+	}
+	w.line(name + ": " + textScalar(fd, v))
+}
+
+// textScalar renders a non-message value of field fd.
+func textScalar(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return quoteText([]byte(v.String()))
+	case protoreflect.BytesKind:
+		return quoteText(v.Bytes())
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return strconv.Itoa(int(v.Enum()))
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		f := v.Float()
+		switch {
+		case math.IsInf(f, 1):
+			return "inf"
+		case math.IsInf(f, -1):
+			return "-inf"
+		case math.IsNaN(f):
+			return "nan"
+		}
+		bits := 64
+		if fd.Kind() == protoreflect.FloatKind {
+			bits = 32
+		}
+		return strconv.FormatFloat(f, 'g', -1, bits)
+	}
+	return v.String()
+}
+
+// quoteText quotes b as a text-format string literal. Valid UTF-8 is kept
+// as is; control characters, and every non-ASCII byte when b is not UTF-8,
+// are escaped in octal.
+func quoteText(b []byte) string {
+	valid := utf8.Valid(b)
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, c := range b {
+		switch {
+		case c == '"' || c == '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case c == '\n':
+			sb.WriteString(`\n`)
+		case c == '\r':
+			sb.WriteString(`\r`)
+		case c == '\t':
+			sb.WriteString(`\t`)
+		case c < 0x20 || c == 0x7f || (c >= 0x80 && !valid):
+			fmt.Fprintf(&sb, `\%03o`, c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
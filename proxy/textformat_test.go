@@ -0,0 +1,129 @@
+package proxy_test
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+func TestProtoWireToText(t *testing.T) {
+	t.Parallel()
+
+	t.Run("scalar fields", func(t *testing.T) {
+		t.Parallel()
+		var wire []byte
+		wire = protowire.AppendTag(wire, 1, protowire.BytesType)
+		wire = protowire.AppendString(wire, "say \"hi\"\n")
+		wire = protowire.AppendTag(wire, 2, protowire.VarintType)
+		wire = protowire.AppendVarint(wire, 42)
+		wire = protowire.AppendTag(wire, 2, protowire.VarintType)
+		wire = protowire.AppendVarint(wire, 43)
+		wire = protowire.AppendTag(wire, 3, protowire.Fixed32Type)
+		wire = protowire.AppendFixed32(wire, 7)
+
+		got, err := proxy.ProtoWireToText(wire)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "1: \"say \\\"hi\\\"\\n\"\n2: 42\n2: 43\n3: 7"
+		if string(got) != want {
+			t.Errorf("got\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("nested message", func(t *testing.T) {
+		t.Parallel()
+		var inner []byte
+		inner = protowire.AppendTag(inner, 1, protowire.VarintType)
+		inner = protowire.AppendVarint(inner, 7)
+		var nested []byte
+		nested = protowire.AppendTag(nested, 2, protowire.BytesType)
+		nested = protowire.AppendBytes(nested, inner)
+		var wire []byte
+		wire = protowire.AppendTag(wire, 1, protowire.BytesType)
+		wire = protowire.AppendBytes(wire, nested)
+		wire = protowire.AppendTag(wire, 2, protowire.BytesType)
+		wire = protowire.AppendBytes(wire, []byte{0xff, 0x00})
+
+		got, err := proxy.ProtoWireToText(wire)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "1 {\n  2 {\n    1: 7\n  }\n}\n2: \"\\377\\000\""
+		if string(got) != want {
+			t.Errorf("got\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("empty and invalid data", func(t *testing.T) {
+		t.Parallel()
+		if got, err := proxy.ProtoWireToText(nil); err != nil || len(got) != 0 {
+			t.Errorf("empty: got %q, %v", got, err)
+		}
+		if _, err := proxy.ProtoWireToText([]byte{0x0a, 0x05, 'a'}); err == nil {
+			t.Error("truncated bytes: no error")
+		}
+	})
+}
+
+func TestDescriptors_DecodeText(t *testing.T) {
+	t.Parallel()
+
+	d, err := proxy.LoadDescriptorSet(writeTapDescriptorSet(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := proto.Marshal(&tapv1.ReplayRequest{Method: "/pkg.Svc/Get", Protocol: tapv1.Protocol_PROTOCOL_CONNECT})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := d.DecodeText("/tap.v1.TapService/Replay", false, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "method: \"/pkg.Svc/Get\"\nprotocol: PROTOCOL_CONNECT"; string(got) != want {
+		t.Errorf("request got\n%s\nwant\n%s", got, want)
+	}
+
+	resp, err := proto.Marshal(&tapv1.ReplayResponse{Event: &tapv1.GRPCEvent{
+		Id:             "1",
+		Duration:       durationpb.New(1500000000),
+		RequestHeaders: map[string]string{"b": "2", "a": "1"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = d.DecodeText("/tap.v1.TapService/Replay", true, resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `event {
+  id: "1"
+  duration {
+    seconds: 1
+    nanos: 500000000
+  }
+  request_headers {
+    key: "a"
+    value: "1"
+  }
+  request_headers {
+    key: "b"
+    value: "2"
+  }
+}`
+	if string(got) != want {
+		t.Errorf("response got\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := d.DecodeText("/other.Svc/Call", false, req); !errors.Is(err, proxy.ErrUnknownMethod) {
+		t.Errorf("unknown method: err = %v, want ErrUnknownMethod", err)
+	}
+}
@@ -0,0 +1,103 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ParseTimeout parses a grpc-timeout header value: at most eight ASCII
+// digits followed by a unit, one of H (hours), M (minutes), S (seconds),
+// m (milliseconds), u (microseconds) or n (nanoseconds), e.g. "100m" or "5S".
+func ParseTimeout(v string) (time.Duration, error) {
+	if len(v) < 2 || len(v) > 9 {
+		return 0, fmt.Errorf("proxy: invalid grpc-timeout %q", v)
+	}
+	digits, unit := v[:len(v)-1], v[len(v)-1]
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("proxy: invalid grpc-timeout %q", v)
+		}
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("proxy: invalid grpc-timeout %q: %w", v, err)
+	}
+
+	var d time.Duration
+	switch unit {
+	case 'H':
+		d = time.Hour
+	case 'M':
+		d = time.Minute
+	case 'S':
+		d = time.Second
+	case 'm':
+		d = time.Millisecond
+	case 'u':
+		d = time.Microsecond
+	case 'n':
+		d = time.Nanosecond
+	default:
+		return 0, fmt.Errorf("proxy: invalid grpc-timeout unit %q", unit)
+	}
+	// 99999999H overflows time.Duration; treat it as no deadline at all.
+	if n > int64(maxDuration/d) {
+		return maxDuration, nil
+	}
+	return time.Duration(n) * d, nil
+}
+
+// FormatTimeout formats d as a grpc-timeout header value, in the finest unit
+// that fits into eight digits. Timeouts are rounded up, so the upstream never
+// sees a shorter one.
+func FormatTimeout(d time.Duration) string {
+	const maxValue = 99999999
+	if d <= 0 {
+		return "0n"
+	}
+	for _, u := range []struct {
+		unit byte
+		d    time.Duration
+	}{
+		{'n', time.Nanosecond}, {'u', time.Microsecond}, {'m', time.Millisecond},
+		{'S', time.Second}, {'M', time.Minute},
+	} {
+		if n := ceilDiv(d, u.d); n <= maxValue {
+			return strconv.FormatInt(n, 10) + string(u.unit)
+		}
+	}
+	return strconv.FormatInt(min(ceilDiv(d, time.Hour), maxValue), 10) + "H"
+}
+
+func ceilDiv(d, unit time.Duration) int64 {
+	n := int64(d / unit)
+	if d%unit != 0 {
+		n++
+	}
+	return n
+}
+
+const maxDuration = time.Duration(1<<63 - 1)
+
+// requestDeadline returns the deadline the client set for the call in h:
+// grpc-timeout for gRPC and gRPC-Web, Connect-Timeout-Ms for Connect. It
+// returns 0 when there is none or it cannot be parsed.
+func requestDeadline(h http.Header) time.Duration {
+	if v := h.Get("Grpc-Timeout"); v != "" {
+		d, err := ParseTimeout(v)
+		if err != nil {
+			return 0
+		}
+		return d
+	}
+	if v := h.Get("Connect-Timeout-Ms"); v != "" {
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || ms <= 0 || ms > int64(maxDuration/time.Millisecond) {
+			return 0
+		}
+		return time.Duration(ms) * time.Millisecond
+	}
+	return 0
+}
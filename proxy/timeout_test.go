@@ -0,0 +1,116 @@
+package proxy_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+func TestParseTimeout(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"100n", 100 * time.Nanosecond},
+		{"250u", 250 * time.Microsecond},
+		{"100m", 100 * time.Millisecond},
+		{"5S", 5 * time.Second},
+		{"2M", 2 * time.Minute},
+		{"1H", time.Hour},
+		{"0m", 0},
+		{"99999999m", 99999999 * time.Millisecond},
+		{"99999999H", time.Duration(1<<63 - 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Parallel()
+			got, err := proxy.ParseTimeout(tt.value)
+			if err != nil {
+				t.Fatalf("ParseTimeout(%q): %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTimeout(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	for _, value := range []string{"", "m", "100", "100s", "100ms", "-1S", "1.5S", "123456789m", " 1S"} {
+		t.Run("invalid "+value, func(t *testing.T) {
+			t.Parallel()
+			if d, err := proxy.ParseTimeout(value); err == nil {
+				t.Errorf("ParseTimeout(%q) = %v, want error", value, d)
+			}
+		})
+	}
+}
+
+func TestFormatTimeout(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0n"},
+		{250 * time.Millisecond, "250000u"},
+		{99 * time.Millisecond, "99000000n"},
+		{5 * time.Second, "5000000u"},
+		{time.Minute, "60000000u"},
+		{time.Hour, "3600000m"},
+		{time.Hour + time.Nanosecond, "3600001m"},
+		{48 * time.Hour, "172800S"},
+		{time.Duration(1<<63 - 1), "2562048H"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.d.String(), func(t *testing.T) {
+			t.Parallel()
+			got := proxy.FormatTimeout(tt.d)
+			if got != tt.want {
+				t.Errorf("FormatTimeout(%v) = %q, want %q", tt.d, got, tt.want)
+			}
+			if back, err := proxy.ParseTimeout(got); err != nil || back < tt.d {
+				t.Errorf("ParseTimeout(%q) = %v, %v; want at least %v", got, back, err, tt.d)
+			}
+		})
+	}
+}
+
+func TestServeHTTP_Deadline(t *testing.T) {
+	t.Parallel()
+
+	upstream := newUpstream(t, nil, buildGRPCFrame([]byte("ok")))
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{name: "grpc-timeout", header: http.Header{"Grpc-Timeout": {"250m"}}, want: 250 * time.Millisecond},
+		{name: "connect timeout", header: http.Header{"Connect-Timeout-Ms": {"1500"}}, want: 1500 * time.Millisecond},
+		{name: "none", header: http.Header{}, want: 0},
+		{name: "malformed", header: http.Header{"Grpc-Timeout": {"soon"}}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rp, err := proxy.New(":0", upstream.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/test.Service/Method",
+				bytes.NewReader(buildGRPCFrame([]byte("req"))))
+			req.Header = tt.header
+			req.Header.Set("Content-Type", "application/grpc")
+
+			if ev := serveOnce(t, rp, req); ev.Deadline != tt.want {
+				t.Errorf("deadline = %v, want %v", ev.Deadline, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/net/http2"
+)
+
+// newTransport builds the upstream transport: h2c (HTTP/2 over plain TCP) by
+// default, or a standard HTTP/1.1 transport when requested. https upstreams,
+// such as replay upstreams, are reached over HTTP/2 with TLS.
+func (rp *ReverseProxy) newTransport() http.RoundTripper {
+	dial := rp.dialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	if rp.connectTimeout > 0 {
+		dialUpstream := dial
+		dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dialCtx, cancel := context.WithTimeout(ctx, rp.connectTimeout)
+			defer cancel()
+			conn, err := dialUpstream(dialCtx, network, addr)
+			if err != nil && ctx.Err() == nil && errors.Is(dialCtx.Err(), context.DeadlineExceeded) {
+				// Report an unreachable upstream, not a call deadline.
+				return nil, fmt.Errorf("proxy: connect to %s timed out after %v", addr, rp.connectTimeout)
+			}
+			return conn, err
+		}
+	}
+
+	if rp.upstreamHTTP1 {
+		t := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert // DefaultTransport is always *http.Transport
+		t.Proxy = nil                                        // never route captured traffic through HTTP_PROXY
+		t.ForceAttemptHTTP2 = false
+		t.DialContext = dial
+		t.MaxConnsPerHost = rp.upstreamMaxConns
+		if rp.upstreamIdleTimeout > 0 {
+			t.IdleConnTimeout = rp.upstreamIdleTimeout
+		}
+		return t
+	}
+
+	if rp.upstreamMaxConns > 0 {
+		dial = newConnLimiter(rp.upstreamMaxConns, dial).dial
+	}
+	h2c := func(strict bool) *http2.Transport {
+		return &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dial(ctx, network, addr)
+			},
+			// Queue on the existing connection rather than dialing a new one
+			// whenever the server's stream limit is reached.
+			StrictMaxConcurrentStreams: strict,
+			IdleConnTimeout:            rp.upstreamIdleTimeout,
+		}
+	}
+	var plain http.RoundTripper = h2c(false)
+	if rp.upstreamMaxConns > 0 {
+		pool := &connPool{transports: make([]*http2.Transport, rp.upstreamMaxConns)}
+		for i := range pool.transports {
+			pool.transports[i] = h2c(true)
+		}
+		plain = pool
+	}
+	return &schemeTransport{
+		h2c: plain,
+		tls: &http2.Transport{
+			DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+				conn, err := dial(ctx, network, addr)
+				if err != nil {
+					return nil, err
+				}
+				tc := tls.Client(conn, cfg)
+				if err := tc.HandshakeContext(ctx); err != nil {
+					_ = conn.Close()
+					return nil, err //nolint:wrapcheck // surfaced by the transport as-is
+				}
+				return tc, nil
+			},
+			IdleConnTimeout: rp.upstreamIdleTimeout,
+		},
+	}
+}
+
+// schemeTransport sends https requests over HTTP/2 with TLS and everything
+// else over h2c. The h2c transport dials plain TCP even for https URLs.
+type schemeTransport struct {
+	h2c http.RoundTripper
+	tls *http2.Transport
+}
+
+func (t *schemeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "https" {
+		return t.tls.RoundTrip(req) //nolint:wrapcheck // pass-through
+	}
+	return t.h2c.RoundTrip(req) //nolint:wrapcheck // pass-through
+}
+
+// connPool spreads calls round robin over transports that each keep a
+// single connection, queueing calls for a free stream on it, so that n
+// transports use up to n connections.
+type connPool struct {
+	next       atomic.Uint64
+	transports []*http2.Transport
+}
+
+func (p *connPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	i := (p.next.Add(1) - 1) % uint64(len(p.transports))
+	return p.transports[i].RoundTrip(req) //nolint:wrapcheck // pass-through
+}
+
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// connLimiter bounds the number of simultaneously open connections. Dials
+// beyond the limit wait until a connection is closed or ctx is done.
+type connLimiter struct {
+	sem  chan struct{}
+	next dialFunc
+}
+
+func newConnLimiter(n int, next dialFunc) *connLimiter {
+	return &connLimiter{sem: make(chan struct{}, n), next: next}
+}
+
+func (l *connLimiter) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	select {
+	case l.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err() //nolint:wrapcheck // surfaced by the transport as-is
+	}
+	conn, err := l.next(ctx, network, addr)
+	if err != nil {
+		<-l.sem
+		return nil, err
+	}
+	return &limitedConn{Conn: conn, release: func() { <-l.sem }}, nil
+}
+
+// limitedConn returns its slot to the limiter when closed.
+type limitedConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *limitedConn) Close() error {
+	c.once.Do(c.release)
+	return c.Conn.Close() //nolint:wrapcheck // pass-through
+}
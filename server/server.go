@@ -2,18 +2,24 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net"
 	"net/http"
 	"strings"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/mickamy/grpc-tap/broker"
 	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
 	"github.com/mickamy/grpc-tap/proxy"
+	"github.com/mickamy/grpc-tap/stats"
 )
 
 // Server exposes a gRPC TapService for TUI clients to connect to.
@@ -21,10 +27,33 @@ type Server struct {
 	grpcServer *grpc.Server
 }
 
+// Option configures a Server.
+type Option func(*tapService)
+
+// WithStats serves GetStats from a. The caller feeds a with events, typically
+// by running it on a broker subscription. Without it, GetStats fails with
+// FailedPrecondition.
+func WithStats(a *stats.Aggregator) Option {
+	return func(s *tapService) {
+		s.stats = a
+	}
+}
+
+// WithReadOnly rejects the RPCs that act on the daemon, Replay and Clear, with
+// PermissionDenied. Watching and stats stay available.
+func WithReadOnly() Option {
+	return func(s *tapService) {
+		s.readOnly = true
+	}
+}
+
 // New creates a new Server backed by the given Broker and Proxy.
-func New(b *broker.Broker, p proxy.Proxy) *Server {
+func New(b *broker.Broker, p proxy.Proxy, opts ...Option) *Server {
 	gs := grpc.NewServer()
 	svc := &tapService{broker: b, proxy: p}
+	for _, opt := range opts {
+		opt(svc)
+	}
 	tapv1.RegisterTapServiceServer(gs, svc)
 
 	return &Server{grpcServer: gs}
@@ -38,6 +67,14 @@ func (s *Server) Serve(lis net.Listener) error {
 	return nil
 }
 
+// Handler returns the server as an http.Handler, for serving gRPC next to
+// other HTTP handlers on one port. It only handles HTTP/2 requests, so the
+// listening server must speak HTTP/2, e.g. cleartext via h2c. Calls served
+// this way cannot be drained: stop the server with Stop, not GracefulStop.
+func (s *Server) Handler() http.Handler {
+	return s.grpcServer
+}
+
 // Stop immediately stops the server.
 func (s *Server) Stop() {
 	s.grpcServer.Stop()
@@ -53,12 +90,22 @@ type tapService struct {
 
 	broker *broker.Broker
 	proxy  proxy.Proxy
+	stats  *stats.Aggregator
+
+	readOnly bool
 }
 
 func (s *tapService) Watch(_ *tapv1.WatchRequest, stream grpc.ServerStreamingServer[tapv1.WatchResponse]) error {
-	ch, unsub := s.broker.Subscribe()
+	backlog, ch, unsub := s.broker.SubscribeWithBacklog()
 	defer unsub()
 
+	for _, ev := range backlog {
+		if err := stream.Send(&tapv1.WatchResponse{Event: eventToProto(ev)}); err != nil {
+			slog.Debug("server: watch send", "error", err)
+			return fmt.Errorf("server: watch send: %w", err)
+		}
+	}
+
 	ctx := stream.Context()
 	for {
 		select {
@@ -71,6 +118,7 @@ func (s *tapService) Watch(_ *tapv1.WatchRequest, stream grpc.ServerStreamingSer
 			if err := stream.Send(&tapv1.WatchResponse{
 				Event: eventToProto(ev),
 			}); err != nil {
+				slog.Debug("server: watch send", "error", err)
 				return fmt.Errorf("server: watch send: %w", err)
 			}
 		}
@@ -78,8 +126,25 @@ func (s *tapService) Watch(_ *tapv1.WatchRequest, stream grpc.ServerStreamingSer
 }
 
 func (s *tapService) Replay(ctx context.Context, req *tapv1.ReplayRequest) (*tapv1.ReplayResponse, error) {
-	ev, err := s.proxy.Replay(ctx, req.GetMethod(), req.GetRequestBody())
+	if s.readOnly {
+		return nil, status.Error(codes.PermissionDenied, "server: replay: daemon is read-only")
+	}
+	if err := proxy.ValidateReplayMethod(s.proxy, req.GetMethod()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "server: replay: %v", err)
+	}
+	ev, err := s.proxy.Replay(ctx, proxy.ReplayRequest{
+		Method:      req.GetMethod(),
+		Body:        req.GetRequestBody(),
+		Upstream:    req.GetUpstream(),
+		Protocol:    protocolFromProto(req.GetProtocol()),
+		ContentType: req.GetContentType(),
+	})
+	if errors.Is(err, proxy.ErrUnknownUpstream) || errors.Is(err, proxy.ErrInvalidMethod) ||
+		errors.Is(err, proxy.ErrInvalidContentType) {
+		return nil, status.Errorf(codes.InvalidArgument, "server: %v", err)
+	}
 	if err != nil {
+		slog.Warn("server: replay", "method", req.GetMethod(), "error", err)
 		return nil, fmt.Errorf("server: replay: %w", err)
 	}
 	return &tapv1.ReplayResponse{
@@ -87,21 +152,99 @@ func (s *tapService) Replay(ctx context.Context, req *tapv1.ReplayRequest) (*tap
 	}, nil
 }
 
+func (s *tapService) GetStats(_ context.Context, req *tapv1.GetStatsRequest) (*tapv1.GetStatsResponse, error) {
+	if s.stats == nil {
+		return nil, status.Error(codes.FailedPrecondition, "server: stats: not enabled")
+	}
+	window := s.stats.Window()
+	if w := req.GetWindow().AsDuration(); w > 0 && w < window {
+		window = w
+	}
+
+	methods := s.stats.Snapshot(window)
+	resp := &tapv1.GetStatsResponse{
+		Methods: make([]*tapv1.MethodStats, 0, len(methods)),
+		Window:  durationpb.New(window),
+	}
+	for _, m := range methods {
+		resp.Methods = append(resp.Methods, &tapv1.MethodStats{
+			Method:    m.Method,
+			Count:     int64(m.Count),
+			Errors:    int64(m.Errors),
+			ErrorRate: m.ErrorRate(),
+			Total:     durationpb.New(m.Total),
+			P50:       durationpb.New(m.P50),
+			P95:       durationpb.New(m.P95),
+			P99:       durationpb.New(m.P99),
+		})
+	}
+	return resp, nil
+}
+
+func (s *tapService) Clear(context.Context, *tapv1.ClearRequest) (*tapv1.ClearResponse, error) {
+	if s.readOnly {
+		return nil, status.Error(codes.PermissionDenied, "server: clear: daemon is read-only")
+	}
+	n := s.broker.Clear()
+	if s.stats != nil {
+		s.stats.Reset()
+	}
+	slog.Info("server: cleared backlog and stats", "events", n)
+	return &tapv1.ClearResponse{Cleared: int64(n)}, nil
+}
+
 func eventToProto(ev proxy.Event) *tapv1.GRPCEvent {
 	return &tapv1.GRPCEvent{
-		Id:              ev.ID,
-		Method:          ev.Method,
-		CallType:        callTypeToProto(ev.CallType),
-		StartTime:       timestamppb.New(ev.StartTime),
-		Duration:        durationpb.New(ev.Duration),
-		Status:          ev.Status,
-		Error:           ev.Error,
-		Protocol:        protocolToProto(ev.Protocol),
-		RequestBody:     ev.RequestBody,
-		ResponseBody:    ev.ResponseBody,
-		RequestHeaders:  flattenHeaders(ev.RequestHeaders),
-		ResponseHeaders: flattenHeaders(ev.ResponseHeaders),
+		Id:               ev.ID,
+		Phase:            phaseToProto(ev.Phase),
+		Method:           ev.Method,
+		CallType:         callTypeToProto(ev.CallType),
+		StartTime:        timestamppb.New(ev.StartTime),
+		Duration:         durationpb.New(ev.Duration),
+		Status:           ev.Status,
+		Error:            ev.Error,
+		Protocol:         protocolToProto(ev.Protocol),
+		RequestBody:      ev.RequestBody,
+		ResponseBody:     ev.ResponseBody,
+		RequestHeaders:   flattenHeaders(ev.RequestHeaders),
+		ResponseHeaders:  flattenHeaders(ev.ResponseHeaders),
+		ResponseTrailers: flattenHeaders(ev.ResponseTrailers),
+
+		RequestEncoding:        ev.RequestEncoding,
+		ResponseEncoding:       ev.ResponseEncoding,
+		RequestCompressedSize:  ev.RequestCompressedSize,
+		ResponseCompressedSize: ev.ResponseCompressedSize,
+		BodyCaptureDisabled:    ev.BodyCaptureDisabled,
+		Upstream:               ev.Upstream,
+		Deadline:               optionalDuration(ev.Deadline),
+		PeerAddr:               ev.PeerAddr,
+		Authority:              ev.Authority,
+		CorrelationId:          ev.CorrelationID,
+		TimeToFirstByte:        optionalDuration(ev.TimeToFirstByte),
+		ResponseMessageRate:    ev.ResponseMessageRate,
+		ContentType:            ev.ContentType,
+		RequestFrames:          framesToProto(ev.RequestFrames),
+		ResponseFrames:         framesToProto(ev.ResponseFrames),
+	}
+}
+
+func framesToProto(frames []proxy.Frame) []*tapv1.Frame {
+	if len(frames) == 0 {
+		return nil
+	}
+	out := make([]*tapv1.Frame, len(frames))
+	for i, f := range frames {
+		out[i] = &tapv1.Frame{Offset: durationpb.New(f.Offset), Body: f.Body}
+	}
+	return out
+}
+
+// optionalDuration converts d, leaving it unset when it is not positive.
+func optionalDuration(d time.Duration) *durationpb.Duration {
+	if d <= 0 {
+		return nil
 	}
+	return durationpb.New(d)
 }
 
 // flattenHeaders converts http.Header (multi-value) to map[string]string
@@ -132,6 +275,31 @@ func callTypeToProto(ct proxy.CallType) tapv1.CallType {
 	}
 }
 
+func phaseToProto(p proxy.Phase) tapv1.EventPhase {
+	switch p {
+	case proxy.PhaseComplete:
+		return tapv1.EventPhase_EVENT_PHASE_COMPLETE
+	case proxy.PhaseProgress:
+		return tapv1.EventPhase_EVENT_PHASE_PROGRESS
+	case proxy.PhaseStart:
+		return tapv1.EventPhase_EVENT_PHASE_START
+	default:
+		return tapv1.EventPhase_EVENT_PHASE_UNSPECIFIED
+	}
+}
+
+// protocolFromProto converts a replay protocol; unspecified means gRPC.
+func protocolFromProto(p tapv1.Protocol) proxy.Protocol {
+	switch p {
+	case tapv1.Protocol_PROTOCOL_GRPC_WEB:
+		return proxy.ProtocolGRPCWeb
+	case tapv1.Protocol_PROTOCOL_CONNECT:
+		return proxy.ProtocolConnect
+	default:
+		return proxy.ProtocolGRPC
+	}
+}
+
 func protocolToProto(p proxy.Protocol) tapv1.Protocol {
 	switch p {
 	case proxy.ProtocolGRPC:
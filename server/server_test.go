@@ -4,29 +4,35 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/mickamy/grpc-tap/broker"
 	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
 	"github.com/mickamy/grpc-tap/proxy"
 	"github.com/mickamy/grpc-tap/server"
+	"github.com/mickamy/grpc-tap/stats"
 )
 
 // fakeProxy implements proxy.Proxy for testing.
 type fakeProxy struct {
-	replayFunc func(ctx context.Context, method string, body []byte) (proxy.Event, error)
+	replayFunc func(ctx context.Context, req proxy.ReplayRequest) (proxy.Event, error)
 }
 
 func (f *fakeProxy) ListenAndServe(context.Context) error { return nil }
 func (f *fakeProxy) Events() <-chan proxy.Event           { return nil }
 func (f *fakeProxy) Close() error                         { return nil }
-func (f *fakeProxy) Replay(ctx context.Context, method string, body []byte) (proxy.Event, error) {
+func (f *fakeProxy) Replay(ctx context.Context, req proxy.ReplayRequest) (proxy.Event, error) {
 	if f.replayFunc != nil {
-		return f.replayFunc(ctx, method, body)
+		return f.replayFunc(ctx, req)
 	}
 	return proxy.Event{}, nil
 }
@@ -35,7 +41,7 @@ func startServer(t *testing.T, b *broker.Broker) tapv1.TapServiceClient {
 	return startServerWithProxy(t, b, &fakeProxy{})
 }
 
-func startServerWithProxy(t *testing.T, b *broker.Broker, p proxy.Proxy) tapv1.TapServiceClient {
+func startServerWithProxy(t *testing.T, b *broker.Broker, p proxy.Proxy, opts ...server.Option) tapv1.TapServiceClient {
 	t.Helper()
 
 	lis, err := net.Listen("tcp", "localhost:0") //nolint:noctx // test code
@@ -43,7 +49,7 @@ func startServerWithProxy(t *testing.T, b *broker.Broker, p proxy.Proxy) tapv1.T
 		t.Fatal(err)
 	}
 
-	srv := server.New(b, p)
+	srv := server.New(b, p, opts...)
 	t.Cleanup(srv.Stop)
 
 	go func() {
@@ -157,22 +163,57 @@ func TestWatch_MultipleEvents(t *testing.T) {
 	}
 }
 
+func TestWatch_Phases(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+
+	b := broker.New(8)
+	client := startServer(t, b)
+
+	stream, err := client.Watch(ctx, &tapv1.WatchRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitForSubscriber(t, b)
+
+	b.Publish(proxy.Event{ID: "s", Method: "/test.Service/Watch", Phase: proxy.PhaseStart})
+	b.Publish(proxy.Event{ID: "s", Method: "/test.Service/Watch", Phase: proxy.PhaseProgress})
+	b.Publish(proxy.Event{ID: "s", Method: "/test.Service/Watch", Status: 1, Duration: time.Second})
+
+	want := []tapv1.EventPhase{
+		tapv1.EventPhase_EVENT_PHASE_START,
+		tapv1.EventPhase_EVENT_PHASE_PROGRESS,
+		tapv1.EventPhase_EVENT_PHASE_COMPLETE,
+	}
+	for i, phase := range want {
+		resp, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv[%d]: %v", i, err)
+		}
+		got := resp.GetEvent()
+		if got.GetId() != "s" || got.GetPhase() != phase {
+			t.Errorf("event[%d] id/phase = %q/%v, want s/%v", i, got.GetId(), got.GetPhase(), phase)
+		}
+	}
+}
+
 func TestReplay(t *testing.T) {
 	t.Parallel()
 	ctx := t.Context()
 
 	b := broker.New(8)
 	fp := &fakeProxy{
-		replayFunc: func(_ context.Context, method string, body []byte) (proxy.Event, error) {
+		replayFunc: func(_ context.Context, req proxy.ReplayRequest) (proxy.Event, error) {
 			return proxy.Event{
 				ID:           "replay-1",
-				Method:       method,
+				Method:       req.Method,
 				CallType:     proxy.Unary,
 				Protocol:     proxy.ProtocolGRPC,
 				StartTime:    time.Now(),
 				Duration:     10 * time.Millisecond,
 				Status:       0,
-				RequestBody:  body,
+				RequestBody:  req.Body,
 				ResponseBody: []byte("response"),
 			}, nil
 		},
@@ -199,3 +240,243 @@ func TestReplay(t *testing.T) {
 		t.Errorf("RequestBody = %q, want %q", got.GetRequestBody(), "hello")
 	}
 }
+
+func TestReplay_UnknownUpstream(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+
+	p, err := proxy.New(":0", "http://localhost:1", proxy.WithReplayUpstream("staging", "http://localhost:2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := startServerWithProxy(t, broker.New(8), p)
+
+	for _, upstream := range []string{"prod", "http://169.254.169.254"} {
+		_, err := client.Replay(ctx, &tapv1.ReplayRequest{
+			Method:      "/test.Service/Hello",
+			RequestBody: []byte("hello"),
+			Upstream:    upstream,
+		})
+		if got := status.Code(err); got != codes.InvalidArgument {
+			t.Errorf("Replay(upstream=%q) code = %v, want InvalidArgument (err: %v)", upstream, got, err)
+		}
+	}
+}
+
+func TestReplay_Protocol(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+
+	got := make(chan proxy.ReplayRequest, 1)
+	fp := &fakeProxy{
+		replayFunc: func(_ context.Context, req proxy.ReplayRequest) (proxy.Event, error) {
+			got <- req
+			return proxy.Event{Method: req.Method, Protocol: req.Protocol}, nil
+		},
+	}
+	client := startServerWithProxy(t, broker.New(8), fp)
+
+	resp, err := client.Replay(ctx, &tapv1.ReplayRequest{
+		Method:      "/test.Service/Hello",
+		Protocol:    tapv1.Protocol_PROTOCOL_GRPC_WEB,
+		ContentType: "application/grpc-web-text",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req := <-got; req.Protocol != proxy.ProtocolGRPCWeb || req.ContentType != "application/grpc-web-text" {
+		t.Errorf("replayed with %v/%q, want gRPC-Web/application/grpc-web-text", req.Protocol, req.ContentType)
+	}
+	if resp.GetEvent().GetProtocol() != tapv1.Protocol_PROTOCOL_GRPC_WEB {
+		t.Errorf("event protocol = %v, want gRPC-Web", resp.GetEvent().GetProtocol())
+	}
+
+	if _, err := client.Replay(ctx, &tapv1.ReplayRequest{Method: "/test.Service/Hello"}); err != nil {
+		t.Fatal(err)
+	}
+	if req := <-got; req.Protocol != proxy.ProtocolGRPC {
+		t.Errorf("unspecified protocol replayed as %v, want gRPC", req.Protocol)
+	}
+}
+
+func TestReplay_InvalidMethod(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+
+	var called atomic.Bool
+	fp := &fakeProxy{
+		replayFunc: func(context.Context, proxy.ReplayRequest) (proxy.Event, error) {
+			called.Store(true)
+			return proxy.Event{}, nil
+		},
+	}
+	client := startServerWithProxy(t, broker.New(8), fp)
+
+	for _, method := range []string{"", "test.Service/Hello", "/test.Service/../admin"} {
+		_, err := client.Replay(ctx, &tapv1.ReplayRequest{Method: method})
+		if got := status.Code(err); got != codes.InvalidArgument {
+			t.Errorf("Replay(%q) code = %v, want InvalidArgument", method, got)
+		}
+	}
+	if called.Load() {
+		t.Error("proxy Replay called for an invalid method")
+	}
+}
+
+func TestGetStats(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+
+	st := stats.New(time.Minute)
+	for _, d := range []time.Duration{10, 20, 30} {
+		st.Add(proxy.Event{Method: "/test.Service/Hello", Duration: d * time.Millisecond})
+	}
+	st.Add(proxy.Event{Method: "/test.Service/Fail", Duration: time.Millisecond, Status: 13})
+
+	client := startServerWithProxy(t, broker.New(8), &fakeProxy{}, server.WithStats(st))
+
+	resp, err := client.GetStats(ctx, &tapv1.GetStatsRequest{Window: durationpb.New(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.GetWindow().AsDuration(); got != time.Minute {
+		t.Errorf("window = %v, want the server's 1m", got)
+	}
+	methods := resp.GetMethods()
+	if len(methods) != 2 {
+		t.Fatalf("methods = %d, want 2", len(methods))
+	}
+	fail, hello := methods[0], methods[1]
+	if fail.GetMethod() != "/test.Service/Fail" || fail.GetErrors() != 1 || fail.GetErrorRate() != 100 {
+		t.Errorf("Fail = %v, want 1 error (100%%)", fail)
+	}
+	if hello.GetCount() != 3 || hello.GetP50().AsDuration() != 20*time.Millisecond ||
+		hello.GetP99().AsDuration() != 30*time.Millisecond || hello.GetTotal().AsDuration() != 60*time.Millisecond {
+		t.Errorf("Hello = %v, want count 3, p50 20ms, p99 30ms, total 60ms", hello)
+	}
+}
+
+func TestGetStats_NotEnabled(t *testing.T) {
+	t.Parallel()
+
+	client := startServer(t, broker.New(8))
+	_, err := client.GetStats(t.Context(), &tapv1.GetStatsRequest{})
+	if got := status.Code(err); got != codes.FailedPrecondition {
+		t.Errorf("code = %v, want FailedPrecondition", got)
+	}
+}
+
+func TestWatch_Backlog(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+
+	b := broker.New(8, broker.WithBacklog(8))
+	b.Publish(proxy.Event{ID: "old-1", Method: "/test.Service/Hello"})
+	b.Publish(proxy.Event{ID: "old-2", Method: "/test.Service/Hello"})
+	client := startServer(t, b)
+
+	stream, err := client.Watch(ctx, &tapv1.WatchRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForSubscriber(t, b)
+	b.Publish(proxy.Event{ID: "new", Method: "/test.Service/Hello"})
+
+	for _, want := range []string{"old-1", "old-2", "new"} {
+		resp, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resp.GetEvent().GetId(); got != want {
+			t.Errorf("event id = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestClear(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+
+	b := broker.New(8, broker.WithBacklog(8))
+	st := stats.New(time.Minute)
+	for _, id := range []string{"1", "2"} {
+		ev := proxy.Event{ID: id, Method: "/test.Service/Hello", Duration: time.Millisecond}
+		b.Publish(ev)
+		st.Add(ev)
+	}
+	client := startServerWithProxy(t, b, &fakeProxy{}, server.WithStats(st))
+
+	resp, err := client.Clear(ctx, &tapv1.ClearRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetCleared() != 2 {
+		t.Errorf("cleared = %d, want 2", resp.GetCleared())
+	}
+	if n := len(b.Backlog()); n != 0 {
+		t.Errorf("backlog = %d events, want 0", n)
+	}
+	statsResp, err := client.GetStats(ctx, &tapv1.GetStatsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(statsResp.GetMethods()); n != 0 {
+		t.Errorf("stats methods after Clear = %d, want 0", n)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+
+	b := broker.New(8, broker.WithBacklog(8))
+	b.Publish(proxy.Event{ID: "1", Method: "/test.Service/Hello"})
+	client := startServerWithProxy(t, b, &fakeProxy{}, server.WithReadOnly())
+
+	if _, err := client.Clear(ctx, &tapv1.ClearRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Clear code = %v, want PermissionDenied", status.Code(err))
+	}
+	_, err := client.Replay(ctx, &tapv1.ReplayRequest{Method: "/test.Service/Hello"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Replay code = %v, want PermissionDenied", status.Code(err))
+	}
+	if n := len(b.Backlog()); n != 1 {
+		t.Errorf("backlog = %d events, want 1", n)
+	}
+}
+
+func TestWatch_UnixSocket(t *testing.T) {
+	t.Parallel()
+
+	addr := "unix:" + filepath.Join(t.TempDir(), "tap.sock")
+	lis, err := proxy.Listen(t.Context(), addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := broker.New(8)
+	srv := server.New(b, &fakeProxy{})
+	t.Cleanup(srv.Stop)
+	go func() { _ = srv.Serve(lis) }()
+
+	// grpc-go resolves unix: targets itself, as the TUI's dial does.
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	stream, err := tapv1.NewTapServiceClient(conn).Watch(t.Context(), &tapv1.WatchRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForSubscriber(t, b)
+
+	b.Publish(proxy.Event{ID: "unix-1", Method: "/test.Service/Hello"})
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.GetEvent().GetId(); got != "unix-1" {
+		t.Errorf("ID = %q, want unix-1", got)
+	}
+}
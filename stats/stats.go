@@ -0,0 +1,279 @@
+// Package stats maintains rolling per-method aggregates of proxied calls.
+package stats
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+// DefaultWindow is how far back an Aggregator remembers calls unless told
+// otherwise.
+const DefaultWindow = 15 * time.Minute
+
+// maxCalls bounds the memory of an Aggregator under heavy traffic: beyond it,
+// the oldest calls are forgotten even if they are still inside the window.
+const maxCalls = 100_000
+
+// Method holds the aggregates of one method over a window.
+type Method struct {
+	Method string
+	Count  int
+	Errors int
+	Total  time.Duration
+	P50    time.Duration
+	P95    time.Duration
+	P99    time.Duration
+}
+
+// ErrorRate returns the share of failed calls in percent.
+func (m Method) ErrorRate() float64 {
+	if m.Count == 0 {
+		return 0
+	}
+	return float64(m.Errors) / float64(m.Count) * 100
+}
+
+// call is one completed call as remembered by the Aggregator.
+type call struct {
+	at       time.Time // when the call was recorded
+	method   string
+	duration time.Duration
+	failed   bool
+}
+
+// Aggregator remembers the completed calls of a rolling window and computes
+// per-method stats over all or part of it. Calls are placed in the window by
+// the time they are recorded, i.e. when they complete. It also keeps track
+// of the calls in flight, forgetting those with no event for a window in
+// case their final event was dropped. It is safe for concurrent use.
+type Aggregator struct {
+	mu     sync.Mutex
+	window time.Duration
+	calls  []call               // oldest first, from head on
+	head   int                  // calls before head are forgotten
+	count  int                  // calls from head on
+	errors int                  // failed calls from head on
+	active map[string]time.Time // calls in flight by ID, with when their last event arrived
+	now    func() time.Time
+}
+
+// New creates an Aggregator remembering calls for window. A non-positive
+// window uses DefaultWindow.
+func New(window time.Duration) *Aggregator {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	return &Aggregator{window: window, active: make(map[string]time.Time), now: time.Now}
+}
+
+// Window returns how far back the Aggregator remembers calls.
+func (a *Aggregator) Window() time.Duration {
+	return a.window
+}
+
+// Run records events from ch until it is closed.
+func (a *Aggregator) Run(ch <-chan proxy.Event) {
+	for ev := range ch {
+		a.Add(ev)
+	}
+}
+
+// Add records a completed call. Start and progress events only mark the
+// call as in flight; the call is counted once its final event arrives.
+func (a *Aggregator) Add(ev proxy.Event) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	if ev.Phase != proxy.PhaseComplete {
+		if ev.ID == "" {
+			return
+		}
+		if len(a.active) >= maxCalls {
+			a.pruneActive(now)
+		}
+		if _, ok := a.active[ev.ID]; ok || len(a.active) < maxCalls {
+			a.active[ev.ID] = now
+		}
+		return
+	}
+	delete(a.active, ev.ID)
+	if ev.Method == "" {
+		return
+	}
+
+	a.calls = append(a.calls, call{
+		at:       now,
+		method:   ev.Method,
+		duration: ev.Duration,
+		failed:   ev.Status != 0,
+	})
+	a.count++
+	if ev.Status != 0 {
+		a.errors++
+	}
+	a.prune(now)
+}
+
+// prune forgets calls that fell out of the window or past maxCalls. It only
+// advances head; the forgotten calls are compacted away once they make up
+// half of the slice, so that each call is moved a constant number of times.
+func (a *Aggregator) prune(now time.Time) {
+	live := a.calls[a.head:]
+	cutoff := now.Add(-a.window)
+	drop := sort.Search(len(live), func(i int) bool {
+		return live[i].at.After(cutoff)
+	})
+	drop = max(drop, len(live)-maxCalls)
+	for _, c := range live[:drop] {
+		a.count--
+		if c.failed {
+			a.errors--
+		}
+	}
+	a.head += drop
+	if a.head > len(a.calls)/2 {
+		n := copy(a.calls, a.calls[a.head:])
+		clear(a.calls[n:])
+		a.calls = a.calls[:n]
+		a.head = 0
+	}
+}
+
+// pruneActive forgets the calls in flight with no event for a window: their
+// final event never arrived, e.g. because a full subscriber buffer dropped
+// it.
+func (a *Aggregator) pruneActive(now time.Time) {
+	cutoff := now.Add(-a.window)
+	for id, seen := range a.active {
+		if !seen.After(cutoff) {
+			delete(a.active, id)
+		}
+	}
+}
+
+// Snapshot computes per-method stats over the last window, ordered by method.
+// A non-positive window, or one longer than the Aggregator's, covers
+// everything remembered.
+func (a *Aggregator) Snapshot(window time.Duration) []Method {
+	calls := a.recent(window)
+
+	type group struct {
+		Method
+		durations []time.Duration
+	}
+	groups := make(map[string]*group)
+	for _, c := range calls {
+		g, ok := groups[c.method]
+		if !ok {
+			g = &group{Method: Method{Method: c.method}}
+			groups[c.method] = g
+		}
+		g.Count++
+		g.Total += c.duration
+		if c.failed {
+			g.Errors++
+		}
+		g.durations = append(g.durations, c.duration)
+	}
+
+	methods := make([]Method, 0, len(groups))
+	for _, g := range groups {
+		slices.Sort(g.durations)
+		g.P50 = percentile(g.durations, 50)
+		g.P95 = percentile(g.durations, 95)
+		g.P99 = percentile(g.durations, 99)
+		methods = append(methods, g.Method)
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Method < methods[j].Method
+	})
+	return methods
+}
+
+// Totals computes stats over every call of the last window, as one Method
+// with an empty name. The window is as for Snapshot.
+func (a *Aggregator) Totals(window time.Duration) Method {
+	calls := a.recent(window)
+	var m Method
+	durations := make([]time.Duration, 0, len(calls))
+	for _, c := range calls {
+		m.Count++
+		m.Total += c.duration
+		if c.failed {
+			m.Errors++
+		}
+		durations = append(durations, c.duration)
+	}
+	slices.Sort(durations)
+	m.P50 = percentile(durations, 50)
+	m.P95 = percentile(durations, 95)
+	m.P99 = percentile(durations, 99)
+	return m
+}
+
+// Counts returns how many calls the last window holds and how many of them
+// failed, as Totals(0) would, without going over the calls.
+func (a *Aggregator) Counts() (count, errors int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.prune(a.now())
+	return a.count, a.errors
+}
+
+// Active returns how many calls are in flight: calls running for long enough
+// to have sent a start event (see proxy.WithStreamUpdateInterval), such as
+// open streams, that have not completed yet. Calls with no event for a
+// window are no longer counted.
+func (a *Aggregator) Active() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.pruneActive(a.now())
+	return len(a.active)
+}
+
+// recent returns a copy of the calls recorded in the last window, covering
+// everything remembered for a non-positive window or one longer than the
+// Aggregator's.
+func (a *Aggregator) recent(window time.Duration) []call {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	a.prune(now)
+	live := a.calls[a.head:]
+	start := 0
+	if window > 0 && window < a.window {
+		cutoff := now.Add(-window)
+		start = sort.Search(len(live), func(i int) bool {
+			return live[i].at.After(cutoff)
+		})
+	}
+	return slices.Clone(live[start:])
+}
+
+// Reset forgets all recorded calls. Calls in flight are still tracked, as
+// they keep running.
+func (a *Aggregator) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.calls = nil
+	a.head = 0
+	a.count, a.errors = 0, 0
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	return sorted[max(rank, 1)-1]
+}
@@ -0,0 +1,258 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+// fakeClock is a settable time source for the Aggregator.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestAggregator(window time.Duration) (*Aggregator, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)}
+	a := New(window)
+	a.now = clock.now
+	return a, clock
+}
+
+func event(method string, d time.Duration, status int32) proxy.Event {
+	return proxy.Event{Method: method, Duration: d, Status: status}
+}
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+
+	a, _ := newTestAggregator(time.Minute)
+	for i := 1; i <= 100; i++ {
+		var status int32
+		if i%10 == 0 {
+			status = 14
+		}
+		a.Add(event("/svc/A", time.Duration(i)*time.Millisecond, status))
+	}
+	a.Add(event("/svc/B", 5*time.Millisecond, 0))
+	a.Add(proxy.Event{Method: "/svc/B", Phase: proxy.PhaseStart})
+	a.Add(proxy.Event{Method: "/svc/B", Phase: proxy.PhaseProgress, Duration: time.Hour})
+
+	got := a.Snapshot(0)
+	if len(got) != 2 {
+		t.Fatalf("methods = %d, want 2", len(got))
+	}
+
+	m := got[0]
+	if m.Method != "/svc/A" || m.Count != 100 || m.Errors != 10 {
+		t.Errorf("A = %s count=%d errors=%d, want /svc/A 100 10", m.Method, m.Count, m.Errors)
+	}
+	if m.ErrorRate() != 10 {
+		t.Errorf("A error rate = %v, want 10", m.ErrorRate())
+	}
+	if m.Total != 5050*time.Millisecond {
+		t.Errorf("A total = %v, want 5.05s", m.Total)
+	}
+	if m.P50 != 50*time.Millisecond || m.P95 != 95*time.Millisecond || m.P99 != 99*time.Millisecond {
+		t.Errorf("A percentiles = %v/%v/%v, want 50ms/95ms/99ms", m.P50, m.P95, m.P99)
+	}
+
+	if b := got[1]; b.Method != "/svc/B" || b.Count != 1 || b.P50 != 5*time.Millisecond || b.P99 != 5*time.Millisecond {
+		t.Errorf("B = %+v, want one 5ms call (in-flight events ignored)", b)
+	}
+}
+
+func TestSnapshot_Window(t *testing.T) {
+	t.Parallel()
+
+	a, clock := newTestAggregator(10 * time.Minute)
+	a.Add(event("/svc/Old", time.Millisecond, 0))
+	clock.t = clock.t.Add(6 * time.Minute)
+	a.Add(event("/svc/New", time.Millisecond, 0))
+	clock.t = clock.t.Add(time.Minute)
+
+	t.Run("shorter window", func(t *testing.T) {
+		t.Parallel()
+		got := a.Snapshot(5 * time.Minute)
+		if len(got) != 1 || got[0].Method != "/svc/New" {
+			t.Errorf("Snapshot(5m) = %+v, want only /svc/New", got)
+		}
+	})
+
+	t.Run("full window", func(t *testing.T) {
+		t.Parallel()
+		if got := a.Snapshot(time.Hour); len(got) != 2 {
+			t.Errorf("Snapshot(1h) = %d methods, want 2", len(got))
+		}
+	})
+}
+
+func TestTotals(t *testing.T) {
+	t.Parallel()
+
+	a, clock := newTestAggregator(10 * time.Minute)
+	a.Add(event("/svc/Old", time.Second, 14))
+	clock.t = clock.t.Add(6 * time.Minute)
+	for i := 1; i <= 20; i++ {
+		var status int32
+		if i%5 == 0 {
+			status = 13
+		}
+		a.Add(event("/svc/"+string(rune('A'+i%2)), time.Duration(i)*time.Millisecond, status))
+	}
+
+	got := a.Totals(5 * time.Minute)
+	if got.Method != "" || got.Count != 20 || got.Errors != 4 || got.ErrorRate() != 20 {
+		t.Errorf("Totals(5m) = %+v, want 20 calls and 4 errors across methods", got)
+	}
+	if got.P95 != 19*time.Millisecond || got.Total != 210*time.Millisecond {
+		t.Errorf("Totals(5m) p95 = %v, total = %v; want 19ms, 210ms", got.P95, got.Total)
+	}
+	if got := a.Totals(0); got.Count != 21 || got.P99 != time.Second {
+		t.Errorf("Totals(0) = %+v, want 21 calls with p99 1s", got)
+	}
+
+	a.Reset()
+	if got := a.Totals(0); got != (Method{}) {
+		t.Errorf("Totals after Reset = %+v, want zero", got)
+	}
+}
+
+func TestAdd_Rolling(t *testing.T) {
+	t.Parallel()
+
+	a, clock := newTestAggregator(time.Minute)
+	a.Add(event("/svc/A", time.Millisecond, 0))
+	clock.t = clock.t.Add(time.Minute)
+	a.Add(event("/svc/B", time.Millisecond, 0))
+
+	got := a.Snapshot(0)
+	if len(got) != 1 || got[0].Method != "/svc/B" {
+		t.Errorf("Snapshot = %+v, want only /svc/B after /svc/A left the window", got)
+	}
+	if n := len(a.calls) - a.head; n != 1 {
+		t.Errorf("remembered calls = %d, want 1", n)
+	}
+}
+
+func TestAdd_MaxCalls(t *testing.T) {
+	t.Parallel()
+
+	a, _ := newTestAggregator(time.Minute)
+	for i := range maxCalls + 10 {
+		a.Add(event("/svc/A", time.Duration(i), 0))
+	}
+	if n := len(a.calls) - a.head; n != maxCalls {
+		t.Errorf("remembered calls = %d, want %d", n, maxCalls)
+	}
+	if count, _ := a.Counts(); count != maxCalls {
+		t.Errorf("Counts = %d, want %d", count, maxCalls)
+	}
+	if got := a.Totals(0); got.Count != maxCalls || got.P50 < 10 {
+		t.Errorf("Totals = %+v, want the newest %d calls", got, maxCalls)
+	}
+	if cap(a.calls) > 4*maxCalls {
+		t.Errorf("calls capacity = %d, want forgotten calls compacted", cap(a.calls))
+	}
+}
+
+func TestCounts(t *testing.T) {
+	t.Parallel()
+
+	a, clock := newTestAggregator(10 * time.Minute)
+	a.Add(event("/svc/Old", time.Second, 14))
+	clock.t = clock.t.Add(6 * time.Minute)
+	a.Add(event("/svc/A", time.Millisecond, 0))
+	a.Add(event("/svc/A", time.Millisecond, 13))
+	if count, errs := a.Counts(); count != 3 || errs != 2 {
+		t.Errorf("Counts = %d, %d; want 3, 2", count, errs)
+	}
+
+	// The first call leaves the window.
+	clock.t = clock.t.Add(5 * time.Minute)
+	if count, errs := a.Counts(); count != 2 || errs != 1 {
+		t.Errorf("Counts after the first call expired = %d, %d; want 2, 1", count, errs)
+	}
+
+	a.Reset()
+	if count, errs := a.Counts(); count != 0 || errs != 0 {
+		t.Errorf("Counts after Reset = %d, %d; want 0, 0", count, errs)
+	}
+}
+
+func TestReset(t *testing.T) {
+	t.Parallel()
+
+	a, _ := newTestAggregator(time.Minute)
+	a.Add(event("/svc/A", time.Millisecond, 0))
+	a.Reset()
+	if got := a.Snapshot(0); len(got) != 0 {
+		t.Errorf("Snapshot after Reset = %+v, want empty", got)
+	}
+}
+
+func TestActive(t *testing.T) {
+	t.Parallel()
+
+	a, _ := newTestAggregator(time.Minute)
+	a.Add(proxy.Event{ID: "1", Method: "/svc/Watch", Phase: proxy.PhaseStart})
+	a.Add(proxy.Event{ID: "1", Method: "/svc/Watch", Phase: proxy.PhaseProgress})
+	a.Add(proxy.Event{ID: "2", Method: "/svc/Chat", Phase: proxy.PhaseStart})
+	if got := a.Active(); got != 2 {
+		t.Errorf("Active = %d, want 2", got)
+	}
+
+	a.Reset()
+	if got := a.Active(); got != 2 {
+		t.Errorf("Active after Reset = %d, want the 2 calls still running", got)
+	}
+	a.Add(proxy.Event{ID: "1", Method: "/svc/Watch", Phase: proxy.PhaseComplete})
+	if got := a.Active(); got != 1 {
+		t.Errorf("Active after a call completed = %d, want 1", got)
+	}
+	if got := a.Totals(0).Count; got != 1 {
+		t.Errorf("Totals count = %d, want only the completed call", got)
+	}
+}
+
+func TestActive_Expire(t *testing.T) {
+	t.Parallel()
+
+	a, clock := newTestAggregator(time.Minute)
+	a.Add(proxy.Event{ID: "1", Method: "/svc/Watch", Phase: proxy.PhaseStart})
+	a.Add(proxy.Event{ID: "2", Method: "/svc/Chat", Phase: proxy.PhaseStart})
+	clock.t = clock.t.Add(40 * time.Second)
+	a.Add(proxy.Event{ID: "2", Method: "/svc/Chat", Phase: proxy.PhaseProgress})
+
+	// The complete event of call 1 never arrives.
+	clock.t = clock.t.Add(30 * time.Second)
+	if got := a.Active(); got != 1 {
+		t.Errorf("Active = %d, want only the call with an event in the window", got)
+	}
+	clock.t = clock.t.Add(time.Minute)
+	if got := a.Active(); got != 0 {
+		t.Errorf("Active = %d, want 0 once no call had an event in the window", got)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		sorted []time.Duration
+		p      int
+		want   time.Duration
+	}{
+		{nil, 50, 0},
+		{[]time.Duration{7}, 99, 7},
+		{[]time.Duration{1, 2}, 50, 1},
+		{[]time.Duration{1, 2}, 95, 2},
+		{[]time.Duration{1, 2, 3, 4}, 50, 2},
+		{[]time.Duration{1, 2, 3, 4}, 99, 4},
+	}
+	for _, tt := range tests {
+		if got := percentile(tt.sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%v, %d) = %v, want %v", tt.sorted, tt.p, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/mickamy/grpc-tap/filter"
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+	"github.com/mickamy/grpc-tap/tui"
+)
+
+// tableMain runs `grpc-tap table`: it watches the daemon for a while, then
+// prints the calls that started meanwhile as a table and exits.
+func tableMain(args []string) {
+	fs := flag.NewFlagSet("grpc-tap table", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "grpc-tap table — Print the calls made during a few seconds as a table\n\n"+
+			"Usage:\n  grpc-tap table [flags] <addr>\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	duration := fs.Duration("for", 5*time.Second, "how long to collect calls before printing them")
+	filterExpr := fs.String("filter", "", "only print calls matching this filter expression (e.g. \"code:error -method:Health\")")
+	columns := fs.String("columns", "proto,method,type,status,duration,time",
+		"table columns in order, from proto, method, host, trace, type, content-type, status, duration and time (method is required)")
+	durationUnit := fs.String("duration-unit", "auto", "show durations in one unit: auto (ns, µs, ms or s by magnitude), us, ms or s")
+	_ = fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	search, err := filter.Parse(*filterExpr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	cols, err := tui.ParseColumns(*columns)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	unit, err := tui.ParseDurationUnit(*durationUnit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *duration)
+	defer cancel()
+	events, err := collectEvents(ctx, fs.Arg(0), search)
+	if err == nil {
+		err = tui.WriteTable(os.Stdout, events, cols, unit)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// collectEvents watches the daemon at target until ctx is done and returns
+// the calls matching f that started meanwhile, in the order they were first
+// seen, each in its latest state. The daemon's backlog of earlier calls is
+// skipped.
+func collectEvents(ctx context.Context, target string, f *filter.Filter) ([]*tapv1.GRPCEvent, error) {
+	since := time.Now()
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("dial %s: %w", target, err)
+	}
+	defer func() { _ = conn.Close() }()
+	stream, err := tapv1.NewTapServiceClient(conn).Watch(ctx, &tapv1.WatchRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("watch %s: %w", target, err)
+	}
+
+	var events []*tapv1.GRPCEvent
+	index := make(map[string]int)
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil || status.Code(err) == codes.DeadlineExceeded || errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("watch %s: %w", target, err)
+		}
+		ev := resp.GetEvent()
+		if ev.GetStartTime().AsTime().Before(since) {
+			continue
+		}
+		if i, ok := index[ev.GetId()]; ok {
+			events[i] = ev
+			continue
+		}
+		index[ev.GetId()] = len(events)
+		events = append(events, ev)
+	}
+
+	matched := events[:0]
+	for _, ev := range events {
+		if f.Empty() || f.Evaluate(filter.FromProto(ev)) {
+			matched = append(matched, ev)
+		}
+	}
+	return matched, nil
+}
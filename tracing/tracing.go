@@ -0,0 +1,122 @@
+// Package tracing records proxied calls as OpenTelemetry spans.
+//
+// Spans are built from completed events after the fact, so the proxy itself
+// is not instrumented and tracing costs nothing unless a Tracer subscribes.
+// Each span joins the trace of its call when the client sent W3C trace
+// context (traceparent), which the proxy passes on to the upstream unchanged.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+const instrumentationName = "github.com/mickamy/grpc-tap/tracing"
+
+// Tracer turns completed events into spans.
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// New creates a Tracer that records spans with tp.
+func New(tp trace.TracerProvider) *Tracer {
+	return &Tracer{
+		tracer:     tp.Tracer(instrumentationName),
+		propagator: propagation.TraceContext{},
+	}
+}
+
+// NewOTLPProvider creates a TracerProvider that batches spans to the OTLP/gRPC
+// collector at endpoint, e.g. "http://localhost:4317"; an https URL connects
+// with TLS. The OTEL_EXPORTER_OTLP_* environment variables apply as usual.
+// Shut the provider down to flush pending spans.
+func NewOTLPProvider(ctx context.Context, endpoint, serviceName string) (*sdktrace.TracerProvider, error) {
+	exp, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("tracing: otlp exporter: %w", err)
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))),
+	), nil
+}
+
+// Run records a span for every completed call received from ch until ch is
+// closed. Progress events of in-flight streams are skipped.
+func (t *Tracer) Run(ch <-chan proxy.Event) {
+	for ev := range ch {
+		if ev.Phase == proxy.PhaseComplete {
+			t.Record(ev)
+		}
+	}
+}
+
+// Record records ev as a span covering the call, as a child of the client's
+// span when the request carried trace context.
+func (t *Tracer) Record(ev proxy.Event) {
+	ctx := context.Background()
+	if ev.RequestHeaders != nil {
+		ctx = t.propagator.Extract(ctx, propagation.HeaderCarrier(ev.RequestHeaders))
+	}
+
+	service, method := splitMethod(ev.Method)
+	attrs := []attribute.KeyValue{
+		rpcSystem(ev.Protocol),
+		semconv.RPCService(service),
+		semconv.RPCMethod(method),
+		semconv.RPCGRPCStatusCodeKey.Int64(int64(ev.Status)),
+		attribute.String("grpc_tap.call_type", ev.CallType.String()),
+		attribute.String("grpc_tap.protocol", ev.Protocol.String()),
+		attribute.String("grpc_tap.id", ev.ID),
+		attribute.Int64("grpc_tap.request_size", ev.RequestSize),
+		attribute.Int64("grpc_tap.response_size", ev.ResponseSize),
+	}
+	if ev.PeerAddr != "" {
+		attrs = append(attrs, semconv.ClientAddress(ev.PeerAddr))
+	}
+	if ev.Authority != "" {
+		attrs = append(attrs, semconv.ServerAddress(ev.Authority))
+	}
+	if ev.Upstream != "" {
+		attrs = append(attrs, attribute.String("grpc_tap.replay_upstream", ev.Upstream))
+	}
+
+	_, span := t.tracer.Start(ctx, strings.TrimPrefix(ev.Method, "/"),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithTimestamp(ev.StartTime),
+		trace.WithAttributes(attrs...),
+	)
+	if ev.Status != 0 {
+		span.SetStatus(codes.Error, ev.Error)
+	}
+	span.End(trace.WithTimestamp(ev.StartTime.Add(ev.Duration)))
+}
+
+// splitMethod splits "/pkg.Service/Method" into its service and method.
+func splitMethod(fullMethod string) (string, string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return "", service
+	}
+	return service, method
+}
+
+func rpcSystem(p proxy.Protocol) attribute.KeyValue {
+	if p == proxy.ProtocolConnect {
+		return semconv.RPCSystemConnectRPC
+	}
+	return semconv.RPCSystemGRPC
+}
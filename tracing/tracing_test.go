@@ -0,0 +1,139 @@
+package tracing_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/mickamy/grpc-tap/proxy"
+	"github.com/mickamy/grpc-tap/tracing"
+)
+
+func newTracer(t *testing.T) (*tracing.Tracer, *tracetest.InMemoryExporter) {
+	t.Helper()
+
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	t.Cleanup(func() { _ = tp.Shutdown(t.Context()) })
+	return tracing.New(tp), exp
+}
+
+func attrs(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value, len(span.Attributes))
+	for _, kv := range span.Attributes {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestTracer_Record(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	ev := proxy.Event{
+		ID:        "ev-1",
+		Phase:     proxy.PhaseComplete,
+		Method:    "/users.v1.UserService/GetUser",
+		CallType:  proxy.Unary,
+		Protocol:  proxy.ProtocolGRPC,
+		StartTime: start,
+		Duration:  25 * time.Millisecond,
+		Status:    5,
+		Error:     "user not found",
+		PeerAddr:  "10.0.0.7:51234",
+		Authority: "users.internal",
+		RequestHeaders: http.Header{
+			"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		},
+	}
+
+	t.Run("span", func(t *testing.T) {
+		t.Parallel()
+		tr, exp := newTracer(t)
+		tr.Record(ev)
+
+		spans := exp.GetSpans()
+		if len(spans) != 1 {
+			t.Fatalf("got %d spans, want 1", len(spans))
+		}
+		span := spans[0]
+		if span.Name != "users.v1.UserService/GetUser" || span.SpanKind != trace.SpanKindServer {
+			t.Errorf("span name/kind = %q/%v", span.Name, span.SpanKind)
+		}
+		if !span.StartTime.Equal(start) || span.EndTime.Sub(span.StartTime) != ev.Duration {
+			t.Errorf("span covers %v..%v, want the call", span.StartTime, span.EndTime)
+		}
+		if span.Status.Code != codes.Error || span.Status.Description != "user not found" {
+			t.Errorf("span status = %v, want the call's error", span.Status)
+		}
+
+		got := attrs(span)
+		want := map[attribute.Key]attribute.Value{
+			"rpc.system":           attribute.StringValue("grpc"),
+			"rpc.service":          attribute.StringValue("users.v1.UserService"),
+			"rpc.method":           attribute.StringValue("GetUser"),
+			"rpc.grpc.status_code": attribute.Int64Value(5),
+			"client.address":       attribute.StringValue("10.0.0.7:51234"),
+			"server.address":       attribute.StringValue("users.internal"),
+			"grpc_tap.call_type":   attribute.StringValue("Unary"),
+		}
+		for k, v := range want {
+			if got[k] != v {
+				t.Errorf("attribute %s = %v, want %v", k, got[k].Emit(), v.Emit())
+			}
+		}
+	})
+
+	t.Run("joins the client's trace", func(t *testing.T) {
+		t.Parallel()
+		tr, exp := newTracer(t)
+		tr.Record(ev)
+
+		span := exp.GetSpans()[0]
+		if got := span.SpanContext.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("trace ID = %s, want the client's", got)
+		}
+		if got := span.Parent.SpanID().String(); got != "00f067aa0ba902b7" || !span.Parent.IsRemote() {
+			t.Errorf("parent = %s (remote %v), want the client's span", got, span.Parent.IsRemote())
+		}
+	})
+
+	t.Run("new trace without context", func(t *testing.T) {
+		t.Parallel()
+		tr, exp := newTracer(t)
+		plain := ev
+		plain.RequestHeaders = nil
+		plain.Status, plain.Error = 0, ""
+		tr.Record(plain)
+
+		span := exp.GetSpans()[0]
+		if span.Parent.IsValid() {
+			t.Errorf("parent = %v, want a root span", span.Parent)
+		}
+		if span.Status.Code == codes.Error {
+			t.Error("successful call recorded as an error")
+		}
+	})
+}
+
+func TestTracer_Run(t *testing.T) {
+	t.Parallel()
+
+	tr, exp := newTracer(t)
+	ch := make(chan proxy.Event, 3)
+	ch <- proxy.Event{ID: "1", Phase: proxy.PhaseStart, Method: "/pkg.Svc/Stream"}
+	ch <- proxy.Event{ID: "1", Phase: proxy.PhaseProgress, Method: "/pkg.Svc/Stream"}
+	ch <- proxy.Event{ID: "1", Phase: proxy.PhaseComplete, Method: "/pkg.Svc/Stream"}
+	close(ch)
+	tr.Run(ch)
+
+	if spans := exp.GetSpans(); len(spans) != 1 {
+		t.Errorf("got %d spans, want one per completed call", len(spans))
+	}
+}
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"fmt"
+	"maps"
 	"sort"
 	"strconv"
 	"strings"
@@ -9,6 +10,8 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
 )
 
 type analyticsSortMode int
@@ -48,12 +51,58 @@ func (s analyticsSortMode) next() analyticsSortMode {
 	return analyticsSortTotalDuration
 }
 
+// analyticsWindows are the time windows the analytics view cycles through.
+// Zero means all events.
+var analyticsWindows = []time.Duration{0, time.Minute, 5 * time.Minute, 15 * time.Minute}
+
+// nextAnalyticsWindow returns the window after w in analyticsWindows.
+func nextAnalyticsWindow(w time.Duration) time.Duration {
+	for i, cur := range analyticsWindows {
+		if cur == w {
+			return analyticsWindows[(i+1)%len(analyticsWindows)]
+		}
+	}
+	return analyticsWindows[0]
+}
+
+// windowLabel renders a time window for titles, e.g. "5m" or "all".
+func windowLabel(w time.Duration) string {
+	if w <= 0 {
+		return "all"
+	}
+	s := w.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// windowStart returns the earliest start time within window of now, or the
+// zero time when there is no window.
+func windowStart(now time.Time, window time.Duration) time.Time {
+	if window <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-window)
+}
+
+// inWindow reports whether ev started at or after since. A zero since
+// includes every event.
+func inWindow(ev *tapv1.GRPCEvent, since time.Time) bool {
+	return since.IsZero() || !ev.GetStartTime().AsTime().Before(since)
+}
+
 type analyticsRow struct {
 	method        string
+	category      category
 	count         int
 	errors        int
 	totalDuration time.Duration
 	avgDuration   time.Duration
+	codes         map[int32]int // error count by status code
 }
 
 func (r analyticsRow) errorRate() float64 {
@@ -63,47 +112,151 @@ func (r analyticsRow) errorRate() float64 {
 	return float64(r.errors) / float64(r.count) * 100
 }
 
-func (m Model) buildAnalyticsRows() []analyticsRow {
-	type agg struct {
-		count    int
-		errors   int
-		totalDur time.Duration
+// methodTotals aggregates the finished calls to one method.
+type methodTotals struct {
+	count    int
+	errors   int
+	totalDur time.Duration
+	codes    map[int32]int // error count by status code
+}
+
+// analyticsTotals aggregates finished calls by method. The Model keeps one
+// for all its events up to date as they arrive and leave, so the analytics
+// view opens without scanning them.
+type analyticsTotals map[string]*methodTotals
+
+// add counts ev once more (delta 1) or once less (delta -1). Calls still in
+// flight and calls without a method are not counted.
+func (t analyticsTotals) add(ev *tapv1.GRPCEvent, delta int) {
+	method := ev.GetMethod()
+	if method == "" || inFlight(ev) {
+		return
 	}
-	groups := make(map[string]*agg)
+	g, ok := t[method]
+	if !ok {
+		g = &methodTotals{codes: make(map[int32]int)}
+		t[method] = g
+	}
+	g.count += delta
+	g.totalDur += time.Duration(delta) * ev.GetDuration().AsDuration()
+	if status := ev.GetStatus(); status != 0 {
+		g.errors += delta
+		if g.codes[status] += delta; g.codes[status] == 0 {
+			delete(g.codes, status)
+		}
+	}
+	if g.count == 0 {
+		delete(t, method)
+	}
+}
 
+// scanTotals aggregates the events started at or after since, which the
+// running totals cannot tell apart from older ones.
+func (m Model) scanTotals(since time.Time) analyticsTotals {
+	t := make(analyticsTotals)
 	for _, ev := range m.events {
-		method := ev.GetMethod()
-		if method == "" {
-			continue
+		if inWindow(ev, since) {
+			t.add(ev, 1)
 		}
+	}
+	return t
+}
 
-		g, ok := groups[method]
-		if !ok {
-			g = &agg{}
-			groups[method] = g
-		}
-		g.count++
-		g.totalDur += ev.GetDuration().AsDuration()
-		if ev.GetStatus() != 0 {
-			g.errors++
-		}
+func (m Model) buildAnalyticsRows() []analyticsRow {
+	totals := m.analyticsTotals
+	if m.analyticsWindow > 0 {
+		totals = m.scanTotals(windowStart(time.Now(), m.analyticsWindow))
 	}
 
-	rows := make([]analyticsRow, 0, len(groups))
-	for method, g := range groups {
+	rows := make([]analyticsRow, 0, len(totals))
+	for method, g := range totals {
+		if m.hiddenMethod(method) {
+			continue
+		}
 		rows = append(rows, analyticsRow{
 			method:        method,
+			category:      m.categorize(method),
 			count:         g.count,
 			errors:        g.errors,
 			totalDuration: g.totalDur,
 			avgDuration:   g.totalDur / time.Duration(g.count),
+			codes:         maps.Clone(g.codes),
 		})
 	}
 	return rows
 }
 
+// analyticsCodeRow counts the calls of one method that failed with one
+// status code, for the analytics breakdown by code.
+type analyticsCodeRow struct {
+	method   string
+	category category
+	code     int32
+	count    int
+	calls    int // all calls of the method
+}
+
+// share returns the share of the method's calls that failed with the code,
+// in percent.
+func (r analyticsCodeRow) share() float64 {
+	return float64(r.count) / float64(r.calls) * 100
+}
+
+// buildAnalyticsCodeRows breaks the errors of rows down by status code, most
+// frequent first, with infra methods after the app methods.
+func buildAnalyticsCodeRows(rows []analyticsRow) []analyticsCodeRow {
+	var out []analyticsCodeRow
+	for _, r := range rows {
+		for code, n := range r.codes {
+			out = append(out, analyticsCodeRow{
+				method:   r.method,
+				category: r.category,
+				code:     code,
+				count:    n,
+				calls:    r.count,
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		switch {
+		case a.category != b.category:
+			return a.category < b.category
+		case a.count != b.count:
+			return a.count > b.count
+		case a.method != b.method:
+			return a.method < b.method
+		}
+		return a.code < b.code
+	})
+	return out
+}
+
+// analyticsLen returns the number of rows of the analytics view shown.
+func (m Model) analyticsLen() int {
+	if m.analyticsByCode {
+		return len(m.analyticsCodeRows)
+	}
+	return len(m.analyticsRows)
+}
+
+// refreshAnalytics rebuilds and sorts the analytics rows and puts the cursor
+// back on the first row.
+func (m Model) refreshAnalytics() Model {
+	m.analyticsRows = m.buildAnalyticsRows()
+	sortAnalyticsRows(m.analyticsRows, m.analyticsSortMode)
+	m.analyticsCodeRows = buildAnalyticsCodeRows(m.analyticsRows)
+	m.analyticsCursor = 0
+	return m
+}
+
+// sortAnalyticsRows sorts rows by mode, keeping infra methods grouped after
+// the app methods.
 func sortAnalyticsRows(rows []analyticsRow, mode analyticsSortMode) {
 	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].category != rows[j].category {
+			return rows[i].category < rows[j].category
+		}
 		switch mode {
 		case analyticsSortTotalDuration:
 			return rows[i].totalDuration > rows[j].totalDuration
@@ -119,42 +272,56 @@ func sortAnalyticsRows(rows []analyticsRow, mode analyticsSortMode) {
 }
 
 func (m Model) updateAnalytics(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "ctrl+c":
-		if m.conn != nil {
-			_ = m.conn.Close()
-		}
-		return m, tea.Quit
-	case "q":
+	k := m.keys
+	switch {
+	case k.forceQuit.matches(msg):
+		return m.quit()
+	case k.back.matches(msg):
 		m.view = viewList
 		m.displayRows = m.rebuildDisplayRows()
 		if m.follow {
 			m.cursor = max(len(m.displayRows)-1, 0)
 		}
 		return m, nil
-	case "j", "down":
-		if len(m.analyticsRows) > 0 && m.analyticsCursor < len(m.analyticsRows)-1 {
+	case k.down.matches(msg):
+		if m.analyticsCursor < m.analyticsLen()-1 {
 			m.analyticsCursor++
 		}
 		return m, nil
-	case "k", "up":
+	case k.up.matches(msg):
 		if m.analyticsCursor > 0 {
 			m.analyticsCursor--
 		}
 		return m, nil
-	case "ctrl+d", "pgdown":
-		half := m.analyticsVisibleRows() / 2
-		m.analyticsCursor = min(m.analyticsCursor+half, max(len(m.analyticsRows)-1, 0))
+	case k.halfPageDown.matches(msg):
+		half := max(m.analyticsVisibleRows()/2, 1)
+		m.analyticsCursor = min(m.analyticsCursor+half, max(m.analyticsLen()-1, 0))
 		return m, nil
-	case "ctrl+u", "pgup":
-		half := m.analyticsVisibleRows() / 2
+	case k.halfPageUp.matches(msg):
+		half := max(m.analyticsVisibleRows()/2, 1)
 		m.analyticsCursor = max(m.analyticsCursor-half, 0)
 		return m, nil
-	case "s":
+	case k.top.matches(msg):
+		m.analyticsCursor = 0
+		return m, nil
+	case k.bottom.matches(msg):
+		m.analyticsCursor = max(m.analyticsLen()-1, 0)
+		return m, nil
+	case k.help.matches(msg):
+		m.showHelp = true
+		return m, nil
+	case k.analyticsSort.matches(msg):
 		m.analyticsSortMode = m.analyticsSortMode.next()
 		sortAnalyticsRows(m.analyticsRows, m.analyticsSortMode)
 		m.analyticsCursor = 0
 		return m, nil
+	case k.analyticsWindow.matches(msg):
+		m.analyticsWindow = nextAnalyticsWindow(m.analyticsWindow)
+		return m.refreshAnalytics(), nil
+	case k.analyticsCodes.matches(msg):
+		m.analyticsByCode = !m.analyticsByCode
+		m.analyticsCursor = 0
+		return m, nil
 	}
 	return m, nil
 }
@@ -165,6 +332,8 @@ const (
 	analyticsColErrors = 8
 	analyticsColAvg    = 10
 	analyticsColTotal  = 10
+	analyticsColShare  = 6
+	analyticsColCode   = 21 // fits FailedPrecondition(9), the longest status
 )
 
 func (m Model) analyticsVisibleRows() int {
@@ -172,11 +341,27 @@ func (m Model) analyticsVisibleRows() int {
 }
 
 func (m Model) renderAnalytics() string {
-	innerWidth := max(m.width-4, 20)
+	innerWidth := m.innerWidth()
 	visibleRows := m.analyticsVisibleRows()
 
-	title := fmt.Sprintf(" Analytics (%d methods) [sort: %s] ", len(m.analyticsRows), m.analyticsSortMode)
+	title := fmt.Sprintf(" Analytics (%d methods) [sort: %s] [window: %s] ",
+		len(m.analyticsRows), m.analyticsSortMode, windowLabel(m.analyticsWindow))
+	var rows []string
+	if m.analyticsByCode {
+		title = fmt.Sprintf(" Analytics by code (%d) [window: %s] ",
+			len(m.analyticsCodeRows), windowLabel(m.analyticsWindow))
+		rows = m.analyticsCodeLines(innerWidth, visibleRows)
+	} else {
+		rows = m.analyticsMethodLines(innerWidth, visibleRows)
+	}
+	k := m.keys
+	help := fmt.Sprintf(" %s: back  %s/%s: scroll  %s: sort  %s: by code  %s: help ",
+		k.back.key(), k.down.key(), k.up.key(), k.analyticsSort.key(), k.analyticsCodes.key(), k.help.key())
+	return boxWith(strings.Join(rows, "\n"), innerWidth, lipgloss.Color("240"), title, help)
+}
 
+// analyticsMethodLines renders the per-method table of the analytics view.
+func (m Model) analyticsMethodLines(innerWidth, visibleRows int) []string {
 	fixedCols := analyticsColMarker + analyticsColCount + analyticsColErrors + analyticsColAvg + analyticsColTotal + 4
 	colMethod := max(innerWidth-fixedCols, 10)
 
@@ -199,8 +384,7 @@ func (m Model) renderAnalytics() string {
 	}
 	end := min(start+dataRows, len(m.analyticsRows))
 
-	var rows []string
-	rows = append(rows, lipgloss.NewStyle().Bold(true).Render(header))
+	rows := []string{lipgloss.NewStyle().Bold(true).Render(header)}
 	for i := start; i < end; i++ {
 		r := m.analyticsRows[i]
 		marker := "  "
@@ -210,8 +394,11 @@ func (m Model) renderAnalytics() string {
 
 		method := truncate(r.method, colMethod)
 
+		dim := i != m.analyticsCursor && r.category == categoryInfra
 		errStr := strconv.Itoa(r.errors)
-		if r.errors > 0 {
+		if r.errors > 0 && dim {
+			errStr = fmt.Sprintf("%d(%.0f%%)", r.errors, r.errorRate())
+		} else if r.errors > 0 {
 			errStr = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render(
 				fmt.Sprintf("%d(%.0f%%)", r.errors, r.errorRate()),
 			)
@@ -221,43 +408,66 @@ func (m Model) renderAnalytics() string {
 			marker,
 			analyticsColCount, r.count,
 			padLeft(errStr, analyticsColErrors),
-			padLeft(formatDurationValue(r.avgDuration), analyticsColAvg),
-			padLeft(formatDurationValue(r.totalDuration), analyticsColTotal),
+			padLeft(m.durationUnit.format(r.avgDuration), analyticsColAvg),
+			padLeft(m.durationUnit.format(r.totalDuration), analyticsColTotal),
 			method,
 		)
 		if i == m.analyticsCursor {
 			row = lipgloss.NewStyle().Bold(true).Render(row)
+		} else if dim {
+			row = lipgloss.NewStyle().Faint(true).Render(row)
 		}
 		rows = append(rows, row)
 	}
+	return rows
+}
 
-	content := strings.Join(rows, "\n")
-
-	borderColor := lipgloss.Color("240")
-	box := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		Width(innerWidth).
-		BorderForeground(borderColor).
-		Render(content)
-
-	boxLines := strings.Split(box, "\n")
-	if len(boxLines) > 0 {
-		borderFg := lipgloss.NewStyle().Foreground(borderColor)
-		titleStyle := lipgloss.NewStyle().Bold(true)
-		dashes := max(innerWidth-len([]rune(title)), 0)
-		boxLines[0] = borderFg.Render("╭") +
-			titleStyle.Render(title) +
-			borderFg.Render(strings.Repeat("─", dashes)+"╮")
-	}
+// analyticsCodeLines renders the breakdown of errors by method and status
+// code.
+func (m Model) analyticsCodeLines(innerWidth, visibleRows int) []string {
+	colMethod := max(innerWidth-analyticsColMarker-analyticsColCount-analyticsColShare-analyticsColCode-3, 10)
+	header := fmt.Sprintf("  %*s %*s %-*s %s",
+		analyticsColCount, "Count",
+		analyticsColShare, "Share",
+		analyticsColCode, "Code",
+		"Method",
+	)
+	rows := []string{lipgloss.NewStyle().Bold(true).Render(header)}
 
-	if n := len(boxLines); n > 0 {
-		borderFg := lipgloss.NewStyle().Foreground(borderColor)
-		help := " q: back  j/k: scroll  s: sort "
-		dashes := max(innerWidth-len([]rune(help)), 0)
-		boxLines[n-1] = borderFg.Render("╰") +
-			lipgloss.NewStyle().Faint(true).Render(help) +
-			borderFg.Render(strings.Repeat("─", dashes)+"╯")
+	dataRows := max(visibleRows-1, 1)
+	start := 0
+	if len(m.analyticsCodeRows) > dataRows {
+		start = max(m.analyticsCursor-dataRows/2, 0)
+		if start+dataRows > len(m.analyticsCodeRows) {
+			start = len(m.analyticsCodeRows) - dataRows
+		}
 	}
+	end := min(start+dataRows, len(m.analyticsCodeRows))
 
-	return strings.Join(boxLines, "\n")
+	for i := start; i < end; i++ {
+		r := m.analyticsCodeRows[i]
+		marker := "  "
+		if i == m.analyticsCursor {
+			marker = "▶ "
+		}
+		dim := i != m.analyticsCursor && r.category == categoryInfra
+		code := padRight(statusString(r.code), analyticsColCode)
+		if !dim {
+			code = statusStyle(r.code).Render(code)
+		}
+		row := fmt.Sprintf("%s%*d %*s %s %s",
+			marker,
+			analyticsColCount, r.count,
+			analyticsColShare, fmt.Sprintf("%.0f%%", r.share()),
+			code,
+			truncate(r.method, colMethod),
+		)
+		if i == m.analyticsCursor {
+			row = lipgloss.NewStyle().Bold(true).Render(row)
+		} else if dim {
+			row = lipgloss.NewStyle().Faint(true).Render(row)
+		}
+		rows = append(rows, row)
+	}
+	return rows
 }
@@ -0,0 +1,210 @@
+package tui
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+func startedAt(ev *tapv1.GRPCEvent, t time.Time) *tapv1.GRPCEvent {
+	ev.StartTime = timestamppb.New(t)
+	return ev
+}
+
+func TestInWindow(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	since := windowStart(now, 5*time.Minute)
+
+	tests := []struct {
+		name  string
+		start time.Time
+		want  bool
+	}{
+		{name: "at boundary", start: since, want: true},
+		{name: "just inside", start: since.Add(time.Nanosecond), want: true},
+		{name: "just outside", start: since.Add(-time.Nanosecond), want: false},
+		{name: "now", start: now, want: true},
+		{name: "long ago", start: now.Add(-time.Hour), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ev := startedAt(testEvent("1", "/pkg.Svc/Get", 0, time.Millisecond), tt.start)
+			if got := inWindow(ev, since); got != tt.want {
+				t.Errorf("inWindow = %v, want %v", got, tt.want)
+			}
+			if !inWindow(ev, windowStart(now, 0)) {
+				t.Error("no window excluded an event")
+			}
+		})
+	}
+}
+
+func TestAnalyticsWindow(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	m := newTestModel(
+		startedAt(testEvent("1", "/pkg.Svc/Old", 0, time.Millisecond), now.Add(-10*time.Minute)),
+		startedAt(testEvent("2", "/pkg.Svc/Recent", 0, time.Millisecond), now.Add(-2*time.Minute)),
+		startedAt(testEvent("3", "/pkg.Svc/New", 0, time.Millisecond), now),
+	)
+	m = press(m, "a")
+
+	wants := []struct {
+		label string
+		rows  int
+	}{
+		{"1m", 1},
+		{"5m", 2},
+		{"15m", 3},
+		{"all", 3},
+	}
+	if len(m.analyticsRows) != 3 {
+		t.Fatalf("rows without window = %d, want 3", len(m.analyticsRows))
+	}
+	for _, w := range wants {
+		m = press(m, "t")
+		if got := windowLabel(m.analyticsWindow); got != w.label {
+			t.Errorf("window = %s, want %s", got, w.label)
+		}
+		if len(m.analyticsRows) != w.rows {
+			t.Errorf("window %s: rows = %d, want %d", w.label, len(m.analyticsRows), w.rows)
+		}
+		if view := m.renderAnalytics(); !strings.Contains(view, "[window: "+w.label+"]") {
+			t.Errorf("window %s: title missing from view", w.label)
+		}
+	}
+}
+
+func TestFilteredExportEvents_Since(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	events := []*tapv1.GRPCEvent{
+		startedAt(testEvent("1", "/pkg.Svc/Old", 0, time.Millisecond), now.Add(-time.Hour)),
+		startedAt(testEvent("2", "/pkg.Svc/Edge", 0, time.Millisecond), now.Add(-5*time.Minute)),
+		startedAt(testEvent("3", "/pkg.Svc/New", 0, time.Millisecond), now),
+	}
+
+	got := filteredExportEvents(events, exportFilter{since: windowStart(now, 5*time.Minute)})
+	if len(got) != 2 || got[0].GetId() != "2" || got[1].GetId() != "3" {
+		t.Errorf("exported %d events, want the boundary and newer ones", len(got))
+	}
+	if got := filteredExportEvents(events, exportFilter{}); len(got) != 3 {
+		t.Errorf("exported %d events without a window, want 3", len(got))
+	}
+
+	d := buildExportDataFromEvents(events, exportFilter{since: windowStart(now, 5*time.Minute)}, nil)
+	if d.Captured != 3 || d.Exported != 2 || d.Since == "" {
+		t.Errorf("captured/exported/since = %d/%d/%q, want 3/2/set", d.Captured, d.Exported, d.Since)
+	}
+	if len(d.Analytics) != 2 {
+		t.Errorf("analytics rows = %d, want 2", len(d.Analytics))
+	}
+}
+
+func TestAnalyticsByCode(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel(
+		testEvent("1", "/pkg.Svc/Get", 0, time.Millisecond),
+		testEvent("2", "/pkg.Svc/Get", 14, time.Millisecond),
+		testEvent("3", "/pkg.Svc/Get", 14, time.Millisecond),
+		testEvent("4", "/pkg.Svc/Get", 4, time.Millisecond),
+		testEvent("5", "/pkg.Svc/Put", 3, time.Millisecond),
+	)
+	m = press(press(m, "a"), "c")
+	if !m.analyticsByCode {
+		t.Fatal("c did not switch to the breakdown by code")
+	}
+
+	want := []struct {
+		method string
+		code   int32
+		count  int
+		share  float64
+	}{
+		{"/pkg.Svc/Get", 14, 2, 50},
+		{"/pkg.Svc/Get", 4, 1, 25},
+		{"/pkg.Svc/Put", 3, 1, 100},
+	}
+	if len(m.analyticsCodeRows) != len(want) {
+		t.Fatalf("code rows = %d, want %d", len(m.analyticsCodeRows), len(want))
+	}
+	for i, w := range want {
+		r := m.analyticsCodeRows[i]
+		if r.method != w.method || r.code != w.code || r.count != w.count || r.share() != w.share {
+			t.Errorf("row %d = %s %d ×%d (%.0f%%), want %s %d ×%d (%.0f%%)",
+				i, r.method, r.code, r.count, r.share(), w.method, w.code, w.count, w.share)
+		}
+	}
+
+	view := m.renderAnalytics()
+	for _, s := range []string{"Analytics by code (3)", "Unavailable", "DeadlineExceeded", "InvalidArgument"} {
+		if !strings.Contains(view, s) {
+			t.Errorf("view does not contain %q", s)
+		}
+	}
+
+	m = press(press(press(m, "j"), "j"), "j")
+	if m.analyticsCursor != 2 {
+		t.Errorf("cursor = %d, want it to stop on the last code row", m.analyticsCursor)
+	}
+	if m = press(m, "c"); m.analyticsByCode || m.analyticsCursor != 0 {
+		t.Error("c did not switch back to the method table")
+	}
+}
+
+func TestAnalyticsTotals_MatchScan(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel()
+	WithMaxEvents(40)(&m)
+	for i := range 100 {
+		// Every third event finishes a call started earlier, replacing its
+		// start event; the limit evicts the oldest.
+		id := strconv.Itoa(i)
+		ev := testEvent(id, "/pkg.Svc/M"+strconv.Itoa(i%4), int32(i%3), time.Duration(i)*time.Millisecond)
+		switch i % 3 {
+		case 1:
+			ev.Phase = tapv1.EventPhase_EVENT_PHASE_START
+		case 2:
+			ev.Id = strconv.Itoa(i - 1)
+		}
+		updated, _ := m.Update(eventMsg{Event: ev})
+		m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+		if i == 60 {
+			m.bookmarks = map[string]bool{"30": true}
+			m = m.clearEvents()
+		}
+		if want := m.scanTotals(time.Time{}); !reflect.DeepEqual(m.analyticsTotals, want) {
+			t.Fatalf("after event %d: totals differ from a scan", i)
+		}
+	}
+	if len(m.analyticsTotals) == 0 {
+		t.Fatal("no totals")
+	}
+}
+
+// BenchmarkRefreshAnalytics measures opening the analytics view over 100k
+// events of 50 methods.
+func BenchmarkRefreshAnalytics(b *testing.B) {
+	m := newTestModel()
+	for i := range 100_000 {
+		m, _ = m.upsertEvent(testEvent(strconv.Itoa(i), "/pkg.Svc/M"+strconv.Itoa(i%50), int32(i%3), time.Millisecond))
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = m.refreshAnalytics()
+	}
+}
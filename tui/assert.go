@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/mickamy/grpc-tap/compare"
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+// WithReplayAssert compares the response of every replay with the response
+// of the call it repeats, under mode, and shows PASS or FAIL with a diff in
+// the inspector of the replayed call.
+func WithReplayAssert(mode compare.Mode) Option {
+	return func(m *Model) {
+		m.assertReplays = true
+		m.assertMode = mode
+	}
+}
+
+// replayAssertion is the comparison of a replayed call with its original.
+type replayAssertion struct {
+	sourceID string
+	mode     compare.Mode
+	result   compare.Result
+}
+
+// assertReplay compares replayed with the call it repeats, sourceID, if
+// that call is still around.
+func (m Model) assertReplay(sourceID string, replayed *tapv1.GRPCEvent) Model {
+	i, ok := m.eventIdx[sourceID]
+	if !ok {
+		return m
+	}
+	source := m.events[i]
+	if m.assertions == nil {
+		m.assertions = make(map[string]replayAssertion)
+	}
+	m.assertions[replayed.GetId()] = replayAssertion{
+		sourceID: source.GetId(),
+		mode:     m.assertMode,
+		result: compare.Compare(m.assertMode,
+			compare.Response{Status: source.GetStatus(), Body: source.GetResponseBody()},
+			compare.Response{Status: replayed.GetStatus(), Body: replayed.GetResponseBody()},
+		),
+	}
+	return m
+}
+
+// lines renders the assertion for the inspector: the verdict, and for a
+// failure the diff of the decoded responses.
+func (a replayAssertion) lines() []string {
+	if a.result.Match {
+		pass := lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Render("PASS")
+		return []string{fmt.Sprintf("Assert:   %s (%s, vs %s)", pass, a.mode, a.sourceID)}
+	}
+	fail := lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render("FAIL")
+	lines := []string{fmt.Sprintf("Assert:   %s: %s (%s, vs %s)", fail, a.result.Reason, a.mode, a.sourceID)}
+	if a.result.Diff != nil {
+		lines = append(lines, "", "── Response Diff (- original, + replay) ──")
+		lines = append(lines, a.result.Diff...)
+	}
+	return lines
+}
@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/mickamy/grpc-tap/compare"
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+func replayed(m Model, ev *tapv1.GRPCEvent) Model {
+	updated, _ := m.Update(replayResultMsg{EventID: ev.GetId(), Event: ev})
+	m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+	updated, _ = m.Update(eventMsg{Event: ev})
+	return updated.(Model) //nolint:forcetypeassert // Update always returns Model
+}
+
+func TestReplayAssert(t *testing.T) {
+	t.Parallel()
+
+	original := testEvent("1", "/pkg.Svc/Get", 0, time.Millisecond)
+	original.ResponseBody = []byte{0x0a, 0x05, 'a', 'l', 'i', 'c', 'e'} // 1: "alice"
+
+	newModel := func() Model {
+		m := newTestModel(original)
+		WithReplayAssert(compare.Fields)(&m)
+		m.replaySourceID = "1"
+		return m
+	}
+
+	t.Run("pass", func(t *testing.T) {
+		t.Parallel()
+		ev := testEvent("2", "/pkg.Svc/Get", 0, time.Millisecond)
+		ev.ResponseBody = original.GetResponseBody()
+		m := replayed(newModel(), ev)
+
+		if m.view != viewInspect || m.cursorEvent().GetId() != "2" {
+			t.Fatal("replayed call not opened in the inspector")
+		}
+		lines := m.inspectLines(m.cursorEvent())
+		if !slices.ContainsFunc(lines, func(l string) bool { return ansi.Strip(l) == "Assert:   PASS (fields, vs 1)" }) {
+			t.Errorf("inspector lines = %q, want a PASS line", lines)
+		}
+	})
+
+	t.Run("fail with diff", func(t *testing.T) {
+		t.Parallel()
+		ev := testEvent("2", "/pkg.Svc/Get", 0, time.Millisecond)
+		ev.ResponseBody = []byte{0x0a, 0x03, 'b', 'o', 'b'}
+		m := replayed(newModel(), ev)
+
+		text := ansi.Strip(strings.Join(m.inspectLines(m.cursorEvent()), "\n"))
+		for _, want := range []string{
+			"Assert:   FAIL: response fields differ (fields, vs 1)",
+			`- 1: "alice"`,
+			`+ 1: "bob"`,
+		} {
+			if !strings.Contains(text, want) {
+				t.Errorf("inspector does not show %q:\n%s", want, text)
+			}
+		}
+	})
+
+	t.Run("off by default", func(t *testing.T) {
+		t.Parallel()
+		m := newTestModel(original)
+		m.replaySourceID = "1"
+		m = replayed(m, testEvent("2", "/pkg.Svc/Get", 13, time.Millisecond))
+		if strings.Contains(strings.Join(m.inspectLines(m.cursorEvent()), "\n"), "Assert:") {
+			t.Error("replay asserted without WithReplayAssert")
+		}
+	})
+}
@@ -0,0 +1,263 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+// ReplayPacing spaces the replays of a batch replay. The zero value sends
+// them as fast as possible.
+type ReplayPacing struct {
+	original bool          // keep the gaps between the start times of the calls
+	delay    time.Duration // a fixed gap, unless original
+}
+
+// ParseReplayPacing parses "fast", "original" or a fixed delay such as
+// "200ms".
+func ParseReplayPacing(s string) (ReplayPacing, error) {
+	switch s {
+	case "", "fast":
+		return ReplayPacing{}, nil
+	case "original":
+		return ReplayPacing{original: true}, nil
+	}
+	delay, err := time.ParseDuration(s)
+	if err != nil || delay < 0 {
+		return ReplayPacing{}, fmt.Errorf("unknown replay pacing %q (want fast, original or a delay such as 200ms)", s)
+	}
+	return ReplayPacing{delay: delay}, nil
+}
+
+// gaps returns how long to wait before sending each of calls, counted from
+// when the previous one was sent. The original gaps of calls listed out of
+// chronological order are zero.
+func (p ReplayPacing) gaps(calls []*tapv1.GRPCEvent) []time.Duration {
+	gaps := make([]time.Duration, len(calls))
+	for i := 1; i < len(calls); i++ {
+		if p.original {
+			gaps[i] = max(calls[i].GetStartTime().AsTime().Sub(calls[i-1].GetStartTime().AsTime()), 0)
+		} else {
+			gaps[i] = p.delay
+		}
+	}
+	return gaps
+}
+
+// WithReplayPacing sets how the replays of a batch replay are spaced.
+func WithReplayPacing(p ReplayPacing) Option {
+	return func(m *Model) {
+		m.batchPacing = p
+	}
+}
+
+// WithBatchConcurrency sets how many replays of a batch replay are in flight
+// at once. One, the default, replays the listed calls strictly in order.
+func WithBatchConcurrency(n int) Option {
+	return func(m *Model) {
+		m.batchConcurrency = n
+	}
+}
+
+// batchReplay is a replay of the unary calls listed, in list order. It only
+// starts once confirmed, as every call reaches the upstream again.
+type batchReplay struct {
+	seq        int                // tells the timers of this batch from those of earlier ones
+	confirming bool               // waiting for confirmation to start
+	running    bool               // replays are being sent or awaited
+	stopped    bool               // no further replays are sent
+	calls      []*tapv1.GRPCEvent // the calls to replay
+	gaps       []time.Duration    // wait before sending each call, after sending the previous one
+	skipped    int                // listed calls left out: streams, in flight or without a captured body
+	next       int                // index in calls of the next replay to send
+	waiting    bool               // a timer runs until the next replay is due
+	lastSent   time.Time          // when the last replay was sent
+	done       int                // replays answered
+	failed     int                // replays that returned an error
+	results    []string           // IDs of the replayed events, in the order they were answered
+}
+
+type batchResultMsg struct {
+	source string           // ID of the replayed call
+	event  *tapv1.GRPCEvent // the replayed event (nil on error)
+	err    error
+}
+
+// batchSendMsg is due when the next replay of batch seq is.
+type batchSendMsg struct{ seq int }
+
+// batchable reports whether ev can be replayed in a batch: a finished unary
+// call whose request body was captured.
+func batchable(ev *tapv1.GRPCEvent) bool {
+	return ev.GetCallType() == tapv1.CallType_CALL_TYPE_UNARY && !inFlight(ev) &&
+		!ev.GetBodyCaptureDisabled() && ev.GetMethod() != ""
+}
+
+// startBatch asks to replay the listed calls, or stops a running batch
+// replay.
+func (m Model) startBatch() (Model, tea.Cmd) {
+	if m.batch.running {
+		return m.stopBatch()
+	}
+	if m.client == nil {
+		return m, nil
+	}
+	b := batchReplay{seq: m.batch.seq + 1, confirming: true}
+	for _, idx := range m.displayRows {
+		if ev := m.events[idx]; batchable(ev) {
+			b.calls = append(b.calls, ev)
+		} else {
+			b.skipped++
+		}
+	}
+	if len(b.calls) == 0 {
+		return m.showAlert("no listed unary calls to replay")
+	}
+	b.gaps = m.batchPacing.gaps(b.calls)
+	m.batch = b
+	return m, nil
+}
+
+// updateBatchConfirm starts the batch replay on y and drops it on any other
+// key.
+func (m Model) updateBatchConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.batch.confirming = false
+	if msg.String() != "y" {
+		m.batch = batchReplay{seq: m.batch.seq}
+		return m, nil
+	}
+	m.batch.running = true
+	return m.fillBatch(time.Now())
+}
+
+// stopBatch sends no further replays of the batch; the replays in flight
+// are still awaited.
+func (m Model) stopBatch() (Model, tea.Cmd) {
+	m.batch.stopped = true
+	m.batch.waiting = false
+	if m.batch.done < m.batch.next {
+		return m.showAlert(fmt.Sprintf("stopping batch replay after %d of %d", m.batch.next, len(m.batch.calls)))
+	}
+	return m.finishBatch()
+}
+
+// fillBatch sends replays of the batch until as many as the concurrency
+// allows are in flight, or until the next replay is not due yet, in which
+// case it sets a timer for it.
+func (m Model) fillBatch(now time.Time) (Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	for m.batchCanSend() {
+		if wait := m.batch.gaps[m.batch.next] - now.Sub(m.batch.lastSent); m.batch.next > 0 && wait > 0 {
+			m.batch.waiting = true
+			seq := m.batch.seq
+			cmds = append(cmds, tea.Tick(wait, func(time.Time) tea.Msg { return batchSendMsg{seq: seq} }))
+			break
+		}
+		var cmd tea.Cmd
+		m, cmd = m.sendBatchReplay(now)
+		cmds = append(cmds, cmd)
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// batchCanSend reports whether another replay of the batch is to be sent
+// now or scheduled.
+func (m Model) batchCanSend() bool {
+	b := m.batch
+	return !b.stopped && !b.waiting && b.next < len(b.calls) && b.next-b.done < max(m.batchConcurrency, 1)
+}
+
+// sendBatchReplay sends the next replay of the batch.
+func (m Model) sendBatchReplay(now time.Time) (Model, tea.Cmd) {
+	ev := m.batch.calls[m.batch.next]
+	m.batch.next++
+	m.batch.lastSent = now
+	client := m.client
+	return m, func() tea.Msg {
+		resp, err := client.Replay(context.Background(), &tapv1.ReplayRequest{
+			Method:      ev.GetMethod(),
+			RequestBody: ev.GetRequestBody(),
+			Protocol:    ev.GetProtocol(),
+			ContentType: ev.GetContentType(),
+		})
+		if err != nil {
+			return batchResultMsg{source: ev.GetId(), err: fmt.Errorf("replay %s: %w", ev.GetMethod(), err)}
+		}
+		return batchResultMsg{source: ev.GetId(), event: resp.GetEvent()}
+	}
+}
+
+// batchSend sends the replay whose timer went off, and any others the
+// concurrency allows.
+func (m Model) batchSend(msg batchSendMsg) (Model, tea.Cmd) {
+	if msg.seq != m.batch.seq || !m.batch.waiting {
+		return m, nil
+	}
+	m.batch.waiting = false
+	now := time.Now()
+	m, cmd := m.sendBatchReplay(now)
+	m, more := m.fillBatch(now)
+	return m, tea.Batch(cmd, more)
+}
+
+// batchResult records the answer to a replay of the batch and sends the
+// next ones.
+func (m Model) batchResult(msg batchResultMsg) (Model, tea.Cmd) {
+	if !m.batch.running {
+		return m, nil
+	}
+	m.batch.done++
+	if msg.err != nil {
+		m.batch.failed++
+	} else {
+		m.batch.results = append(m.batch.results, msg.event.GetId())
+		if m.assertReplays {
+			m = m.assertReplay(msg.source, msg.event)
+		}
+	}
+	m, cmd := m.fillBatch(time.Now())
+	if cmd != nil {
+		return m, cmd
+	}
+	return m.finishBatch()
+}
+
+// finishBatch reports how the batch went once every replay sent is
+// answered and no other is to be sent.
+func (m Model) finishBatch() (Model, tea.Cmd) {
+	b := m.batch
+	if b.done < b.next || b.waiting || !b.stopped && b.next < len(b.calls) {
+		return m, nil
+	}
+	m.batch.running = false
+	summary := fmt.Sprintf("batch replay: %d replayed", len(b.results))
+	if b.failed > 0 {
+		summary += fmt.Sprintf(", %d failed", b.failed)
+	}
+	if b.next < len(b.calls) {
+		summary += fmt.Sprintf(", stopped after %d of %d", b.next, len(b.calls))
+	}
+	return m.showAlert(summary)
+}
+
+// batchFooter asks for confirmation of the batch replay.
+func (m Model) batchFooter() string {
+	footer := fmt.Sprintf("  replay %d unary calls to the upstream again", len(m.batch.calls))
+	if m.batch.skipped > 0 {
+		footer += fmt.Sprintf(", skipping %d other", m.batch.skipped)
+	}
+	return footer + "? [y/N]"
+}
+
+// batchProgress describes a running batch replay for the title.
+func (m Model) batchProgress() string {
+	progress := fmt.Sprintf("[replay %d/%d", m.batch.done, len(m.batch.calls))
+	if m.batch.failed > 0 {
+		progress += fmt.Sprintf(", %d failed", m.batch.failed)
+	}
+	return progress + "] "
+}
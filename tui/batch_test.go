@@ -0,0 +1,281 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+// batchClient answers each replay with an event named after the replayed
+// request body, and fails replays of the failing method.
+type batchClient struct {
+	tapv1.TapServiceClient
+	failing string
+
+	mu       sync.Mutex
+	requests []*tapv1.ReplayRequest
+}
+
+func (c *batchClient) Replay(
+	_ context.Context, req *tapv1.ReplayRequest, _ ...grpc.CallOption,
+) (*tapv1.ReplayResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, req)
+	if req.GetMethod() == c.failing {
+		return nil, errors.New("unavailable")
+	}
+	return &tapv1.ReplayResponse{Event: &tapv1.GRPCEvent{
+		Id:           "replay-" + string(req.GetRequestBody()),
+		ResponseBody: []byte("ok"),
+	}}, nil
+}
+
+func batchEvent(id, method string) *tapv1.GRPCEvent {
+	ev := testEvent(id, method, 0, time.Millisecond)
+	ev.CallType = tapv1.CallType_CALL_TYPE_UNARY
+	ev.RequestBody = []byte(id)
+	ev.ResponseBody = []byte("ok")
+	return ev
+}
+
+// runBatch confirms the batch replay m asks for and answers its replays
+// until none is left, returning the model and the replays answered.
+func runBatch(t *testing.T, m Model) (Model, int) {
+	t.Helper()
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+	pending := []tea.Cmd{cmd}
+	answered := 0
+	for len(pending) > 0 && m.batch.running {
+		cmd, pending = pending[0], pending[1:]
+		if cmd == nil {
+			continue
+		}
+		switch msg := cmd().(type) {
+		case tea.BatchMsg:
+			pending = append(pending, msg...)
+		case batchResultMsg:
+			answered++
+			updated, cmd = m.Update(msg)
+			m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+			// Once done, the only command left is the alert's timer.
+			if m.batch.running {
+				pending = append(pending, cmd)
+			}
+		default:
+			t.Fatalf("unexpected message %T", msg)
+		}
+	}
+	return m, answered
+}
+
+func TestBatchReplay(t *testing.T) {
+	t.Parallel()
+
+	stream := batchEvent("3", "/pkg.Svc/Watch")
+	stream.CallType = tapv1.CallType_CALL_TYPE_SERVER_STREAM
+	m := newTestModel(
+		batchEvent("1", "/pkg.Svc/Get"),
+		batchEvent("2", "/pkg.Svc/Fail"),
+		stream,
+		batchEvent("4", "/pkg.Svc/Get"),
+	)
+	client := &batchClient{failing: "/pkg.Svc/Fail"}
+	m.client = client
+	m.assertReplays = true
+
+	m = press(m, "R")
+	if !m.batch.confirming {
+		t.Fatal("R did not ask for confirmation")
+	}
+	if len(m.batch.calls) != 3 || m.batch.skipped != 1 {
+		t.Fatalf("batch of %d calls skipping %d, want 3 skipping 1", len(m.batch.calls), m.batch.skipped)
+	}
+	m, answered := runBatch(t, m)
+
+	if answered != 3 || m.batch.running {
+		t.Fatalf("answered %d replays, running = %v; want 3 and done", answered, m.batch.running)
+	}
+	var replayed []string
+	for _, req := range client.requests {
+		replayed = append(replayed, string(req.GetRequestBody()))
+	}
+	if want := []string{"1", "2", "4"}; !slices.Equal(replayed, want) {
+		t.Errorf("replayed %v, want %v in list order", replayed, want)
+	}
+	if want := []string{"replay-1", "replay-4"}; !slices.Equal(m.batch.results, want) {
+		t.Errorf("results = %v, want %v", m.batch.results, want)
+	}
+	if m.batch.failed != 1 {
+		t.Errorf("failed = %d, want 1", m.batch.failed)
+	}
+	if a, ok := m.assertions["replay-4"]; !ok || a.sourceID != "4" || !a.result.Match {
+		t.Errorf("assertion of replay-4 = %+v, want a match against 4", a)
+	}
+	if want := "batch replay: 2 replayed, 1 failed"; m.alertMessage != want {
+		t.Errorf("alert = %q, want %q", m.alertMessage, want)
+	}
+}
+
+func TestBatchReplay_Concurrency(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel(
+		batchEvent("1", "/pkg.Svc/Get"),
+		batchEvent("2", "/pkg.Svc/Get"),
+		batchEvent("3", "/pkg.Svc/Get"),
+	)
+	m.client = &batchClient{}
+	WithBatchConcurrency(2)(&m)
+
+	m = press(m, "R")
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	started := updated.(Model) //nolint:forcetypeassert // Update always returns Model
+	if started.batch.next != 2 {
+		t.Errorf("sent %d replays at once, want 2", started.batch.next)
+	}
+
+	m, answered := runBatch(t, m)
+	if answered != 3 || len(m.batch.results) != 3 {
+		t.Errorf("answered %d replays with %d results, want 3", answered, len(m.batch.results))
+	}
+}
+
+func TestBatchReplay_CancelAndStop(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel(batchEvent("1", "/pkg.Svc/Get"), batchEvent("2", "/pkg.Svc/Get"))
+	m.client = &batchClient{}
+
+	if m = press(press(m, "R"), "n"); m.batch.confirming || m.batch.running {
+		t.Fatal("n did not drop the batch replay")
+	}
+
+	m = press(press(m, "R"), "y")
+	if !m.batch.running || m.batch.next != 1 {
+		t.Fatalf("running = %v with %d sent, want one replay sent", m.batch.running, m.batch.next)
+	}
+	if m = press(m, "R"); !m.batch.stopped {
+		t.Fatal("R did not stop the running batch replay")
+	}
+	// The replay in flight is still counted, but no other is sent.
+	updated, _ := m.Update(batchResultMsg{source: "1", event: &tapv1.GRPCEvent{Id: "replay-1"}})
+	m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+	if m.batch.running || m.batch.next != 1 || len(m.batch.results) != 1 {
+		t.Errorf("after stopping: running = %v, sent %d, %d results; want done after 1",
+			m.batch.running, m.batch.next, len(m.batch.results))
+	}
+}
+
+func TestParseReplayPacing(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		want    ReplayPacing
+		wantErr bool
+	}{
+		{in: "", want: ReplayPacing{}},
+		{in: "fast", want: ReplayPacing{}},
+		{in: "original", want: ReplayPacing{original: true}},
+		{in: "250ms", want: ReplayPacing{delay: 250 * time.Millisecond}},
+		{in: "-1s", wantErr: true},
+		{in: "slow", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseReplayPacing(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseReplayPacing(%q) = %+v, %v; want %+v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestReplayPacing_Gaps(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	calls := make([]*tapv1.GRPCEvent, 0, 4)
+	for _, offset := range []time.Duration{0, 100 * time.Millisecond, 1500 * time.Millisecond, time.Second} {
+		ev := batchEvent("", "/pkg.Svc/Get")
+		ev.StartTime = timestamppb.New(base.Add(offset))
+		calls = append(calls, ev)
+	}
+
+	tests := []struct {
+		name   string
+		pacing ReplayPacing
+		want   []time.Duration
+	}{
+		{"fast", ReplayPacing{}, []time.Duration{0, 0, 0, 0}},
+		{"fixed", ReplayPacing{delay: 200 * time.Millisecond},
+			[]time.Duration{0, 200 * time.Millisecond, 200 * time.Millisecond, 200 * time.Millisecond}},
+		// The last call started before the one listed ahead of it.
+		{"original", ReplayPacing{original: true},
+			[]time.Duration{0, 100 * time.Millisecond, 1400 * time.Millisecond, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.pacing.gaps(calls); !slices.Equal(got, tt.want) {
+				t.Errorf("gaps = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchReplay_Paced(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel(
+		batchEvent("1", "/pkg.Svc/Get"),
+		batchEvent("2", "/pkg.Svc/Get"),
+		batchEvent("3", "/pkg.Svc/Get"),
+	)
+	m.client = &batchClient{}
+	WithReplayPacing(ReplayPacing{delay: time.Hour})(&m)
+	WithBatchConcurrency(3)(&m)
+
+	// Only the first replay goes out at once; the second waits for its timer.
+	m = press(press(m, "R"), "y")
+	if m.batch.next != 1 || !m.batch.waiting {
+		t.Fatalf("sent %d replays, waiting = %v; want 1 sent and waiting", m.batch.next, m.batch.waiting)
+	}
+	updated, _ := m.Update(batchSendMsg{seq: m.batch.seq - 1})
+	m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+	if m.batch.next != 1 {
+		t.Fatal("a timer of an earlier batch sent a replay")
+	}
+	updated, _ = m.Update(batchSendMsg{seq: m.batch.seq})
+	m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+	if m.batch.next != 2 || !m.batch.waiting {
+		t.Fatalf("after the timer: sent %d replays, waiting = %v; want 2 sent and waiting", m.batch.next, m.batch.waiting)
+	}
+
+	// Ctrl+C aborts the sequence instead of quitting.
+	if m = press(m, "ctrl+c"); !m.batch.stopped || m.batch.waiting {
+		t.Fatalf("ctrl+c: stopped = %v, waiting = %v; want stopped", m.batch.stopped, m.batch.waiting)
+	}
+	updated, _ = m.Update(batchSendMsg{seq: m.batch.seq})
+	m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+	for _, id := range []string{"1", "2"} {
+		updated, _ = m.Update(batchResultMsg{source: id, event: &tapv1.GRPCEvent{Id: "replay-" + id}})
+		m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+	}
+	if m.batch.running || m.batch.next != 2 {
+		t.Errorf("after aborting: running = %v with %d sent, want done after 2", m.batch.running, m.batch.next)
+	}
+	if want := "batch replay: 2 replayed, stopped after 2 of 3"; m.alertMessage != want {
+		t.Errorf("alert = %q, want %q", m.alertMessage, want)
+	}
+}
@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	_ "google.golang.org/genproto/googleapis/rpc/errdetails" // registers the detail types for decoding
+	statuspb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+// statusDetailsHeader carries a serialized google.rpc.Status with the error
+// details of a failed call.
+const statusDetailsHeader = "grpc-status-details-bin"
+
+// isBinaryHeader reports whether name is binary metadata, whose values are
+// base64-encoded on the wire.
+func isBinaryHeader(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), "-bin")
+}
+
+// decodeBinaryValue decodes a binary metadata value. gRPC implementations
+// send it with or without padding and must accept both.
+func decodeBinaryValue(v string) ([]byte, bool) {
+	enc := base64.RawStdEncoding
+	if v = strings.TrimSpace(v); strings.HasSuffix(v, "=") {
+		enc = base64.StdEncoding
+	}
+	data, err := enc.DecodeString(v)
+	return data, err == nil
+}
+
+// formatBinaryHeader renders the values of a binary header: status details
+// as the decoded google.rpc.Status, anything else as hex. Values are joined
+// with ", " as in the captured headers. ok is false if a value is not valid
+// base64, in which case only the raw form makes sense.
+func formatBinaryHeader(name, value string) (string, bool) {
+	var parts []string
+	for v := range strings.SplitSeq(value, ",") {
+		data, ok := decodeBinaryValue(v)
+		if !ok {
+			return "", false
+		}
+		if strings.EqualFold(name, statusDetailsHeader) {
+			if s, ok := formatStatusDetails(data); ok {
+				parts = append(parts, s)
+				continue
+			}
+		}
+		parts = append(parts, hex.EncodeToString(data))
+	}
+	return strings.Join(parts, ", "), true
+}
+
+// formatStatusDetails renders a serialized google.rpc.Status on one line.
+// Details of a known type are shown as text; others by type URL.
+func formatStatusDetails(data []byte) (string, bool) {
+	var st statuspb.Status
+	if err := proto.Unmarshal(data, &st); err != nil {
+		return "", false
+	}
+	s := fmt.Sprintf("code=%d message=%q", st.GetCode(), st.GetMessage())
+	for _, d := range st.GetDetails() {
+		msg, err := d.UnmarshalNew()
+		if err != nil {
+			s += " [" + d.GetTypeUrl() + "]"
+			continue
+		}
+		// prototext varies its spacing between runs; normalize it.
+		text := strings.Join(strings.Fields(prototext.Format(msg)), " ")
+		s += fmt.Sprintf(" [%s {%s}]", msg.ProtoReflect().Descriptor().FullName(), text)
+	}
+	return s, true
+}
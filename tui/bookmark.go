@@ -0,0 +1,110 @@
+package tui
+
+import (
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+// bookmarkMarker flags bookmarked events in the list's marker column.
+const bookmarkMarker = "★"
+
+// WithMaxEvents keeps at most n events, evicting the oldest ones that are not
+// bookmarked as new events arrive. Zero, the default, keeps every event.
+// Eviction rebuilds the list, so once n is exceeded a tenth of n is dropped
+// at once rather than one event per arrival.
+func WithMaxEvents(n int) Option {
+	return func(m *Model) {
+		m.maxEvents = max(n, 0)
+	}
+}
+
+// bookmarked reports whether ev is bookmarked.
+func (m Model) bookmarked(ev *tapv1.GRPCEvent) bool {
+	return m.bookmarks[ev.GetId()]
+}
+
+// bookmarkCount returns how many captured events are bookmarked.
+func (m Model) bookmarkCount() int {
+	n := 0
+	for _, ev := range m.events {
+		if m.bookmarked(ev) {
+			n++
+		}
+	}
+	return n
+}
+
+// toggleBookmark bookmarks the selected event, or removes its bookmark.
+func (m Model) toggleBookmark() Model {
+	ev := m.cursorEvent()
+	if ev == nil {
+		return m
+	}
+	if m.bookmarks == nil {
+		m.bookmarks = make(map[string]bool)
+	}
+	if m.bookmarks[ev.GetId()] {
+		delete(m.bookmarks, ev.GetId())
+	} else {
+		m.bookmarks[ev.GetId()] = true
+	}
+	if m.filterBookmarks {
+		m.displayRows = m.rebuildDisplayRows()
+		m.cursor = min(m.cursor, max(len(m.displayRows)-1, 0))
+	}
+	return m
+}
+
+// retain keeps the events for which keep returns true, in order, and remaps
+// eventIdx and displayRows to their new indices. Display rows, notes and
+// assertions of dropped events are removed.
+func (m Model) retain(keep func(ev *tapv1.GRPCEvent) bool) Model {
+	newIdx := make([]int, len(m.events))
+	events := make([]*tapv1.GRPCEvent, 0, len(m.events))
+	m.eventIdx = make(map[string]int, len(m.events))
+	for i, ev := range m.events {
+		if !keep(ev) {
+			newIdx[i] = -1
+			m.analyticsTotals.add(ev, -1)
+			delete(m.notes, ev.GetId())
+			delete(m.assertions, ev.GetId())
+			delete(m.responseChanges, ev.GetId())
+			continue
+		}
+		newIdx[i] = len(events)
+		m.eventIdx[ev.GetId()] = len(events)
+		events = append(events, ev)
+	}
+	m.events = events
+
+	// Keep the cursor on its event by moving it up past dropped rows above.
+	rows := make([]int, 0, len(m.displayRows))
+	cursor := m.cursor
+	for row, i := range m.displayRows {
+		switch {
+		case newIdx[i] >= 0:
+			rows = append(rows, newIdx[i])
+		case row < m.cursor:
+			cursor--
+		}
+	}
+	m.displayRows = rows
+	m.cursor = min(max(cursor, 0), max(len(m.displayRows)-1, 0))
+	return m
+}
+
+// evict drops the oldest events that are not bookmarked once there are more
+// than maxEvents, until a tenth of maxEvents is free again or only bookmarks
+// are left.
+func (m Model) evict() Model {
+	if m.maxEvents == 0 || len(m.events) <= m.maxEvents {
+		return m
+	}
+	excess := len(m.events) - (m.maxEvents - m.maxEvents/10)
+	return m.retain(func(ev *tapv1.GRPCEvent) bool {
+		if excess == 0 || m.bookmarked(ev) {
+			return true
+		}
+		excess--
+		return false
+	})
+}
@@ -0,0 +1,152 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func eventIDs(m Model) []string {
+	ids := make([]string, 0, len(m.events))
+	for _, ev := range m.events {
+		ids = append(ids, ev.GetId())
+	}
+	return ids
+}
+
+func TestBookmark_SurvivesClear(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel(
+		testEvent("1", "/pkg.Svc/A", 0, time.Millisecond),
+		testEvent("2", "/pkg.Svc/B", 0, time.Millisecond),
+		testEvent("3", "/pkg.Svc/C", 0, time.Millisecond),
+	)
+	m = press(press(m, "j"), "b") // bookmark 2
+	if !strings.Contains(m.renderListView(), bookmarkMarker) {
+		t.Error("list does not mark the bookmarked event")
+	}
+
+	m = press(press(m, "ctrl+l"), "y")
+	if got := strings.Join(eventIDs(m), ","); got != "2" {
+		t.Fatalf("events after clear = %s, want 2", got)
+	}
+	if len(m.displayRows) != 1 || m.cursorEvent().GetId() != "2" {
+		t.Errorf("rows after clear = %v, want the bookmarked event", m.displayRows)
+	}
+
+	m, _ = m.upsertEvent(testEvent("4", "/pkg.Svc/D", 0, time.Millisecond))
+	if i := m.eventIdx["4"]; m.events[i].GetId() != "4" {
+		t.Errorf("eventIdx[4] = %d, points at %s", i, m.events[i].GetId())
+	}
+
+	m = press(m, "b") // un-bookmark 2
+	m = press(press(m, "ctrl+l"), "y")
+	if len(m.events) != 0 {
+		t.Errorf("events after clearing without bookmarks = %v, want none", eventIDs(m))
+	}
+}
+
+func TestBookmark_SurvivesEviction(t *testing.T) {
+	t.Parallel()
+
+	m := New("localhost:9092", WithMaxEvents(3))
+	m.width, m.height = 80, 24
+	m, _ = m.upsertEvent(testEvent("1", "/pkg.Svc/A", 0, time.Millisecond))
+	m, _ = m.upsertEvent(testEvent("2", "/pkg.Svc/B", 0, time.Millisecond))
+	m.displayRows = m.rebuildDisplayRows()
+	m = press(m, "b") // bookmark 1
+
+	for _, id := range []string{"3", "4", "5"} {
+		var idx int
+		m, idx = m.upsertEvent(testEvent(id, "/pkg.Svc/"+id, 0, time.Millisecond))
+		if m.events[idx].GetId() != id {
+			t.Fatalf("upsertEvent(%s) returned index of %s", id, m.events[idx].GetId())
+		}
+	}
+	if got := strings.Join(eventIDs(m), ","); got != "1,4,5" {
+		t.Errorf("events = %s, want 1,4,5", got)
+	}
+	for id, i := range m.eventIdx {
+		if m.events[i].GetId() != id {
+			t.Errorf("eventIdx[%s] = %d, points at %s", id, i, m.events[i].GetId())
+		}
+	}
+
+	// Updates of retained events do not evict anything.
+	m, _ = m.upsertEvent(testEvent("4", "/pkg.Svc/4", 2, time.Millisecond))
+	if len(m.events) != 3 || m.events[m.eventIdx["4"]].GetStatus() != 2 {
+		t.Errorf("update of 4: events = %v", eventIDs(m))
+	}
+}
+
+func TestBookmark_KeepsCursorOnEviction(t *testing.T) {
+	t.Parallel()
+
+	m := New("localhost:9092", WithMaxEvents(3))
+	m.width, m.height = 80, 24
+	for _, id := range []string{"1", "2", "3"} {
+		m, _ = m.upsertEvent(testEvent(id, "/pkg.Svc/"+id, 0, time.Millisecond))
+	}
+	m.displayRows = m.rebuildDisplayRows()
+	m = press(m, "j")
+
+	updated, _ := m.Update(eventMsg{Event: testEvent("4", "/pkg.Svc/4", 0, time.Millisecond)})
+	m = updated.(Model)
+	if ev := m.cursorEvent(); ev.GetId() != "2" {
+		t.Errorf("cursor on %s after eviction, want 2", ev.GetId())
+	}
+}
+
+func TestBookmark_EvictsInBatches(t *testing.T) {
+	t.Parallel()
+
+	m := New("localhost:9092", WithMaxEvents(20))
+	add := func(from, to int) {
+		for i := from; i <= to; i++ {
+			id := strconv.Itoa(i)
+			m, _ = m.upsertEvent(testEvent(id, "/pkg.Svc/"+id, 0, time.Millisecond))
+		}
+	}
+
+	add(1, 21)
+	if len(m.events) != 18 || m.events[0].GetId() != "4" {
+		t.Fatalf("events after exceeding the limit = %v, want 4..21", eventIDs(m))
+	}
+	// The freed room is used up before anything else is evicted.
+	add(22, 23)
+	if len(m.events) != 20 || m.events[0].GetId() != "4" {
+		t.Errorf("events = %v, want 4..23", eventIDs(m))
+	}
+}
+
+func TestBookmark_Filter(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel(
+		testEvent("1", "/pkg.Svc/A", 0, time.Millisecond),
+		testEvent("2", "/pkg.Svc/B", 0, time.Millisecond),
+		testEvent("3", "/pkg.Svc/C", 0, time.Millisecond),
+	)
+	m = press(m, "b")
+	m = press(press(press(m, "j"), "j"), "b")
+
+	m = press(m, "B")
+	if len(m.displayRows) != 2 {
+		t.Errorf("bookmark filter: rows = %d, want 2", len(m.displayRows))
+	}
+	if !strings.Contains(m.renderListView(), "[bookmarks]") {
+		t.Error("title does not show the bookmark filter")
+	}
+
+	m = press(m, "b") // un-bookmark the selected event, which leaves the filter
+	if len(m.displayRows) != 1 || m.cursorEvent().GetId() != "1" {
+		t.Errorf("rows after removing a bookmark = %v, want only 1", m.displayRows)
+	}
+
+	m = press(m, "B")
+	if len(m.displayRows) != 3 {
+		t.Errorf("filter off: rows = %d, want 3", len(m.displayRows))
+	}
+}
@@ -0,0 +1,60 @@
+package tui
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+// responseChange tells how the response of a call compares with that of the
+// call of the same method that finished before it.
+type responseChange int
+
+const (
+	responseFirst   responseChange = iota // no earlier call to compare with
+	responseSame                          // same status and body as the earlier call
+	responseChanged                       // status or body differs
+)
+
+// changedMarker marks the rows whose response changed in changes mode.
+const changedMarker = "Δ"
+
+// responseHash hashes the status and response body of ev.
+func responseHash(ev *tapv1.GRPCEvent) uint64 {
+	h := fnv.New64a()
+	_ = binary.Write(h, binary.BigEndian, ev.GetStatus())
+	_, _ = h.Write(ev.GetResponseBody())
+	return h.Sum64()
+}
+
+// trackChange compares the response of ev, once it has finished, with the
+// latest response of its method. It is called by upsertEvent for every event,
+// so changes are known before changes mode is turned on.
+func (m Model) trackChange(ev *tapv1.GRPCEvent) {
+	if inFlight(ev) {
+		return
+	}
+	if _, ok := m.responseChanges[ev.GetId()]; ok {
+		return
+	}
+	hash := responseHash(ev)
+	change := responseFirst
+	if last, ok := m.lastResponses[ev.GetMethod()]; ok {
+		change = responseSame
+		if last != hash {
+			change = responseChanged
+		}
+	}
+	m.lastResponses[ev.GetMethod()] = hash
+	m.responseChanges[ev.GetId()] = change
+}
+
+// rowChange returns how the response of ev changed, or responseFirst when
+// changes mode is off.
+func (m Model) rowChange(ev *tapv1.GRPCEvent) responseChange {
+	if !m.changesMode {
+		return responseFirst
+	}
+	return m.responseChanges[ev.GetId()]
+}
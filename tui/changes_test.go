@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+func TestResponseChanges(t *testing.T) {
+	t.Parallel()
+
+	call := func(id, method string, status int32, body string) *tapv1.GRPCEvent {
+		ev := testEvent(id, method, status, time.Millisecond)
+		ev.ResponseBody = []byte(body)
+		return ev
+	}
+	start := testEvent("7", "/pkg.Svc/Poll", 0, 0)
+	start.Phase = tapv1.EventPhase_EVENT_PHASE_START
+
+	m := newTestModel(
+		call("1", "/pkg.Svc/Poll", 0, "a"),
+		call("2", "/pkg.Svc/Poll", 0, "a"),
+		call("3", "/pkg.Svc/Other", 0, "b"), // another method compares on its own
+		call("4", "/pkg.Svc/Poll", 0, "b"),
+		call("5", "/pkg.Svc/Poll", 0, "b"),
+		call("6", "/pkg.Svc/Poll", 14, "b"), // same body, another status
+		start,
+		call("7", "/pkg.Svc/Poll", 14, "b"), // the started call finishes
+		call("8", "/pkg.Svc/Poll", 0, "a"),
+	)
+
+	want := map[string]responseChange{
+		"1": responseFirst,
+		"2": responseSame,
+		"3": responseFirst,
+		"4": responseChanged,
+		"5": responseSame,
+		"6": responseChanged,
+		"7": responseSame,
+		"8": responseChanged,
+	}
+	for id, change := range want {
+		if got := m.responseChanges[id]; got != change {
+			t.Errorf("event %s: change = %d, want %d", id, got, change)
+		}
+	}
+
+	m.changesMode = true
+	m.height = 40
+	m.cursor = 0
+	var changed []string
+	lines := strings.Split(m.renderListView(), "\n")
+	for _, line := range lines[2 : 2+len(m.displayRows)] {
+		if strings.Contains(line, changedMarker) {
+			changed = append(changed, strings.TrimSpace(line))
+		}
+	}
+	if len(changed) != 3 {
+		t.Errorf("rows marked changed = %d, want 3:\n%s", len(changed), strings.Join(changed, "\n"))
+	}
+	if !strings.Contains(lines[0], "[changes]") {
+		t.Error("title does not show changes mode")
+	}
+	if press(m, "D").changesMode {
+		t.Error("D did not turn changes mode off")
+	}
+}
@@ -0,0 +1,250 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+// Column is a column of the list view.
+type Column int
+
+const (
+	// ColumnProto shows the protocol of the call and the codec its content
+	// type names, e.g. "Connect/json".
+	ColumnProto Column = iota
+	// ColumnMethod shows the full method. It takes the width the other
+	// columns leave and must always be shown.
+	ColumnMethod
+	// ColumnHost shows the authority the call addressed.
+	ColumnHost
+	// ColumnTrace shows the correlation ID of the call.
+	ColumnTrace
+	// ColumnType shows the call type: unary or which kind of stream.
+	ColumnType
+	// ColumnContentType shows the Content-Type of the request.
+	ColumnContentType
+	// ColumnStatus shows the status code of the call.
+	ColumnStatus
+	// ColumnDuration shows how long the call took.
+	ColumnDuration
+	// ColumnTime shows when the call started.
+	ColumnTime
+)
+
+// DefaultColumns are the list columns shown unless WithColumns says
+// otherwise. The type column is left out on terminals too narrow for it.
+var DefaultColumns = []Column{ColumnProto, ColumnMethod, ColumnType, ColumnStatus, ColumnDuration, ColumnTime}
+
+// columnDef describes how a column is laid out.
+type columnDef struct {
+	name     string // as ParseColumns reads it
+	header   string
+	width    int  // the method column takes what the others leave
+	right    bool // right-aligned
+	optional bool // left out, rather than squeezing the method column below listMinMethodWidth
+}
+
+var columnDefs = [...]columnDef{
+	ColumnProto:       {name: "proto", header: "Proto", width: listColProto},
+	ColumnMethod:      {name: "method", header: "Method"},
+	ColumnHost:        {name: "host", header: "Host", width: listColHost, optional: true},
+	ColumnTrace:       {name: "trace", header: "Trace", width: listColTrace, optional: true},
+	ColumnType:        {name: "type", header: "Type", width: listColType, optional: true},
+	ColumnContentType: {name: "content-type", header: "Content-Type", width: listColContentType, optional: true},
+	ColumnStatus:      {name: "status", header: "Status", width: listColStatus},
+	ColumnDuration:    {name: "duration", header: "Duration", width: listColDuration, right: true},
+	ColumnTime:        {name: "time", header: "Time", width: listColTime, right: true},
+}
+
+// ParseColumns parses a comma-separated list of column names, e.g.
+// "time,method,type,status", into the columns of the list view in that
+// order. The method column is required; an empty s selects DefaultColumns.
+func ParseColumns(s string) ([]Column, error) {
+	if strings.TrimSpace(s) == "" {
+		return DefaultColumns, nil
+	}
+	var cols []Column
+	for name := range strings.SplitSeq(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		i := slices.IndexFunc(columnDefs[:], func(d columnDef) bool { return d.name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown column %q (want %s)", name, columnNames())
+		}
+		if slices.Contains(cols, Column(i)) {
+			return nil, fmt.Errorf("column %q listed twice", name)
+		}
+		cols = append(cols, Column(i))
+	}
+	if !slices.Contains(cols, ColumnMethod) {
+		return nil, fmt.Errorf("columns %q leave out the method column", s)
+	}
+	return cols, nil
+}
+
+// columnNames lists the column names ParseColumns accepts.
+func columnNames() string {
+	names := make([]string, len(columnDefs))
+	for i, d := range columnDefs {
+		names[i] = d.name
+	}
+	return strings.Join(names, ", ")
+}
+
+// WithColumns sets the columns of the list view and their order, as parsed
+// by ParseColumns. WithHostColumn and WithTraceColumn add their column after
+// the method column when cols leaves it out.
+func WithColumns(cols []Column) Option {
+	return func(m *Model) {
+		m.columns = cols
+	}
+}
+
+// listColumns returns the columns the list view shows, before those that do
+// not fit are left out.
+func (m Model) listColumns() []Column {
+	cols := m.columns
+	if cols == nil {
+		cols = DefaultColumns
+	}
+	cols = slices.Clone(cols)
+	method := slices.Index(cols, ColumnMethod)
+	for _, extra := range []struct {
+		col Column
+		on  bool
+	}{{ColumnTrace, m.traceColumn}, {ColumnHost, m.hostColumn}} {
+		if extra.on && !slices.Contains(cols, extra.col) {
+			cols = slices.Insert(cols, method+1, extra.col)
+		}
+	}
+	return cols
+}
+
+// listLayout holds the columns the list view shows and their widths.
+type listLayout struct {
+	compact bool     // method + status only
+	columns []Column // in display order
+	widths  []int    // width of each of columns
+}
+
+// newListLayout lays out cols for the given inner width. The method column
+// takes the width the others leave. When that would squeeze it below
+// listMinMethodWidth, optional columns are left out, in the order cols lists
+// them; when the others alone do, it switches to a compact method + status
+// layout.
+func newListLayout(innerWidth int, cols []Column) listLayout {
+	method := innerWidth - listColMarker
+	for _, c := range cols {
+		if d := columnDefs[c]; c != ColumnMethod && !d.optional {
+			method -= d.width + 1
+		}
+	}
+	if method < listMinMethodWidth {
+		return listLayout{
+			compact: true,
+			columns: []Column{ColumnMethod, ColumnStatus},
+			widths:  []int{max(innerWidth-listColMarker-listColStatus-1, 1), listColStatus},
+		}
+	}
+
+	shown := make([]bool, len(cols))
+	for i, c := range cols {
+		d := columnDefs[c]
+		switch {
+		case !d.optional:
+			shown[i] = true
+		case method-d.width-1 >= listMinMethodWidth:
+			method -= d.width + 1
+			shown[i] = true
+		}
+	}
+	var l listLayout
+	for i, c := range cols {
+		if !shown[i] {
+			continue
+		}
+		l.columns = append(l.columns, c)
+		if c == ColumnMethod {
+			l.widths = append(l.widths, method)
+		} else {
+			l.widths = append(l.widths, columnDefs[c].width)
+		}
+	}
+	return l
+}
+
+// width returns the width of column c, or 0 when the layout leaves it out.
+func (l listLayout) width(c Column) int {
+	if i := slices.Index(l.columns, c); i >= 0 {
+		return l.widths[i]
+	}
+	return 0
+}
+
+// header renders the header row, aligned with the rows.
+func (l listLayout) header() string {
+	cells := make([]string, len(l.columns))
+	for i, c := range l.columns {
+		cells[i] = alignCell(columnDefs[c], columnDefs[c].header, l.widths[i])
+	}
+	return strings.Repeat(" ", listColMarker) + strings.Join(cells, " ")
+}
+
+// cells renders the cells of ev's row. The status cell is colored by
+// statusStyle, and every cell is bold on the cursor row.
+func (m Model) cells(l listLayout, ev *tapv1.GRPCEvent, cursor, dim bool) string {
+	cells := make([]string, len(l.columns))
+	for i, c := range l.columns {
+		text := truncate(m.cellText(c, ev), l.widths[i])
+		var style lipgloss.Style
+		styled := false
+		if c == ColumnStatus && !dim {
+			style, styled = statusStyle(ev.GetStatus()), true
+		}
+		if cursor {
+			style, styled = style.Bold(true), true
+		}
+		if styled {
+			text = style.Render(text)
+		}
+		cells[i] = alignCell(columnDefs[c], text, l.widths[i])
+	}
+	return strings.Join(cells, " ")
+}
+
+// cellText returns the unstyled text of column c for ev.
+func (m Model) cellText(c Column, ev *tapv1.GRPCEvent) string {
+	switch c {
+	case ColumnProto:
+		return protocolLabel(ev)
+	case ColumnMethod:
+		return ev.GetMethod()
+	case ColumnHost:
+		return ev.GetAuthority()
+	case ColumnTrace:
+		return ev.GetCorrelationId()
+	case ColumnType:
+		return callTypeString(ev.GetCallType())
+	case ColumnContentType:
+		return ev.GetContentType()
+	case ColumnStatus:
+		return eventStatusName(ev)
+	case ColumnDuration:
+		return m.durationUnit.formatProto(ev.GetDuration())
+	case ColumnTime:
+		return formatTime(ev.GetStartTime())
+	}
+	return ""
+}
+
+// alignCell pads s to width on the side d is aligned away from.
+func alignCell(d columnDef, s string, width int) string {
+	if d.right {
+		return padLeft(s, width)
+	}
+	return padRight(s, width)
+}
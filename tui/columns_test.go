@@ -0,0 +1,128 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+func TestParseColumns(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		want    []Column
+		wantErr bool
+	}{
+		{in: "", want: DefaultColumns},
+		{in: "method", want: []Column{ColumnMethod}},
+		{in: "time, Method ,type,status", want: []Column{ColumnTime, ColumnMethod, ColumnType, ColumnStatus}},
+		{in: "method,content-type", want: []Column{ColumnMethod, ColumnContentType}},
+		{in: "status,duration", wantErr: true}, // no method
+		{in: "method,method", wantErr: true},
+		{in: "method,size", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseColumns(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseColumns(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ParseColumns(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewListLayout_Columns(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		innerWidth int
+		cols       []Column
+		want       []Column
+		wantMethod int
+	}{
+		{
+			name:       "method only",
+			innerWidth: 40,
+			cols:       []Column{ColumnMethod},
+			want:       []Column{ColumnMethod},
+			wantMethod: 40 - listColMarker,
+		},
+		{
+			name:       "reordered",
+			innerWidth: 100,
+			cols:       []Column{ColumnTime, ColumnStatus, ColumnMethod},
+			want:       []Column{ColumnTime, ColumnStatus, ColumnMethod},
+			wantMethod: 100 - listColMarker - listColTime - listColStatus - 2,
+		},
+		{
+			name:       "type fits",
+			innerWidth: listFixedWidth + listMinMethodWidth + listColType + 1,
+			cols:       []Column{ColumnProto, ColumnType, ColumnMethod, ColumnStatus, ColumnDuration, ColumnTime},
+			want:       []Column{ColumnProto, ColumnType, ColumnMethod, ColumnStatus, ColumnDuration, ColumnTime},
+			wantMethod: listMinMethodWidth,
+		},
+		{
+			name:       "type left out",
+			innerWidth: listFixedWidth + listMinMethodWidth + listColType,
+			cols:       []Column{ColumnProto, ColumnType, ColumnMethod, ColumnStatus, ColumnDuration, ColumnTime},
+			want:       []Column{ColumnProto, ColumnMethod, ColumnStatus, ColumnDuration, ColumnTime},
+			wantMethod: listMinMethodWidth + listColType,
+		},
+		{
+			name:       "first optional column wins",
+			innerWidth: listFixedWidth + listMinMethodWidth + listColType + 1 + listColHost,
+			cols:       []Column{ColumnProto, ColumnMethod, ColumnType, ColumnHost, ColumnStatus, ColumnDuration, ColumnTime},
+			want:       []Column{ColumnProto, ColumnMethod, ColumnType, ColumnStatus, ColumnDuration, ColumnTime},
+			wantMethod: listMinMethodWidth + listColHost,
+		},
+		{
+			name:       "compact",
+			innerWidth: 50,
+			cols:       []Column{ColumnTime, ColumnMethod, ColumnType, ColumnDuration, ColumnStatus},
+			want:       []Column{ColumnMethod, ColumnStatus},
+			wantMethod: 50 - listColMarker - listColStatus - 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			l := newListLayout(tt.innerWidth, tt.cols)
+			if !slices.Equal(l.columns, tt.want) {
+				t.Errorf("columns = %v, want %v", l.columns, tt.want)
+			}
+			if got := l.width(ColumnMethod); got != tt.wantMethod {
+				t.Errorf("method width = %d, want %d", got, tt.wantMethod)
+			}
+			if got := rowWidth(l); got != tt.innerWidth {
+				t.Errorf("row width = %d, want the inner width %d", got, tt.innerWidth)
+			}
+		})
+	}
+}
+
+func TestColumnsRender(t *testing.T) {
+	t.Parallel()
+
+	ev := testEvent("1", "/pkg.Svc/Watch", 0, time.Millisecond)
+	ev.CallType = tapv1.CallType_CALL_TYPE_SERVER_STREAM
+	m := newTestModel(ev)
+	m.width = 120
+	WithColumns([]Column{ColumnType, ColumnMethod, ColumnStatus})(&m)
+
+	lines := strings.Split(m.renderListView(), "\n")
+	header, row := lines[1], lines[2]
+	if !strings.Contains(header, "Type") || strings.Contains(header, "Proto") || strings.Contains(header, "Time") {
+		t.Errorf("header = %q, want only the configured columns", header)
+	}
+	if typ, method := strings.Index(row, "ServerStream"), strings.Index(row, "/pkg.Svc/Watch"); typ < 0 || method < typ {
+		t.Errorf("row = %q, want the call type before the method", row)
+	}
+}
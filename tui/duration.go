@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// DurationUnit selects the unit durations are shown in.
+type DurationUnit int
+
+const (
+	// DurationAuto picks ns, µs, ms or s by magnitude.
+	DurationAuto DurationUnit = iota
+	// DurationMicros always shows µs.
+	DurationMicros
+	// DurationMillis always shows ms, so columns of durations line up.
+	DurationMillis
+	// DurationSeconds always shows s.
+	DurationSeconds
+)
+
+// ParseDurationUnit parses "auto", "us" (or "µs"), "ms" or "s".
+func ParseDurationUnit(s string) (DurationUnit, error) {
+	switch s {
+	case "", "auto":
+		return DurationAuto, nil
+	case "us", "µs":
+		return DurationMicros, nil
+	case "ms":
+		return DurationMillis, nil
+	case "s":
+		return DurationSeconds, nil
+	}
+	return DurationAuto, fmt.Errorf("unknown duration unit %q (want auto, us, ms or s)", s)
+}
+
+// WithDurationUnit sets the unit durations are shown in, in the TUI and in
+// Markdown exports.
+func WithDurationUnit(u DurationUnit) Option {
+	return func(m *Model) {
+		m.durationUnit = u
+	}
+}
+
+// format renders dur in unit u: µs without decimals, ms with one and s with
+// two. In auto mode, durations under a microsecond, zero included, are shown
+// in ns, durations under a millisecond in µs and durations under a second in
+// ms.
+func (u DurationUnit) format(dur time.Duration) string {
+	if u == DurationAuto {
+		switch {
+		case dur < time.Microsecond:
+			return fmt.Sprintf("%dns", dur.Nanoseconds())
+		case dur < time.Millisecond:
+			u = DurationMicros
+		case dur < time.Second:
+			u = DurationMillis
+		default:
+			u = DurationSeconds
+		}
+	}
+	switch u {
+	case DurationMicros:
+		return fmt.Sprintf("%.0fµs", float64(dur.Microseconds()))
+	case DurationMillis:
+		return fmt.Sprintf("%.1fms", float64(dur.Microseconds())/1000)
+	default:
+		return fmt.Sprintf("%.2fs", dur.Seconds())
+	}
+}
+
+// formatProto is format for a protobuf duration. A missing duration, as
+// opposed to a zero one, renders as "-".
+func (u DurationUnit) formatProto(d *durationpb.Duration) string {
+	if d == nil {
+		return "-"
+	}
+	return u.format(d.AsDuration())
+}
+
+// formatMs is format for a duration in milliseconds, as in exports.
+func (u DurationUnit) formatMs(ms float64) string {
+	return u.format(time.Duration(math.Round(ms * float64(time.Millisecond))))
+}
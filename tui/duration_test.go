@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestDurationUnit_Format(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		unit DurationUnit
+		dur  time.Duration
+		want string
+	}{
+		{name: "auto zero", unit: DurationAuto, dur: 0, want: "0ns"},
+		{name: "auto ns", unit: DurationAuto, dur: 1, want: "1ns"},
+		{name: "auto below µs", unit: DurationAuto, dur: 999, want: "999ns"},
+		{name: "auto at µs", unit: DurationAuto, dur: time.Microsecond, want: "1µs"},
+		{name: "auto below ms", unit: DurationAuto, dur: 999 * time.Microsecond, want: "999µs"},
+		{name: "auto at ms", unit: DurationAuto, dur: time.Millisecond, want: "1.0ms"},
+		{name: "auto below s", unit: DurationAuto, dur: 999900 * time.Microsecond, want: "999.9ms"},
+		{name: "auto at s", unit: DurationAuto, dur: time.Second, want: "1.00s"},
+		{name: "auto minutes", unit: DurationAuto, dur: 90 * time.Second, want: "90.00s"},
+		{name: "ms below ms", unit: DurationMillis, dur: 250 * time.Microsecond, want: "0.2ms"},
+		{name: "ms at ms", unit: DurationMillis, dur: time.Millisecond, want: "1.0ms"},
+		{name: "ms above s", unit: DurationMillis, dur: 1500 * time.Millisecond, want: "1500.0ms"},
+		{name: "us above ms", unit: DurationMicros, dur: 12 * time.Millisecond, want: "12000µs"},
+		{name: "s below ms", unit: DurationSeconds, dur: 500 * time.Microsecond, want: "0.00s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.unit.format(tt.dur); got != tt.want {
+				t.Errorf("format(%v) = %q, want %q", tt.dur, got, tt.want)
+			}
+			ms := float64(tt.dur) / float64(time.Millisecond)
+			if got := tt.unit.formatMs(ms); got != tt.want {
+				t.Errorf("formatMs(%v) = %q, want %q", ms, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDurationUnit_FormatProto(t *testing.T) {
+	t.Parallel()
+
+	if got := DurationMillis.formatProto(nil); got != "-" {
+		t.Errorf("formatProto(nil) = %q, want -", got)
+	}
+	if got := DurationAuto.formatProto(nil); got != "-" {
+		t.Errorf("formatProto(nil) = %q, want -", got)
+	}
+	if got := DurationAuto.formatProto(durationpb.New(0)); got != "0ns" {
+		t.Errorf("formatProto(0) = %q, want 0ns", got)
+	}
+	if got := DurationAuto.formatProto(durationpb.New(250 * time.Nanosecond)); got != "250ns" {
+		t.Errorf("formatProto(250ns) = %q, want 250ns", got)
+	}
+}
+
+func TestParseDurationUnit(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]DurationUnit{
+		"":     DurationAuto,
+		"auto": DurationAuto,
+		"us":   DurationMicros,
+		"µs":   DurationMicros,
+		"ms":   DurationMillis,
+		"s":    DurationSeconds,
+	} {
+		got, err := ParseDurationUnit(in)
+		if err != nil || got != want {
+			t.Errorf("ParseDurationUnit(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseDurationUnit("min"); err == nil {
+		t.Error("ParseDurationUnit(min) succeeded, want an error")
+	}
+}
+
+func TestWithDurationUnit_List(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel(
+		testEvent("1", "/pkg.Svc/Fast", 0, 300*time.Microsecond),
+		testEvent("2", "/pkg.Svc/Slow", 0, 2*time.Second),
+	)
+	WithDurationUnit(DurationMillis)(&m)
+	view := m.renderListView()
+	for _, want := range []string{"0.3ms", "2000.0ms"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("list does not show %q:\n%s", want, view)
+		}
+	}
+}
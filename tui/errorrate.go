@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// errorRateWindow is how far back the error rate of a method is taken.
+	errorRateWindow = time.Minute
+	// errorRateMinCalls is how many calls a method needs in the window before
+	// its error rate counts, so a single failed call does not raise an alert.
+	errorRateMinCalls = 5
+	// errorRateSettle is how long every method must stay at or below the
+	// threshold before a raised alert clears, so that it does not flap.
+	errorRateSettle = 10 * time.Second
+	// errorRateInterval is how often the error rates are checked. Rates are
+	// also checked when no calls arrive, as failures age out of the window.
+	errorRateInterval = 2 * time.Second
+)
+
+// WithErrorRateAlert shows a banner while the error rate of a method over the
+// last minute exceeds threshold, a share of its calls such as 0.05 for 5%.
+// Zero disables the alert.
+func WithErrorRateAlert(threshold float64) Option {
+	return func(m *Model) {
+		m.errorRate.threshold = threshold
+	}
+}
+
+// errorRateAlert tracks whether the error rate of any method is above the
+// threshold. The zero value never alerts.
+type errorRateAlert struct {
+	threshold float64   // share of failed calls that raises the alert; zero when off
+	method    string    // the method alerted on; empty when no alert is raised
+	rate      float64   // its share of failed calls
+	settling  time.Time // since when every method has been at or below the threshold
+}
+
+type errorRateTickMsg time.Time
+
+// errorRateTick schedules the next check of the error rates.
+func errorRateTick() tea.Cmd {
+	return tea.Tick(errorRateInterval, func(t time.Time) tea.Msg { return errorRateTickMsg(t) })
+}
+
+// update checks the error rates in totals at now. An alert is raised on the
+// method with the highest rate above the threshold and moves to another
+// method when that one's rate gets higher. It clears once every method has
+// stayed at or below the threshold for errorRateSettle.
+func (a errorRateAlert) update(now time.Time, totals analyticsTotals) errorRateAlert {
+	if a.threshold <= 0 {
+		return a
+	}
+	worst, worstRate := "", 0.0
+	for method, g := range totals {
+		if g.count < errorRateMinCalls {
+			continue
+		}
+		rate := float64(g.errors) / float64(g.count)
+		if rate > a.threshold && (rate > worstRate || (rate == worstRate && method < worst)) {
+			worst, worstRate = method, rate
+		}
+	}
+	if worst != "" {
+		a.method, a.rate, a.settling = worst, worstRate, time.Time{}
+		return a
+	}
+	if a.method == "" {
+		return a
+	}
+	switch {
+	case a.settling.IsZero():
+		a.settling = now
+	case now.Sub(a.settling) >= errorRateSettle:
+		return errorRateAlert{threshold: a.threshold}
+	}
+	a.rate = 0
+	if g, ok := totals[a.method]; ok {
+		a.rate = float64(g.errors) / float64(g.count)
+	}
+	return a
+}
+
+// message describes the alert for its banner.
+func (a errorRateAlert) message() string {
+	msg := fmt.Sprintf("error rate %.1f%% on %s in the last %s", a.rate*100, a.method, windowLabel(errorRateWindow))
+	if !a.settling.IsZero() {
+		msg += " (recovering)"
+	}
+	return msg
+}
+
+// checkErrorRate updates the error-rate alert with the calls started in the
+// last errorRateWindow, leaving out hidden internal methods.
+func (m Model) checkErrorRate(now time.Time) Model {
+	totals := m.scanTotals(windowStart(now, errorRateWindow))
+	for method := range totals {
+		if m.hiddenMethod(method) {
+			delete(totals, method)
+		}
+	}
+	m.errorRate = m.errorRate.update(now, totals)
+	return m
+}
@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rateTotals counts calls to method, failed of which failed.
+func rateTotals(t analyticsTotals, method string, calls, failed int) analyticsTotals {
+	for i := range calls {
+		status := int32(0)
+		if i < failed {
+			status = 14
+		}
+		t.add(testEvent(strconv.Itoa(i), method, status, time.Millisecond), 1)
+	}
+	return t
+}
+
+func TestErrorRateAlert_Update(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	a := errorRateAlert{threshold: 0.05}
+
+	a = a.update(now, rateTotals(make(analyticsTotals), "/pkg.Svc/Get", 4, 4))
+	if a.method != "" {
+		t.Errorf("alert raised on %s with fewer than %d calls", a.method, errorRateMinCalls)
+	}
+	a = a.update(now, rateTotals(make(analyticsTotals), "/pkg.Svc/Get", 20, 1))
+	if a.method != "" {
+		t.Errorf("alert raised on %s at the threshold", a.method)
+	}
+
+	// Crossing.
+	failing := rateTotals(rateTotals(make(analyticsTotals), "/pkg.Svc/Get", 10, 1), "/pkg.Svc/List", 10, 0)
+	a = a.update(now, failing)
+	if a.method != "/pkg.Svc/Get" || a.rate != 0.1 {
+		t.Fatalf("alert = %s at %v, want /pkg.Svc/Get at 0.1", a.method, a.rate)
+	}
+	if msg := a.message(); !strings.Contains(msg, "10.0%") || !strings.Contains(msg, "/pkg.Svc/Get") {
+		t.Errorf("message = %q, want the method and its rate", msg)
+	}
+	worse := rateTotals(rateTotals(make(analyticsTotals), "/pkg.Svc/Get", 10, 1), "/pkg.Svc/List", 10, 5)
+	if a = a.update(now, worse); a.method != "/pkg.Svc/List" {
+		t.Errorf("alert on %s, want it to move to the worse /pkg.Svc/List", a.method)
+	}
+
+	// Recovery only clears after errorRateSettle below the threshold.
+	recovered := rateTotals(make(analyticsTotals), "/pkg.Svc/List", 40, 1)
+	a = a.update(now.Add(time.Second), recovered)
+	if a.method != "/pkg.Svc/List" || a.rate != 0.025 {
+		t.Fatalf("alert = %s at %v right after recovering, want it kept with the current rate", a.method, a.rate)
+	}
+	if !strings.Contains(a.message(), "recovering") {
+		t.Errorf("message = %q, want it marked recovering", a.message())
+	}
+	a = a.update(now.Add(5*time.Second), recovered)
+	if a.method == "" {
+		t.Fatal("alert cleared before it settled")
+	}
+	// Flapping back above the threshold restarts the settling.
+	a = a.update(now.Add(6*time.Second), worse)
+	a = a.update(now.Add(7*time.Second), recovered)
+	if a = a.update(now.Add(7*time.Second+errorRateSettle-time.Millisecond), recovered); a.method == "" {
+		t.Fatal("alert cleared before settling again")
+	}
+	if a = a.update(now.Add(7*time.Second+errorRateSettle), recovered); a.method != "" {
+		t.Errorf("alert on %s after settling, want it cleared", a.method)
+	}
+	if a.threshold != 0.05 {
+		t.Errorf("threshold = %v after clearing, want it kept", a.threshold)
+	}
+
+	if off := (errorRateAlert{}).update(now, failing); off.method != "" {
+		t.Errorf("alert raised on %s without a threshold", off.method)
+	}
+}
+
+func TestErrorRateAlert_Banner(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel()
+	WithErrorRateAlert(0.05)(&m)
+	for i := range 10 {
+		status := int32(0)
+		if i%2 == 0 {
+			status = 14
+		}
+		m, _ = m.upsertEvent(testEvent(strconv.Itoa(i), "/pkg.Svc/Get", status, time.Millisecond))
+	}
+	m.displayRows = m.rebuildDisplayRows()
+
+	updated, cmd := m.Update(errorRateTickMsg(time.Now()))
+	m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+	if cmd == nil {
+		t.Error("tick did not schedule the next check")
+	}
+	if view := m.View(); !strings.Contains(view, "error rate 50.0% on /pkg.Svc/Get") {
+		t.Errorf("view does not show the banner:\n%s", view)
+	}
+
+	// Calls older than the window no longer count.
+	updated, _ = m.Update(errorRateTickMsg(time.Now().Add(errorRateWindow + errorRateSettle + time.Second)))
+	m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+	updated, _ = m.Update(errorRateTickMsg(time.Now().Add(errorRateWindow + 2*errorRateSettle + time.Second)))
+	m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+	if strings.Contains(m.View(), "error rate") {
+		t.Error("banner still shown after the failures left the window")
+	}
+}
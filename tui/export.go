@@ -3,7 +3,9 @@ package tui
 import (
 	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"slices"
@@ -35,6 +37,7 @@ type exportCall struct {
 	DurationMs float64 `json:"duration_ms"`
 	Status     int32   `json:"status"`
 	Error      string  `json:"error"`
+	Note       string  `json:"note,omitempty"`
 }
 
 type exportAnalyticsRow struct {
@@ -51,6 +54,7 @@ type exportData struct {
 	Captured int    `json:"captured"`
 	Exported int    `json:"exported"`
 	Search   string `json:"search"`
+	Since    string `json:"since,omitempty"`
 	Period   struct {
 		Start string `json:"start"`
 		End   string `json:"end"`
@@ -59,16 +63,28 @@ type exportData struct {
 	Analytics []exportAnalyticsRow `json:"analytics"`
 }
 
-func filteredExportEvents(
-	events []*tapv1.GRPCEvent, searchQuery string, filterErrors bool,
-) []*tapv1.GRPCEvent {
-	filter := strings.ToLower(searchQuery)
+// exportFilter selects the events to export, mirroring the list view.
+type exportFilter struct {
+	search     string    // search query, as matched by searchMatcher
+	errorsOnly bool      // only events with a non-OK status
+	since      time.Time // only events started at or after since, unless zero
+	hidden     []string  // method prefixes to leave out
+}
+
+func filteredExportEvents(events []*tapv1.GRPCEvent, f exportFilter) []*tapv1.GRPCEvent {
 	result := make([]*tapv1.GRPCEvent, 0, len(events))
+	matches := searchMatcher(f.search)
 	for _, ev := range events {
-		if filter != "" && !strings.Contains(strings.ToLower(ev.GetMethod()), filter) {
+		if !matches(ev) {
+			continue
+		}
+		if f.errorsOnly && ev.GetStatus() == 0 {
+			continue
+		}
+		if !inWindow(ev, f.since) {
 			continue
 		}
-		if filterErrors && ev.GetStatus() == 0 {
+		if isInternalMethod(ev.GetMethod(), f.hidden) {
 			continue
 		}
 		result = append(result, ev)
@@ -135,15 +151,18 @@ func percentile(sorted []time.Duration, p float64) time.Duration {
 	return sorted[idx]
 }
 
-func buildExportDataFromEvents(
-	allEvents []*tapv1.GRPCEvent, searchQuery string, filterErrors bool,
-) exportData {
-	exported := filteredExportEvents(allEvents, searchQuery, filterErrors)
+// buildExportDataFromEvents builds the export of the events f selects. notes
+// maps event IDs to the notes attached to them in the TUI.
+func buildExportDataFromEvents(allEvents []*tapv1.GRPCEvent, f exportFilter, notes map[string]string) exportData {
+	exported := filteredExportEvents(allEvents, f)
 
 	var d exportData
 	d.Captured = len(allEvents)
 	d.Exported = len(exported)
-	d.Search = searchQuery
+	d.Search = f.search
+	if !f.since.IsZero() {
+		d.Since = f.since.In(time.Local).Format("15:04:05") //nolint:gosmopolitan // export uses local time
+	}
 
 	if len(exported) > 0 {
 		first := exported[0].GetStartTime()
@@ -170,6 +189,7 @@ func buildExportDataFromEvents(
 			DurationMs: durMs,
 			Status:     ev.GetStatus(),
 			Error:      ev.GetError(),
+			Note:       notes[ev.GetId()],
 		})
 	}
 
@@ -193,10 +213,8 @@ func callTypeString(ct tapv1.CallType) string {
 	return "Unknown"
 }
 
-func renderExportJSON(
-	allEvents []*tapv1.GRPCEvent, searchQuery string, filterErrors bool,
-) (string, error) {
-	d := buildExportDataFromEvents(allEvents, searchQuery, filterErrors)
+func renderExportJSON(allEvents []*tapv1.GRPCEvent, f exportFilter, notes map[string]string) (string, error) {
+	d := buildExportDataFromEvents(allEvents, f, notes)
 	b, err := json.MarshalIndent(d, "", "  ")
 	if err != nil {
 		return "", fmt.Errorf("marshal export: %w", err)
@@ -205,9 +223,12 @@ func renderExportJSON(
 }
 
 func renderExportMarkdown(
-	allEvents []*tapv1.GRPCEvent, searchQuery string, filterErrors bool,
+	allEvents []*tapv1.GRPCEvent,
+	f exportFilter,
+	notes map[string]string,
+	units DurationUnit,
 ) string {
-	d := buildExportDataFromEvents(allEvents, searchQuery, filterErrors)
+	d := buildExportDataFromEvents(allEvents, f, notes)
 
 	var sb strings.Builder
 	sb.WriteString("# grpc-tap export\n\n")
@@ -218,23 +239,37 @@ func renderExportMarkdown(
 		exportLine += " (search: " + d.Search + ")"
 	}
 	sb.WriteString(exportLine + "\n")
+	if d.Since != "" {
+		fmt.Fprintf(&sb, "- Since: %s\n", d.Since)
+	}
 	if d.Period.Start != "" {
 		fmt.Fprintf(&sb, "- Period: %s — %s\n",
 			d.Period.Start, d.Period.End)
 	}
 
+	// The note column is only added when a call has a note.
+	withNotes := slices.ContainsFunc(d.Calls, func(c exportCall) bool { return c.Note != "" })
 	sb.WriteString("\n## Calls\n\n")
-	sb.WriteString("| # | Time | Method | Type | Protocol | Duration | Status | Error |\n")
-	sb.WriteString("|---|------|--------|------|----------|----------|--------|-------|\n")
+	if withNotes {
+		sb.WriteString("| # | Time | Method | Type | Protocol | Duration | Status | Error | Note |\n")
+		sb.WriteString("|---|------|--------|------|----------|----------|--------|-------|------|\n")
+	} else {
+		sb.WriteString("| # | Time | Method | Type | Protocol | Duration | Status | Error |\n")
+		sb.WriteString("|---|------|--------|------|----------|----------|--------|-------|\n")
+	}
 	for i, c := range d.Calls {
-		fmt.Fprintf(&sb, "| %d | %s | %s | %s | %s | %s | %s | %s |\n",
+		fmt.Fprintf(&sb, "| %d | %s | %s | %s | %s | %s | %s | %s |",
 			i+1, c.Time,
 			escapeMarkdownPipe(c.Method),
 			c.CallType, c.Protocol,
-			formatDurationMs(c.DurationMs),
+			units.formatMs(c.DurationMs),
 			formatStatusMarkdown(c.Status),
 			escapeMarkdownPipe(c.Error),
 		)
+		if withNotes {
+			fmt.Fprintf(&sb, " %s |", escapeMarkdownPipe(c.Note))
+		}
+		sb.WriteString("\n")
 	}
 
 	if len(d.Analytics) > 0 {
@@ -250,10 +285,10 @@ func renderExportMarkdown(
 				escapeMarkdownPipe(a.Method),
 				a.Count,
 				errStr,
-				formatDurationMs(a.AvgMs),
-				formatDurationMs(a.P95Ms),
-				formatDurationMs(a.MaxMs),
-				formatDurationMs(a.TotalMs),
+				units.formatMs(a.AvgMs),
+				units.formatMs(a.P95Ms),
+				units.formatMs(a.MaxMs),
+				units.formatMs(a.TotalMs),
 			)
 		}
 	}
@@ -261,22 +296,8 @@ func renderExportMarkdown(
 	return sb.String()
 }
 
-func formatDurationMs(ms float64) string {
-	switch {
-	case ms < 1:
-		return fmt.Sprintf("%.0fµs", ms*1000)
-	case ms < 1000:
-		return fmt.Sprintf("%.1fms", ms)
-	default:
-		return fmt.Sprintf("%.2fs", ms/1000)
-	}
-}
-
 func formatStatusMarkdown(status int32) string {
-	if status == 0 {
-		return "OK"
-	}
-	return fmt.Sprintf("ERR(%d)", status)
+	return statusString(status)
 }
 
 func escapeMarkdownPipe(s string) string {
@@ -287,8 +308,9 @@ func escapeMarkdownPipe(s string) string {
 // dir specifies the output directory; if empty, the current directory is used.
 func writeExport(
 	allEvents []*tapv1.GRPCEvent,
-	searchQuery string,
-	filterErrors bool,
+	f exportFilter,
+	notes map[string]string,
+	units DurationUnit,
 	format exportFormat,
 	dir string,
 ) (string, error) {
@@ -297,12 +319,12 @@ func writeExport(
 
 	switch format {
 	case exportJSON:
-		content, err = renderExportJSON(allEvents, searchQuery, filterErrors)
+		content, err = renderExportJSON(allEvents, f, notes)
 		if err != nil {
 			return "", err
 		}
 	case exportMarkdown:
-		content = renderExportMarkdown(allEvents, searchQuery, filterErrors)
+		content = renderExportMarkdown(allEvents, f, notes, units)
 	}
 
 	filename := fmt.Sprintf("grpc-tap-%s.%s",
@@ -316,3 +338,69 @@ func writeExport(
 	}
 	return filename, nil
 }
+
+// writeRawExport writes the captured request and response bodies of ev,
+// byte for byte, to files named after the event ID and start time, e.g.
+// grpc-tap-<id>-20250102-030405.request.bin. Empty bodies are skipped.
+// Existing files are never overwritten; a numeric suffix is added instead.
+// dir specifies the output directory; if empty, the current directory is used.
+// It returns the paths written.
+func writeRawExport(ev *tapv1.GRPCEvent, dir string) ([]string, error) {
+	base := fmt.Sprintf("grpc-tap-%s-%s",
+		sanitizeFilename(ev.GetId()), ev.GetStartTime().AsTime().Local().Format("20060102-150405"))
+
+	var paths []string
+	for _, body := range []struct {
+		name string
+		data []byte
+	}{
+		{"request", ev.GetRequestBody()},
+		{"response", ev.GetResponseBody()},
+	} {
+		if len(body.data) == 0 {
+			continue
+		}
+		path, err := createUnique(filepath.Join(dir, base+"."+body.name), ".bin", body.data)
+		if err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// createUnique writes data to stem+ext, or to stem-1+ext, stem-2+ext, … if
+// the file already exists.
+func createUnique(stem, ext string, data []byte) (string, error) {
+	for i := 0; ; i++ {
+		path := stem + ext
+		if i > 0 {
+			path = fmt.Sprintf("%s-%d%s", stem, i, ext)
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) //nolint:gosec // G304: name is built from the event ID
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("write raw export: %w", err)
+		}
+		_, err = f.Write(data)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return "", fmt.Errorf("write raw export: %w", err)
+		}
+		return path, nil
+	}
+}
+
+// sanitizeFilename keeps event IDs from escaping the export directory.
+func sanitizeFilename(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,154 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+func TestWriteRawExport(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.Local)
+	ev := &tapv1.GRPCEvent{
+		Id:           "abc",
+		StartTime:    timestamppb.New(start),
+		RequestBody:  []byte{0x08, 0x2a, 0xff},
+		ResponseBody: []byte("raw\x00bytes"),
+	}
+
+	t.Run("writes both bodies", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		paths, err := writeRawExport(ev, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{
+			filepath.Join(dir, "grpc-tap-abc-20250102-030405.request.bin"),
+			filepath.Join(dir, "grpc-tap-abc-20250102-030405.response.bin"),
+		}
+		if strings.Join(paths, ",") != strings.Join(want, ",") {
+			t.Fatalf("paths = %v, want %v", paths, want)
+		}
+		for i, body := range [][]byte{ev.GetRequestBody(), ev.GetResponseBody()} {
+			got, err := os.ReadFile(paths[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, body) {
+				t.Errorf("%s = %q, want %q", paths[i], got, body)
+			}
+		}
+	})
+
+	t.Run("does not overwrite", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		if _, err := writeRawExport(ev, dir); err != nil {
+			t.Fatal(err)
+		}
+		paths, err := writeRawExport(ev, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(paths) != 2 || !strings.HasSuffix(paths[0], ".request-1.bin") || !strings.HasSuffix(paths[1], ".response-1.bin") {
+			t.Errorf("paths = %v, want -1 suffixes", paths)
+		}
+	})
+
+	t.Run("skips empty bodies", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		paths, err := writeRawExport(&tapv1.GRPCEvent{Id: "e", StartTime: timestamppb.New(start)}, dir)
+		if err != nil || len(paths) != 0 {
+			t.Errorf("paths = %v, err = %v; want none", paths, err)
+		}
+	})
+
+	t.Run("keeps IDs inside the directory", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		paths, err := writeRawExport(&tapv1.GRPCEvent{
+			Id:          "../../etc/x",
+			StartTime:   timestamppb.New(start),
+			RequestBody: []byte("x"),
+		}, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(paths) != 1 || filepath.Dir(paths[0]) != dir {
+			t.Errorf("paths = %v, want a file directly in %s", paths, dir)
+		}
+	})
+}
+
+func TestExport_Notes(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel(
+		testEvent("1", "/pkg.Svc/Get", 0, time.Millisecond),
+		testEvent("2", "/pkg.Svc/Put", 13, time.Millisecond),
+	)
+	m = press(press(m, "j"), "enter")
+	m = press(m, "m")
+	for _, r := range "this is the failing | one" {
+		m = press(m, string(r))
+	}
+	m = press(m, "enter")
+	if m.noteMode || m.notes["2"] != "this is the failing | one" {
+		t.Fatalf("notes = %v, want a note on 2", m.notes)
+	}
+	if !slices.Contains(m.inspectLines(m.cursorEvent()), "Note:     this is the failing | one") {
+		t.Error("inspector does not show the note")
+	}
+
+	f := exportFilter{}
+	js, err := renderExportJSON(m.events, f, m.notes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var d exportData
+	if err := json.Unmarshal([]byte(js), &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Calls[0].Note != "" || d.Calls[1].Note != "this is the failing | one" {
+		t.Errorf("JSON notes = %q, %q", d.Calls[0].Note, d.Calls[1].Note)
+	}
+
+	md := renderExportMarkdown(m.events, f, m.notes, DurationAuto)
+	if !strings.Contains(md, "| Error | Note |") || !strings.Contains(md, `| this is the failing \| one |`) {
+		t.Errorf("markdown does not include the note:\n%s", md)
+	}
+	if md := renderExportMarkdown(m.events, f, nil, DurationAuto); strings.Contains(md, "Note") {
+		t.Errorf("markdown without notes has a note column:\n%s", md)
+	}
+
+	// An empty note removes it; Esc leaves the note untouched.
+	m = press(m, "m")
+	m = press(press(m, "x"), "esc")
+	if m.notes["2"] != "this is the failing | one" {
+		t.Errorf("esc changed the note to %q", m.notes["2"])
+	}
+	m = press(m, "m")
+	for range len("this is the failing | one") {
+		m = press(m, "backspace")
+	}
+	m = press(m, "enter")
+	if _, ok := m.notes["2"]; ok {
+		t.Errorf("empty note kept: %v", m.notes)
+	}
+}
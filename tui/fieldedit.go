@@ -0,0 +1,276 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+// fieldEditor is the in-TUI form for tweaking the top-level fields of a
+// request before resending it. Nested messages cannot be edited inline; they
+// are kept as-is unless the request is handed over to $EDITOR.
+type fieldEditor struct {
+	active   bool
+	method   string
+	protocol tapv1.Protocol // of the original call, which the replay keeps
+	sourceID string         // ID of the original call, whose response replays are asserted against
+	fields   []editField
+	cursor   int
+
+	// descriptors is set when the fields were decoded with the method's
+	// schema, and so are keyed by name and encoded back with it.
+	descriptors *proxy.Descriptors
+}
+
+// editField is one top-level field of the request being edited.
+type editField struct {
+	key    string // field number, the key in the schema-less JSON, or the field name with a schema
+	number int    // field number, which fields are listed by
+	value  any    // decoded JSON value: string, float64, bool or map[string]any
+	text   string // current text for inline-editable values
+}
+
+// nested reports whether the field is a message and must be edited in $EDITOR.
+func (f editField) nested() bool {
+	_, ok := f.value.(map[string]any)
+	return ok
+}
+
+// newFieldEditor decodes body into editable top-level fields: by name with
+// the method's schema when d describes it and body fits it, else by number.
+// With a schema, enum fields hold the value's name and accept a name or a
+// number.
+func newFieldEditor(method string, body []byte, d *proxy.Descriptors) (fieldEditor, error) {
+	editor := fieldEditor{active: true, method: method}
+	data, err := d.DecodeJSON(method, false, body)
+	if err == nil {
+		editor.descriptors = d
+	} else if data, err = proxy.ProtoWireToJSON(body); err != nil {
+		return fieldEditor{}, fmt.Errorf("decode request: %w", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fieldEditor{}, fmt.Errorf("decode request: %w", err)
+	}
+
+	md, _ := d.Method(method)
+	fields := make([]editField, 0, len(doc))
+	for key, v := range doc {
+		f := editField{key: key, value: v}
+		if editor.descriptors != nil {
+			f.number = int(md.Input().Fields().ByName(protoreflect.Name(key)).Number())
+		} else {
+			f.number, _ = strconv.Atoi(key)
+		}
+		switch v := v.(type) {
+		case string:
+			f.text = v
+		case float64:
+			f.text = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			f.text = strconv.FormatBool(v)
+		}
+		fields = append(fields, f)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].number < fields[j].number })
+	editor.fields = fields
+	return editor, nil
+}
+
+// encode converts the JSON of the edited request back to protobuf wire
+// format, with the method's schema when the fields were decoded with it.
+func (e fieldEditor) encode(data []byte) ([]byte, error) {
+	if e.descriptors != nil {
+		return e.descriptors.EncodeJSON(e.method, false, data) //nolint:wrapcheck // callers add context
+	}
+	return proxy.JSONToProtoWire(data) //nolint:wrapcheck // callers add context
+}
+
+// document rebuilds the JSON object of the request from the edited fields.
+func (e fieldEditor) document() (map[string]any, error) {
+	doc := make(map[string]any, len(e.fields))
+	for _, f := range e.fields {
+		switch f.value.(type) {
+		case float64:
+			v, err := strconv.ParseFloat(strings.TrimSpace(f.text), 64)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %q is not a number", f.key, f.text)
+			}
+			doc[f.key] = v
+		case bool:
+			v, err := strconv.ParseBool(strings.TrimSpace(f.text))
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %q is not true or false", f.key, f.text)
+			}
+			doc[f.key] = v
+		case string:
+			doc[f.key] = f.text
+		default:
+			doc[f.key] = f.value
+		}
+	}
+	return doc, nil
+}
+
+// editedRequest is the request last edited and resent, kept so that it can
+// be resent again as is without the editor.
+type editedRequest struct {
+	sourceID string // ID of the call it was edited from
+	method   string
+	protocol tapv1.Protocol
+	wire     []byte // protobuf request body
+}
+
+// openFieldEditor starts editing the request of ev. The last edited request
+// is forgotten when ev is a different call.
+func (m Model) openFieldEditor(ev *tapv1.GRPCEvent) (Model, tea.Cmd) {
+	if m.lastEdit != nil && m.lastEdit.sourceID != ev.GetId() {
+		m.lastEdit = nil
+	}
+	editor, err := newFieldEditor(ev.GetMethod(), ev.GetRequestBody(), m.descriptors)
+	if err != nil {
+		return m.showAlert(err.Error())
+	}
+	editor.protocol = ev.GetProtocol()
+	editor.sourceID = ev.GetId()
+	m.fieldEdit = editor
+	return m, nil
+}
+
+func (m Model) updateFieldEditor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	e := &m.fieldEdit
+	switch msg.String() {
+	case "ctrl+c":
+		return m.quit()
+	case "esc":
+		m.fieldEdit = fieldEditor{}
+		return m, nil
+	case "up", "shift+tab":
+		e.cursor = max(e.cursor-1, 0)
+		return m, nil
+	case "down", "tab":
+		e.cursor = min(e.cursor+1, max(len(e.fields)-1, 0))
+		return m, nil
+	case "enter":
+		doc, err := e.document()
+		if err != nil {
+			return m.showAlert(err.Error())
+		}
+		wire, err := e.encode(mustMarshal(doc))
+		if err != nil {
+			return m.showAlert("encode protobuf: " + err.Error())
+		}
+		m.lastEdit = &editedRequest{sourceID: e.sourceID, method: e.method, protocol: e.protocol, wire: wire}
+		m.fieldEdit = fieldEditor{}
+		return m.resendEdit()
+	case "ctrl+e":
+		// Hand the edits so far over to $EDITOR, e.g. for nested messages.
+		doc, err := e.document()
+		if err != nil {
+			return m.showAlert(err.Error())
+		}
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return m.showAlert("encode JSON: " + err.Error())
+		}
+		method, protocol, encode := e.method, e.protocol, e.encode
+		m.replaySourceID = e.sourceID
+		m.fieldEdit = fieldEditor{}
+		return m, m.editJSONAndResend(method, protocol, data, encode)
+	}
+
+	if len(e.fields) == 0 || e.fields[e.cursor].nested() {
+		return m, nil
+	}
+	f := &e.fields[e.cursor]
+	switch msg.String() {
+	case "backspace":
+		if f.text != "" {
+			_, size := utf8.DecodeLastRuneInString(f.text)
+			f.text = f.text[:len(f.text)-size]
+		}
+	case " ":
+		f.text += " "
+	default:
+		if msg.Type == tea.KeyRunes {
+			f.text += string(msg.Runes)
+		}
+	}
+	return m, nil
+}
+
+// mustMarshal encodes a document built from decoded JSON values, which
+// cannot fail.
+func mustMarshal(v any) []byte {
+	b, _ := json.Marshal(v)
+	return b
+}
+
+// renderFieldEditor renders the field editor overlay.
+func (m Model) renderFieldEditor() string {
+	e := m.fieldEdit
+	innerWidth := m.innerWidth()
+	visibleRows := max(m.height-2, 3)
+
+	numWidth := 0
+	for _, f := range e.fields {
+		numWidth = max(numWidth, len(f.key))
+	}
+
+	var lines []string
+	if len(e.fields) == 0 {
+		lines = append(lines, "(no fields)")
+	}
+	for i, f := range e.fields {
+		marker := "  "
+		if i == e.cursor {
+			marker = "▶ "
+		}
+		var value string
+		switch {
+		case f.nested():
+			value = lipgloss.NewStyle().Faint(true).Render("{…} nested message — ctrl+e to edit in $EDITOR")
+		case i == e.cursor:
+			value = f.text + "█"
+		default:
+			value = f.text
+		}
+		if _, ok := f.value.(string); ok && !f.nested() {
+			value = `"` + value + `"`
+		}
+		lines = append(lines, truncate(fmt.Sprintf("%s%s: %s", marker, padLeft(f.key, numWidth), value), innerWidth))
+	}
+	// Keep the selected field on screen.
+	if start := e.cursor - visibleRows + 1; start > 0 {
+		lines = lines[start:]
+	}
+	if len(lines) > visibleRows {
+		lines = lines[:visibleRows]
+	}
+
+	return boxWith(strings.Join(lines, "\n"), innerWidth, lipgloss.Color("240"),
+		truncate(" Edit "+e.method+" ", innerWidth), " enter: resend  esc: cancel  ↑/↓: field  ctrl+e: $EDITOR ")
+}
+
+// resendEdit replays the last edited request again.
+func (m Model) resendEdit() (Model, tea.Cmd) {
+	r := m.lastEdit
+	if r == nil {
+		return m.showAlert("No edited request to resend; press " + m.keys.edit.key() + " to edit one")
+	}
+	m.replaySourceID = r.sourceID
+	if m.client == nil {
+		return m, nil
+	}
+	return m, replayCmd(m.client, r.method, r.protocol, r.wire)
+}
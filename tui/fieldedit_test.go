@@ -0,0 +1,275 @@
+package tui
+
+import (
+	"context"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+func fieldEditRequest() []byte {
+	var b []byte
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	b = protowire.AppendVarint(b, 42)
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendString(b, "hello")
+	var nested []byte
+	nested = protowire.AppendTag(nested, 1, protowire.VarintType)
+	nested = protowire.AppendVarint(nested, 7)
+	b = protowire.AppendTag(b, 3, protowire.BytesType)
+	b = protowire.AppendBytes(b, nested)
+	return b
+}
+
+func openTestFieldEditor(t *testing.T) Model {
+	t.Helper()
+	ev := &tapv1.GRPCEvent{Id: "1", Method: "/svc/Method", RequestBody: fieldEditRequest()}
+	m := newTestModel(ev)
+	m, _ = m.openFieldEditor(ev)
+	if !m.fieldEdit.active {
+		t.Fatal("field editor not active")
+	}
+	return m
+}
+
+func TestFieldEditor(t *testing.T) {
+	t.Parallel()
+
+	t.Run("lists top-level fields by number", func(t *testing.T) {
+		t.Parallel()
+		m := openTestFieldEditor(t)
+		fields := m.fieldEdit.fields
+		if len(fields) != 3 {
+			t.Fatalf("fields = %d, want 3", len(fields))
+		}
+		want := []struct {
+			num    string
+			text   string
+			nested bool
+		}{{"1", "hello", false}, {"2", "42", false}, {"3", "", true}}
+		for i, w := range want {
+			f := fields[i]
+			if f.key != w.num || f.text != w.text || f.nested() != w.nested {
+				t.Errorf("field %d = {%s %q nested=%v}, want {%s %q nested=%v}",
+					i, f.key, f.text, f.nested(), w.num, w.text, w.nested)
+			}
+		}
+	})
+
+	t.Run("edits values inline", func(t *testing.T) {
+		t.Parallel()
+		m := openTestFieldEditor(t)
+		m = press(m, "!")
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyDown})
+		m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+		updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+		m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+		m = press(m, "3")
+
+		doc, err := m.fieldEdit.document()
+		if err != nil {
+			t.Fatalf("document: %v", err)
+		}
+		if doc["1"] != "hello!" {
+			t.Errorf("field 1 = %v, want %q", doc["1"], "hello!")
+		}
+		if doc["2"] != float64(43) {
+			t.Errorf("field 2 = %v, want 43", doc["2"])
+		}
+		if _, ok := doc["3"].(map[string]any); !ok {
+			t.Errorf("field 3 = %v, want nested message kept", doc["3"])
+		}
+	})
+
+	t.Run("rejects invalid numbers", func(t *testing.T) {
+		t.Parallel()
+		m := openTestFieldEditor(t)
+		m.fieldEdit.fields[1].text = "forty"
+		if _, err := m.fieldEdit.document(); err == nil {
+			t.Fatal("expected error for non-numeric value")
+		}
+		m = press(m, "enter")
+		if !m.fieldEdit.active {
+			t.Error("editor closed despite invalid value")
+		}
+		if m.alertMessage == "" {
+			t.Error("expected an alert for the invalid value")
+		}
+	})
+
+	t.Run("nested fields ignore typing", func(t *testing.T) {
+		t.Parallel()
+		m := openTestFieldEditor(t)
+		m.fieldEdit.cursor = 2
+		m = press(m, "x")
+		if got := m.fieldEdit.fields[2].text; got != "" {
+			t.Errorf("nested field text = %q, want empty", got)
+		}
+	})
+
+	t.Run("esc cancels", func(t *testing.T) {
+		t.Parallel()
+		m := openTestFieldEditor(t)
+		m = press(m, "esc")
+		if m.fieldEdit.active {
+			t.Error("editor still active after esc")
+		}
+		if m.view != viewList {
+			t.Errorf("view = %v, want list", m.view)
+		}
+	})
+
+	t.Run("enter closes the editor", func(t *testing.T) {
+		t.Parallel()
+		m := openTestFieldEditor(t)
+		m = press(m, "enter")
+		if m.fieldEdit.active {
+			t.Error("editor still active after enter")
+		}
+	})
+
+	t.Run("edits by name with a schema", func(t *testing.T) {
+		t.Parallel()
+		body, err := proto.Marshal(&tapv1.ReplayRequest{Method: "/pkg.Svc/Get", Protocol: tapv1.Protocol_PROTOCOL_CONNECT})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ev := &tapv1.GRPCEvent{Id: "1", Method: "/tap.v1.TapService/Replay", RequestBody: body}
+		m := newTestModel(ev)
+		WithDescriptors(tapDescriptors(t))(&m)
+		m, _ = m.openFieldEditor(ev)
+		fields := m.fieldEdit.fields
+		if len(fields) != 2 || fields[0].key != "method" || fields[1].key != "protocol" {
+			t.Fatalf("fields = %+v, want method and protocol in field order", fields)
+		}
+		if fields[1].text != "PROTOCOL_CONNECT" {
+			t.Errorf("protocol = %q, want the enum name", fields[1].text)
+		}
+
+		for _, text := range []string{"PROTOCOL_GRPC", "1"} {
+			fields[1].text = text
+			doc, err := m.fieldEdit.document()
+			if err != nil {
+				t.Fatal(err)
+			}
+			wire, err := m.fieldEdit.encode(mustMarshal(doc))
+			if err != nil {
+				t.Fatalf("protocol %q: %v", text, err)
+			}
+			var got tapv1.ReplayRequest
+			if err := proto.Unmarshal(wire, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.GetMethod() != "/pkg.Svc/Get" || got.GetProtocol() != tapv1.Protocol_PROTOCOL_GRPC {
+				t.Errorf("protocol %q: encoded %v", text, &got)
+			}
+		}
+	})
+
+	t.Run("undecodable body alerts", func(t *testing.T) {
+		t.Parallel()
+		ev := &tapv1.GRPCEvent{Id: "1", Method: "/svc/Method", RequestBody: []byte{0xff, 0xff}}
+		m := newTestModel(ev)
+		m, _ = m.openFieldEditor(ev)
+		if m.fieldEdit.active {
+			t.Error("editor opened for undecodable body")
+		}
+		if m.alertMessage == "" {
+			t.Error("expected an alert")
+		}
+	})
+}
+
+// replayClient records the requests replayed through it.
+type replayClient struct {
+	tapv1.TapServiceClient
+	requests []*tapv1.ReplayRequest
+}
+
+func (c *replayClient) Replay(
+	_ context.Context, req *tapv1.ReplayRequest, _ ...grpc.CallOption,
+) (*tapv1.ReplayResponse, error) {
+	c.requests = append(c.requests, req)
+	return &tapv1.ReplayResponse{Event: &tapv1.GRPCEvent{Id: "replay"}}, nil
+}
+
+func TestResendEdit(t *testing.T) {
+	t.Parallel()
+
+	first := &tapv1.GRPCEvent{Id: "1", Method: "/svc/Method", RequestBody: fieldEditRequest()}
+	second := &tapv1.GRPCEvent{Id: "2", Method: "/svc/Other", RequestBody: fieldEditRequest()}
+	client := &replayClient{}
+	m := newTestModel(first, second)
+	m.client = client
+	m = press(m, "enter")
+
+	// Nothing edited yet.
+	m = press(m, "r")
+	if m.alertMessage == "" || m.replaySourceID != "" {
+		t.Fatal("r without an edit: want an alert and no replay")
+	}
+
+	m, _ = m.openFieldEditor(first)
+	m = press(m, "!")
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+	if cmd == nil {
+		t.Fatal("enter did not replay")
+	}
+	cmd()
+	if m.lastEdit == nil {
+		t.Fatal("edited request not kept")
+	}
+	edited := client.requests[0].GetRequestBody()
+
+	for range 2 {
+		updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+		m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+		if cmd == nil {
+			t.Fatal("r did not replay")
+		}
+		if msg, ok := cmd().(replayResultMsg); !ok || msg.Err != nil || msg.EventID != "replay" {
+			t.Fatalf("r: result = %+v", msg)
+		}
+	}
+	if len(client.requests) != 3 {
+		t.Fatalf("replays = %d, want 3", len(client.requests))
+	}
+	for _, req := range client.requests[1:] {
+		if req.GetMethod() != "/svc/Method" || string(req.GetRequestBody()) != string(edited) {
+			t.Errorf("resent %s %x, want the edited %x", req.GetMethod(), req.GetRequestBody(), edited)
+		}
+	}
+	if m.replaySourceID != "1" {
+		t.Errorf("replay source = %q, want 1 for assertions", m.replaySourceID)
+	}
+
+	// Editing the same call again keeps the edit until a new one is sent;
+	// editing another call forgets it.
+	m, _ = m.openFieldEditor(first)
+	if m.lastEdit == nil {
+		t.Error("reopening the same call forgot the edit")
+	}
+	m = press(m, "esc")
+	m, _ = m.openFieldEditor(second)
+	if m.lastEdit != nil {
+		t.Error("editing another call kept the previous edit")
+	}
+}
+
+func TestReplayResult_KeepsEditorEdit(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel()
+	edited := &editedRequest{sourceID: "1", method: "/svc/Method", wire: fieldEditRequest()}
+	updated, _ := m.Update(replayResultMsg{EventID: "replay", Edited: edited})
+	m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+	if m.lastEdit != edited {
+		t.Errorf("lastEdit = %+v, want the request edited in $EDITOR", m.lastEdit)
+	}
+}
@@ -0,0 +1,214 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/mickamy/grpc-tap/filter"
+)
+
+var (
+	matchStyle        = lipgloss.NewStyle().Reverse(true)
+	currentMatchStyle = lipgloss.NewStyle().Background(lipgloss.Color("220")).Foreground(lipgloss.Color("0")).Bold(true)
+)
+
+// findMatch is one occurrence of a highlighted term in the inspector.
+type findMatch struct {
+	line  int // index into inspectLines
+	col   int // display column the match starts at
+	width int // display width of the match
+}
+
+// highlightTerms returns the texts the inspector highlights: the find query
+// when there is one, or else the texts the list search looks for.
+func (m Model) highlightTerms() []string {
+	if m.findQuery != "" {
+		return []string{m.findQuery}
+	}
+	f, err := filter.Parse(m.searchQuery)
+	if err != nil {
+		return nil
+	}
+	return f.Texts()
+}
+
+// matchRanges returns the byte ranges of plain that match any of terms,
+// ignoring case, sorted and merged where they overlap.
+func matchRanges(plain string, terms []string) [][2]int {
+	var ranges [][2]int
+	for _, term := range terms {
+		if term == "" {
+			continue
+		}
+		for i := 0; i+len(term) <= len(plain); {
+			if strings.EqualFold(plain[i:i+len(term)], term) {
+				ranges = append(ranges, [2]int{i, i + len(term)})
+				i += len(term)
+				continue
+			}
+			_, size := utf8.DecodeRuneInString(plain[i:])
+			i += size
+		}
+	}
+	slices.SortFunc(ranges, func(a, b [2]int) int { return a[0] - b[0] })
+	merged := ranges[:0]
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && r[0] <= merged[n-1][1] {
+			merged[n-1][1] = max(merged[n-1][1], r[1])
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// findMatches returns every match of terms in lines, in order.
+func findMatches(lines []string, terms []string) []findMatch {
+	if len(terms) == 0 {
+		return nil
+	}
+	var matches []findMatch
+	for i, line := range lines {
+		plain := ansi.Strip(line)
+		for _, r := range matchRanges(plain, terms) {
+			matches = append(matches, findMatch{
+				line:  i,
+				col:   ansi.StringWidth(plain[:r[0]]),
+				width: ansi.StringWidth(plain[r[0]:r[1]]),
+			})
+		}
+	}
+	return matches
+}
+
+// highlightMatches styles the matches of terms in lines. A line with a match
+// loses its syntax highlighting, so the match styles never nest inside other
+// escape sequences; its text, and so its width, is unchanged. The match at
+// current, an index into findMatches' result, stands out from the others.
+func highlightMatches(lines []string, terms []string, current int) []string {
+	if len(terms) == 0 {
+		return lines
+	}
+	out := make([]string, len(lines))
+	n := 0
+	for i, line := range lines {
+		plain := ansi.Strip(line)
+		ranges := matchRanges(plain, terms)
+		if len(ranges) == 0 {
+			out[i] = line
+			continue
+		}
+		var b strings.Builder
+		prev := 0
+		for _, r := range ranges {
+			style := matchStyle
+			if n == current {
+				style = currentMatchStyle
+			}
+			n++
+			b.WriteString(plain[prev:r[0]])
+			b.WriteString(style.Render(plain[r[0]:r[1]]))
+			prev = r[1]
+		}
+		b.WriteString(plain[prev:])
+		out[i] = b.String()
+	}
+	return out
+}
+
+// inspectMatches returns the matches of the find query in the inspected
+// event.
+func (m Model) inspectMatches() []findMatch {
+	ev := m.cursorEvent()
+	if ev == nil || m.findQuery == "" {
+		return nil
+	}
+	return findMatches(m.inspectLines(ev), []string{m.findQuery})
+}
+
+// updateFind handles keys while the find query is typed. The inspector
+// jumps to the first match at or below the top line as the query changes.
+// Enter keeps the query for n/N; Esc drops it.
+func (m Model) updateFind(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		m.findMode = false
+		return m, nil
+	case "esc":
+		m.findMode = false
+		m.findQuery = ""
+		return m, nil
+	case "backspace":
+		if len(m.findQuery) > 0 {
+			_, size := utf8.DecodeLastRuneInString(m.findQuery)
+			m.findQuery = m.findQuery[:len(m.findQuery)-size]
+		}
+	case "ctrl+c":
+		return m.quit()
+	default:
+		if len(msg.Runes) == 0 {
+			return m, nil
+		}
+		m.findQuery += string(msg.Runes)
+	}
+	matches := m.inspectMatches()
+	m.findIndex = 0
+	for i, match := range matches {
+		if match.line >= m.inspectScroll {
+			m.findIndex = i
+			break
+		}
+	}
+	return m.scrollToMatch(matches), nil
+}
+
+// jumpMatch moves to the next (delta 1) or previous (delta -1) match,
+// wrapping around at either end.
+func (m Model) jumpMatch(delta int) Model {
+	matches := m.inspectMatches()
+	if len(matches) == 0 {
+		return m
+	}
+	m.findIndex = ((m.findIndex+delta)%len(matches) + len(matches)) % len(matches)
+	return m.scrollToMatch(matches)
+}
+
+// scrollToMatch scrolls the inspector so the current match is in view, a
+// third of the way down when it has to move, and panned in from the left
+// edge when it is off screen horizontally.
+func (m Model) scrollToMatch(matches []findMatch) Model {
+	if m.findIndex >= len(matches) {
+		return m
+	}
+	match := matches[m.findIndex]
+	visibleRows := m.inspectVisibleRows()
+	if match.line < m.inspectScroll || match.line >= m.inspectScroll+visibleRows {
+		m.inspectScroll = min(max(match.line-visibleRows/3, 0), m.inspectMaxScroll())
+	}
+	innerWidth := m.innerWidth()
+	if match.col < m.inspectHScroll || match.col+match.width > m.inspectHScroll+innerWidth {
+		m.inspectHScroll = max(match.col-inspectHScrollStep, 0)
+	}
+	return m
+}
+
+// findFooter renders the inspector's bottom border hint while finding.
+func (m Model) findFooter() string {
+	if m.findMode {
+		return fmt.Sprintf(" find: %s█  enter: done  esc: cancel ", m.findQuery)
+	}
+	matches := m.inspectMatches()
+	if len(matches) == 0 {
+		return fmt.Sprintf(" find: %s (no matches)  %s: clear ", m.findQuery, m.keys.clearFilter.key())
+	}
+	k := m.keys
+	return fmt.Sprintf(" find: %s (%d/%d)  %s/%s: next/prev  %s: clear ",
+		m.findQuery, min(m.findIndex, len(matches)-1)+1, len(matches),
+		k.findNext.key(), k.findPrev.key(), k.clearFilter.key())
+}
@@ -0,0 +1,118 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestMatchRanges(t *testing.T) {
+	t.Parallel()
+
+	got := matchRanges("GetUser getuser users", []string{"user", "getu"})
+	want := [][2]int{{0, 7}, {8, 15}, {16, 20}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("matchRanges = %v, want %v", got, want)
+	}
+	if got := matchRanges("abc", []string{"", "x"}); len(got) != 0 {
+		t.Errorf("matchRanges(no match) = %v", got)
+	}
+}
+
+func TestHighlightMatches_KeepsWidth(t *testing.T) {
+	t.Parallel()
+
+	lines := []string{
+		"\x1b[31m\"name\"\x1b[0m: \x1b[32m\"Alice\"\x1b[0m",
+		"no hit here",
+		"ALICE and alice",
+	}
+	out := highlightMatches(lines, []string{"alice"}, 1)
+	for i := range lines {
+		if ansi.Strip(out[i]) != ansi.Strip(lines[i]) {
+			t.Errorf("line %d text = %q, want %q", i, ansi.Strip(out[i]), ansi.Strip(lines[i]))
+		}
+		if ansi.StringWidth(out[i]) != ansi.StringWidth(lines[i]) {
+			t.Errorf("line %d width = %d, want %d", i, ansi.StringWidth(out[i]), ansi.StringWidth(lines[i]))
+		}
+	}
+	if out[1] != lines[1] {
+		t.Errorf("line without a match changed: %q", out[1])
+	}
+
+	matches := findMatches(lines, []string{"alice"})
+	if len(matches) != 3 || matches[0] != (findMatch{line: 0, col: 9, width: 5}) || matches[2].col != 10 {
+		t.Errorf("findMatches = %+v", matches)
+	}
+}
+
+func TestInspectorFind(t *testing.T) {
+	t.Parallel()
+
+	var body strings.Builder
+	body.WriteString("{")
+	for i := range 60 {
+		if i > 0 {
+			body.WriteString(",\n")
+		}
+		value := "filler"
+		if i == 30 || i == 50 {
+			value = "needle"
+		}
+		fmt.Fprintf(&body, "%q:%q", fmt.Sprintf("field%d", i), value)
+	}
+	body.WriteString("}")
+	ev := testEvent("1", "/pkg.Svc/Get", 0, time.Millisecond)
+	ev.ResponseBody = []byte(body.String())
+
+	m := newTestModel(ev)
+	m = press(m, "enter")
+	m = press(m, "L") // unfold the response body
+	m = press(m, "/")
+	for _, r := range "Needle" {
+		m = press(m, string(r))
+	}
+	m = press(m, "enter")
+	if m.findMode || m.findQuery != "Needle" {
+		t.Fatalf("find mode %v, query %q", m.findMode, m.findQuery)
+	}
+
+	matches := m.inspectMatches()
+	if len(matches) != 2 {
+		t.Fatalf("%d matches, want 2", len(matches))
+	}
+	visible := func(m Model, match findMatch) bool {
+		return match.line >= m.inspectScroll && match.line < m.inspectScroll+m.inspectVisibleRows()
+	}
+	if m.findIndex != 0 || !visible(m, matches[0]) {
+		t.Errorf("first match: index %d, scroll %d, match line %d", m.findIndex, m.inspectScroll, matches[0].line)
+	}
+	if !strings.Contains(m.renderInspector(), "(1/2)") {
+		t.Error("inspector footer does not count the matches")
+	}
+
+	m = press(m, "n")
+	if m.findIndex != 1 || !visible(m, matches[1]) || m.noteMode {
+		t.Errorf("n: index %d, scroll %d, note mode %v", m.findIndex, m.inspectScroll, m.noteMode)
+	}
+	m = press(m, "n")
+	if m.findIndex != 0 {
+		t.Errorf("n past the last match: index %d, want 0", m.findIndex)
+	}
+	m = press(m, "N")
+	if m.findIndex != 1 {
+		t.Errorf("N before the first match: index %d, want 1", m.findIndex)
+	}
+
+	m = press(m, "esc")
+	if m.findQuery != "" {
+		t.Errorf("esc left the query %q", m.findQuery)
+	}
+	m = press(m, "n")
+	if m.noteMode {
+		t.Error("n without a find starts a note")
+	}
+}
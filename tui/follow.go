@@ -0,0 +1,49 @@
+package tui
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// Follow mode keeps the cursor on the newest event as events arrive. It is
+// only possible in chronological order, the one order that puts the newest
+// event last, so other sorts stop it.
+//
+//   - Moving the cursor up, or to the top, stops following.
+//   - F toggles following; turning it on jumps to the newest event.
+//   - With auto-follow, the default, moving the cursor down onto the last row
+//     (j, Ctrl+d, G) resumes following as well. Without it, the cursor can
+//     rest on the last row without being carried along by new events.
+
+// WithAutoFollow sets whether moving the cursor down onto the last row
+// resumes follow mode. Disabled, only the follow key does.
+func WithAutoFollow(enabled bool) Option {
+	return func(m *Model) {
+		m.noAutoFollow = !enabled
+	}
+}
+
+// canFollow reports whether the list order allows follow mode.
+func (m Model) canFollow() bool {
+	return m.sortMode == sortChronological
+}
+
+// followAtBottom resumes follow mode after the cursor moved down, if
+// auto-follow is on and the cursor landed on the last row.
+func (m Model) followAtBottom() Model {
+	if !m.noAutoFollow && m.canFollow() && len(m.displayRows) > 0 && m.cursor == len(m.displayRows)-1 {
+		m.follow = true
+	}
+	return m
+}
+
+// toggleFollow stops following, or starts following from the newest event.
+func (m Model) toggleFollow() (Model, tea.Cmd) {
+	if m.follow {
+		m.follow = false
+		return m, nil
+	}
+	if !m.canFollow() {
+		return m.showAlert("follow needs chronological order")
+	}
+	m.follow = true
+	m.cursor = max(len(m.displayRows)-1, 0)
+	return m, nil
+}
@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+func followModel(n int) Model {
+	events := make([]*tapv1.GRPCEvent, n)
+	for i := range events {
+		events[i] = testEvent(fmt.Sprint(i), "/pkg.Svc/Get", 0, time.Millisecond)
+	}
+	return newTestModel(events...)
+}
+
+func TestFollow_Transitions(t *testing.T) {
+	t.Parallel()
+
+	steps := []struct {
+		key        string
+		wantCursor int
+		wantFollow bool
+	}{
+		{"j", 1, false},
+		{"G", 2, true},       // reaching the bottom resumes following
+		{"k", 1, false},      // moving up stops it
+		{"j", 2, true},       // and so does moving down onto the last row
+		{"F", 2, false},      // F stops following, leaving the cursor
+		{"j", 2, true},       // j on the last row resumes it
+		{"g", 0, false},      // the top stops it
+		{"F", 2, true},       // F starts following from the newest event
+		{"ctrl+u", 0, false}, // half a page up stops it
+		{"ctrl+d", 2, true},
+	}
+	m := followModel(3)
+	for i, s := range steps {
+		m = press(m, s.key)
+		if m.cursor != s.wantCursor || m.follow != s.wantFollow {
+			t.Fatalf("step %d (%s): cursor = %d follow = %v, want %d %v",
+				i, s.key, m.cursor, m.follow, s.wantCursor, s.wantFollow)
+		}
+	}
+}
+
+func TestFollow_NoAutoFollow(t *testing.T) {
+	t.Parallel()
+
+	m := followModel(3)
+	WithAutoFollow(false)(&m)
+
+	for _, key := range []string{"G", "j", "ctrl+d"} {
+		if m = press(m, key); m.cursor != 2 || m.follow {
+			t.Fatalf("%s: cursor = %d follow = %v, want 2 false", key, m.cursor, m.follow)
+		}
+	}
+	// Resting on the last row, the cursor is not carried along.
+	m = deliver(m, testEvent("3", "/pkg.Svc/Get", 0, time.Millisecond))
+	if m.cursor != 2 {
+		t.Errorf("new event moved the cursor to %d, want it kept on 2", m.cursor)
+	}
+
+	m = press(m, "F")
+	if m.cursor != 3 || !m.follow {
+		t.Fatalf("F: cursor = %d follow = %v, want 3 true", m.cursor, m.follow)
+	}
+	m = deliver(m, testEvent("4", "/pkg.Svc/Get", 0, time.Millisecond))
+	if m.cursor != 4 {
+		t.Errorf("new event left the cursor on %d while following, want 4", m.cursor)
+	}
+	if m = press(m, "ctrl+l"); !m.clearMode {
+		t.Fatal("ctrl+l did not ask to clear")
+	}
+	if m = press(m, "y"); m.follow {
+		t.Error("clearing resumed following without auto-follow")
+	}
+}
+
+func TestFollow_Sorted(t *testing.T) {
+	t.Parallel()
+
+	m := press(followModel(3), "s") // by duration
+	m = press(m, "F")
+	if m.follow {
+		t.Error("F followed in a non-chronological order")
+	}
+	if m.alertMessage == "" {
+		t.Error("F gave no reason for not following")
+	}
+}
+
+// deliver hands ev to m as the Watch stream does.
+func deliver(m Model, ev *tapv1.GRPCEvent) Model {
+	updated, _ := m.Update(eventMsg{Event: ev})
+	return updated.(Model) //nolint:forcetypeassert // Update always returns Model
+}
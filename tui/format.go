@@ -2,6 +2,7 @@ package tui
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -11,26 +12,47 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/x/ansi"
 	"google.golang.org/protobuf/encoding/protowire"
-	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+	"github.com/mickamy/grpc-tap/proxy"
 )
 
-func formatDuration(d *durationpb.Duration) string {
-	if d == nil {
-		return "-"
+// formatBytes renders a byte count as B, KB or MB.
+func formatBytes(n int64) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%dB", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1fKB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1024*1024))
 	}
-	return formatDurationValue(d.AsDuration())
 }
 
-func formatDurationValue(dur time.Duration) string {
-	switch {
-	case dur < time.Millisecond:
-		return fmt.Sprintf("%.0fµs", float64(dur.Microseconds()))
-	case dur < time.Second:
-		return fmt.Sprintf("%.1fms", float64(dur.Microseconds())/1000)
-	default:
-		return fmt.Sprintf("%.2fs", dur.Seconds())
+// formatEncoding describes the compression of the captured messages, e.g.
+// "request gzip (1.2KB → 8.0KB captured)". The second size is what was
+// captured after decompression, which falls short of the full message when
+// the capture was truncated. It is empty when nothing was compressed.
+func formatEncoding(ev *tapv1.GRPCEvent) string {
+	var parts []string
+	if enc := ev.GetRequestEncoding(); enc != "" {
+		parts = append(parts, fmt.Sprintf("request %s (%s → %s captured)",
+			enc, formatBytes(ev.GetRequestCompressedSize()), formatBytes(int64(len(ev.GetRequestBody())))))
 	}
+	if enc := ev.GetResponseEncoding(); enc != "" {
+		parts = append(parts, fmt.Sprintf("response %s (%s → %s captured)",
+			enc, formatBytes(ev.GetResponseCompressedSize()), formatBytes(int64(len(ev.GetResponseBody())))))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatRate renders a stream's message rate, e.g. "12.5 msg/s".
+func formatRate(perSecond float64) string {
+	if perSecond >= 100 {
+		return fmt.Sprintf("%.0f msg/s", perSecond)
+	}
+	return fmt.Sprintf("%.1f msg/s", perSecond)
 }
 
 func formatTime(t *timestamppb.Timestamp) string {
@@ -40,15 +62,21 @@ func formatTime(t *timestamppb.Timestamp) string {
 	return t.AsTime().In(time.Local).Format("15:04:05.000") //nolint:gosmopolitan
 }
 
-func truncate(s string, maxLen int) string {
+// truncate shortens s to at most maxWidth display columns, appending "…"
+// when it cuts. It measures display width rather than bytes, so it never
+// splits a multi-byte rune and accounts for wide and zero-width characters.
+func truncate(s string, maxWidth int) string {
 	s = strings.TrimSpace(s)
-	if len(s) <= maxLen {
+	if maxWidth <= 0 {
+		return ""
+	}
+	if ansi.StringWidth(s) <= maxWidth {
 		return s
 	}
-	if maxLen <= 1 {
-		return s[:maxLen]
+	if maxWidth == 1 {
+		return ansi.Truncate(s, 1, "")
 	}
-	return s[:maxLen-1] + "…"
+	return ansi.Truncate(s, maxWidth, "…")
 }
 
 func padRight(s string, width int) string {
@@ -91,21 +119,122 @@ func statusStyle(status int32) lipgloss.Style {
 	return lipgloss.NewStyle().Foreground(lipgloss.Color("1")) // red
 }
 
+// statusString names a gRPC status code with its number, e.g. "OK" or
+// "Unavailable(14)".
 func statusString(status int32) string {
-	if status == 0 {
-		return "OK"
+	return proxy.FormatStatus(status)
+}
+
+// inFlight reports whether ev describes a call that has not finished yet.
+func inFlight(ev *tapv1.GRPCEvent) bool {
+	switch ev.GetPhase() {
+	case tapv1.EventPhase_EVENT_PHASE_START, tapv1.EventPhase_EVENT_PHASE_PROGRESS:
+		return true
+	default:
+		return false
 	}
-	return fmt.Sprintf("ERR(%d)", status)
+}
+
+// nearDeadline is the share of its deadline a call may use before it is
+// flagged as close to timing out.
+const nearDeadline = 0.9
+
+// deadlineString describes the client-set deadline of ev and how much of it
+// the call used, e.g. "100ms (used 92%) near deadline". It returns "" when
+// the client set no deadline.
+func deadlineString(ev *tapv1.GRPCEvent) string {
+	deadline := ev.GetDeadline().AsDuration()
+	if ev.GetDeadline() == nil || deadline <= 0 {
+		return ""
+	}
+	used := float64(ev.GetDuration().AsDuration()) / float64(deadline)
+	s := fmt.Sprintf("%s (used %.0f%%)", deadline, used*100)
+	switch {
+	case used >= 1:
+		s += " exceeded"
+	case used >= nearDeadline:
+		s += " near deadline"
+	}
+	return s
+}
+
+// eventStatusString is statusString for a detail line: a call that is still
+// in flight has no status yet.
+func eventStatusString(ev *tapv1.GRPCEvent) string {
+	if inFlight(ev) {
+		return "…"
+	}
+	return statusString(ev.GetStatus())
+}
+
+// eventStatusName is eventStatusString without the code number, for the
+// narrow status column of the list.
+func eventStatusName(ev *tapv1.GRPCEvent) string {
+	if inFlight(ev) {
+		return "…"
+	}
+	return proxy.StatusName(ev.GetStatus())
 }
 
 func formatBody(data []byte) []string {
+	lines, _ := decodeBody(data)
+	return lines
+}
+
+// bodyView selects how the inspector renders bodies.
+type bodyView int
+
+const (
+	bodyViewAuto    bodyView = iota // decode when possible, else hex dump
+	bodyViewHex                     // always a hex dump
+	bodyViewDecoded                 // always decoded, never a hex dump
+)
+
+func (v bodyView) String() string {
+	switch v {
+	case bodyViewHex:
+		return "hex"
+	case bodyViewDecoded:
+		return "decoded"
+	default:
+		return "auto"
+	}
+}
+
+// renderBody renders a body in the given view. It also reports whether the
+// result is structured (decoded fields or JSON) and can therefore be syntax
+// highlighted.
+func renderBody(data []byte, view bodyView) ([]string, bool) {
+	switch view {
+	case bodyViewHex:
+		return hexDumpLines(data), false
+	case bodyViewDecoded:
+		if lines := decodeProtoWire(data, ""); lines != nil {
+			return lines, true
+		}
+		// Show what text there is, with invalid bytes replaced.
+		s := strings.TrimSpace(strings.ToValidUTF8(string(data), "\uFFFD"))
+		return strings.Split(s, "\n"), json.Valid(data)
+	default:
+		return decodeBody(data)
+	}
+}
+
+// decodeBody renders a body as protobuf wire fields, text or a hex dump. It
+// also reports whether the result is structured (decoded fields or JSON) and
+// can therefore be syntax highlighted.
+func decodeBody(data []byte) ([]string, bool) {
 	if lines := decodeProtoWire(data, ""); lines != nil {
-		return lines
+		return lines, true
 	}
 	if utf8.Valid(data) {
 		s := strings.TrimSpace(string(data))
-		return strings.Split(s, "\n")
+		return strings.Split(s, "\n"), json.Valid(data)
 	}
+	return hexDumpLines(data), false
+}
+
+func hexDumpLines(data []byte) []string {
 	dump := hex.Dump(data)
 	return strings.Split(strings.TrimRight(dump, "\n"), "\n")
 }
@@ -175,6 +304,8 @@ func isPrintable(data []byte) bool {
 	return true
 }
 
+// formatHeaders renders headers sorted by name. Binary (-bin) headers are
+// shown decoded, followed by their raw base64 form.
 func formatHeaders(headers map[string]string) []string {
 	keys := make([]string, 0, len(headers))
 	for k := range headers {
@@ -184,11 +315,54 @@ func formatHeaders(headers map[string]string) []string {
 
 	lines := make([]string, 0, len(keys))
 	for _, k := range keys {
+		if isBinaryHeader(k) {
+			if decoded, ok := formatBinaryHeader(k, headers[k]); ok {
+				lines = append(lines, k+": "+decoded, "  raw: "+headers[k])
+				continue
+			}
+		}
 		lines = append(lines, k+": "+headers[k])
 	}
 	return lines
 }
 
+// protocolLabel returns the protocol of ev followed by the codec its content
+// type names, e.g. "Connect/json" or "gRPC/json", which tells apart calls
+// that share a protocol but not an encoding. gRPC and gRPC-Web calls in
+// their usual proto codec, and events captured without a content type, get
+// the bare protocol.
+func protocolLabel(ev *tapv1.GRPCEvent) string {
+	name := protocolString(int32(ev.GetProtocol()))
+	codec := contentCodec(ev.GetContentType())
+	if codec == "" || (codec == "proto" && ev.GetProtocol() != tapv1.Protocol_PROTOCOL_CONNECT) {
+		return name
+	}
+	return name + "/" + codec
+}
+
+// contentCodec returns the codec a gRPC, gRPC-Web or Connect content type
+// names: the suffix after "+" (application/grpc+json,
+// application/connect+proto) or the subtype of a Connect unary call
+// (application/json). gRPC and gRPC-Web default to proto; other content types
+// name none.
+func contentCodec(ct string) string {
+	mediaType, _, _ := strings.Cut(ct, ";")
+	sub, ok := strings.CutPrefix(strings.ToLower(strings.TrimSpace(mediaType)), "application/")
+	if !ok {
+		return ""
+	}
+	if _, codec, ok := strings.Cut(sub, "+"); ok {
+		return codec
+	}
+	switch sub {
+	case "grpc", "grpc-web", "grpc-web-text":
+		return "proto"
+	case "json", "proto":
+		return sub
+	}
+	return ""
+}
+
 func protocolString(p int32) string {
 	switch p {
 	case 1:
@@ -202,22 +376,40 @@ func protocolString(p int32) string {
 	}
 }
 
+// overlayAlert draws msg in a green box in the middle of bg.
 func overlayAlert(bg, msg string, width int) string {
+	return overlayBox(bg, msg, lipgloss.Color("2"), width, -1)
+}
+
+// overlayBanner draws msg in a red box at the top of bg, just below the
+// first line, for alerts that stay up while their condition lasts.
+func overlayBanner(bg, msg string, width int) string {
+	return overlayBox(bg, msg, lipgloss.Color("1"), width, 1)
+}
+
+// overlayBox draws msg in a box with the given border color over bg,
+// centered horizontally. The box starts at line y, or is centered vertically
+// when y is negative.
+func overlayBox(bg, msg string, color lipgloss.Color, width, y int) string {
 	box := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("2")).
+		BorderForeground(color).
 		Padding(0, 2).
 		Render(msg)
 
 	fgLines := strings.Split(box, "\n")
 	bgLines := strings.Split(bg, "\n")
 
-	startY := max((len(bgLines)-len(fgLines))/2, 0)
+	startY := y
+	if startY < 0 {
+		startY = max((len(bgLines)-len(fgLines))/2, 0)
+	}
 	for i, fl := range fgLines {
 		y := startY + i
 		if y >= len(bgLines) {
 			break
 		}
+		fl = ansi.Truncate(fl, width, "")
 		fw := lipgloss.Width(fl)
 		pad := max((width-fw)/2, 0)
 		left := ansi.Cut(bgLines[y], 0, pad)
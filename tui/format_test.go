@@ -0,0 +1,293 @@
+package tui
+
+import (
+	"encoding/base64"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	statuspb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+func TestTruncate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		s        string
+		maxWidth int
+		want     string
+	}{
+		{name: "fits", s: "/pkg.Svc/Get", maxWidth: 20, want: "/pkg.Svc/Get"},
+		{name: "exact", s: "abcde", maxWidth: 5, want: "abcde"},
+		{name: "ascii cut", s: "abcdefgh", maxWidth: 5, want: "abcd…"},
+		{name: "trims space", s: "  abc  ", maxWidth: 3, want: "abc"},
+		{name: "width one", s: "abcdef", maxWidth: 1, want: "a"},
+		{name: "width zero", s: "abcdef", maxWidth: 0, want: ""},
+		{name: "negative width", s: "abcdef", maxWidth: -3, want: ""},
+		{name: "multi-byte", s: "/pkg.Sérvïce/Méthod", maxWidth: 8, want: "/pkg.Sé…"},
+		{name: "wide chars", s: "日本語のメソッド", maxWidth: 7, want: "日本語…"},
+		{name: "wide char does not split", s: "日本語", maxWidth: 4, want: "日…"},
+		{name: "zero-width joiner", s: "a‍b‍cdef", maxWidth: 4, want: "a‍b‍c…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := truncate(tt.s, tt.maxWidth)
+			if got != tt.want {
+				t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.maxWidth, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("truncate(%q, %d) produced invalid UTF-8: %q", tt.s, tt.maxWidth, got)
+			}
+			if w := ansi.StringWidth(got); tt.maxWidth >= 0 && w > tt.maxWidth {
+				t.Errorf("truncate(%q, %d) width = %d, exceeds max", tt.s, tt.maxWidth, w)
+			}
+		})
+	}
+}
+
+func TestFormatEncoding(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		ev   *tapv1.GRPCEvent
+		want string
+	}{
+		{name: "uncompressed", ev: &tapv1.GRPCEvent{RequestBody: []byte("abc")}, want: ""},
+		{
+			name: "response gzip",
+			ev: &tapv1.GRPCEvent{
+				ResponseEncoding:       "gzip",
+				ResponseCompressedSize: 1229,
+				ResponseBody:           make([]byte, 8192),
+			},
+			want: "response gzip (1.2KB → 8.0KB captured)",
+		},
+		{
+			name: "both",
+			ev: &tapv1.GRPCEvent{
+				RequestEncoding:        "snappy",
+				RequestCompressedSize:  10,
+				RequestBody:            make([]byte, 100),
+				ResponseEncoding:       "deflate",
+				ResponseCompressedSize: 2 * 1024 * 1024,
+				ResponseBody:           make([]byte, 64*1024),
+			},
+			want: "request snappy (10B → 100B captured), response deflate (2.0MB → 64.0KB captured)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := formatEncoding(tt.ev); got != tt.want {
+				t.Errorf("formatEncoding() = %q, want %q", got, tt.want)
+			}
+			hasEncoding := slices.ContainsFunc(newTestModel(tt.ev).inspectSummary(tt.ev), func(l string) bool {
+				return l == "Encoding: "+tt.want
+			})
+			if hasEncoding != (tt.want != "") {
+				t.Errorf("inspector shows encoding = %v, want %v", hasEncoding, tt.want != "")
+			}
+		})
+	}
+}
+
+func TestRenderBody(t *testing.T) {
+	t.Parallel()
+
+	binary := []byte("ok\xff\xfe")
+	if lines, _ := renderBody(binary, bodyViewAuto); !strings.HasPrefix(lines[0], "00000000") {
+		t.Errorf("auto: %q, want a hex dump for invalid UTF-8", lines)
+	}
+	if lines, _ := renderBody(binary, bodyViewDecoded); len(lines) != 1 || lines[0] != "ok\uFFFD" {
+		t.Errorf("decoded: %q, want text with a replacement character", lines)
+	}
+	if lines, structured := renderBody([]byte(`{"a":1}`), bodyViewHex); structured || !strings.HasPrefix(lines[0], "00000000") {
+		t.Errorf("hex: %q (structured=%v), want an unhighlighted hex dump", lines, structured)
+	}
+}
+
+func TestFormatHeaders_Binary(t *testing.T) {
+	t.Parallel()
+
+	details, err := proto.Marshal(&statuspb.Status{
+		Code:    3,
+		Message: "bad name",
+		Details: []*anypb.Any{mustAny(t, &errdetails.ErrorInfo{Reason: "NAME_EMPTY"})},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	detailsB64 := base64.RawStdEncoding.EncodeToString(details)
+
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		want  []string
+	}{
+		{
+			name:  "padded",
+			key:   "trace-bin",
+			value: base64.StdEncoding.EncodeToString([]byte{0x01, 0x02, 0xff, 0x00}),
+			want:  []string{"trace-bin: 0102ff00", "  raw: AQL/AA=="},
+		},
+		{
+			name:  "unpadded values",
+			key:   "Trace-Bin",
+			value: "AQL/AA, AQ",
+			want:  []string{"Trace-Bin: 0102ff00, 01", "  raw: AQL/AA, AQ"},
+		},
+		{
+			name:  "status details",
+			key:   "grpc-status-details-bin",
+			value: detailsB64,
+			want: []string{
+				`grpc-status-details-bin: code=3 message="bad name" [google.rpc.ErrorInfo {reason: "NAME_EMPTY"}]`,
+				"  raw: " + detailsB64,
+			},
+		},
+		{
+			name:  "not base64",
+			key:   "trace-bin",
+			value: "not base64!",
+			want:  []string{"trace-bin: not base64!"},
+		},
+		{
+			name:  "text header",
+			key:   "x-user",
+			value: "AQL/AA==",
+			want:  []string{"x-user: AQL/AA=="},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := formatHeaders(map[string]string{tt.key: tt.value})
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("formatHeaders = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func mustAny(t *testing.T, m proto.Message) *anypb.Any {
+	t.Helper()
+	a, err := anypb.New(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestDeadlineString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		deadline time.Duration
+		duration time.Duration
+		want     string
+	}{
+		{name: "none", deadline: 0, duration: time.Millisecond, want: ""},
+		{name: "plenty left", deadline: 100 * time.Millisecond, duration: 40 * time.Millisecond, want: "100ms (used 40%)"},
+		{name: "near", deadline: 100 * time.Millisecond, duration: 92 * time.Millisecond, want: "100ms (used 92%) near deadline"},
+		{name: "exceeded", deadline: 5 * time.Second, duration: 6 * time.Second, want: "5s (used 120%) exceeded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ev := testEvent("1", "/pkg.Svc/Get", 0, tt.duration)
+			if tt.deadline > 0 {
+				ev.Deadline = durationpb.New(tt.deadline)
+			}
+			if got := deadlineString(ev); got != tt.want {
+				t.Errorf("deadlineString = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDurationString(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel()
+	ev := testEvent("1", "/pkg.Svc/Get", 0, 340*time.Millisecond)
+	if got, want := m.durationString(ev), "340.0ms"; got != want {
+		t.Errorf("without TTFB = %q, want %q", got, want)
+	}
+	ev.TimeToFirstByte = durationpb.New(12 * time.Millisecond)
+	if got, want := m.durationString(ev), "TTFB: 12.0ms, Total: 340.0ms"; got != want {
+		t.Errorf("with TTFB = %q, want %q", got, want)
+	}
+}
+
+func TestFormatRate(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[float64]string{12.5: "12.5 msg/s", 0.5: "0.5 msg/s", 1234.4: "1234 msg/s"} {
+		if got := formatRate(in); got != want {
+			t.Errorf("formatRate(%v) = %q, want %q", in, got, want)
+		}
+	}
+
+	ev := testEvent("1", "/pkg.Svc/Watch", 0, time.Second)
+	m := newTestModel(ev)
+	hasRate := func() bool {
+		return slices.ContainsFunc(m.inspectSummary(ev), func(l string) bool { return strings.HasPrefix(l, "Rate:") })
+	}
+	if hasRate() {
+		t.Error("inspector shows a rate for a call without one")
+	}
+	ev.ResponseMessageRate = 12.5
+	if !slices.Contains(m.inspectSummary(ev), "Rate:     12.5 msg/s") {
+		t.Errorf("inspector summary = %q, want a Rate line", m.inspectSummary(ev))
+	}
+}
+
+func TestProtocolLabel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		protocol    tapv1.Protocol
+		contentType string
+		want        string
+	}{
+		{tapv1.Protocol_PROTOCOL_CONNECT, "application/json", "Connect/json"},
+		{tapv1.Protocol_PROTOCOL_CONNECT, "application/json; charset=utf-8", "Connect/json"},
+		{tapv1.Protocol_PROTOCOL_CONNECT, "application/proto", "Connect/proto"},
+		{tapv1.Protocol_PROTOCOL_CONNECT, "application/connect+json", "Connect/json"},
+		{tapv1.Protocol_PROTOCOL_CONNECT, "Application/Connect+Proto", "Connect/proto"},
+		{tapv1.Protocol_PROTOCOL_CONNECT, "text/plain", "Connect"},
+		{tapv1.Protocol_PROTOCOL_CONNECT, "", "Connect"},
+		{tapv1.Protocol_PROTOCOL_GRPC, "application/grpc", "gRPC"},
+		{tapv1.Protocol_PROTOCOL_GRPC, "application/grpc+proto", "gRPC"},
+		{tapv1.Protocol_PROTOCOL_GRPC, "application/grpc+json", "gRPC/json"},
+		{tapv1.Protocol_PROTOCOL_GRPC_WEB, "application/grpc-web-text", "gRPC-Web"},
+		{tapv1.Protocol_PROTOCOL_GRPC_WEB, "application/grpc-web+json", "gRPC-Web/json"},
+	}
+	for _, tt := range tests {
+		ev := &tapv1.GRPCEvent{Protocol: tt.protocol, ContentType: tt.contentType}
+		if got := protocolLabel(ev); got != tt.want {
+			t.Errorf("protocolLabel(%v, %q) = %q, want %q", tt.protocol, tt.contentType, got, tt.want)
+		}
+		if len(tt.want) > listColProto {
+			t.Errorf("label %q is wider than the proto column", tt.want)
+		}
+	}
+}
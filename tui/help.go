@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+type helpBinding struct {
+	keys string
+	desc string
+}
+
+type helpSection struct {
+	title    string
+	bindings []helpBinding
+}
+
+func helpLines(sections []helpSection) []string {
+	keyWidth := 0
+	for _, sec := range sections {
+		for _, b := range sec.bindings {
+			keyWidth = max(keyWidth, lipgloss.Width(b.keys))
+		}
+	}
+
+	bold := lipgloss.NewStyle().Bold(true)
+	var lines []string
+	for i, sec := range sections {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, bold.Render("── "+sec.title+" ──"))
+		for _, b := range sec.bindings {
+			lines = append(lines, fmt.Sprintf("  %s  %s", padRight(b.keys, keyWidth), b.desc))
+		}
+	}
+	return lines
+}
+
+// renderHelp renders the full-screen help overlay.
+func (m Model) renderHelp() string {
+	innerWidth := m.innerWidth()
+	visibleRows := max(m.height-2, 3)
+
+	// The current view's section goes first, so it stays visible when the
+	// overlay is cut off at the bottom. Sections are in viewMode order.
+	sections := m.keys.helpSections()
+	if i := int(m.view); i > 0 && i < len(sections) {
+		sections = append([]helpSection{sections[i]}, append(sections[:i:i], sections[i+1:]...)...)
+	}
+	lines := helpLines(sections)
+	if len(lines) > visibleRows {
+		lines = lines[:visibleRows]
+	}
+
+	return boxWith(strings.Join(lines, "\n"), innerWidth, lipgloss.Color("240"), " Help ", " press any key to close ")
+}
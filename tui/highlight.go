@@ -0,0 +1,116 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// WithSyntaxHighlight enables or disables coloring of field numbers, strings
+// and numbers in decoded bodies. It is enabled by default.
+func WithSyntaxHighlight(enabled bool) Option {
+	return func(m *Model) {
+		m.highlight = enabled
+	}
+}
+
+// bodyTheme colors the tokens of a decoded body.
+type bodyTheme struct {
+	key    func(...string) string // field numbers and JSON object keys
+	str    func(...string) string // string values
+	number func(...string) string // numbers, true, false and null
+}
+
+func defaultBodyTheme() bodyTheme {
+	return bodyTheme{
+		key:    lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Render, // cyan
+		str:    lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Render, // green
+		number: lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render, // yellow
+	}
+}
+
+// highlightLines applies theme to each line of a decoded body.
+func highlightLines(lines []string, theme bodyTheme) []string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = highlightLine(line, theme)
+	}
+	return out
+}
+
+// highlightLine colors one line of decodeProtoWire or formatMessage output
+// (`1: "text"`, `name: "text"`) or JSON. A string, number or name followed by
+// a colon is a key; anything else it does not recognize, such as
+// hex-encoded bytes or enum names, is left as-is. Only styling is added,
+// so the visible text and its width are unchanged.
+func highlightLine(line string, theme bodyTheme) string {
+	var b strings.Builder
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == '"':
+			end := stringEnd(line, i)
+			tok := line[i:end]
+			if isKey(line, end) {
+				b.WriteString(theme.key(tok))
+			} else {
+				b.WriteString(theme.str(tok))
+			}
+			i = end
+		case isWordByte(c) || c == '-':
+			end := i + 1
+			for end < len(line) && (isWordByte(line[end]) || line[end] == '.' || line[end] == '-' || line[end] == '+') {
+				end++
+			}
+			tok := line[i:end]
+			switch {
+			case isKey(line, end):
+				b.WriteString(theme.key(tok))
+			case isLiteral(tok):
+				b.WriteString(theme.number(tok))
+			default:
+				b.WriteString(tok)
+			}
+			i = end
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// stringEnd returns the index just past the quoted string starting at
+// line[start], or len(line) if it is not terminated.
+func stringEnd(line string, start int) int {
+	for i := start + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(line)
+}
+
+// isKey reports whether the token ending at line[end] is followed by a colon.
+func isKey(line string, end int) bool {
+	rest := strings.TrimLeft(line[end:], " ")
+	return strings.HasPrefix(rest, ":")
+}
+
+func isWordByte(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+// isLiteral reports whether tok is a number or a JSON literal.
+func isLiteral(tok string) bool {
+	switch tok {
+	case "true", "false", "null":
+		return true
+	}
+	_, err := strconv.ParseFloat(tok, 64)
+	return err == nil
+}
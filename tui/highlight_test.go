@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// markTheme wraps tokens in visible markers instead of ANSI styles.
+var markTheme = bodyTheme{
+	key:    func(s ...string) string { return "<k:" + strings.Join(s, "") + ">" },
+	str:    func(s ...string) string { return "<s:" + strings.Join(s, "") + ">" },
+	number: func(s ...string) string { return "<n:" + strings.Join(s, "") + ">" },
+}
+
+func TestHighlightLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{name: "wire string", line: `  1: "hello"`, want: `  <k:1>: <s:"hello">`},
+		{name: "wire varint", line: `2: 42`, want: `<k:2>: <n:42>`},
+		{name: "wire nested", line: `3: {`, want: `<k:3>: {`},
+		{name: "wire hex bytes", line: `4: 0a0bff`, want: `<k:4>: 0a0bff`},
+		{name: "schema name", line: `  call_type: CALL_TYPE_UNARY (1)`, want: `  <k:call_type>: CALL_TYPE_UNARY (<n:1>)`},
+		{
+			name: "json object",
+			line: `{"name": "x", "n": -1.5, "ok": true, "v": null}`,
+			want: `{<k:"name">: <s:"x">, <k:"n">: <n:-1.5>, <k:"ok">: <n:true>, <k:"v">: <n:null>}`,
+		},
+		{name: "escaped quote", line: `"a\"b": "c:d"`, want: `<k:"a\"b">: <s:"c:d">`},
+		{name: "unterminated string", line: `"abc`, want: `<s:"abc>`},
+		{name: "plain brace", line: `}`, want: `}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := highlightLine(tt.line, markTheme); got != tt.want {
+				t.Errorf("highlightLine(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlightLines_KeepsWidth(t *testing.T) {
+	t.Parallel()
+
+	lines := []string{`1: "héllo"`, `  2: 12345`, `{"k": [1, 2, "three"]}`}
+	for i, got := range highlightLines(lines, defaultBodyTheme()) {
+		if plain := ansi.Strip(got); plain != lines[i] {
+			t.Errorf("line %d: stripped = %q, want %q", i, plain, lines[i])
+		}
+		if w := ansi.StringWidth(got); w != ansi.StringWidth(lines[i]) {
+			t.Errorf("line %d: width = %d, want %d", i, w, ansi.StringWidth(lines[i]))
+		}
+	}
+}
+
+func TestDecodeBody_Structured(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{name: "protobuf", data: []byte{0x08, 0x2a}, want: true},
+		{name: "json", data: []byte(`{"a": 1}`), want: true},
+		{name: "text", data: []byte("hello world"), want: false},
+		{name: "binary", data: []byte{0xff, 0xfe, 0x00}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, got := decodeBody(tt.data); got != tt.want {
+				t.Errorf("decodeBody(%q) structured = %v, want %v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"strings"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+// DefaultInternalMethods are the method prefixes of housekeeping traffic —
+// health checks and server reflection — hidden from the list, analytics and
+// exports by default.
+var DefaultInternalMethods = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.v1.ServerReflection/",
+	"/grpc.reflection.v1alpha.ServerReflection/",
+}
+
+// WithInternalMethods replaces DefaultInternalMethods with prefixes.
+func WithInternalMethods(prefixes []string) Option {
+	return func(m *Model) {
+		m.internalMethods = prefixes
+	}
+}
+
+// WithHideInternal sets whether internal methods start out hidden. They are
+// hidden by default; the internal key toggles them at runtime.
+func WithHideInternal(hide bool) Option {
+	return func(m *Model) {
+		m.hideInternal = hide
+	}
+}
+
+// isInternalMethod reports whether method starts with one of prefixes.
+func isInternalMethod(method string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if p != "" && strings.HasPrefix(method, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// hidden reports whether ev is internal traffic that is currently hidden.
+func (m Model) hidden(ev *tapv1.GRPCEvent) bool {
+	return m.hiddenMethod(ev.GetMethod())
+}
+
+// hiddenMethod reports whether calls to method are hidden as internal.
+func (m Model) hiddenMethod(method string) bool {
+	return m.hideInternal && isInternalMethod(method, m.internalMethods)
+}
+
+// hiddenPrefixes returns the internal prefixes to leave out of exports, or
+// nil when internal methods are shown.
+func (m Model) hiddenPrefixes() []string {
+	if !m.hideInternal {
+		return nil
+	}
+	return m.internalMethods
+}
+
+// category classifies calls as application or infrastructure traffic.
+type category int
+
+const (
+	categoryApp   category = iota // the services under inspection
+	categoryInfra                 // health checks, reflection and other internalMethods
+)
+
+func (c category) String() string {
+	if c == categoryInfra {
+		return "infra"
+	}
+	return "app"
+}
+
+// categorize classifies method by the configured internal method prefixes.
+// The list dims infra calls and analytics groups them after the app methods.
+func (m Model) categorize(method string) category {
+	if isInternalMethod(method, m.internalMethods) {
+		return categoryInfra
+	}
+	return categoryApp
+}
+
+// infraCount returns how many captured calls are infra traffic, hidden or not.
+func (m Model) infraCount() int {
+	n := 0
+	for _, ev := range m.events {
+		if m.categorize(ev.GetMethod()) == categoryInfra {
+			n++
+		}
+	}
+	return n
+}
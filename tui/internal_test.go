@@ -0,0 +1,140 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+func internalTestEvents() []*tapv1.GRPCEvent {
+	return []*tapv1.GRPCEvent{
+		testEvent("1", "/pkg.Svc/Get", 0, time.Millisecond),
+		testEvent("2", "/grpc.health.v1.Health/Check", 0, time.Millisecond),
+		testEvent("3", "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo", 0, time.Millisecond),
+		testEvent("4", "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo", 0, time.Millisecond),
+	}
+}
+
+func TestIsInternalMethod(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		method string
+		want   bool
+	}{
+		{method: "/grpc.health.v1.Health/Check", want: true},
+		{method: "/grpc.health.v1.Health/Watch", want: true},
+		{method: "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo", want: true},
+		{method: "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo", want: true},
+		{method: "/pkg.Svc/Get", want: false},
+		{method: "/grpc.health.v1.HealthCheck/Get", want: false},
+		{method: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			t.Parallel()
+			if got := isInternalMethod(tt.method, DefaultInternalMethods); got != tt.want {
+				t.Errorf("isInternalMethod(%q) = %v, want %v", tt.method, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHideInternal(t *testing.T) {
+	t.Parallel()
+
+	t.Run("hidden by default", func(t *testing.T) {
+		t.Parallel()
+		m := newTestModel(internalTestEvents()...)
+		if len(m.displayRows) != 1 {
+			t.Errorf("rows = %d, want 1", len(m.displayRows))
+		}
+		if rows := m.buildAnalyticsRows(); len(rows) != 1 || rows[0].method != "/pkg.Svc/Get" {
+			t.Errorf("analytics rows = %v, want only /pkg.Svc/Get", rows)
+		}
+		f := exportFilter{hidden: m.hiddenPrefixes()}
+		if got := filteredExportEvents(m.events, f); len(got) != 1 {
+			t.Errorf("exported %d events, want 1", len(got))
+		}
+	})
+
+	t.Run("toggled back in", func(t *testing.T) {
+		t.Parallel()
+		m := press(newTestModel(internalTestEvents()...), "I")
+		if len(m.displayRows) != 4 {
+			t.Errorf("rows = %d, want 4", len(m.displayRows))
+		}
+		if rows := m.buildAnalyticsRows(); len(rows) != 4 {
+			t.Errorf("analytics rows = %d, want 4", len(rows))
+		}
+		f := exportFilter{hidden: m.hiddenPrefixes()}
+		if got := filteredExportEvents(m.events, f); len(got) != 4 {
+			t.Errorf("exported %d events, want 4", len(got))
+		}
+
+		m = press(m, "I")
+		if len(m.displayRows) != 1 {
+			t.Errorf("rows after second toggle = %d, want 1", len(m.displayRows))
+		}
+	})
+
+	t.Run("custom prefixes", func(t *testing.T) {
+		t.Parallel()
+		m := New("localhost:9092", WithInternalMethods([]string{"/pkg.Svc/"}))
+		for _, ev := range internalTestEvents() {
+			m, _ = m.upsertEvent(ev)
+		}
+		m.displayRows = m.rebuildDisplayRows()
+		if len(m.displayRows) != 3 {
+			t.Errorf("rows = %d, want 3", len(m.displayRows))
+		}
+	})
+
+	t.Run("shown by option", func(t *testing.T) {
+		t.Parallel()
+		m := New("localhost:9092", WithHideInternal(false))
+		for _, ev := range internalTestEvents() {
+			m, _ = m.upsertEvent(ev)
+		}
+		m.displayRows = m.rebuildDisplayRows()
+		if len(m.displayRows) != 4 {
+			t.Errorf("rows = %d, want 4", len(m.displayRows))
+		}
+	})
+}
+
+func TestInfraCategory(t *testing.T) {
+	t.Parallel()
+
+	m := press(newTestModel(internalTestEvents()...), "I")
+	if got := m.categorize("/grpc.health.v1.Health/Check"); got != categoryInfra {
+		t.Errorf("health check categorized as %s, want infra", got)
+	}
+	if got := m.categorize("/pkg.Svc/Get"); got != categoryApp {
+		t.Errorf("app method categorized as %s, want app", got)
+	}
+
+	if !strings.Contains(m.renderListView(), "[infra: 3]") {
+		t.Error("title does not show the infra count")
+	}
+	if hidden := press(m, "I"); !strings.Contains(hidden.renderListView(), "[infra: 3]") {
+		t.Error("title does not count hidden infra calls")
+	}
+
+	rows := m.buildAnalyticsRows()
+	for _, mode := range []analyticsSortMode{
+		analyticsSortTotalDuration, analyticsSortCount, analyticsSortAvgDuration, analyticsSortErrorRate,
+	} {
+		sortAnalyticsRows(rows, mode)
+		if rows[0].method != "/pkg.Svc/Get" {
+			t.Errorf("sort %s: first row = %s, want the app method", mode, rows[0].method)
+		}
+		for _, r := range rows[1:] {
+			if r.category != categoryInfra {
+				t.Errorf("sort %s: %s grouped with infra", mode, r.method)
+			}
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyBinding maps one or more key strings (as reported by tea.KeyMsg.String)
+// to an action.
+type keyBinding struct {
+	keys []string
+	desc string
+}
+
+func newBinding(desc string, keys ...string) keyBinding {
+	return keyBinding{keys: keys, desc: desc}
+}
+
+func (b keyBinding) matches(msg tea.KeyMsg) bool {
+	return slices.Contains(b.keys, msg.String())
+}
+
+// key returns the primary key, used in compact footer hints.
+func (b keyBinding) key() string {
+	if len(b.keys) == 0 {
+		return ""
+	}
+	return displayKey(b.keys[0])
+}
+
+// helpKeys returns all keys joined for the help overlay, e.g. "j / ↓".
+func (b keyBinding) helpKeys() string {
+	labels := make([]string, 0, len(b.keys))
+	for _, k := range b.keys {
+		labels = append(labels, displayKey(k))
+	}
+	return strings.Join(labels, " / ")
+}
+
+func displayKey(k string) string {
+	switch k {
+	case "up":
+		return "↑"
+	case "down":
+		return "↓"
+	case "left":
+		return "←"
+	case "right":
+		return "→"
+	case "pgdown":
+		return "pgdn"
+	}
+	return k
+}
+
+// KeyMap holds every keybinding of the TUI. Start from DefaultKeyMap and
+// override individual actions with LoadKeyMap.
+type KeyMap struct {
+	quit      keyBinding
+	forceQuit keyBinding
+	back      keyBinding
+	help      keyBinding
+
+	down         keyBinding
+	up           keyBinding
+	halfPageDown keyBinding
+	halfPageUp   keyBinding
+	top          keyBinding
+	bottom       keyBinding
+	follow       keyBinding
+
+	inspect     keyBinding
+	search      keyBinding
+	sort        keyBinding
+	reverseSort keyBinding
+	errors      keyBinding
+	internal    keyBinding
+	preview     keyBinding
+	changes     keyBinding
+	bookmark    keyBinding
+	bookmarks   keyBinding
+	analytics   keyBinding
+	views       keyBinding
+	write       keyBinding
+	replayAll   keyBinding
+	clear       keyBinding
+	clearFilter keyBinding
+
+	scrollDown   keyBinding
+	scrollUp     keyBinding
+	panLeft      keyBinding
+	panRight     keyBinding
+	copyRequest  keyBinding
+	copyResponse keyBinding
+	copyReqText  keyBinding
+	copyRespText keyBinding
+	edit         keyBinding
+	resendEdit   keyBinding
+	note         keyBinding
+	find         keyBinding
+	findNext     keyBinding
+	findPrev     keyBinding
+	splitView    keyBinding
+	splitFocus   keyBinding
+	expand       keyBinding
+	hexView      keyBinding
+	decodedView  keyBinding
+	writeRaw     keyBinding
+
+	analyticsSort   keyBinding
+	analyticsWindow keyBinding
+	analyticsCodes  keyBinding
+}
+
+// DefaultKeyMap returns the built-in keybindings.
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		quit:      newBinding("quit", "q"),
+		forceQuit: newBinding("quit from any view", "ctrl+c"),
+		back:      newBinding("back to list", "q"),
+		help:      newBinding("toggle help", "?"),
+
+		down:         newBinding("move down", "j", "down"),
+		up:           newBinding("move up", "k", "up"),
+		halfPageDown: newBinding("half-page down", "ctrl+d", "pgdown"),
+		halfPageUp:   newBinding("half-page up", "ctrl+u", "pgup"),
+		top:          newBinding("jump to top", "g", "home"),
+		bottom:       newBinding("jump to bottom", "G", "end"),
+		follow:       newBinding("follow new events on/off", "F"),
+
+		inspect:     newBinding("inspect call", "enter"),
+		search:      newBinding("incremental search", "/"),
+		sort:        newBinding("cycle sort (time/duration/method/status/protocol)", "s"),
+		reverseSort: newBinding("reverse sort direction", "S"),
+		errors:      newBinding("toggle error filter", "e"),
+		internal:    newBinding("show/hide internal methods (health, reflection)", "I"),
+		preview:     newBinding("show/hide the preview pane", "P"),
+		changes:     newBinding("mark changed responses, dim repeated ones", "D"),
+		bookmark:    newBinding("bookmark call (kept through clears)", "b"),
+		bookmarks:   newBinding("toggle bookmark filter", "B"),
+		analytics:   newBinding("analytics view", "a"),
+		views:       newBinding("saved views (apply/save/delete)", "V"),
+		write:       newBinding("write export (json/markdown)", "w"),
+		replayAll:   newBinding("replay the listed unary calls (again to stop)", "R"),
+		clear:       newBinding("clear captured events", "ctrl+l"),
+		clearFilter: newBinding("clear search filter", "esc"),
+
+		scrollDown:   newBinding("scroll down", "j", "down"),
+		scrollUp:     newBinding("scroll up", "k", "up"),
+		panLeft:      newBinding("pan left", "h", "left"),
+		panRight:     newBinding("pan right", "l", "right"),
+		copyRequest:  newBinding("copy request body", "c"),
+		copyResponse: newBinding("copy response body", "C"),
+		copyReqText:  newBinding("copy request as protobuf text format", "t"),
+		copyRespText: newBinding("copy response as protobuf text format", "T"),
+		edit:         newBinding("edit request fields & resend", "e"),
+		resendEdit:   newBinding("resend the last edited request", "r"),
+		note:         newBinding("add a note (included in exports)", "m"),
+		find:         newBinding("find in call (highlights matches)", "/"),
+		findNext:     newBinding("next match (while finding)", "n"),
+		findPrev:     newBinding("previous match (while finding)", "N"),
+		splitView:    newBinding("toggle request/response side by side", "v"),
+		splitFocus:   newBinding("switch the scrolled pane (side by side)", "tab"),
+		expand:       newBinding("expand/collapse long bodies", "L"),
+		hexView:      newBinding("toggle hexdump of bodies", "x"),
+		decodedView:  newBinding("toggle forced decoding of bodies", "d"),
+		writeRaw:     newBinding("write raw bodies to .bin files", "w"),
+
+		analyticsSort:   newBinding("cycle sort (total/count/avg/errors)", "s"),
+		analyticsWindow: newBinding("cycle time window (all/1m/5m/15m)", "t"),
+		analyticsCodes:  newBinding("toggle error breakdown by status code", "c"),
+	}
+}
+
+// actions maps the action names used in keymap files to their bindings.
+func (k *KeyMap) actions() map[string]*keyBinding {
+	return map[string]*keyBinding{
+		"quit":               &k.quit,
+		"force_quit":         &k.forceQuit,
+		"back":               &k.back,
+		"help":               &k.help,
+		"down":               &k.down,
+		"up":                 &k.up,
+		"half_page_down":     &k.halfPageDown,
+		"half_page_up":       &k.halfPageUp,
+		"top":                &k.top,
+		"bottom":             &k.bottom,
+		"follow":             &k.follow,
+		"inspect":            &k.inspect,
+		"search":             &k.search,
+		"sort":               &k.sort,
+		"reverse_sort":       &k.reverseSort,
+		"errors":             &k.errors,
+		"internal":           &k.internal,
+		"preview":            &k.preview,
+		"changes":            &k.changes,
+		"bookmark":           &k.bookmark,
+		"bookmarks":          &k.bookmarks,
+		"analytics":          &k.analytics,
+		"views":              &k.views,
+		"write":              &k.write,
+		"replay_all":         &k.replayAll,
+		"clear":              &k.clear,
+		"clear_filter":       &k.clearFilter,
+		"scroll_down":        &k.scrollDown,
+		"scroll_up":          &k.scrollUp,
+		"pan_left":           &k.panLeft,
+		"pan_right":          &k.panRight,
+		"copy_request":       &k.copyRequest,
+		"copy_response":      &k.copyResponse,
+		"copy_request_text":  &k.copyReqText,
+		"copy_response_text": &k.copyRespText,
+		"edit":               &k.edit,
+		"resend_edit":        &k.resendEdit,
+		"note":               &k.note,
+		"find":               &k.find,
+		"find_next":          &k.findNext,
+		"find_prev":          &k.findPrev,
+		"split_view":         &k.splitView,
+		"split_focus":        &k.splitFocus,
+		"expand":             &k.expand,
+		"hex_view":           &k.hexView,
+		"decoded_view":       &k.decodedView,
+		"write_raw":          &k.writeRaw,
+		"analytics_sort":     &k.analyticsSort,
+		"analytics_window":   &k.analyticsWindow,
+		"analytics_codes":    &k.analyticsCodes,
+	}
+}
+
+// LoadKeyMap reads a JSON keymap file and applies it on top of the defaults.
+// The file maps action names to key lists, e.g.
+//
+//	{"down": ["n", "down"], "up": ["p", "up"]}
+func LoadKeyMap(path string) (KeyMap, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is user-provided config
+	if err != nil {
+		return KeyMap{}, fmt.Errorf("read keymap: %w", err)
+	}
+	return parseKeyMap(data)
+}
+
+func parseKeyMap(data []byte) (KeyMap, error) {
+	var overrides map[string][]string
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return KeyMap{}, fmt.Errorf("parse keymap: %w", err)
+	}
+
+	km := DefaultKeyMap()
+	actions := km.actions()
+
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		b, ok := actions[name]
+		if !ok {
+			return KeyMap{}, fmt.Errorf("parse keymap: unknown action %q", name)
+		}
+		keys := overrides[name]
+		if len(keys) == 0 {
+			return KeyMap{}, fmt.Errorf("parse keymap: action %q has no keys", name)
+		}
+		b.keys = keys
+	}
+	return km, nil
+}
+
+// helpSections builds the help overlay content from the keymap.
+func (k KeyMap) helpSections() []helpSection {
+	section := func(title string, bindings ...keyBinding) helpSection {
+		sec := helpSection{title: title}
+		for _, b := range bindings {
+			sec.bindings = append(sec.bindings, helpBinding{keys: b.helpKeys(), desc: b.desc})
+		}
+		return sec
+	}
+	return []helpSection{
+		section("List",
+			k.down, k.up, k.halfPageDown, k.halfPageUp, k.top, k.bottom, k.follow,
+			k.search, k.sort, k.reverseSort, k.inspect, k.errors, k.internal, k.preview, k.changes, k.bookmark, k.bookmarks, k.analytics, k.views, k.write, k.replayAll, k.clear,
+			k.clearFilter, k.help, k.quit, k.forceQuit,
+		),
+		section("Inspector",
+			k.scrollDown, k.scrollUp, k.halfPageDown, k.halfPageUp, k.top, k.bottom,
+			k.panLeft, k.panRight,
+			k.copyRequest, k.copyResponse, k.copyReqText, k.copyRespText, k.edit, k.resendEdit, k.note, k.find, k.findNext, k.findPrev, k.expand,
+			k.splitView, k.splitFocus,
+			k.hexView, k.decodedView, k.writeRaw, k.help, k.back,
+		),
+		section("Analytics",
+			k.down, k.up, k.halfPageDown, k.halfPageUp, k.top, k.bottom,
+			k.analyticsSort, k.analyticsWindow, k.analyticsCodes, k.help, k.back,
+		),
+	}
+}
@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadKeyMap(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, []byte(`{"down": ["n"], "up": ["p"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	km, err := LoadKeyMap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestModel(
+		testEvent("1", "/pkg.Svc/A", 0, time.Millisecond),
+		testEvent("2", "/pkg.Svc/B", 0, time.Millisecond),
+	)
+	m.keys = km
+
+	m = press(m, "n")
+	if m.cursor != 1 {
+		t.Errorf("remapped down: cursor = %d, want 1", m.cursor)
+	}
+	m = press(m, "j")
+	if m.cursor != 1 {
+		t.Errorf("old down key still active: cursor = %d, want 1", m.cursor)
+	}
+	m = press(m, "p")
+	if m.cursor != 0 {
+		t.Errorf("remapped up: cursor = %d, want 0", m.cursor)
+	}
+
+	// Untouched actions keep their defaults.
+	m = press(m, "enter")
+	if m.view != viewInspect {
+		t.Errorf("default inspect binding: view = %v, want inspector", m.view)
+	}
+}
+
+func TestParseKeyMap_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{name: "invalid json", data: `{`, want: "parse keymap"},
+		{name: "unknown action", data: `{"fly": ["f"]}`, want: `unknown action "fly"`},
+		{name: "no keys", data: `{"down": []}`, want: `action "down" has no keys`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := parseKeyMap([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultKeyMap_HelpCoversActions(t *testing.T) {
+	t.Parallel()
+
+	km := DefaultKeyMap()
+	var help strings.Builder
+	for _, sec := range km.helpSections() {
+		for _, b := range sec.bindings {
+			help.WriteString(b.desc + "\n")
+		}
+	}
+	for name, b := range km.actions() {
+		if !strings.Contains(help.String(), b.desc) {
+			t.Errorf("action %q (%s) missing from help", name, b.desc)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package tui
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
 	"os/exec"
 	"sort"
@@ -12,10 +13,12 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/mickamy/grpc-tap/clipboard"
+	"github.com/mickamy/grpc-tap/compare"
 	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
 	"github.com/mickamy/grpc-tap/proxy"
 )
@@ -28,47 +31,114 @@ const (
 	viewAnalytics
 )
 
-type sortMode int
-
-const (
-	sortChronological sortMode = iota
-	sortDuration
-)
-
 // Model is the Bubble Tea model for the grpc-tap TUI.
 type Model struct {
 	target string
+	keys   KeyMap
 	conn   *grpc.ClientConn
 	client tapv1.TapServiceClient
 	stream tapv1.TapService_WatchClient
 
-	events []*tapv1.GRPCEvent
-	cursor int
-	follow bool
-	width  int
-	height int
-	err    error
-	view   viewMode
+	events   []*tapv1.GRPCEvent
+	eventIdx map[string]int // event ID → index in events
+	cursor   int
+	follow   bool // keep the cursor on the newest event (see follow.go)
+	width    int
+	height   int
+	err      error
+	view     viewMode
 
 	searchMode   bool
 	searchQuery  string
 	sortMode     sortMode
 	filterErrors bool
+	hostColumn   bool // show the authority column in the list
+	traceColumn  bool // show the correlation ID column in the list
+	hidePreview  bool // give the preview pane's lines to the list
+	noAutoFollow bool // reaching the last row does not resume following
 
-	displayRows []int // indices into events
+	columns []Column // list columns in order; nil for DefaultColumns
 
-	inspectScroll int
-	inspectStatus string // temporary status message (e.g. "Copied!")
-	replayEventID string // when set, navigate to this event in inspector on arrival
+	changesMode     bool                      // mark changed responses and dim repeated ones
+	lastResponses   map[string]uint64         // method → hash of its latest finished response
+	responseChanges map[string]responseChange // event ID → how its response compares with the one before
+
+	bookmarks       map[string]bool   // IDs of bookmarked events, kept through clears and eviction
+	filterBookmarks bool              // show only bookmarked events
+	maxEvents       int               // evict the oldest unbookmarked events beyond this many; zero for no limit
+	notes           map[string]string // event ID → note, shown in the inspector and exports
+	noteMode        bool              // typing a note on the inspected event
+	noteInput       string
+
+	hideInternal    bool     // hide health checks, reflection and other internalMethods
+	internalMethods []string // method prefixes of internal traffic
+
+	displayRows []int // indices into events
 
-	writeMode bool // waiting for export format selection
+	inspectScroll  int
+	inspectHScroll int      // horizontal offset in display columns
+	expandRequest  bool     // show the full request body instead of a preview
+	expandResponse bool     // show the full response body instead of a preview
+	highlight      bool     // syntax highlight decoded bodies
+	bodyView       bodyView // how bodies are rendered (auto, hex or decoded)
+	inspectStatus  string   // temporary status message (e.g. "Copied!")
+	findMode       bool     // typing a find query in the inspector
+	findQuery      string   // text found and highlighted in the inspector
+	findIndex      int      // index of the current find match
+	splitView      bool     // show request and response side by side, when wide enough
+	splitFocus     int      // pane scrolled by the scroll keys in the split layout
+	splitScroll    [2]int   // scroll offset of each pane in the split layout
+	replayEventID  string   // when set, navigate to this event in inspector on arrival
+	replaySourceID string   // ID of the call the pending replay repeats
+
+	lastEdit *editedRequest // request last edited and resent, for resending it again
+
+	batch            batchReplay  // replay of the listed calls, when asked for or running
+	batchConcurrency int          // replays of a batch in flight at once
+	batchPacing      ReplayPacing // how the replays of a batch are spaced
+
+	assertReplays bool                       // compare replayed responses with the original ones
+	assertMode    compare.Mode               // when replayed responses match
+	assertions    map[string]replayAssertion // replayed event ID → comparison with its original
+
+	views       []SavedView // saved views, in picker order
+	statePath   string      // state file the saved views persist to; empty to keep them for the session
+	viewsPrompt viewsPrompt // step of the saved-views prompt, when open
+	viewName    string      // name typed for the view being saved
+
+	writeMode bool        // waiting for export format selection
+	clearMode bool        // waiting for confirmation to clear events
+	fieldEdit fieldEditor // request field editor overlay, when active
+	showHelp  bool        // help overlay is visible on top of the current view
 
 	alertMessage string // overlay alert text
 	alertSeq     int    // monotonic counter to debounce clearAlertMsg
+	errorMode    ErrorMode
+	errorCount   int       // error events received this session
+	bellOnError  bool      // ring the terminal bell on new errors
+	bellAt       time.Time // when the bell last rang, for debouncing
+
+	errorRate errorRateAlert // banner raised while a method's error rate is too high
+
+	notifyOnError bool      // send a desktop notification on new errors
+	notifiedAt    time.Time // when the last notification was sent, for rate limiting
+
+	sessionStart  time.Time       // when the Model was created
+	sessionTotals analyticsTotals // totals of every call received, kept through evictions and clears
+	summaryOnExit bool            // write a summary of the session on quit
+	exitSummary   string          // the summary written on quit, for ExitSummary
 
 	analyticsRows     []analyticsRow
+	analyticsCodeRows []analyticsCodeRow // errors by method and code
+	analyticsByCode   bool               // show analyticsCodeRows instead of analyticsRows
 	analyticsCursor   int
 	analyticsSortMode analyticsSortMode
+	analyticsWindow   time.Duration   // only aggregate events started this recently; zero for all
+	analyticsTotals   analyticsTotals // running totals of all events, kept by upsertEvent and retain
+
+	exportWindow time.Duration      // only export events started this recently; zero for all
+	descriptors  *proxy.Descriptors // message schemas bodies are decoded with, when known
+	durationUnit DurationUnit       // unit of the durations shown and exported
 }
 
 type eventMsg struct{ Event *tapv1.GRPCEvent }
@@ -81,7 +151,9 @@ type connectedMsg struct {
 }
 
 type replayResultMsg struct {
-	EventID string // ID of the replayed event (empty on error)
+	EventID string           // ID of the replayed event (empty on error)
+	Event   *tapv1.GRPCEvent // the replayed event (nil on error)
+	Edited  *editedRequest   // the request edited in $EDITOR, if it was
 	Err     error
 }
 
@@ -93,15 +165,77 @@ type exportResultMsg struct {
 	err  error
 }
 
+type rawExportResultMsg struct {
+	paths []string
+	err   error
+}
+
+// Option configures a Model.
+type Option func(*Model)
+
+// WithKeyMap overrides the default keybindings.
+func WithKeyMap(km KeyMap) Option {
+	return func(m *Model) {
+		m.keys = km
+	}
+}
+
+// WithExportWindow limits exports to events started within d of the time of
+// the export. Zero exports all events.
+func WithExportWindow(d time.Duration) Option {
+	return func(m *Model) {
+		m.exportWindow = d
+	}
+}
+
+// WithHostColumn shows the authority each call addressed as a list column,
+// for proxies fronting several virtual hosts. The column is left out when
+// the terminal is too narrow for it.
+func WithHostColumn(enabled bool) Option {
+	return func(m *Model) {
+		m.hostColumn = enabled
+	}
+}
+
+// WithPreview shows or hides the preview pane below the list. Hiding it
+// gives its lines to the list, which suits short terminals.
+func WithPreview(enabled bool) Option {
+	return func(m *Model) {
+		m.hidePreview = !enabled
+	}
+}
+
+// WithTraceColumn shows the correlation ID of each call (see the daemon's
+// -correlation-header) as a list column. The column is left out when the
+// terminal is too narrow for it.
+func WithTraceColumn(enabled bool) Option {
+	return func(m *Model) {
+		m.traceColumn = enabled
+	}
+}
+
 // New creates a new Model targeting the given grpc-tapd address.
-func New(target string) Model {
-	return Model{
+func New(target string, opts ...Option) Model {
+	m := Model{
 		target: target,
+		keys:   DefaultKeyMap(),
 		follow: false,
+
+		highlight:       true,
+		hideInternal:    true,
+		internalMethods: DefaultInternalMethods,
+		sessionStart:    time.Now(),
 	}
+	for _, opt := range opts {
+		opt(&m)
+	}
+	return m
 }
 
 func (m Model) Init() tea.Cmd {
+	if m.errorRate.threshold > 0 {
+		return tea.Batch(connectCmd(m.target), errorRateTick())
+	}
 	return connectCmd(m.target)
 }
 
@@ -140,29 +274,51 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, recvEvent(msg.stream)
 
 	case eventMsg:
-		m.events = append(m.events, msg.Event)
+		_, replaced := m.eventIdx[msg.Event.GetId()]
+		n := len(m.events)
+		m, idx := m.upsertEvent(msg.Event)
 		if m.replayEventID != "" && msg.Event.GetId() == m.replayEventID {
 			// Replayed event arrived — show it in inspector.
 			m.replayEventID = ""
 			m.displayRows = m.rebuildDisplayRows()
 			m.cursor = max(len(m.displayRows)-1, 0)
-			m.view = viewInspect
-			m.inspectScroll = 0
-		} else if m.view == viewList {
-			m.displayRows = m.rebuildDisplayRows()
-			if m.follow {
-				m.cursor = max(len(m.displayRows)-1, 0)
+			if row := m.rowOf(idx); row >= 0 {
+				m.cursor = row
 			}
+			m = m.openInspector()
+			return m, recvEvent(m.stream)
 		}
-		return m, recvEvent(m.stream)
+		if m.view == viewList {
+			// Only an eviction moves other events, and only then do the rows
+			// need rebuilding.
+			if replaced || len(m.events) == n+1 {
+				m = m.updateRow(idx)
+			} else {
+				m = m.refreshRows()
+			}
+		}
+		m, cmd := m.onNewError(msg.Event)
+		return m, tea.Batch(recvEvent(m.stream), cmd)
+
+	case batchResultMsg:
+		return m.batchResult(msg)
+
+	case batchSendMsg:
+		return m.batchSend(msg)
 
 	case replayResultMsg:
+		if msg.Edited != nil {
+			m.lastEdit = msg.Edited
+		}
 		if msg.Err != nil {
 			m.err = msg.Err
 			return m, nil
 		}
 		// Wait for the replayed event to arrive via Watch stream, then show in inspector.
 		m.replayEventID = msg.EventID
+		if m.assertReplays && msg.Event != nil {
+			m = m.assertReplay(m.replaySourceID, msg.Event)
+		}
 		return m, nil
 
 	case exportResultMsg:
@@ -173,6 +329,26 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m, cmd := m.showAlert(alertMsg)
 		return m, cmd
 
+	case rawExportResultMsg:
+		var alertMsg string
+		switch {
+		case msg.err != nil:
+			alertMsg = "write error: " + msg.err.Error()
+		case len(msg.paths) == 0:
+			alertMsg = "nothing to write: bodies are empty"
+		default:
+			alertMsg = "wrote: " + strings.Join(msg.paths, ", ")
+		}
+		m, cmd := m.showAlert(alertMsg)
+		return m, cmd
+
+	case stateSavedMsg:
+		if msg.err != nil {
+			m, cmd := m.showAlert(msg.err.Error())
+			return m, cmd
+		}
+		return m, nil
+
 	case clearStatusMsg:
 		m.inspectStatus = ""
 		return m, nil
@@ -183,12 +359,30 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case errorRateTickMsg:
+		return m.checkErrorRate(time.Time(msg)), errorRateTick()
+
 	case errMsg:
 		m.err = msg.Err
 		return m, nil
 
 	case tea.KeyMsg:
 		m.alertMessage = ""
+		if m.batch.running && !m.batch.stopped && m.keys.forceQuit.matches(msg) {
+			// Ctrl+C aborts a batch replay first, so a long one can be cut short.
+			return m.stopBatch()
+		}
+		if m.width > 0 && m.tooSmall() {
+			return m.updateTooSmall(msg)
+		}
+		if m.showHelp {
+			// Any key dismisses the help overlay and returns to the underlying view.
+			m.showHelp = false
+			return m, nil
+		}
+		if m.fieldEdit.active {
+			return m.updateFieldEditor(msg)
+		}
 		switch m.view {
 		case viewAnalytics:
 			return m.updateAnalytics(msg)
@@ -199,8 +393,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case tea.WindowSizeMsg:
-		m.width = msg.Width
-		m.height = msg.Height
+		m.width = max(msg.Width, 0)
+		m.height = max(msg.Height, 0)
 		return m, nil
 	}
 	return m, nil
@@ -211,6 +405,10 @@ func (m Model) View() string {
 		return ""
 	}
 
+	if m.tooSmall() {
+		return m.renderTooSmall()
+	}
+
 	if m.err != nil {
 		return friendlyError(m.err, m.width)
 	}
@@ -219,6 +417,17 @@ func (m Model) View() string {
 		return "Waiting for gRPC traffic..."
 	}
 
+	if m.showHelp {
+		return m.renderHelp()
+	}
+	if m.fieldEdit.active {
+		view := m.renderFieldEditor()
+		if m.alertMessage != "" {
+			view = overlayAlert(view, m.alertMessage, m.width)
+		}
+		return view
+	}
+
 	var view string
 	switch m.view {
 	case viewAnalytics:
@@ -229,6 +438,9 @@ func (m Model) View() string {
 		view = m.renderListView()
 	}
 
+	if m.errorRate.method != "" {
+		view = overlayBanner(view, m.errorRate.message(), m.width)
+	}
 	if m.alertMessage != "" {
 		view = overlayAlert(view, m.alertMessage, m.width)
 	}
@@ -236,29 +448,46 @@ func (m Model) View() string {
 	return view
 }
 
+// listChrome is how many lines of the list view are neither list rows nor
+// preview: the list's borders and the footer.
+const listChrome = 3
+
+// listMinRows is the fewest list rows, header included, the list view
+// shows; the preview gives way to keep them on a short terminal.
+const listMinRows = 3
+
+// listHeight returns how many rows, header included, the list box shows
+// beside the preview and footer.
 func (m Model) listHeight() int {
-	return max(m.height-8, 3)
+	return max(m.height-listChrome-m.previewHeight(), listMinRows)
+}
+
+// previewHeight returns how many lines the preview pane takes, or 0 when
+// it is hidden, there is no event to preview or the list would otherwise be
+// left fewer than listMinRows rows.
+func (m Model) previewHeight() int {
+	if m.hidePreview || m.cursorEvent() == nil {
+		return 0
+	}
+	h := lipgloss.Height(m.renderPreview(m.innerWidth()))
+	if m.height-listChrome-h < listMinRows {
+		return 0
+	}
+	return h
 }
 
 func (m Model) rebuildDisplayRows() []int {
 	var rows []int
-	filter := strings.ToLower(m.searchQuery)
-
+	matches := searchMatcher(m.searchQuery)
 	for i, ev := range m.events {
-		if filter != "" && !strings.Contains(strings.ToLower(ev.GetMethod()), filter) {
-			continue
-		}
-		if m.filterErrors && ev.GetStatus() == 0 {
-			continue
+		if m.shown(ev, matches) {
+			rows = append(rows, i)
 		}
-		rows = append(rows, i)
 	}
 
-	if m.sortMode == sortDuration {
+	if m.sortMode != sortChronological {
 		sort.Slice(rows, func(a, b int) bool {
-			da := m.events[rows[a]].GetDuration().AsDuration()
-			db := m.events[rows[b]].GetDuration().AsDuration()
-			return da > db
+			return m.compareEvents(rows[a], rows[b]) < 0
 		})
 	}
 
@@ -269,70 +498,109 @@ func (m Model) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	if m.writeMode {
 		return m.updateWrite(msg)
 	}
+	if m.clearMode {
+		return m.updateClear(msg)
+	}
+	if m.batch.confirming {
+		return m.updateBatchConfirm(msg)
+	}
 	if m.searchMode {
 		return m.updateSearch(msg)
 	}
+	if m.viewsPrompt != viewsOff {
+		return m.updateViews(msg)
+	}
 
-	switch msg.String() {
-	case "q", "ctrl+c":
-		if m.conn != nil {
-			_ = m.conn.Close()
-		}
-		return m, tea.Quit
-	case "enter":
+	k := m.keys
+	switch {
+	case k.quit.matches(msg), k.forceQuit.matches(msg):
+		return m.quit()
+	case k.inspect.matches(msg):
 		if len(m.displayRows) > 0 {
-			m.view = viewInspect
-			m.inspectScroll = 0
+			m = m.openInspector()
 		}
 		return m, nil
-	case "/":
+	case k.search.matches(msg):
 		m.searchMode = true
 		m.searchQuery = ""
 		return m, nil
-	case "e":
+	case k.errors.matches(msg):
 		m.filterErrors = !m.filterErrors
 		m.displayRows = m.rebuildDisplayRows()
 		m.cursor = min(m.cursor, max(len(m.displayRows)-1, 0))
 		return m, nil
-	case "a":
+	case k.internal.matches(msg):
+		m.hideInternal = !m.hideInternal
+		m.displayRows = m.rebuildDisplayRows()
+		m.cursor = min(m.cursor, max(len(m.displayRows)-1, 0))
+		return m, nil
+	case k.preview.matches(msg):
+		m.hidePreview = !m.hidePreview
+		return m, nil
+	case k.changes.matches(msg):
+		m.changesMode = !m.changesMode
+		return m, nil
+	case k.bookmark.matches(msg):
+		return m.toggleBookmark(), nil
+	case k.bookmarks.matches(msg):
+		m.filterBookmarks = !m.filterBookmarks
+		m.displayRows = m.rebuildDisplayRows()
+		m.cursor = min(m.cursor, max(len(m.displayRows)-1, 0))
+		return m, nil
+	case k.analytics.matches(msg):
 		m.view = viewAnalytics
-		m.analyticsRows = m.buildAnalyticsRows()
-		sortAnalyticsRows(m.analyticsRows, m.analyticsSortMode)
-		m.analyticsCursor = 0
+		return m.refreshAnalytics(), nil
+	case k.views.matches(msg):
+		m.viewsPrompt = viewsPick
 		return m, nil
-	case "w":
+	case k.write.matches(msg):
 		m.writeMode = true
 		return m, nil
-	case "s":
-		return m.toggleSort(), nil
-	case "esc":
+	case k.replayAll.matches(msg):
+		return m.startBatch()
+	case k.clear.matches(msg):
+		if len(m.events) > 0 {
+			m.clearMode = true
+		}
+		return m, nil
+	case k.help.matches(msg):
+		m.showHelp = true
+		return m, nil
+	case k.sort.matches(msg):
+		return m.cycleSort(), nil
+	case k.reverseSort.matches(msg):
+		return m.reverseSort(), nil
+	case k.clearFilter.matches(msg):
 		return m.clearFilter(), nil
-	case "j", "down":
+	case k.down.matches(msg):
 		if len(m.displayRows) > 0 && m.cursor < len(m.displayRows)-1 {
 			m.cursor++
 		}
-		if len(m.displayRows) > 0 && m.cursor == len(m.displayRows)-1 {
-			m.follow = true
-		}
-		return m, nil
-	case "k", "up":
+		return m.followAtBottom(), nil
+	case k.up.matches(msg):
 		if m.cursor > 0 {
 			m.cursor--
 			m.follow = false
 		}
 		return m, nil
-	case "ctrl+d", "pgdown":
+	case k.halfPageDown.matches(msg):
 		half := max(m.listHeight()/2, 1)
 		m.cursor = min(m.cursor+half, max(len(m.displayRows)-1, 0))
-		if len(m.displayRows) > 0 && m.cursor == len(m.displayRows)-1 {
-			m.follow = true
-		}
-		return m, nil
-	case "ctrl+u", "pgup":
+		return m.followAtBottom(), nil
+	case k.halfPageUp.matches(msg):
 		half := max(m.listHeight()/2, 1)
 		m.cursor = max(m.cursor-half, 0)
 		m.follow = false
 		return m, nil
+	case k.top.matches(msg):
+		m.cursor = 0
+		m.follow = false
+		return m, nil
+	case k.bottom.matches(msg):
+		m.cursor = max(len(m.displayRows)-1, 0)
+		return m.followAtBottom(), nil
+	case k.follow.matches(msg):
+		return m.toggleFollow()
 	}
 	return m, nil
 }
@@ -357,10 +625,7 @@ func (m Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 	case "ctrl+c":
-		if m.conn != nil {
-			_ = m.conn.Close()
-		}
-		return m, tea.Quit
+		return m.quit()
 	}
 
 	r := msg.Runes
@@ -374,19 +639,6 @@ func (m Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func (m Model) toggleSort() Model {
-	switch m.sortMode {
-	case sortChronological:
-		m.sortMode = sortDuration
-		m.follow = false
-	case sortDuration:
-		m.sortMode = sortChronological
-	}
-	m.displayRows = m.rebuildDisplayRows()
-	m.cursor = 0
-	return m
-}
-
 func (m Model) clearFilter() Model {
 	if m.searchQuery != "" {
 		m.searchQuery = ""
@@ -407,13 +659,51 @@ func (m Model) updateWrite(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+func (m Model) updateClear(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.clearMode = false
+	if msg.String() != "y" {
+		return m, nil
+	}
+	m = m.clearEvents()
+	if m.bellOnError {
+		// Drop the error count from the terminal title.
+		return m, tea.SetWindowTitle("grpc-tap")
+	}
+	return m, nil
+}
+
+// clearEvents drops all captured events but the bookmarked ones, and
+// everything derived from them. The connection and Watch stream are kept, so
+// new events keep arriving.
+func (m Model) clearEvents() Model {
+	m = m.retain(m.bookmarked)
+	m.displayRows = m.rebuildDisplayRows()
+	m.cursor = 0
+	m.follow = !m.noAutoFollow && m.canFollow()
+	m.view = viewList
+	m.inspectScroll = 0
+	m.inspectHScroll = 0
+	m.replayEventID = ""
+	m.analyticsRows = nil
+	m.analyticsCodeRows = nil
+	m.analyticsCursor = 0
+	m.errorCount = 0
+	return m
+}
+
 func (m Model) runExport(format exportFormat) tea.Cmd {
 	events := make([]*tapv1.GRPCEvent, len(m.events))
 	copy(events, m.events)
-	searchQuery := m.searchQuery
-	filterErrors := m.filterErrors
+	notes := maps.Clone(m.notes)
+	units := m.durationUnit
+	filter := exportFilter{
+		search:     m.searchQuery,
+		errorsOnly: m.filterErrors,
+		since:      windowStart(time.Now(), m.exportWindow),
+		hidden:     m.hiddenPrefixes(),
+	}
 	return func() tea.Msg {
-		path, err := writeExport(events, searchQuery, filterErrors, format, "")
+		path, err := writeExport(events, filter, notes, units, format, "")
 		return exportResultMsg{path: path, err: err}
 	}
 }
@@ -425,9 +715,31 @@ func (m Model) cursorEvent() *tapv1.GRPCEvent {
 	return m.events[m.displayRows[m.cursor]]
 }
 
+// List column widths. The marker column plus the single-space separators
+// between columns account for listFixedWidth.
+const (
+	listColMarker      = 4
+	listColProto       = 13 // fits "Connect/proto"
+	listColStatus      = 12
+	listColDuration    = 10
+	listColTime        = 13
+	listColHost        = 24
+	listColTrace       = 18
+	listColType        = 12
+	listColContentType = 26
+
+	// listFixedWidth is the width of the marker and the columns of
+	// DefaultColumns that are always shown, separators included.
+	listFixedWidth = listColMarker + listColProto + listColStatus + listColDuration + listColTime + 4
+
+	// listMinMethodWidth is the narrowest method column the full layout
+	// accepts before falling back to the compact layout.
+	listMinMethodWidth = 20
+)
+
 // renderListView renders the main list + preview + footer.
 func (m Model) renderListView() string {
-	innerWidth := max(m.width-4, 20)
+	innerWidth := m.innerWidth()
 	listHeight := m.listHeight()
 
 	// Title
@@ -440,26 +752,30 @@ func (m Model) renderListView() string {
 	if m.filterErrors {
 		title += "[errors] "
 	}
-	if m.sortMode == sortDuration {
-		title += "[slow] "
+	if !m.hideInternal {
+		title += "[internal] "
+	}
+	if n := m.infraCount(); n > 0 {
+		title += fmt.Sprintf("[infra: %d] ", n)
+	}
+	if m.sortMode != sortChronological {
+		title += "[sort: " + m.sortMode.label() + "] "
+	}
+	if m.filterBookmarks {
+		title += "[bookmarks] "
+	}
+	if m.changesMode {
+		title += "[changes] "
+	}
+	if m.follow {
+		title += "[follow] "
+	}
+	if m.batch.running {
+		title += m.batchProgress()
 	}
 
-	// Column widths
-	colMarker := 4
-	colProto := 10
-	colStatus := 10
-	colDuration := 10
-	colTime := 13
-	colMethod := max(innerWidth-colMarker-colProto-colStatus-colDuration-colTime-4, 10)
-
-	// Header
-	header := fmt.Sprintf("    %-*s %-*s %-*s %*s %*s",
-		colProto, "Proto",
-		colMethod, "Method",
-		colStatus, "Status",
-		colDuration, "Duration",
-		colTime, "Time",
-	)
+	layout := newListLayout(innerWidth, m.listColumns())
+	header := layout.header()
 
 	// Visible rows
 	dataRows := max(listHeight-1, 1)
@@ -482,81 +798,73 @@ func (m Model) renderListView() string {
 		if isCursor {
 			marker = "▶ "
 		}
+		if m.bookmarked(ev) {
+			marker = marker[:len(marker)-1] + bookmarkMarker
+		}
 
-		proto := protocolString(int32(ev.GetProtocol()))
-		method := truncate(ev.GetMethod(), colMethod)
-		status := statusString(ev.GetStatus())
-		dur := formatDuration(ev.GetDuration())
-		t := formatTime(ev.GetStartTime())
-
-		stStyle := statusStyle(ev.GetStatus())
+		// Infra rows, and repeated responses in changes mode, are dimmed as
+		// a whole, so their status is left uncolored.
+		change := m.rowChange(ev)
+		dim := !isCursor && (m.categorize(ev.GetMethod()) == categoryInfra || change == responseSame)
 		if isCursor {
-			bold := lipgloss.NewStyle().Bold(true)
-			stStyle = stStyle.Bold(true)
-			row := fmt.Sprintf("%s  %s %s %s %s %s",
-				bold.Render(marker),
-				padRight(bold.Render(proto), colProto),
-				padRight(bold.Render(method), colMethod),
-				padRight(stStyle.Render(status), colStatus),
-				padLeft(bold.Render(dur), colDuration),
-				padLeft(bold.Render(t), colTime),
-			)
-			rows = append(rows, row)
-			continue
-		}
-		row := fmt.Sprintf("%s  %-*s %-*s %s %*s %*s",
-			marker,
-			colProto, proto,
-			colMethod, method,
-			padRight(stStyle.Render(status), colStatus),
-			colDuration, dur,
-			colTime, t,
-		)
+			marker = lipgloss.NewStyle().Bold(true).Render(marker)
+		}
+		gap := "  "
+		if change == responseChanged {
+			gap = changedMarker + " "
+		}
+		row := marker + gap + m.cells(layout, ev, isCursor, dim)
+		if dim {
+			row = lipgloss.NewStyle().Faint(true).Render(row)
+		}
 		rows = append(rows, row)
 	}
 
-	// Border
-	borderColor := lipgloss.Color("240")
-	border := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		Width(innerWidth).
-		BorderForeground(borderColor)
-
-	content := strings.Join(rows, "\n")
-	box := border.Render(content)
+	box := boxWith(strings.Join(rows, "\n"), innerWidth, lipgloss.Color("240"), title, "")
 
-	// Replace top border with title
-	lines := strings.Split(box, "\n")
-	if len(lines) > 0 {
-		borderFg := lipgloss.NewStyle().Foreground(borderColor)
-		titleStyle := lipgloss.NewStyle().Bold(true)
-		dashes := max(innerWidth-len([]rune(title)), 0)
-		lines[0] = borderFg.Render("╭") +
-			titleStyle.Render(title) +
-			borderFg.Render(strings.Repeat("─", dashes)+"╮")
-		box = strings.Join(lines, "\n")
+	// Preview, unless there is no room for it
+	var preview string
+	if m.previewHeight() > 0 {
+		preview = m.renderPreview(innerWidth)
 	}
 
-	// Preview
-	preview := m.renderPreview(innerWidth)
-
 	// Footer
 	var footer string
 	switch {
 	case m.writeMode:
 		footer = "  write: [j]son [m]arkdown"
+	case m.clearMode:
+		if n := m.bookmarkCount(); n > 0 {
+			footer = fmt.Sprintf("  clear %d events, keeping %d bookmarked? [y/N]", len(m.events)-n, n)
+		} else {
+			footer = fmt.Sprintf("  clear all %d events? [y/N]", len(m.events))
+		}
+	case m.batch.confirming:
+		footer = m.batchFooter()
+	case m.viewsPrompt != viewsOff:
+		footer = m.viewsFooter()
 	case m.searchMode:
 		footer = fmt.Sprintf("  / %s█", m.searchQuery)
+		if msg := searchError(m.searchQuery); msg != "" {
+			footer += "  (" + msg + ")"
+		}
 	default:
-		footer = "  q: quit  j/k: navigate  enter: inspect  /: search  s: sort  e: errors  a: analytics  w: write"
+		k := m.keys
+		footer = fmt.Sprintf("  %s: quit  %s/%s: navigate  %s: inspect  %s: search  %s: sort  %s: errors  %s: analytics  %s: write  %s: help",
+			k.quit.key(), k.down.key(), k.up.key(), k.inspect.key(), k.search.key(),
+			k.sort.key(), k.errors.key(), k.analytics.key(), k.write.key(), k.help.key())
 		if m.searchQuery != "" {
-			footer += "  esc: clear filter"
+			footer += "  " + k.clearFilter.key() + ": clear filter"
 		}
-		if m.sortMode == sortDuration {
-			footer += "  [sorted: duration]"
+		if m.sortMode != sortChronological {
+			footer += "  [sorted: " + m.sortMode.label() + "]"
 		}
 	}
 
+	footer = ansi.Truncate(footer, m.width, "…")
+	if preview == "" {
+		return box + "\n" + footer
+	}
 	return strings.Join([]string{box, preview, footer}, "\n")
 }
 
@@ -569,12 +877,15 @@ func (m Model) renderPreview(innerWidth int) string {
 
 	var lines []string
 	lines = append(lines, "Method:   "+ev.GetMethod())
-	lines = append(lines, "Protocol: "+protocolString(int32(ev.GetProtocol())))
-	lines = append(lines, "Status:   "+statusString(ev.GetStatus()))
-	lines = append(lines, "Duration: "+formatDuration(ev.GetDuration()))
+	lines = append(lines, "Protocol: "+protocolLabel(ev))
+	lines = append(lines, "Status:   "+eventStatusString(ev))
+	lines = append(lines, "Duration: "+m.durationUnit.formatProto(ev.GetDuration()))
 	if ev.GetError() != "" {
 		lines = append(lines, "Error:    "+ev.GetError())
 	}
+	if enc := formatEncoding(ev); enc != "" {
+		lines = append(lines, "Encoding: "+enc)
+	}
 
 	content := strings.Join(lines, "\n")
 
@@ -592,11 +903,18 @@ func (m Model) renderInspector() string {
 	if ev == nil {
 		return ""
 	}
+	if m.splitActive() {
+		return m.renderSplitInspector(ev, m.inspectHelp())
+	}
 
-	innerWidth := max(m.width-4, 20)
-	visibleRows := max(m.height-2, 3)
+	innerWidth := m.innerWidth()
+	visibleRows := m.inspectVisibleRows()
 
-	lines := m.inspectLines(ev)
+	current := -1
+	if m.findQuery != "" {
+		current = m.findIndex
+	}
+	lines := highlightMatches(m.inspectLines(ev), m.highlightTerms(), current)
 
 	maxScroll := max(len(lines)-visibleRows, 0)
 	if m.inspectScroll > maxScroll {
@@ -604,136 +922,430 @@ func (m Model) renderInspector() string {
 	}
 
 	end := min(m.inspectScroll+visibleRows, len(lines))
-	visible := lines[m.inspectScroll:end]
-	content := strings.Join(visible, "\n")
+	content := cutLines(lines[m.inspectScroll:end], m.inspectHScroll, innerWidth)
+	return boxWith(content, innerWidth, lipgloss.Color("240"), m.inspectTitle(), m.inspectHelp())
+}
 
-	borderColor := lipgloss.Color("240")
-	box := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		Width(innerWidth).
-		BorderForeground(borderColor).
-		Render(content)
+// inspectTitle returns the title in the inspector's top border.
+func (m Model) inspectTitle() string {
+	title := " Inspector "
+	if m.bodyView != bodyViewAuto {
+		title += "[" + m.bodyView.String() + "] "
+	}
+	if m.inspectStatus != "" {
+		title += "— " + m.inspectStatus + " "
+	}
+	return title
+}
+
+// inspectHelp returns the key hints, or the open prompt, in the inspector's
+// bottom border.
+func (m Model) inspectHelp() string {
+	k := m.keys
+	switch {
+	case m.noteMode:
+		return fmt.Sprintf(" note: %s█  enter: save  esc: cancel ", m.noteInput)
+	case m.findMode || m.findQuery != "":
+		return m.findFooter()
+	case m.splitActive():
+		return fmt.Sprintf(" %s: back  %s: focus  %s/%s: scroll  %s/%s: copy req/resp  %s: stacked  %s: help ",
+			k.back.key(), k.splitFocus.key(), k.scrollDown.key(), k.scrollUp.key(),
+			k.copyRequest.key(), k.copyResponse.key(), k.splitView.key(), k.help.key())
+	}
+	return fmt.Sprintf(" %s: back  %s/%s: scroll  %s/%s: pan  %s/%s: copy req/resp  %s: edit & resend  %s: note  %s: help ",
+		k.back.key(), k.scrollDown.key(), k.scrollUp.key(), k.panLeft.key(), k.panRight.key(),
+		k.copyRequest.key(), k.copyResponse.key(), k.edit.key(), k.note.key(), k.help.key())
+}
 
-	boxLines := strings.Split(box, "\n")
-	if len(boxLines) > 0 {
-		borderFg := lipgloss.NewStyle().Foreground(borderColor)
-		titleStyle := lipgloss.NewStyle().Bold(true)
-		title := " Inspector "
-		if m.inspectStatus != "" {
-			title += "— " + m.inspectStatus + " "
+// upsertEvent stores ev and returns its index in m.events. Start and progress
+// events of a running call are superseded by later events with the same ID,
+// so an event whose ID is already known replaces the earlier one in place.
+func (m Model) upsertEvent(ev *tapv1.GRPCEvent) (Model, int) {
+	if m.eventIdx == nil {
+		m.eventIdx = make(map[string]int)
+		m.analyticsTotals = make(analyticsTotals)
+		m.sessionTotals = make(analyticsTotals)
+	}
+	if m.responseChanges == nil {
+		m.lastResponses = make(map[string]uint64)
+		m.responseChanges = make(map[string]responseChange)
+	}
+	m.trackChange(ev)
+	if i, ok := m.eventIdx[ev.GetId()]; ok {
+		m.analyticsTotals.add(m.events[i], -1)
+		m.analyticsTotals.add(ev, 1)
+		m.sessionTotals.add(m.events[i], -1)
+		m.sessionTotals.add(ev, 1)
+		m.events[i] = ev
+		return m, i
+	}
+	m.analyticsTotals.add(ev, 1)
+	m.sessionTotals.add(ev, 1)
+	m.events = append(m.events, ev)
+	m.eventIdx[ev.GetId()] = len(m.events) - 1
+	if m.maxEvents > 0 {
+		m = m.evict()
+	}
+	return m, m.eventIdx[ev.GetId()]
+}
+
+// rowOf returns the display row showing m.events[idx], or -1 if it is
+// filtered out.
+func (m Model) rowOf(idx int) int {
+	for row, i := range m.displayRows {
+		if i == idx {
+			return row
 		}
-		dashes := max(innerWidth-len([]rune(title)), 0)
-		boxLines[0] = borderFg.Render("╭") +
-			titleStyle.Render(title) +
-			borderFg.Render(strings.Repeat("─", dashes)+"╮")
 	}
-	if n := len(boxLines); n > 0 {
-		borderFg := lipgloss.NewStyle().Foreground(borderColor)
-		help := " q: back  j/k: scroll  c/C: copy req/resp  e: edit & resend "
-		dashes := max(innerWidth-len([]rune(help)), 0)
-		boxLines[n-1] = borderFg.Render("╰") +
-			lipgloss.NewStyle().Faint(true).Render(help) +
-			borderFg.Render(strings.Repeat("─", dashes)+"╯")
+	return -1
+}
+
+// refreshRows rebuilds displayRows after events changed. When following, the
+// cursor moves to the last row; otherwise it stays on the same event, even if
+// sorting or filtering moved that event to another row.
+func (m Model) refreshRows() Model {
+	prev := -1
+	if m.cursor < len(m.displayRows) {
+		prev = m.displayRows[m.cursor]
+	}
+	m.displayRows = m.rebuildDisplayRows()
+	if m.follow {
+		m.cursor = max(len(m.displayRows)-1, 0)
+		return m
+	}
+	if row := m.rowOf(prev); prev >= 0 && row >= 0 {
+		m.cursor = row
 	}
+	m.cursor = min(m.cursor, max(len(m.displayRows)-1, 0))
+	return m
+}
 
-	return strings.Join(boxLines, "\n")
+// durationString renders the duration of ev, broken down into the time to
+// the first response bytes and the total when the proxy recorded the former.
+func (m Model) durationString(ev *tapv1.GRPCEvent) string {
+	if ev.GetTimeToFirstByte() == nil {
+		return m.durationUnit.formatProto(ev.GetDuration())
+	}
+	return "TTFB: " + m.durationUnit.formatProto(ev.GetTimeToFirstByte()) +
+		", Total: " + m.durationUnit.formatProto(ev.GetDuration())
 }
 
-func (m Model) inspectLines(ev *tapv1.GRPCEvent) []string {
+// inspectSummary returns the inspector's lines about the call as a whole,
+// above the headers and bodies.
+func (m Model) inspectSummary(ev *tapv1.GRPCEvent) []string {
 	var lines []string
 	lines = append(lines, "Method:   "+ev.GetMethod())
-	lines = append(lines, "Protocol: "+protocolString(int32(ev.GetProtocol())))
-	lines = append(lines, "Status:   "+statusString(ev.GetStatus()))
-	lines = append(lines, "Duration: "+formatDuration(ev.GetDuration()))
+	lines = append(lines, "Protocol: "+protocolLabel(ev))
+	lines = append(lines, "Status:   "+eventStatusString(ev))
+	lines = append(lines, "Duration: "+m.durationString(ev))
+	if rate := ev.GetResponseMessageRate(); rate > 0 {
+		lines = append(lines, "Rate:     "+formatRate(rate))
+	}
+	if enc := formatEncoding(ev); enc != "" {
+		lines = append(lines, "Encoding: "+enc)
+	}
 	lines = append(lines, "Time:     "+formatTime(ev.GetStartTime()))
 	lines = append(lines, "ID:       "+ev.GetId())
+	if ev.GetUpstream() != "" {
+		lines = append(lines, "Upstream: "+ev.GetUpstream())
+	}
+	if d := deadlineString(ev); d != "" {
+		lines = append(lines, "Deadline: "+d)
+	}
+	if ev.GetPeerAddr() != "" {
+		lines = append(lines, "Peer:     "+ev.GetPeerAddr())
+	}
+	if ev.GetAuthority() != "" {
+		lines = append(lines, "Host:     "+ev.GetAuthority())
+	}
+	if ev.GetCorrelationId() != "" {
+		lines = append(lines, "Trace:    "+ev.GetCorrelationId())
+	}
 	if ev.GetError() != "" {
 		lines = append(lines, "Error:    "+ev.GetError())
 	}
+	if note := m.notes[ev.GetId()]; note != "" {
+		lines = append(lines, "Note:     "+note)
+	}
+	if a, ok := m.assertions[ev.GetId()]; ok {
+		lines = append(lines, a.lines()...)
+	}
+	return lines
+}
+
+func (m Model) inspectLines(ev *tapv1.GRPCEvent) []string {
+	lines := m.inspectSummary(ev)
 	if len(ev.GetRequestHeaders()) > 0 {
-		lines = append(lines, "")
-		lines = append(lines, "── Request Headers ──")
-		lines = append(lines, formatHeaders(ev.GetRequestHeaders())...)
+		lines = appendSection(lines, "Request Headers", formatHeaders(ev.GetRequestHeaders()))
 	}
 	if len(ev.GetResponseHeaders()) > 0 {
-		lines = append(lines, "")
-		lines = append(lines, "── Response Headers ──")
-		lines = append(lines, formatHeaders(ev.GetResponseHeaders())...)
+		lines = appendSection(lines, "Response Headers", formatHeaders(ev.GetResponseHeaders()))
+	}
+	if len(ev.GetResponseTrailers()) > 0 {
+		lines = appendSection(lines, "Response Trailers", formatHeaders(ev.GetResponseTrailers()))
+	}
+	if ev.GetBodyCaptureDisabled() {
+		return appendSection(lines, "Body", []string{"(body capture disabled)"})
 	}
 	if len(ev.GetRequestBody()) > 0 {
-		lines = append(lines, "")
-		lines = append(lines, "── Request Body ──")
-		lines = append(lines, formatBody(ev.GetRequestBody())...)
+		lines = appendSection(lines, "Request Body", m.bodySection(ev, false, m.expandRequest))
 	}
 	if len(ev.GetResponseBody()) > 0 {
+		lines = appendSection(lines, "Response Body", m.bodySection(ev, true, m.expandResponse))
+	}
+	if n := len(ev.GetRequestFrames()) + len(ev.GetResponseFrames()); n > 0 {
+		lines = appendSection(lines, fmt.Sprintf("Transcript (%d messages)", n), m.transcriptLines(ev))
+	}
+	return lines
+}
+
+// appendSection appends a titled section to lines, after a blank line when
+// lines is not empty.
+func appendSection(lines []string, title string, section []string) []string {
+	if len(lines) > 0 {
 		lines = append(lines, "")
-		lines = append(lines, "── Response Body ──")
-		lines = append(lines, formatBody(ev.GetResponseBody())...)
+	}
+	lines = append(lines, "── "+title+" ──")
+	return append(lines, section...)
+}
+
+// bodyPreviewLines is how many lines of a decoded body the inspector shows
+// before the rest is folded behind the expand key.
+const bodyPreviewLines = 40
+
+// bodySection decodes a body for the inspector. Unless expanded, it is cut to
+// bodyPreviewLines and ends with a marker that tells how to see the rest.
+// Structured bodies are syntax highlighted when enabled; only the lines shown
+// are styled.
+func (m Model) bodySection(ev *tapv1.GRPCEvent, response bool, expanded bool) []string {
+	lines, structured := m.renderEventBody(ev, response)
+	more := 0
+	if !expanded && len(lines) > bodyPreviewLines {
+		more = len(lines) - bodyPreviewLines
+		lines = lines[:bodyPreviewLines]
+	}
+	if structured && m.highlight {
+		lines = highlightLines(lines, defaultBodyTheme())
+	}
+	if more > 0 {
+		lines = append(lines[:len(lines):len(lines)],
+			fmt.Sprintf("… %d more lines (press %s to expand)", more, m.keys.expand.key()))
 	}
 	return lines
 }
 
+// openInspector switches to the inspector for the event under the cursor,
+// starting at the top with long bodies folded.
+func (m Model) openInspector() Model {
+	m.view = viewInspect
+	m.inspectScroll = 0
+	m.inspectHScroll = 0
+	m.expandRequest = false
+	m.expandResponse = false
+	m.bodyView = bodyViewAuto
+	m.findIndex = 0
+	m.splitFocus = paneRequest
+	m.splitScroll = [2]int{}
+	return m
+}
+
+// setBodyView switches the inspector's body rendering to view, or back to
+// bodyViewAuto if view is already active.
+func (m Model) setBodyView(view bodyView) Model {
+	if m.bodyView == view {
+		view = bodyViewAuto
+	}
+	m.bodyView = view
+	m.inspectScroll = min(m.inspectScroll, m.inspectMaxScroll())
+	return m
+}
+
+// toggleExpand unfolds the first folded body (request, then response). Once
+// nothing is left folded, it folds both bodies again.
+func (m Model) toggleExpand() Model {
+	ev := m.cursorEvent()
+	if ev == nil {
+		return m
+	}
+	folded := func(response bool, expanded bool) bool {
+		lines, _ := m.renderEventBody(ev, response)
+		return !expanded && len(lines) > bodyPreviewLines
+	}
+	switch {
+	case folded(false, m.expandRequest):
+		m.expandRequest = true
+	case folded(true, m.expandResponse):
+		m.expandResponse = true
+	default:
+		m.expandRequest = false
+		m.expandResponse = false
+		m.inspectScroll = min(m.inspectScroll, m.inspectMaxScroll())
+	}
+	return m
+}
+
 func (m Model) updateInspect(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "ctrl+c":
-		if m.conn != nil {
-			_ = m.conn.Close()
-		}
-		return m, tea.Quit
-	case "q":
+	if m.noteMode {
+		return m.updateNote(msg)
+	}
+	if m.findMode {
+		return m.updateFind(msg)
+	}
+	k := m.keys
+	switch {
+	case k.forceQuit.matches(msg):
+		return m.quit()
+	case k.back.matches(msg):
 		m.view = viewList
 		m.displayRows = m.rebuildDisplayRows()
 		if m.follow {
 			m.cursor = max(len(m.displayRows)-1, 0)
 		}
 		return m, nil
-	case "e":
+	case k.help.matches(msg):
+		m.showHelp = true
+		return m, nil
+	case k.edit.matches(msg):
 		ev := m.cursorEvent()
 		if ev == nil || len(ev.GetRequestBody()) == 0 || m.client == nil {
 			return m, nil
 		}
-		return m, m.editAndResend(ev)
-	case "c":
+		return m.openFieldEditor(ev)
+	case k.find.matches(msg):
+		m.findMode = true
+		m.findQuery = ""
+		return m, nil
+	case m.findQuery != "" && k.findNext.matches(msg):
+		return m.jumpMatch(1), nil
+	case m.findQuery != "" && k.findPrev.matches(msg):
+		return m.jumpMatch(-1), nil
+	case m.findQuery != "" && k.clearFilter.matches(msg):
+		m.findQuery = ""
+		return m, nil
+	case k.note.matches(msg):
+		ev := m.cursorEvent()
+		if ev == nil {
+			return m, nil
+		}
+		m.noteMode = true
+		m.noteInput = m.notes[ev.GetId()]
+		return m, nil
+	case k.resendEdit.matches(msg):
+		return m.resendEdit()
+	case k.copyRequest.matches(msg):
 		ev := m.cursorEvent()
 		if ev == nil || len(ev.GetRequestBody()) == 0 {
 			return m, nil
 		}
-		return m.copyBody(ev.GetRequestBody(), "Request copied!")
-	case "C":
+		return m.copyBody(ev, false, "Request copied!")
+	case k.copyResponse.matches(msg):
 		ev := m.cursorEvent()
 		if ev == nil || len(ev.GetResponseBody()) == 0 {
 			return m, nil
 		}
-		return m.copyBody(ev.GetResponseBody(), "Response copied!")
-	case "j", "down":
+		return m.copyBody(ev, true, "Response copied!")
+	case k.copyReqText.matches(msg):
 		ev := m.cursorEvent()
-		if ev != nil {
-			maxScroll := max(len(m.inspectLines(ev))-(m.height-2), 0)
-			if m.inspectScroll < maxScroll {
-				m.inspectScroll++
-			}
+		if ev == nil || len(ev.GetRequestBody()) == 0 {
+			return m, nil
+		}
+		return m.copyTextFormat(ev, false, "Request copied as text format!")
+	case k.copyRespText.matches(msg):
+		ev := m.cursorEvent()
+		if ev == nil || len(ev.GetResponseBody()) == 0 {
+			return m, nil
 		}
+		return m.copyTextFormat(ev, true, "Response copied as text format!")
+	case k.writeRaw.matches(msg):
+		ev := m.cursorEvent()
+		if ev == nil {
+			return m, nil
+		}
+		return m, func() tea.Msg {
+			paths, err := writeRawExport(ev, "")
+			return rawExportResultMsg{paths: paths, err: err}
+		}
+	case k.expand.matches(msg):
+		return m.toggleExpand(), nil
+	case k.hexView.matches(msg):
+		return m.setBodyView(bodyViewHex), nil
+	case k.decodedView.matches(msg):
+		return m.setBodyView(bodyViewDecoded), nil
+	case k.splitView.matches(msg):
+		m.splitView = !m.splitView
+		return m, nil
+	case m.splitActive() && k.splitFocus.matches(msg):
+		m.splitFocus = 1 - m.splitFocus
+		return m, nil
+	case m.splitActive() && (k.scrollDown.matches(msg) || k.scrollUp.matches(msg) ||
+		k.halfPageDown.matches(msg) || k.halfPageUp.matches(msg) || k.top.matches(msg) || k.bottom.matches(msg)):
+		return m.scrollPane(paneScrollFunc(k, msg)), nil
+	case k.scrollDown.matches(msg):
+		m.inspectScroll = min(m.inspectScroll+1, m.inspectMaxScroll())
 		return m, nil
-	case "k", "up":
-		if m.inspectScroll > 0 {
-			m.inspectScroll--
+	case k.scrollUp.matches(msg):
+		m.inspectScroll = max(m.inspectScroll-1, 0)
+		return m, nil
+	case k.halfPageDown.matches(msg):
+		half := max(m.inspectVisibleRows()/2, 1)
+		m.inspectScroll = min(m.inspectScroll+half, m.inspectMaxScroll())
+		return m, nil
+	case k.halfPageUp.matches(msg):
+		half := max(m.inspectVisibleRows()/2, 1)
+		m.inspectScroll = max(m.inspectScroll-half, 0)
+		return m, nil
+	case k.top.matches(msg):
+		m.inspectScroll = 0
+		return m, nil
+	case k.bottom.matches(msg):
+		m.inspectScroll = m.inspectMaxScroll()
+		return m, nil
+	case k.panRight.matches(msg):
+		ev := m.cursorEvent()
+		if ev != nil {
+			innerWidth := m.innerWidth()
+			maxHScroll := max(maxLineWidth(m.inspectLines(ev))-innerWidth, 0)
+			m.inspectHScroll = min(m.inspectHScroll+inspectHScrollStep, maxHScroll)
 		}
 		return m, nil
+	case k.panLeft.matches(msg):
+		m.inspectHScroll = max(m.inspectHScroll-inspectHScrollStep, 0)
+		return m, nil
 	}
 	return m, nil
 }
 
-func (m Model) editAndResend(ev *tapv1.GRPCEvent) tea.Cmd {
-	method := ev.GetMethod()
-	body := ev.GetRequestBody()
+func (m Model) inspectVisibleRows() int {
+	return max(m.height-2, 3)
+}
 
-	// Convert request body to JSON for editing.
-	jsonData, err := proxy.ProtoWireToJSON(body)
-	if err != nil {
-		// Fall back to raw hex-ish representation; not ideal but usable.
-		return func() tea.Msg { return replayResultMsg{Err: fmt.Errorf("encode JSON: %w", err)} }
+// inspectMaxScroll returns the largest vertical scroll offset that still
+// fills the inspector, or 0 when there is no event under the cursor.
+func (m Model) inspectMaxScroll() int {
+	ev := m.cursorEvent()
+	if ev == nil {
+		return 0
 	}
+	return max(len(m.inspectLines(ev))-m.inspectVisibleRows(), 0)
+}
+
+// inspectHScrollStep is the number of columns h/l pan the inspector by.
+const inspectHScrollStep = 8
 
+func maxLineWidth(lines []string) int {
+	w := 0
+	for _, line := range lines {
+		w = max(w, ansi.StringWidth(line))
+	}
+	return w
+}
+
+// editJSONAndResend opens jsonData (a JSON request) in $EDITOR and replays
+// the edited request, converted to protobuf wire format by encode, to method
+// with protocol.
+func (m Model) editJSONAndResend(method string, protocol tapv1.Protocol, jsonData []byte,
+	encode func([]byte) ([]byte, error),
+) tea.Cmd {
 	// Write to temp file.
 	tmpFile, err := os.CreateTemp("", "grpc-tap-*.json")
 	if err != nil {
@@ -753,6 +1365,7 @@ func (m Model) editAndResend(ev *tapv1.GRPCEvent) tea.Cmd {
 	}
 
 	client := m.client
+	source := m.replaySourceID
 
 	// Use tea.ExecProcess to open the editor.
 	//nolint:gosec // G204: editor is user-configured $EDITOR
@@ -770,22 +1383,36 @@ func (m Model) editAndResend(ev *tapv1.GRPCEvent) tea.Cmd {
 		}
 
 		// Convert JSON back to protobuf wire format.
-		wire, err := proxy.JSONToProtoWire(edited)
+		wire, err := encode(edited)
 		if err != nil {
 			return replayResultMsg{Err: fmt.Errorf("encode protobuf: %w", err)}
 		}
 
-		// Call Replay RPC.
-		resp, err := client.Replay(context.Background(), &tapv1.ReplayRequest{
-			Method:      method,
-			RequestBody: wire,
-		})
-		if err != nil {
-			return replayResultMsg{Err: fmt.Errorf("replay: %w", err)}
-		}
+		msg := replay(client, method, protocol, wire)
+		msg.Edited = &editedRequest{sourceID: source, method: method, protocol: protocol, wire: wire}
+		return msg
+	})
+}
 
-		return replayResultMsg{EventID: resp.GetEvent().GetId()}
+// replayCmd replays wire (a protobuf request body) to method with protocol.
+func replayCmd(client tapv1.TapServiceClient, method string, protocol tapv1.Protocol, wire []byte) tea.Cmd {
+	return func() tea.Msg {
+		return replay(client, method, protocol, wire)
+	}
+}
+
+// replay sends wire as a protobuf message, so it leaves the content type to
+// the daemon, which picks the protocol's protobuf type.
+func replay(client tapv1.TapServiceClient, method string, protocol tapv1.Protocol, wire []byte) replayResultMsg {
+	resp, err := client.Replay(context.Background(), &tapv1.ReplayRequest{
+		Method:      method,
+		RequestBody: wire,
+		Protocol:    protocol,
 	})
+	if err != nil {
+		return replayResultMsg{Err: fmt.Errorf("replay: %w", err)}
+	}
+	return replayResultMsg{EventID: resp.GetEvent().GetId(), Event: resp.GetEvent()}
 }
 
 func (m Model) showAlert(msg string) (Model, tea.Cmd) {
@@ -797,8 +1424,23 @@ func (m Model) showAlert(msg string) (Model, tea.Cmd) {
 	})
 }
 
-func (m Model) copyBody(body []byte, statusText string) (tea.Model, tea.Cmd) {
-	text := bodyToClipboardText(body)
+func (m Model) copyBody(ev *tapv1.GRPCEvent, response bool, statusText string) (tea.Model, tea.Cmd) {
+	return m.copyText(m.clipboardText(ev, response), statusText)
+}
+
+// copyTextFormat copies the request (response false) or response body of ev
+// as protobuf text format, for pasting into grpcurl or evans.
+func (m Model) copyTextFormat(ev *tapv1.GRPCEvent, response bool, statusText string) (tea.Model, tea.Cmd) {
+	text, err := m.textFormat(ev, response)
+	if err != nil {
+		m, cmd := m.showAlert("Not a protobuf message")
+		return m, cmd
+	}
+	return m.copyText(text, statusText)
+}
+
+// copyText copies text to the clipboard and alerts statusText.
+func (m Model) copyText(text, statusText string) (tea.Model, tea.Cmd) {
 	if err := clipboard.Copy(context.Background(), text); err != nil {
 		m, cmd := m.showAlert("Copy failed")
 		return m, cmd
@@ -807,6 +1449,38 @@ func (m Model) copyBody(body []byte, statusText string) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// textFormat returns the request (response false) or response body of ev in
+// protobuf text format: with field names when the descriptor set describes
+// the method, and by field number otherwise.
+func (m Model) textFormat(ev *tapv1.GRPCEvent, response bool) (string, error) {
+	body := eventBody(ev, response)
+	if t, err := m.descriptors.DecodeText(ev.GetMethod(), response, body); err == nil {
+		return string(t), nil
+	}
+	t, err := proxy.ProtoWireToText(body)
+	if err != nil {
+		return "", fmt.Errorf("text format: %w", err)
+	}
+	return string(t), nil
+}
+
+// clipboardText returns the request (response false) or response body of ev
+// as copied from the inspector: in the forced hex or decoded view, exactly
+// what is displayed; otherwise JSON when the body is protobuf, with field
+// names when the descriptor set describes the method, and the raw text when
+// it is not.
+func (m Model) clipboardText(ev *tapv1.GRPCEvent, response bool) string {
+	body := eventBody(ev, response)
+	if m.bodyView == bodyViewAuto {
+		if j, err := m.descriptors.DecodeJSON(ev.GetMethod(), response, body); err == nil {
+			return string(j)
+		}
+		return bodyToClipboardText(body)
+	}
+	lines, _ := m.renderEventBody(ev, response)
+	return strings.Join(lines, "\n")
+}
+
 func bodyToClipboardText(body []byte) string {
 	// Try JSON (pretty-printed protobuf wire)
 	if j, err := proxy.ProtoWireToJSON(body); err == nil {
@@ -0,0 +1,632 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+func TestNewListLayout(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		innerWidth  int
+		wantCompact bool
+	}{
+		{name: "minimum", innerWidth: 20, wantCompact: true},
+		{name: "narrow", innerWidth: 50, wantCompact: true},
+		{name: "just below threshold", innerWidth: listFixedWidth + listMinMethodWidth - 1, wantCompact: true},
+		{name: "at threshold", innerWidth: listFixedWidth + listMinMethodWidth, wantCompact: false},
+		{name: "80 columns", innerWidth: 76, wantCompact: false},
+		{name: "wide", innerWidth: 200, wantCompact: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			l := newListLayout(tt.innerWidth, DefaultColumns)
+			if l.compact != tt.wantCompact {
+				t.Errorf("compact = %v, want %v", l.compact, tt.wantCompact)
+			}
+			method := l.width(ColumnMethod)
+			if method <= 0 {
+				t.Errorf("method width = %d, want > 0", method)
+			}
+			if !l.compact && method < listMinMethodWidth {
+				t.Errorf("method width = %d, want >= %d", method, listMinMethodWidth)
+			}
+			if width := rowWidth(l); width > tt.innerWidth {
+				t.Errorf("row width = %d, exceeds inner width %d", width, tt.innerWidth)
+			}
+		})
+	}
+}
+
+// rowWidth returns how wide the rows of l are.
+func rowWidth(l listLayout) int {
+	width := listColMarker + len(l.widths) - 1
+	for _, w := range l.widths {
+		width += w
+	}
+	return width
+}
+
+func testEvent(id, method string, status int32, dur time.Duration) *tapv1.GRPCEvent {
+	return &tapv1.GRPCEvent{
+		Id:        id,
+		Method:    method,
+		Status:    status,
+		Duration:  durationpb.New(dur),
+		StartTime: timestamppb.Now(),
+	}
+}
+
+func newTestModel(events ...*tapv1.GRPCEvent) Model {
+	m := New("localhost:9092")
+	m.width = 80
+	m.height = 24
+	for _, ev := range events {
+		m, _ = m.upsertEvent(ev)
+	}
+	m.displayRows = m.rebuildDisplayRows()
+	return m
+}
+
+func press(m Model, key string) Model {
+	var msg tea.KeyMsg
+	switch key {
+	case "enter":
+		msg = tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		msg = tea.KeyMsg{Type: tea.KeyEsc}
+	case "left":
+		msg = tea.KeyMsg{Type: tea.KeyLeft}
+	case "right":
+		msg = tea.KeyMsg{Type: tea.KeyRight}
+	case "ctrl+d":
+		msg = tea.KeyMsg{Type: tea.KeyCtrlD}
+	case "ctrl+u":
+		msg = tea.KeyMsg{Type: tea.KeyCtrlU}
+	case "home":
+		msg = tea.KeyMsg{Type: tea.KeyHome}
+	case "end":
+		msg = tea.KeyMsg{Type: tea.KeyEnd}
+	case "backspace":
+		msg = tea.KeyMsg{Type: tea.KeyBackspace}
+	default:
+		msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	}
+	updated, _ := m.Update(msg)
+	return updated.(Model) //nolint:forcetypeassert // Update always returns Model
+}
+
+func TestInspectHorizontalScroll(t *testing.T) {
+	t.Parallel()
+
+	ev := testEvent("1", "/pkg.Svc/"+strings.Repeat("x", 200), 0, time.Millisecond)
+	m := newTestModel(ev)
+	m = press(m, "enter")
+	if m.view != viewInspect {
+		t.Fatalf("view = %v, want inspector", m.view)
+	}
+
+	m = press(m, "h")
+	if m.inspectHScroll != 0 {
+		t.Errorf("h at left edge: hscroll = %d, want 0", m.inspectHScroll)
+	}
+
+	m = press(m, "l")
+	if m.inspectHScroll != inspectHScrollStep {
+		t.Errorf("l: hscroll = %d, want %d", m.inspectHScroll, inspectHScrollStep)
+	}
+
+	maxHScroll := maxLineWidth(m.inspectLines(ev)) - (m.width - 4)
+	for range 100 {
+		m = press(m, "l")
+	}
+	if m.inspectHScroll != maxHScroll {
+		t.Errorf("l past right edge: hscroll = %d, want %d", m.inspectHScroll, maxHScroll)
+	}
+
+	m = press(m, "left")
+	if m.inspectHScroll != maxHScroll-inspectHScrollStep {
+		t.Errorf("left: hscroll = %d, want %d", m.inspectHScroll, maxHScroll-inspectHScrollStep)
+	}
+
+	m = press(m, "q")
+	m = press(m, "enter")
+	if m.inspectHScroll != 0 {
+		t.Errorf("re-open: hscroll = %d, want 0", m.inspectHScroll)
+	}
+}
+
+func TestHelpOverlay(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel(testEvent("1", "/pkg.Svc/Get", 0, time.Millisecond))
+	m = press(m, "enter")
+	m = press(m, "?")
+	if !m.showHelp {
+		t.Fatal("? did not open help")
+	}
+	if !strings.Contains(m.View(), "Inspector") {
+		t.Error("help view does not list inspector bindings")
+	}
+
+	// Any key dismisses without acting on the underlying view.
+	m = press(m, "q")
+	if m.showHelp {
+		t.Error("key did not dismiss help")
+	}
+	if m.view != viewInspect {
+		t.Errorf("view = %v, want inspector after dismissing help", m.view)
+	}
+}
+
+func TestListJumpAndHalfPage(t *testing.T) {
+	t.Parallel()
+
+	events := make([]*tapv1.GRPCEvent, 50)
+	for i := range events {
+		events[i] = testEvent(fmt.Sprint(i), "/pkg.Svc/Get", 0, time.Millisecond)
+	}
+	m := newTestModel(events...)
+	last := len(m.displayRows) - 1
+
+	m = press(m, "g")
+	if m.cursor != 0 || m.follow {
+		t.Errorf("g: cursor = %d follow = %v, want 0 false", m.cursor, m.follow)
+	}
+
+	m = press(m, "ctrl+u")
+	if m.cursor != 0 {
+		t.Errorf("ctrl+u at top: cursor = %d, want 0", m.cursor)
+	}
+
+	m = press(m, "ctrl+d")
+	if want := max(m.listHeight()/2, 1); m.cursor != want {
+		t.Errorf("ctrl+d: cursor = %d, want %d", m.cursor, want)
+	}
+
+	m = press(m, "G")
+	if m.cursor != last || !m.follow {
+		t.Errorf("G: cursor = %d follow = %v, want %d true", m.cursor, m.follow, last)
+	}
+
+	m = press(m, "ctrl+d")
+	if m.cursor != last {
+		t.Errorf("ctrl+d at bottom: cursor = %d, want %d", m.cursor, last)
+	}
+
+	m = press(m, "home")
+	if m.cursor != 0 {
+		t.Errorf("home: cursor = %d, want 0", m.cursor)
+	}
+	m = press(m, "end")
+	if m.cursor != last {
+		t.Errorf("end: cursor = %d, want %d", m.cursor, last)
+	}
+}
+
+func TestListJumpEmpty(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel()
+	for _, key := range []string{"G", "g", "ctrl+d", "ctrl+u"} {
+		m = press(m, key)
+		if m.cursor != 0 {
+			t.Errorf("%s on empty list: cursor = %d, want 0", key, m.cursor)
+		}
+	}
+}
+
+func TestInspectJumpAndHalfPage(t *testing.T) {
+	t.Parallel()
+
+	ev := testEvent("1", "/pkg.Svc/Get", 0, time.Millisecond)
+	ev.RequestBody = []byte(strings.Repeat("x", 4000))
+	m := newTestModel(ev)
+	m.height = 10
+	m = press(m, "enter")
+
+	maxScroll := m.inspectMaxScroll()
+	if maxScroll == 0 {
+		t.Fatal("test event does not overflow the inspector")
+	}
+
+	m = press(m, "G")
+	if m.inspectScroll != maxScroll {
+		t.Errorf("G: scroll = %d, want %d", m.inspectScroll, maxScroll)
+	}
+	m = press(m, "j")
+	if m.inspectScroll != maxScroll {
+		t.Errorf("j at bottom: scroll = %d, want %d", m.inspectScroll, maxScroll)
+	}
+
+	m = press(m, "ctrl+u")
+	if want := maxScroll - m.inspectVisibleRows()/2; m.inspectScroll != max(want, 0) {
+		t.Errorf("ctrl+u: scroll = %d, want %d", m.inspectScroll, max(want, 0))
+	}
+
+	m = press(m, "g")
+	if m.inspectScroll != 0 {
+		t.Errorf("g: scroll = %d, want 0", m.inspectScroll)
+	}
+	m = press(m, "ctrl+u")
+	if m.inspectScroll != 0 {
+		t.Errorf("ctrl+u at top: scroll = %d, want 0", m.inspectScroll)
+	}
+}
+
+func TestEventPhaseUpdate(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel(testEvent("1", "/pkg.Svc/Get", 0, time.Millisecond))
+
+	for _, phase := range []tapv1.EventPhase{tapv1.EventPhase_EVENT_PHASE_START, tapv1.EventPhase_EVENT_PHASE_PROGRESS} {
+		ev := testEvent("2", "/pkg.Svc/Watch", 0, time.Second)
+		ev.Phase = phase
+		updated, _ := m.Update(eventMsg{Event: ev})
+		m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+		if len(m.events) != 2 {
+			t.Fatalf("%v: events = %d, want 2", phase, len(m.events))
+		}
+		if got := eventStatusString(m.events[1]); got != "…" {
+			t.Errorf("%v: status = %q, want …", phase, got)
+		}
+	}
+	if rows := m.buildAnalyticsRows(); len(rows) != 1 {
+		t.Errorf("analytics rows while in flight = %d, want 1", len(rows))
+	}
+
+	final := testEvent("2", "/pkg.Svc/Watch", 14, 3*time.Second)
+	final.Phase = tapv1.EventPhase_EVENT_PHASE_COMPLETE
+	updated, _ := m.Update(eventMsg{Event: final})
+	m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+	if len(m.events) != 2 || len(m.displayRows) != 2 {
+		t.Fatalf("events/rows = %d/%d, want 2/2", len(m.events), len(m.displayRows))
+	}
+	if m.events[1] != final {
+		t.Error("final event did not replace the in-flight event")
+	}
+	if got := eventStatusString(m.events[1]); got != "Unavailable(14)" {
+		t.Errorf("final status = %q, want Unavailable(14)", got)
+	}
+	if rows := m.buildAnalyticsRows(); len(rows) != 2 {
+		t.Errorf("analytics rows = %d, want 2", len(rows))
+	}
+}
+
+func TestEventUpdateInPlace_Cursor(t *testing.T) {
+	t.Parallel()
+
+	update := func(m Model, ev *tapv1.GRPCEvent) Model {
+		updated, _ := m.Update(eventMsg{Event: ev})
+		return updated.(Model) //nolint:forcetypeassert // Update always returns Model
+	}
+	events := func() []*tapv1.GRPCEvent {
+		return []*tapv1.GRPCEvent{
+			testEvent("a", "/pkg.Svc/A", 0, 30*time.Millisecond),
+			testEvent("b", "/pkg.Svc/B", 0, 20*time.Millisecond),
+			testEvent("c", "/pkg.Svc/C", 0, 10*time.Millisecond),
+		}
+	}
+
+	t.Run("cursor stays on its row", func(t *testing.T) {
+		t.Parallel()
+
+		m := newTestModel(events()...)
+		m.cursor = 0
+		m = update(m, testEvent("c", "/pkg.Svc/C", 2, 15*time.Millisecond))
+		if len(m.events) != 3 || len(m.displayRows) != 3 {
+			t.Fatalf("events/rows = %d/%d, want 3/3", len(m.events), len(m.displayRows))
+		}
+		if m.cursor != 0 || m.cursorEvent().GetId() != "a" {
+			t.Errorf("cursor = %d (%s), want 0 (a)", m.cursor, m.cursorEvent().GetId())
+		}
+		if m.events[m.eventIdx["c"]].GetStatus() != 2 {
+			t.Error("event c was not updated")
+		}
+	})
+
+	t.Run("cursor follows its event when the sort order changes", func(t *testing.T) {
+		t.Parallel()
+
+		m := newTestModel(events()...)
+		m.sortMode = sortDuration
+		m.displayRows = m.rebuildDisplayRows()
+		m.cursor = 1 // b, between a (30ms) and c (10ms)
+		m = update(m, testEvent("c", "/pkg.Svc/C", 0, time.Second))
+		if got := m.cursorEvent().GetId(); got != "b" || m.cursor != 2 {
+			t.Errorf("cursor = %d (%s), want 2 (b)", m.cursor, got)
+		}
+	})
+
+	t.Run("follow keeps the last row", func(t *testing.T) {
+		t.Parallel()
+
+		m := newTestModel(events()...)
+		m.follow = true
+		m.cursor = 2
+		m = update(m, testEvent("a", "/pkg.Svc/A", 0, time.Second))
+		if m.cursor != 2 || len(m.displayRows) != 3 {
+			t.Errorf("cursor/rows = %d/%d, want 2/3", m.cursor, len(m.displayRows))
+		}
+	})
+
+	t.Run("new events still append", func(t *testing.T) {
+		t.Parallel()
+
+		m := newTestModel(events()...)
+		m.cursor = 1
+		m = update(m, testEvent("d", "/pkg.Svc/D", 0, time.Millisecond))
+		if len(m.events) != 4 || m.eventIdx["d"] != 3 {
+			t.Errorf("events = %d, index of d = %d; want 4, 3", len(m.events), m.eventIdx["d"])
+		}
+		if m.cursor != 1 {
+			t.Errorf("cursor = %d, want 1", m.cursor)
+		}
+	})
+}
+
+func TestInspectBodyPreview(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("line\n", 3*bodyPreviewLines)
+	ev := testEvent("1", "/pkg.Svc/Get", 0, time.Millisecond)
+	ev.RequestBody = []byte(body)
+	ev.ResponseBody = []byte(body)
+	m := newTestModel(ev)
+	m = press(m, "enter")
+
+	markers := func(m Model) int {
+		n := 0
+		for _, line := range m.inspectLines(ev) {
+			if strings.Contains(line, "press L to expand") {
+				n++
+			}
+		}
+		return n
+	}
+	folded := len(m.inspectLines(ev))
+	if got := markers(m); got != 2 {
+		t.Fatalf("markers = %d, want 2 (both bodies folded)", got)
+	}
+
+	m = press(m, "L")
+	if !m.expandRequest || m.expandResponse || markers(m) != 1 {
+		t.Errorf("after L: request/response expanded = %v/%v, markers = %d; want true/false, 1",
+			m.expandRequest, m.expandResponse, markers(m))
+	}
+	m = press(m, "L")
+	if !m.expandResponse || markers(m) != 0 {
+		t.Errorf("after 2×L: response expanded = %v, markers = %d; want true, 0", m.expandResponse, markers(m))
+	}
+	if got := len(m.inspectLines(ev)); got <= folded {
+		t.Errorf("expanded lines = %d, want more than %d", got, folded)
+	}
+
+	m = press(m, "G")
+	m = press(m, "L")
+	if m.expandRequest || m.expandResponse || markers(m) != 2 {
+		t.Errorf("after 3×L: bodies not folded again")
+	}
+	if m.inspectScroll > m.inspectMaxScroll() {
+		t.Errorf("scroll %d beyond max %d after folding", m.inspectScroll, m.inspectMaxScroll())
+	}
+
+	m = press(m, "q")
+	m = press(m, "L") // no-op in the list
+	m = press(m, "enter")
+	if m.expandRequest || m.expandResponse {
+		t.Error("reopening the inspector kept bodies expanded")
+	}
+}
+
+func TestInspectBodyView(t *testing.T) {
+	t.Parallel()
+
+	ev := testEvent("1", "/pkg.Svc/Get", 0, time.Millisecond)
+	ev.RequestBody = []byte{0x08, 0x2a} // field 1 = 42
+	m := newTestModel(ev)
+	m = press(m, "enter")
+
+	contains := func(m Model, s string) bool {
+		return slices.ContainsFunc(m.inspectLines(ev), func(line string) bool { return strings.Contains(line, s) })
+	}
+	if !contains(m, "1: 42") {
+		t.Fatal("auto view did not decode the body")
+	}
+
+	m = press(m, "x")
+	if m.bodyView != bodyViewHex || !contains(m, "00000000  08 2a") || contains(m, "1: 42") {
+		t.Errorf("x: view = %v, want a hex dump only", m.bodyView)
+	}
+	if got := m.clipboardText(ev, false); !strings.HasPrefix(got, "00000000  08 2a") {
+		t.Errorf("hex view copies %q, want the hex dump", got)
+	}
+
+	m = press(m, "d")
+	if m.bodyView != bodyViewDecoded || !contains(m, "1: 42") {
+		t.Errorf("d: view = %v, want decoded", m.bodyView)
+	}
+	if got := m.clipboardText(ev, false); got != "1: 42" {
+		t.Errorf("decoded view copies %q, want %q", got, "1: 42")
+	}
+
+	m = press(m, "d")
+	if m.bodyView != bodyViewAuto {
+		t.Errorf("d twice: view = %v, want auto", m.bodyView)
+	}
+	if got := m.clipboardText(ev, false); !strings.Contains(got, `"1": 42`) {
+		t.Errorf("auto view copies %q, want JSON", got)
+	}
+
+	m = press(m, "x")
+	m = press(m, "q")
+	m = press(m, "enter")
+	if m.bodyView != bodyViewAuto {
+		t.Errorf("reopened inspector view = %v, want auto", m.bodyView)
+	}
+}
+
+// fakeWatchStream stands in for a connected Watch stream.
+type fakeWatchStream struct{ tapv1.TapService_WatchClient }
+
+func TestClearEvents(t *testing.T) {
+	t.Parallel()
+
+	newModel := func() Model {
+		m := newTestModel(
+			testEvent("1", "/pkg.Svc/Get", 0, time.Millisecond),
+			testEvent("2", "/pkg.Svc/List", 14, time.Millisecond),
+		)
+		m.stream = &fakeWatchStream{}
+		m.errorCount = 1
+		m = press(m, "a")
+		return press(m, "q")
+	}
+	ctrlL := tea.KeyMsg{Type: tea.KeyCtrlL}
+
+	t.Run("cancelled", func(t *testing.T) {
+		t.Parallel()
+		m := newModel()
+		updated, _ := m.Update(ctrlL)
+		m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+		if !m.clearMode {
+			t.Fatal("clear did not ask for confirmation")
+		}
+		m = press(m, "n")
+		if m.clearMode || len(m.events) != 2 {
+			t.Errorf("clearMode/events = %v/%d, want false/2", m.clearMode, len(m.events))
+		}
+	})
+
+	t.Run("confirmed", func(t *testing.T) {
+		t.Parallel()
+		m := newModel()
+		stream := m.stream
+		updated, _ := m.Update(ctrlL)
+		m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+		m = press(m, "y")
+
+		if len(m.events) != 0 || len(m.eventIdx) != 0 || len(m.displayRows) != 0 || len(m.analyticsRows) != 0 {
+			t.Errorf("events/idx/rows/analytics = %d/%d/%d/%d, want all empty",
+				len(m.events), len(m.eventIdx), len(m.displayRows), len(m.analyticsRows))
+		}
+		if m.cursor != 0 || m.errorCount != 0 || !m.follow {
+			t.Errorf("cursor/errorCount/follow = %d/%d/%v, want 0/0/true", m.cursor, m.errorCount, m.follow)
+		}
+		if m.stream != stream {
+			t.Error("Watch stream was dropped")
+		}
+
+		updated, _ = m.Update(eventMsg{Event: testEvent("3", "/pkg.Svc/Get", 0, time.Millisecond)})
+		m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+		if len(m.events) != 1 || len(m.displayRows) != 1 || m.eventIdx["3"] != 0 {
+			t.Errorf("events/rows after clear = %d/%d, want 1/1", len(m.events), len(m.displayRows))
+		}
+	})
+}
+
+func TestHostColumn(t *testing.T) {
+	t.Parallel()
+
+	ev := testEvent("1", "/pkg.Svc/Get", 0, time.Millisecond)
+	ev.Authority = "users.internal"
+
+	m := newTestModel(ev)
+	m.width = 160
+	if strings.Contains(m.renderListView(), "users.internal") {
+		t.Error("host shown without the host column")
+	}
+
+	m.hostColumn = true
+	if view := m.renderListView(); !strings.Contains(view, "Host") || !strings.Contains(view, "users.internal") {
+		t.Error("host column missing")
+	}
+
+	l := newListLayout(listFixedWidth+listMinMethodWidth, m.listColumns())
+	if host := l.width(ColumnHost); host != 0 {
+		t.Errorf("host width = %d at the narrowest full layout, want the column left out", host)
+	}
+	l = newListLayout(160, m.listColumns())
+	if host, method := l.width(ColumnHost), l.width(ColumnMethod); host != listColHost || method < listMinMethodWidth {
+		t.Errorf("host/method width = %d/%d, want %d/>=%d", host, method, listColHost, listMinMethodWidth)
+	}
+}
+
+func TestTraceColumn(t *testing.T) {
+	t.Parallel()
+
+	ev := testEvent("1", "/pkg.Svc/Get", 0, time.Millisecond)
+	ev.CorrelationId = "4bf92f3577b34da6a3ce929d0e0e4736"
+	ev.Authority = "users.internal"
+
+	m := newTestModel(ev)
+	m.width = 160
+	if strings.Contains(m.renderListView(), "4bf92f35") {
+		t.Error("correlation ID shown without the trace column")
+	}
+
+	m.traceColumn = true
+	if view := m.renderListView(); !strings.Contains(view, "Trace") || !strings.Contains(view, "4bf92f35") {
+		t.Error("trace column missing")
+	}
+	m.hostColumn = true
+	if view := m.renderListView(); !strings.Contains(view, "users.internal") || !strings.Contains(view, "4bf92f35") {
+		t.Error("host and trace columns not shown together")
+	}
+
+	l := newListLayout(160, m.listColumns())
+	if host, trace, method := l.width(ColumnHost), l.width(ColumnTrace), l.width(ColumnMethod); host != listColHost ||
+		trace != listColTrace || method < listMinMethodWidth {
+		t.Errorf("host/trace/method width = %d/%d/%d", host, trace, method)
+	}
+	m.hostColumn = false
+	l = newListLayout(listFixedWidth+listMinMethodWidth+listColTrace, m.listColumns())
+	if trace := l.width(ColumnTrace); trace != 0 {
+		t.Errorf("trace width = %d without room for it, want the column left out", trace)
+	}
+}
+
+func TestHidePreview(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel(testEvent("1", "/pkg.Svc/Get", 0, time.Millisecond))
+	withPreview := m.listHeight()
+	if m.previewHeight() == 0 {
+		t.Fatal("no preview to hide")
+	}
+
+	m = press(m, "P")
+	if got, want := m.listHeight(), m.height-listChrome; got != want {
+		t.Errorf("list height without preview = %d, want %d", got, want)
+	}
+	if m.listHeight() <= withPreview {
+		t.Errorf("list height = %d, want more than %d with the preview", m.listHeight(), withPreview)
+	}
+	if strings.Contains(m.renderListView(), "Protocol:") {
+		t.Error("preview rendered while hidden")
+	}
+
+	m = press(m, "P")
+	if m.listHeight() != withPreview {
+		t.Errorf("list height after P P = %d, want %d", m.listHeight(), withPreview)
+	}
+
+	WithPreview(false)(&m)
+	if m.previewHeight() != 0 {
+		t.Error("WithPreview(false) left the preview shown")
+	}
+}
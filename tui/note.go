@@ -0,0 +1,52 @@
+package tui
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// updateNote handles keys while a note is typed on the inspected event.
+// Enter saves it, replacing any earlier note; saving an empty note removes
+// it. Esc cancels.
+func (m Model) updateNote(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		m.noteMode = false
+		if ev := m.cursorEvent(); ev != nil {
+			m = m.setNote(ev.GetId(), m.noteInput)
+		}
+		m.noteInput = ""
+		return m, nil
+	case "esc":
+		m.noteMode = false
+		m.noteInput = ""
+		return m, nil
+	case "backspace":
+		if len(m.noteInput) > 0 {
+			_, size := utf8.DecodeLastRuneInString(m.noteInput)
+			m.noteInput = m.noteInput[:len(m.noteInput)-size]
+		}
+		return m, nil
+	case "ctrl+c":
+		return m.quit()
+	}
+	m.noteInput += string(msg.Runes)
+	return m, nil
+}
+
+// setNote attaches note to the event with the given ID. Notes live in the
+// TUI only; they are never sent to the daemon.
+func (m Model) setNote(id, note string) Model {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		delete(m.notes, id)
+		return m
+	}
+	if m.notes == nil {
+		m.notes = make(map[string]string)
+	}
+	m.notes[id] = note
+	return m
+}
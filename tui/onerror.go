@@ -0,0 +1,129 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+	"github.com/mickamy/grpc-tap/notify"
+)
+
+// ErrorMode controls how the TUI reacts to newly arrived error events.
+type ErrorMode int
+
+const (
+	// ErrorModeOff ignores new errors.
+	ErrorModeOff ErrorMode = iota
+	// ErrorModeAlert flashes an alert for each new error.
+	ErrorModeAlert
+	// ErrorModeInspect flashes an alert and, when the list is idle in
+	// follow mode, opens the error in the inspector.
+	ErrorModeInspect
+)
+
+// ParseErrorMode parses "off", "alert" or "inspect".
+func ParseErrorMode(s string) (ErrorMode, error) {
+	switch s {
+	case "", "off":
+		return ErrorModeOff, nil
+	case "alert":
+		return ErrorModeAlert, nil
+	case "inspect":
+		return ErrorModeInspect, nil
+	}
+	return ErrorModeOff, fmt.Errorf("unknown error mode %q (want off, alert or inspect)", s)
+}
+
+// WithErrorMode sets how newly arrived error events are surfaced.
+func WithErrorMode(mode ErrorMode) Option {
+	return func(m *Model) {
+		m.errorMode = mode
+	}
+}
+
+// bellDebounce is the minimum interval between two terminal bells, so a burst
+// of errors rings once rather than continuously.
+const bellDebounce = 2 * time.Second
+
+// WithBellOnError rings the terminal bell when an error event arrives and
+// keeps a running error count in the window title.
+func WithBellOnError(enabled bool) Option {
+	return func(m *Model) {
+		m.bellOnError = enabled
+	}
+}
+
+// notifyInterval is the minimum interval between two desktop notifications.
+const notifyInterval = 10 * time.Second
+
+// WithNotifyOnError sends a desktop notification when an error event arrives.
+func WithNotifyOnError(enabled bool) Option {
+	return func(m *Model) {
+		m.notifyOnError = enabled
+	}
+}
+
+// onNewError reacts to a freshly received event according to the configured
+// ErrorMode, bell and notification settings. It must be called after
+// displayRows and the cursor have been updated for ev.
+func (m Model) onNewError(ev *tapv1.GRPCEvent) (Model, tea.Cmd) {
+	if ev.GetStatus() == 0 {
+		return m, nil
+	}
+	m.errorCount++
+
+	var cmds []tea.Cmd
+	if m.bellOnError {
+		cmds = append(cmds, tea.SetWindowTitle(fmt.Sprintf("grpc-tap (%d errors)", m.errorCount)))
+		if now := time.Now(); now.Sub(m.bellAt) >= bellDebounce {
+			m.bellAt = now
+			cmds = append(cmds, ringBell)
+		}
+	}
+
+	summary := fmt.Sprintf("%s %s", statusString(ev.GetStatus()), ev.GetMethod())
+	if m.notifyOnError {
+		if now := time.Now(); now.Sub(m.notifiedAt) >= notifyInterval {
+			m.notifiedAt = now
+			cmds = append(cmds, sendNotification(summary))
+		}
+	}
+
+	if m.errorMode != ErrorModeOff {
+		if m.errorMode == ErrorModeInspect && m.idleFollowing() && m.cursorEvent() == ev {
+			m = m.openInspector()
+		}
+		var cmd tea.Cmd
+		m, cmd = m.showAlert(summary)
+		cmds = append(cmds, cmd)
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// sendNotification fires a desktop notification off the UI goroutine.
+// Failures (e.g. no notifier installed) are ignored.
+func sendNotification(body string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = notify.Send(ctx, "grpc-tap", body)
+		return nil
+	}
+}
+
+// ringBell writes BEL to the terminal. It goes to stderr so it never
+// interleaves with the renderer's stdout frames.
+func ringBell() tea.Msg {
+	_, _ = fmt.Fprint(os.Stderr, "\a")
+	return nil
+}
+
+// idleFollowing reports whether the user is passively tailing the list, i.e.
+// auto-navigating would not interrupt anything they are doing.
+func (m Model) idleFollowing() bool {
+	return m.view == viewList && m.follow && !m.searchMode && !m.writeMode && !m.showHelp
+}
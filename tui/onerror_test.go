@@ -0,0 +1,148 @@
+package tui
+
+import (
+	"testing"
+	"time"
+)
+
+func sendEvent(m Model, id string, status int32) Model {
+	updated, _ := m.Update(eventMsg{Event: testEvent(id, "/pkg.Svc/Get", status, time.Millisecond)})
+	return updated.(Model) //nolint:forcetypeassert // Update always returns Model
+}
+
+func TestParseErrorMode(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]ErrorMode{
+		"":        ErrorModeOff,
+		"off":     ErrorModeOff,
+		"alert":   ErrorModeAlert,
+		"inspect": ErrorModeInspect,
+	} {
+		got, err := ParseErrorMode(in)
+		if err != nil || got != want {
+			t.Errorf("ParseErrorMode(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseErrorMode("loud"); err == nil {
+		t.Error("ParseErrorMode(loud): want error")
+	}
+}
+
+func TestErrorModeAlert(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel()
+	m.errorMode = ErrorModeAlert
+
+	m = sendEvent(m, "1", 0)
+	if m.alertMessage != "" {
+		t.Errorf("OK event raised alert %q", m.alertMessage)
+	}
+
+	m = sendEvent(m, "2", 5)
+	if m.alertMessage == "" {
+		t.Error("error event did not raise an alert")
+	}
+	if m.view != viewList {
+		t.Errorf("view = %v, want list in alert mode", m.view)
+	}
+}
+
+func TestErrorModeInspect(t *testing.T) {
+	t.Parallel()
+
+	t.Run("opens inspector when following", func(t *testing.T) {
+		t.Parallel()
+
+		m := newTestModel()
+		m.errorMode = ErrorModeInspect
+		m.follow = true
+
+		m = sendEvent(m, "1", 0)
+		m = sendEvent(m, "2", 13)
+		if m.view != viewInspect {
+			t.Fatalf("view = %v, want inspector", m.view)
+		}
+		if got := m.cursorEvent().GetId(); got != "2" {
+			t.Errorf("inspected event = %q, want 2", got)
+		}
+	})
+
+	t.Run("stays put while navigating", func(t *testing.T) {
+		t.Parallel()
+
+		m := newTestModel()
+		m.errorMode = ErrorModeInspect
+		m.follow = false
+
+		m = sendEvent(m, "1", 0)
+		m = sendEvent(m, "2", 13)
+		if m.view != viewList {
+			t.Errorf("view = %v, want list when follow is off", m.view)
+		}
+		if m.alertMessage == "" {
+			t.Error("error event did not raise an alert")
+		}
+	})
+
+	t.Run("stays put while searching", func(t *testing.T) {
+		t.Parallel()
+
+		m := newTestModel()
+		m.errorMode = ErrorModeInspect
+		m.follow = true
+		m.searchMode = true
+
+		m = sendEvent(m, "1", 13)
+		if m.view != viewList {
+			t.Errorf("view = %v, want list while searching", m.view)
+		}
+	})
+}
+
+func TestBellOnError(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel()
+	m.bellOnError = true
+
+	m = sendEvent(m, "1", 0)
+	if m.errorCount != 0 || !m.bellAt.IsZero() {
+		t.Fatalf("OK event: errorCount = %d, bellAt = %v", m.errorCount, m.bellAt)
+	}
+
+	m = sendEvent(m, "2", 2)
+	first := m.bellAt
+	if first.IsZero() {
+		t.Fatal("error event did not ring the bell")
+	}
+
+	// A burst within the debounce window must not ring again.
+	m = sendEvent(m, "3", 2)
+	m = sendEvent(m, "4", 2)
+	if !m.bellAt.Equal(first) {
+		t.Errorf("bell rang again within %v", bellDebounce)
+	}
+	if m.errorCount != 3 {
+		t.Errorf("errorCount = %d, want 3", m.errorCount)
+	}
+}
+
+func TestNotifyOnError_RateLimited(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel()
+	m.notifyOnError = true
+
+	m = sendEvent(m, "1", 14)
+	first := m.notifiedAt
+	if first.IsZero() {
+		t.Fatal("error event did not send a notification")
+	}
+
+	m = sendEvent(m, "2", 14)
+	if !m.notifiedAt.Equal(first) {
+		t.Errorf("notification sent again within %v", notifyInterval)
+	}
+}
@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"slices"
+	"sort"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+// shown reports whether the list shows ev under the current search, error,
+// internal and bookmark filters; matches is the search's matcher.
+func (m Model) shown(ev *tapv1.GRPCEvent, matches func(*tapv1.GRPCEvent) bool) bool {
+	switch {
+	case !matches(ev):
+		return false
+	case m.filterErrors && ev.GetStatus() == 0:
+		return false
+	case m.hidden(ev):
+		return false
+	case m.filterBookmarks && !m.bookmarked(ev):
+		return false
+	}
+	return true
+}
+
+// findRow returns the display row of m.events[idx], or -1 if it has none.
+// Chronological rows are in event order, so they are binary searched.
+func (m Model) findRow(idx int) int {
+	if m.sortMode != sortChronological {
+		return m.rowOf(idx)
+	}
+	if row, ok := slices.BinarySearch(m.displayRows, idx); ok {
+		return row
+	}
+	return -1
+}
+
+// rowFor returns the display row that m.events[idx] belongs at, in the
+// sort's order.
+func (m Model) rowFor(idx int) int {
+	if m.sortMode != sortChronological {
+		return sort.Search(len(m.displayRows), func(row int) bool {
+			return m.compareEvents(idx, m.displayRows[row]) < 0
+		})
+	}
+	row, _ := slices.BinarySearch(m.displayRows, idx)
+	return row
+}
+
+// updateRow brings displayRows up to date after m.events[idx] alone was
+// added or replaced, in time linear at worst where rebuildDisplayRows
+// filters and sorts every event. Like refreshRows, it moves the cursor to
+// the last row when following and otherwise keeps it on the same event.
+// Events must not have moved: after an eviction, use refreshRows.
+func (m Model) updateRow(idx int) Model {
+	prev := -1
+	if m.cursor < len(m.displayRows) {
+		prev = m.displayRows[m.cursor]
+	}
+	if row := m.findRow(idx); row >= 0 {
+		m.displayRows = slices.Delete(m.displayRows, row, row+1)
+		if row < m.cursor {
+			m.cursor--
+		}
+	}
+	if m.shown(m.events[idx], searchMatcher(m.searchQuery)) {
+		row := m.rowFor(idx)
+		m.displayRows = slices.Insert(m.displayRows, row, idx)
+		switch {
+		case prev == idx:
+			m.cursor = row
+		case prev >= 0 && row <= m.cursor:
+			m.cursor++
+		}
+	}
+	if m.follow {
+		m.cursor = max(len(m.displayRows)-1, 0)
+		return m
+	}
+	m.cursor = min(m.cursor, max(len(m.displayRows)-1, 0))
+	return m
+}
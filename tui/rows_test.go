@@ -0,0 +1,96 @@
+package tui
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestUpdateRow_MatchesRebuild(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name   string
+		sort   sortMode
+		search string
+		errors bool
+		follow bool
+	}{
+		{name: "chronological", follow: true},
+		{name: "chronological, cursor kept"},
+		{name: "duration", sort: sortDuration},
+		{name: "search", search: "method:Get"},
+		{name: "errors, by duration", sort: sortDuration, errors: true},
+		{name: "method", sort: sortMode{key: sortByMethod}},
+		{name: "status, descending", sort: sortMode{key: sortByStatus, desc: true}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m := newTestModel()
+			m.sortMode, m.searchQuery, m.filterErrors, m.follow = tt.sort, tt.search, tt.errors, tt.follow
+			for i := range 60 {
+				// Every third event replaces an earlier one, as a finished
+				// call replaces its start event, with another duration and
+				// status.
+				id := strconv.Itoa(i)
+				if i%3 == 2 {
+					id = strconv.Itoa(i / 2)
+				}
+				method := []string{"/pkg.Svc/Get", "/pkg.Svc/Put"}[i%2]
+				ev := testEvent(id, method, int32(i%4), time.Duration(i*7%11)*time.Millisecond)
+
+				rows, cursor := slices.Clone(m.displayRows), m.cursor
+				updated, _ := m.Update(eventMsg{Event: ev})
+				m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+
+				want := m
+				want.displayRows, want.cursor = rows, cursor
+				want = want.refreshRows()
+				if !slices.Equal(m.displayRows, want.displayRows) {
+					t.Fatalf("after event %d: rows = %v, want %v", i, m.displayRows, want.displayRows)
+				}
+				if got, want := m.cursorEvent(), want.cursorEvent(); got != want {
+					t.Fatalf("after event %d: cursor on %v, want %v", i, got.GetId(), want.GetId())
+				}
+				if i%5 == 0 && len(m.displayRows) > 0 {
+					m.cursor = i % len(m.displayRows)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkEventUpdate measures the cost of one more event arriving while
+// the list view shows 100k.
+func BenchmarkEventUpdate(b *testing.B) {
+	for _, mode := range []struct {
+		name   string
+		sort   sortMode
+		search string
+	}{
+		{"chronological", sortChronological, ""},
+		{"duration", sortDuration, ""},
+		{"filtered", sortChronological, "method:Get"},
+	} {
+		b.Run(mode.name, func(b *testing.B) {
+			m := New("localhost:9092")
+			m.width, m.height = 80, 24
+			m.sortMode, m.searchQuery = mode.sort, mode.search
+			for i := range 100_000 {
+				m, _ = m.upsertEvent(testEvent(strconv.Itoa(i), "/pkg.Svc/Get", 0, time.Duration(i%997)*time.Millisecond))
+			}
+			m.displayRows = m.rebuildDisplayRows()
+
+			n := 100_000
+			b.ReportAllocs()
+			for b.Loop() {
+				ev := testEvent(strconv.Itoa(n), "/pkg.Svc/Get", 0, time.Duration(n%997)*time.Millisecond)
+				n++
+				updated, _ := m.Update(eventMsg{Event: ev})
+				m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+			}
+		})
+	}
+}
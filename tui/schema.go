@@ -0,0 +1,126 @@
+package tui
+
+import (
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+// WithDescriptors decodes the bodies of the methods d describes with their
+// message schemas, showing field names and enum values by name instead of
+// field numbers.
+func WithDescriptors(d *proxy.Descriptors) Option {
+	return func(m *Model) {
+		m.descriptors = d
+	}
+}
+
+// eventBody returns ev's request body, or its response body when response
+// is set.
+func eventBody(ev *tapv1.GRPCEvent, response bool) []byte {
+	if response {
+		return ev.GetResponseBody()
+	}
+	return ev.GetRequestBody()
+}
+
+// renderEventBody renders ev's request (response false) or response body
+// like renderBody, but with field names and enum value names when the
+// descriptor set describes ev's method. The hex view, methods the set does
+// not describe and bodies that do not fit the schema render without it.
+func (m Model) renderEventBody(ev *tapv1.GRPCEvent, response bool) ([]string, bool) {
+	return m.renderMessage(ev.GetMethod(), response, eventBody(ev, response))
+}
+
+// renderMessage renders body, a request (response false) or response message
+// of method, like renderEventBody.
+func (m Model) renderMessage(method string, response bool, body []byte) ([]string, bool) {
+	if m.bodyView != bodyViewHex && len(body) > 0 {
+		if msg, err := m.descriptors.Decode(method, response, body); err == nil {
+			if lines := formatMessage(msg, ""); len(lines) > 0 {
+				return lines, true
+			}
+		}
+	}
+	return renderBody(body, m.bodyView)
+}
+
+// formatMessage renders the set fields of msg like decodeProtoWire, but by
+// name and in declaration order, with enum values as "ACTIVE (1)". Fields
+// the schema does not know are rendered by number after the others.
+func formatMessage(msg protoreflect.Message, indent string) []string {
+	var lines []string
+	fields := msg.Descriptor().Fields()
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		if !msg.Has(fd) {
+			continue
+		}
+		name := string(fd.Name())
+		v := msg.Get(fd)
+		switch {
+		case fd.IsMap():
+			entries := v.Map()
+			keys := make([]protoreflect.MapKey, 0, entries.Len())
+			entries.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+				keys = append(keys, k)
+				return true
+			})
+			slices.SortFunc(keys, func(a, b protoreflect.MapKey) int { return strings.Compare(a.String(), b.String()) })
+			for _, k := range keys {
+				lines = append(lines, indent+name+": {",
+					indent+"  key: "+formatScalar(fd.MapKey(), k.Value()))
+				lines = append(lines, formatField(fd.MapValue(), "value", entries.Get(k), indent+"  ")...)
+				lines = append(lines, indent+"}")
+			}
+		case fd.IsList():
+			list := v.List()
+			for j := range list.Len() {
+				lines = append(lines, formatField(fd, name, list.Get(j), indent)...)
+			}
+		default:
+			lines = append(lines, formatField(fd, name, v, indent)...)
+		}
+	}
+	if unknown := decodeProtoWire(msg.GetUnknown(), indent); unknown != nil {
+		lines = append(lines, unknown...)
+	}
+	return lines
+}
+
+// formatField renders one value of field fd as name: value, or a nested
+// message as name: { … }.
+func formatField(fd protoreflect.FieldDescriptor, name string, v protoreflect.Value, indent string) []string {
+	if fd.Message() == nil {
+		return []string{indent + name + ": " + formatScalar(fd, v)}
+	}
+	lines := []string{indent + name + ": {"}
+	lines = append(lines, formatMessage(v.Message(), indent+"  ")...)
+	return append(lines, indent+"}")
+}
+
+// formatScalar renders a value of the non-message field fd. Strings are
+// quoted and bytes too when printable, else hex, as in decodeProtoWire.
+func formatScalar(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return strconv.Quote(v.String())
+	case protoreflect.BytesKind:
+		if b := v.Bytes(); utf8.Valid(b) && isPrintable(b) {
+			return strconv.Quote(string(b))
+		}
+		return hex.EncodeToString(v.Bytes())
+	case protoreflect.EnumKind:
+		return proxy.FormatEnum(fd.Enum(), v.Enum())
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
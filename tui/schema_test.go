@@ -0,0 +1,148 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+// tapDescriptors describes the methods of tap.proto, whose messages the
+// tests below send as bodies.
+func tapDescriptors(t *testing.T) *proxy.Descriptors {
+	t.Helper()
+
+	d, err := proxy.NewDescriptors(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(timestamppb.File_google_protobuf_timestamp_proto),
+		protodesc.ToFileDescriptorProto(durationpb.File_google_protobuf_duration_proto),
+		protodesc.ToFileDescriptorProto(tapv1.File_tap_v1_tap_proto),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestInspector_Descriptors(t *testing.T) {
+	t.Parallel()
+
+	body, err := proto.Marshal(&tapv1.ReplayRequest{Method: "/pkg.Svc/Get", Protocol: tapv1.Protocol_PROTOCOL_CONNECT})
+	if err != nil {
+		t.Fatal(err)
+	}
+	known := testEvent("1", "/tap.v1.TapService/Replay", 0, time.Millisecond)
+	known.RequestBody = body
+	unknown := testEvent("2", "/pkg.Svc/Get", 0, time.Millisecond)
+	unknown.RequestBody = body
+
+	m := newTestModel(known, unknown)
+	WithDescriptors(tapDescriptors(t))(&m)
+	m = press(m, "enter")
+
+	contains := func(m Model, ev *tapv1.GRPCEvent, s string) bool {
+		return slices.ContainsFunc(m.inspectLines(ev), func(line string) bool { return strings.Contains(ansi.Strip(line), s) })
+	}
+	if !contains(m, known, `protocol: PROTOCOL_CONNECT (3)`) || !contains(m, known, `method: "/pkg.Svc/Get"`) {
+		t.Errorf("known method not decoded with its schema:\n%s", strings.Join(m.inspectLines(known), "\n"))
+	}
+	if got := m.clipboardText(known, false); !strings.Contains(got, `"protocol": "PROTOCOL_CONNECT"`) {
+		t.Errorf("copied %q, want JSON with field names", got)
+	}
+	if !contains(m, unknown, `1: "/pkg.Svc/Get"`) {
+		t.Error("unknown method not decoded without a schema")
+	}
+
+	m = press(m, "x")
+	if contains(m, known, `protocol`) {
+		t.Error("hex view decoded the body")
+	}
+}
+
+func TestFormatMessage(t *testing.T) {
+	t.Parallel()
+
+	body, err := proto.Marshal(&tapv1.ReplayResponse{Event: &tapv1.GRPCEvent{
+		Id:             "1",
+		CallType:       tapv1.CallType_CALL_TYPE_UNARY,
+		Status:         5,
+		RequestBody:    []byte{0x00, 0xff},
+		RequestHeaders: map[string]string{"b": "2", "a": "1"},
+		Phase:          tapv1.EventPhase(42),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := tapDescriptors(t).Decode("/tap.v1.TapService/Replay", true, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`event: {`,
+		`  id: "1"`,
+		`  call_type: CALL_TYPE_UNARY (1)`,
+		`  status: 5`,
+		`  request_body: 00ff`,
+		`  request_headers: {`,
+		`    key: "a"`,
+		`    value: "1"`,
+		`  }`,
+		`  request_headers: {`,
+		`    key: "b"`,
+		`    value: "2"`,
+		`  }`,
+		`  phase: 42`,
+		`}`,
+	}
+	if got := formatMessage(msg, ""); !slices.Equal(got, want) {
+		t.Errorf("formatMessage =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestTextFormat(t *testing.T) {
+	t.Parallel()
+
+	body, err := proto.Marshal(&tapv1.ReplayRequest{Method: "/pkg.Svc/Get", Protocol: tapv1.Protocol_PROTOCOL_CONNECT})
+	if err != nil {
+		t.Fatal(err)
+	}
+	known := testEvent("1", "/tap.v1.TapService/Replay", 0, time.Millisecond)
+	known.RequestBody = body
+	unknown := testEvent("2", "/pkg.Svc/Get", 0, time.Millisecond)
+	unknown.RequestBody = body
+	text := testEvent("3", "/pkg.Svc/Get", 0, time.Millisecond)
+	text.RequestBody = []byte{0xff, 0xff}
+
+	m := newTestModel(known, unknown, text)
+	WithDescriptors(tapDescriptors(t))(&m)
+
+	tests := []struct {
+		ev      *tapv1.GRPCEvent
+		want    string
+		wantErr bool
+	}{
+		{ev: known, want: "method: \"/pkg.Svc/Get\"\nprotocol: PROTOCOL_CONNECT"},
+		{ev: unknown, want: "1: \"/pkg.Svc/Get\"\n4: 3"},
+		{ev: text, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := m.textFormat(tt.ev, false)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("event %s: error = %v, wantErr %v", tt.ev.GetId(), err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("event %s: got\n%s\nwant\n%s", tt.ev.GetId(), got, tt.want)
+		}
+	}
+}
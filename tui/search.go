@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"github.com/mickamy/grpc-tap/filter"
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+// WithSearch starts with query as the search, as if typed with the search
+// key, so the list opens already filtered.
+func WithSearch(query string) Option {
+	return func(m *Model) {
+		m.searchQuery = query
+	}
+}
+
+// searchMatcher compiles the search query, a filter expression as described
+// in package filter, into a predicate over events. A query that does not
+// parse matches no events; searchError tells why.
+func searchMatcher(query string) func(ev *tapv1.GRPCEvent) bool {
+	f, err := filter.Parse(query)
+	switch {
+	case err != nil:
+		return func(*tapv1.GRPCEvent) bool { return false }
+	case f.Empty():
+		return func(*tapv1.GRPCEvent) bool { return true }
+	}
+	return func(ev *tapv1.GRPCEvent) bool {
+		return f.Evaluate(filter.FromProto(ev))
+	}
+}
+
+// matchesSearch reports whether ev matches the search query.
+func matchesSearch(ev *tapv1.GRPCEvent, query string) bool {
+	return searchMatcher(query)(ev)
+}
+
+// searchError returns why query does not parse, or "" if it does.
+func searchError(query string) string {
+	if _, err := filter.Parse(query); err != nil {
+		return err.Error()
+	}
+	return ""
+}
@@ -0,0 +1,185 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+func TestMatchesSearch(t *testing.T) {
+	t.Parallel()
+
+	ev := testEvent("1", "/pkg.UserService/GetUser", 0, time.Millisecond)
+	ev.PeerAddr = "10.0.0.7:51234"
+	ev.Authority = "users.internal"
+	ev.CorrelationId = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "", want: true},
+		{query: "getuser", want: true},
+		{query: "ListUsers", want: false},
+		{query: "peer:10.0.0.7", want: true},
+		{query: "peer:10.0.0.8", want: false},
+		{query: "user peer:10.0.0.7", want: true},
+		{query: "list peer:10.0.0.7", want: false},
+		{query: "host:users", want: true},
+		{query: "host:Users.Internal", want: true},
+		{query: "host:orders", want: false},
+		{query: "getuser host:users peer:10.0.0.7", want: true},
+		{query: "trace:4bf92f35", want: true},
+		{query: "trace:4BF92F3577B34DA6A3CE929D0E0E4736", want: true},
+		{query: "trace:0af7651916cd43dd", want: false},
+		{query: "getuser trace:4bf9", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			t.Parallel()
+			if got := matchesSearch(ev, tt.query); got != tt.want {
+				t.Errorf("matchesSearch(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesSearch_Code(t *testing.T) {
+	t.Parallel()
+
+	ok := testEvent("1", "/pkg.UserService/GetUser", 0, time.Millisecond)
+	unavailable := testEvent("2", "/pkg.UserService/GetUser", 14, time.Millisecond)
+	deadline := testEvent("3", "/pkg.UserService/ListUsers", 4, time.Millisecond)
+	inflight := testEvent("4", "/pkg.UserService/GetUser", 0, 0)
+	inflight.Phase = tapv1.EventPhase_EVENT_PHASE_START
+
+	tests := []struct {
+		query string
+		want  []bool // ok, unavailable, deadline, inflight
+	}{
+		{query: "code:unavailable", want: []bool{false, true, false, false}},
+		{query: "code:Unavailable", want: []bool{false, true, false, false}},
+		{query: "code:14", want: []bool{false, true, false, false}},
+		{query: "code:deadlineexceeded", want: []bool{false, false, true, false}},
+		{query: "code:deadline_exceeded", want: []bool{false, false, true, false}},
+		{query: "code:ok", want: []bool{true, false, false, false}},
+		{query: "code:0", want: []bool{true, false, false, false}},
+		{query: "code:error", want: []bool{false, true, true, false}},
+		{query: "getuser code:error", want: []bool{false, true, false, false}},
+		{query: "code:notfound", want: []bool{false, false, false, false}},
+		{query: "code:unavail", want: []bool{false, false, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			t.Parallel()
+			for i, ev := range []*tapv1.GRPCEvent{ok, unavailable, deadline, inflight} {
+				if got := matchesSearch(ev, tt.query); got != tt.want[i] {
+					t.Errorf("matchesSearch(event %s, %q) = %v, want %v", ev.GetId(), tt.query, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFilteredExportEvents_Code(t *testing.T) {
+	t.Parallel()
+
+	events := []*tapv1.GRPCEvent{
+		testEvent("1", "/pkg.Svc/A", 0, time.Millisecond),
+		testEvent("2", "/pkg.Svc/A", 14, time.Millisecond),
+		testEvent("3", "/pkg.Svc/B", 13, time.Millisecond),
+	}
+	got := filteredExportEvents(events, exportFilter{search: "code:internal"})
+	if len(got) != 1 || got[0].GetId() != "3" {
+		t.Errorf("code:internal exported %d events, want only event 3", len(got))
+	}
+
+	m := newTestModel(events...)
+	m.searchQuery = "code:14"
+	m.displayRows = m.rebuildDisplayRows()
+	if len(m.displayRows) != 1 || m.events[m.displayRows[0]].GetId() != "2" {
+		t.Errorf("code:14 shows rows %v, want only event 2", m.displayRows)
+	}
+}
+
+func TestWithSearch_Invalid(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel(testEvent("1", "/pkg.Svc/A", 0, time.Millisecond))
+	WithSearch("nope:x")(&m)
+	m.displayRows = m.rebuildDisplayRows()
+	if len(m.displayRows) != 0 {
+		t.Errorf("invalid search shows rows %v, want none", m.displayRows)
+	}
+	m.searchMode = true
+	if view := m.renderListView(); !strings.Contains(view, "unknown key") {
+		t.Errorf("search footer does not show the error:\n%s", view)
+	}
+}
+
+func TestRebuildDisplayRows_Duration(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel(
+		testEvent("1", "/pkg.Svc/GetUser", 0, 50*time.Millisecond),
+		testEvent("2", "/pkg.Svc/GetUser", 0, 100*time.Millisecond),
+		testEvent("3", "/pkg.Svc/GetUser", 0, 250*time.Millisecond),
+		testEvent("4", "/pkg.Svc/ListUsers", 0, 300*time.Millisecond),
+	)
+	for query, want := range map[string][]string{
+		"dur>100ms":         {"3", "4"},
+		"dur>=100ms":        {"2", "3", "4"},
+		"dur<100ms":         {"1"},
+		"GetUser dur>100ms": {"3"},
+	} {
+		m.searchQuery = query
+		m.displayRows = m.rebuildDisplayRows()
+		var got []string
+		for _, i := range m.displayRows {
+			got = append(got, m.events[i].GetId())
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%q shows events %v, want %v", query, got, want)
+		}
+	}
+}
+
+func TestRebuildDisplayRows_CallType(t *testing.T) {
+	t.Parallel()
+
+	var events []*tapv1.GRPCEvent
+	for i, ct := range []tapv1.CallType{
+		tapv1.CallType_CALL_TYPE_UNARY,
+		tapv1.CallType_CALL_TYPE_SERVER_STREAM,
+		tapv1.CallType_CALL_TYPE_CLIENT_STREAM,
+		tapv1.CallType_CALL_TYPE_BIDI_STREAM,
+		tapv1.CallType_CALL_TYPE_UNSPECIFIED,
+	} {
+		ev := testEvent(strconv.Itoa(i+1), "/pkg.Svc/Call", 0, time.Millisecond)
+		ev.CallType = ct
+		events = append(events, ev)
+	}
+	m := newTestModel(events...)
+	for query, want := range map[string][]string{
+		"type:unary":         {"1"},
+		"type:server_stream": {"2"},
+		"type:ServerStream":  {"2"},
+		"type:client_stream": {"3"},
+		"type:bidi_stream":   {"4"},
+		"type:stream":        {"2", "3", "4"},
+		"-type:stream":       {"1", "5"},
+	} {
+		m.searchQuery = query
+		m.displayRows = m.rebuildDisplayRows()
+		var got []string
+		for _, i := range m.displayRows {
+			got = append(got, m.events[i].GetId())
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%q shows events %v, want %v", query, got, want)
+		}
+	}
+}
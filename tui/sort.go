@@ -0,0 +1,155 @@
+package tui
+
+import (
+	"cmp"
+	"strings"
+)
+
+// sortKey is the column the list is sorted by.
+type sortKey int
+
+const (
+	sortByTime sortKey = iota // event order
+	sortByDuration
+	sortByMethod
+	sortByStatus
+	sortByProtocol
+)
+
+// sortKeys are the columns s cycles through, in order, with the direction
+// each starts in.
+var sortKeys = []struct {
+	key  sortKey
+	name string
+	desc bool
+}{
+	{sortByTime, "time", false},
+	{sortByDuration, "duration", true}, // slowest first
+	{sortByMethod, "method", false},
+	{sortByStatus, "status", false},
+	{sortByProtocol, "protocol", false},
+}
+
+// sortMode is the order of the list: by a column, ascending or descending.
+// The zero value is chronological.
+type sortMode struct {
+	key  sortKey
+	desc bool
+}
+
+var (
+	sortChronological = sortMode{key: sortByTime}
+	sortDuration      = sortMode{key: sortByDuration, desc: true}
+)
+
+// next returns the sort by the column after s's, in that column's starting
+// direction.
+func (s sortMode) next() sortMode {
+	i := (s.keyIndex() + 1) % len(sortKeys)
+	return sortMode{key: sortKeys[i].key, desc: sortKeys[i].desc}
+}
+
+func (s sortMode) keyIndex() int {
+	for i, k := range sortKeys {
+		if k.key == s.key {
+			return i
+		}
+	}
+	return 0
+}
+
+// String names s as saved views store it: the column, followed by " asc" or
+// " desc" when s runs the other way than the column starts, e.g. "duration"
+// or "method desc". Chronological order is "".
+func (s sortMode) String() string {
+	if s == sortChronological {
+		return ""
+	}
+	k := sortKeys[s.keyIndex()]
+	switch {
+	case s.desc == k.desc:
+		return k.name
+	case s.desc:
+		return k.name + " desc"
+	}
+	return k.name + " asc"
+}
+
+// label describes s for the title and footer, e.g. "duration ↓".
+func (s sortMode) label() string {
+	arrow := "↑"
+	if s.desc {
+		arrow = "↓"
+	}
+	return sortKeys[s.keyIndex()].name + " " + arrow
+}
+
+// parseSortMode reads a sort written by String. Anything else is
+// chronological.
+func parseSortMode(str string) sortMode {
+	name, dir, _ := strings.Cut(str, " ")
+	for _, k := range sortKeys {
+		if k.name != name {
+			continue
+		}
+		s := sortMode{key: k.key, desc: k.desc}
+		switch dir {
+		case "asc":
+			s.desc = false
+		case "desc":
+			s.desc = true
+		}
+		return s
+	}
+	return sortChronological
+}
+
+// compareEvents orders m.events[a] and m.events[b] by the sort's column, in
+// its direction. Ties are broken by event order, so equal rows keep their
+// places however often the list is rebuilt.
+func (m Model) compareEvents(a, b int) int {
+	ea, eb := m.events[a], m.events[b]
+	var c int
+	switch m.sortMode.key {
+	case sortByTime:
+		c = cmp.Compare(a, b)
+	case sortByDuration:
+		c = cmp.Compare(ea.GetDuration().AsDuration(), eb.GetDuration().AsDuration())
+	case sortByMethod:
+		c = strings.Compare(ea.GetMethod(), eb.GetMethod())
+	case sortByStatus:
+		c = cmp.Compare(ea.GetStatus(), eb.GetStatus())
+	case sortByProtocol:
+		c = strings.Compare(protocolLabel(ea), protocolLabel(eb))
+	}
+	if m.sortMode.desc {
+		c = -c
+	}
+	if c == 0 {
+		return cmp.Compare(a, b)
+	}
+	return c
+}
+
+// cycleSort sorts the list by the next column. Following new events only
+// makes sense in chronological order, so other sorts stop it.
+func (m Model) cycleSort() Model {
+	return m.setSort(m.sortMode.next())
+}
+
+// reverseSort flips the direction of the sort.
+func (m Model) reverseSort() Model {
+	s := m.sortMode
+	s.desc = !s.desc
+	return m.setSort(s)
+}
+
+func (m Model) setSort(s sortMode) Model {
+	m.sortMode = s
+	if s != sortChronological {
+		m.follow = false
+	}
+	m.displayRows = m.rebuildDisplayRows()
+	m.cursor = 0
+	return m
+}
@@ -0,0 +1,131 @@
+package tui
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+func TestSortModes(t *testing.T) {
+	t.Parallel()
+
+	event := func(id, method string, status int32, dur time.Duration, protocol tapv1.Protocol) *tapv1.GRPCEvent {
+		ev := testEvent(id, method, status, dur)
+		ev.Protocol = protocol
+		return ev
+	}
+	m := newTestModel(
+		event("1", "/pkg.Svc/B", 5, 20*time.Millisecond, tapv1.Protocol_PROTOCOL_GRPC),
+		event("2", "/pkg.Svc/C", 0, 30*time.Millisecond, tapv1.Protocol_PROTOCOL_CONNECT),
+		event("3", "/pkg.Svc/A", 14, 10*time.Millisecond, tapv1.Protocol_PROTOCOL_GRPC_WEB),
+	)
+
+	tests := []struct {
+		sort sortMode
+		want string
+	}{
+		{sort: sortMode{key: sortByTime}, want: "1,2,3"},
+		{sort: sortMode{key: sortByTime, desc: true}, want: "3,2,1"},
+		{sort: sortMode{key: sortByDuration}, want: "3,1,2"},
+		{sort: sortMode{key: sortByDuration, desc: true}, want: "2,1,3"},
+		{sort: sortMode{key: sortByMethod}, want: "3,1,2"},
+		{sort: sortMode{key: sortByMethod, desc: true}, want: "2,1,3"},
+		{sort: sortMode{key: sortByStatus}, want: "2,1,3"},
+		{sort: sortMode{key: sortByStatus, desc: true}, want: "3,1,2"},
+		{sort: sortMode{key: sortByProtocol}, want: "2,1,3"}, // Connect, gRPC, gRPC-Web
+		{sort: sortMode{key: sortByProtocol, desc: true}, want: "3,1,2"},
+	}
+	for _, tt := range tests {
+		m.sortMode = tt.sort
+		m.displayRows = m.rebuildDisplayRows()
+		var got []string
+		for _, i := range m.displayRows {
+			got = append(got, m.events[i].GetId())
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("sort %s: rows %v, want %s", tt.sort.label(), got, tt.want)
+		}
+		if parsed := parseSortMode(tt.sort.String()); parsed != tt.sort {
+			t.Errorf("sort %s: saved as %q, read back as %s", tt.sort.label(), tt.sort.String(), parsed.label())
+		}
+	}
+}
+
+func TestSortKeys(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel(
+		testEvent("1", "/pkg.Svc/B", 0, 20*time.Millisecond),
+		testEvent("2", "/pkg.Svc/A", 0, 10*time.Millisecond),
+	)
+	m = press(m, "G")
+	if !m.follow {
+		t.Fatal("G did not follow in chronological order")
+	}
+
+	var labels []string
+	for range sortKeys {
+		m = press(m, "s")
+		labels = append(labels, m.sortMode.label())
+		if m.sortMode != sortChronological && m.follow {
+			t.Errorf("sort %s: still following", m.sortMode.label())
+		}
+	}
+	if got, want := strings.Join(labels, ", "), "duration ↓, method ↑, status ↑, protocol ↑, time ↑"; got != want {
+		t.Errorf("s cycles through %s, want %s", got, want)
+	}
+
+	m = press(m, "S")
+	if m.sortMode != (sortMode{key: sortByTime, desc: true}) || m.cursorEvent().GetId() != "2" {
+		t.Errorf("S: sort %s, first row %s; want time ↓ with the newest call first", m.sortMode.label(), m.cursorEvent().GetId())
+	}
+	if title := m.renderListView(); !strings.Contains(title, "[sort: time ↓]") {
+		t.Error("title does not show the active sort")
+	}
+	m = press(m, "G")
+	if m.follow {
+		t.Error("G followed in reverse chronological order")
+	}
+	m = press(m, "S")
+	if m.sortMode != sortChronological || strings.Contains(m.renderListView(), "[sort:") {
+		t.Errorf("S S: sort %s, want chronological without a title tag", m.sortMode.label())
+	}
+}
+
+func TestSortModes_TiesKeepEventOrder(t *testing.T) {
+	t.Parallel()
+
+	// Three durations, interleaved, among enough events that the sort does
+	// not fall back to insertion sort, which happens to be stable.
+	var events []*tapv1.GRPCEvent
+	byDuration := make([][]string, 3)
+	for i := range 60 {
+		id := strconv.Itoa(i)
+		d := (i * 7) % 3
+		events = append(events, testEvent(id, "/pkg.Svc/Get", 0, time.Duration(d)*time.Millisecond))
+		byDuration[2-d] = append(byDuration[2-d], id)
+	}
+	want := strings.Join(slices.Concat(byDuration...), ",")
+
+	m := newTestModel(events...)
+	m = m.setSort(sortDuration)
+	for range 5 {
+		if got := rowIDs(m); got != want {
+			t.Fatalf("rows = %s, want %s", got, want)
+		}
+		m.displayRows = m.rebuildDisplayRows()
+	}
+}
+
+// rowIDs returns the IDs of the list's rows, comma-separated.
+func rowIDs(m Model) string {
+	ids := make([]string, len(m.displayRows))
+	for i, idx := range m.displayRows {
+		ids[i] = m.events[idx].GetId()
+	}
+	return strings.Join(ids, ",")
+}
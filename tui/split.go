@@ -0,0 +1,187 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+// splitMinWidth is the narrowest terminal the inspector splits into request
+// and response panes in; below it, the split layout falls back to stacked.
+const splitMinWidth = 100
+
+// Inspector panes of the split layout.
+const (
+	paneRequest = iota
+	paneResponse
+)
+
+// splitActive reports whether the inspector shows the split layout.
+func (m Model) splitActive() bool {
+	return m.splitView && m.width >= splitMinWidth
+}
+
+// inspectPanes returns the lines of the request and response panes: each
+// side's headers and body.
+func (m Model) inspectPanes(ev *tapv1.GRPCEvent) [2][]string {
+	var req, resp []string
+	if len(ev.GetRequestHeaders()) > 0 {
+		req = appendSection(req, "Headers", formatHeaders(ev.GetRequestHeaders()))
+	}
+	if len(ev.GetResponseHeaders()) > 0 {
+		resp = appendSection(resp, "Headers", formatHeaders(ev.GetResponseHeaders()))
+	}
+	if len(ev.GetResponseTrailers()) > 0 {
+		resp = appendSection(resp, "Trailers", formatHeaders(ev.GetResponseTrailers()))
+	}
+	switch {
+	case ev.GetBodyCaptureDisabled():
+		req = appendSection(req, "Body", []string{"(body capture disabled)"})
+		resp = appendSection(resp, "Body", []string{"(body capture disabled)"})
+	default:
+		if len(ev.GetRequestBody()) > 0 {
+			req = appendSection(req, "Body", m.bodySection(ev, false, m.expandRequest))
+		}
+		if len(ev.GetResponseBody()) > 0 {
+			resp = appendSection(resp, "Body", m.bodySection(ev, true, m.expandResponse))
+		}
+	}
+	return [2][]string{req, resp}
+}
+
+// splitLayout returns how many summary lines the split layout shows above
+// the panes, and how many rows each pane shows. The summary takes at most a
+// third of the screen.
+func (m Model) splitLayout(summaryLines int) (summaryRows, paneRows int) {
+	summaryRows = min(summaryLines, max(m.inspectVisibleRows()/3, 3))
+	// Two borders around the summary and two around the panes.
+	paneRows = max(m.height-summaryRows-4, 3)
+	return summaryRows, paneRows
+}
+
+// splitWidths returns the inner widths of the request and response panes,
+// which together span the stacked layout's width.
+func (m Model) splitWidths() (left, right int) {
+	total := m.innerWidth() + 2 // the stacked box, borders included
+	left = total/2 - 2
+	right = total - total/2 - 2
+	return left, right
+}
+
+// paneMaxScroll returns the largest scroll offset of pane that still fills
+// it.
+func (m Model) paneMaxScroll(pane int) int {
+	ev := m.cursorEvent()
+	if ev == nil {
+		return 0
+	}
+	_, paneRows := m.splitLayout(len(m.inspectSummary(ev)))
+	return max(len(m.inspectPanes(ev)[pane])-paneRows, 0)
+}
+
+// scrollPane moves the focused pane's scroll offset to the one to returns
+// for the current offset, page size and largest offset, clamped.
+func (m Model) scrollPane(to func(scroll, page, maxScroll int) int) Model {
+	ev := m.cursorEvent()
+	if ev == nil {
+		return m
+	}
+	_, paneRows := m.splitLayout(len(m.inspectSummary(ev)))
+	maxScroll := m.paneMaxScroll(m.splitFocus)
+	page := max(paneRows/2, 1)
+	m.splitScroll[m.splitFocus] = min(max(to(m.splitScroll[m.splitFocus], page, maxScroll), 0), maxScroll)
+	return m
+}
+
+// renderSplitInspector renders the inspector with the call summary on top
+// and the request and response side by side below it, each scrolled on its
+// own. The focused pane has a highlighted border and a marked title.
+func (m Model) renderSplitInspector(ev *tapv1.GRPCEvent, help string) string {
+	innerWidth := m.innerWidth()
+	borderColor := lipgloss.Color("240")
+	terms := m.highlightTerms()
+
+	summary := highlightMatches(m.inspectSummary(ev), terms, -1)
+	summaryRows, paneRows := m.splitLayout(len(summary))
+	top := boxWith(cutLines(summary[:summaryRows], 0, innerWidth), innerWidth, borderColor,
+		m.inspectTitle(), help)
+
+	leftWidth, rightWidth := m.splitWidths()
+	panes := m.inspectPanes(ev)
+	boxes := make([]string, 2)
+	for pane, width := range []int{leftWidth, rightWidth} {
+		lines := highlightMatches(panes[pane], terms, -1)
+		scroll := min(m.splitScroll[pane], max(len(lines)-paneRows, 0))
+		lines = lines[scroll:min(scroll+paneRows, len(lines))]
+		title := []string{" Request ", " Response "}[pane]
+		color := borderColor
+		if pane == m.splitFocus {
+			title = " ▶" + title
+			color = lipgloss.Color("69")
+		}
+		if more := len(panes[pane]) - scroll - paneRows; more > 0 {
+			title += fmt.Sprintf("(+%d) ", more)
+		}
+		// Pad to paneRows so both panes are equally tall.
+		lines = append(lines, make([]string, paneRows-len(lines))...)
+		boxes[pane] = boxWith(cutLines(lines, m.inspectHScroll, width), width, color, title, "")
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, top, lipgloss.JoinHorizontal(lipgloss.Top, boxes...))
+}
+
+// cutLines cuts each line to the display columns from..from+width.
+func cutLines(lines []string, from, width int) string {
+	cut := make([]string, len(lines))
+	for i, line := range lines {
+		cut[i] = ansi.Cut(line, from, from+width)
+	}
+	return strings.Join(cut, "\n")
+}
+
+// boxWith renders content in a rounded box of innerWidth with title in its
+// top border and, unless empty, help in its bottom border. Content lines,
+// the title and help are cut to innerWidth, so the box never wraps or grows
+// wider on a narrow terminal.
+func boxWith(content string, innerWidth int, color lipgloss.Color, title, help string) string {
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Width(innerWidth).
+		BorderForeground(color).
+		Render(cutLines(strings.Split(content, "\n"), 0, innerWidth))
+	lines := strings.Split(box, "\n")
+	borderFg := lipgloss.NewStyle().Foreground(color)
+	title = ansi.Truncate(title, innerWidth, "")
+	lines[0] = borderFg.Render("╭") +
+		lipgloss.NewStyle().Bold(true).Render(title) +
+		borderFg.Render(strings.Repeat("─", max(innerWidth-ansi.StringWidth(title), 0))+"╮")
+	if help != "" {
+		help = ansi.Truncate(help, innerWidth, "")
+		lines[len(lines)-1] = borderFg.Render("╰") +
+			lipgloss.NewStyle().Faint(true).Render(help) +
+			borderFg.Render(strings.Repeat("─", max(innerWidth-ansi.StringWidth(help), 0))+"╯")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// paneScrollFunc returns how the scroll key msg moves a pane.
+func paneScrollFunc(k KeyMap, msg tea.KeyMsg) func(scroll, page, maxScroll int) int {
+	switch {
+	case k.scrollDown.matches(msg):
+		return func(scroll, _, _ int) int { return scroll + 1 }
+	case k.scrollUp.matches(msg):
+		return func(scroll, _, _ int) int { return scroll - 1 }
+	case k.halfPageDown.matches(msg):
+		return func(scroll, page, _ int) int { return scroll + page }
+	case k.halfPageUp.matches(msg):
+		return func(scroll, page, _ int) int { return scroll - page }
+	case k.top.matches(msg):
+		return func(int, int, int) int { return 0 }
+	default:
+		return func(_, _, maxScroll int) int { return maxScroll }
+	}
+}
@@ -0,0 +1,96 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func splitTestEvent() Model {
+	ev := testEvent("1", "/pkg.Svc/Get", 0, time.Millisecond)
+	ev.RequestHeaders = map[string]string{"x-request": "left"}
+	ev.ResponseHeaders = map[string]string{"x-response": "right"}
+	ev.RequestBody = []byte(`{"query":"alice"}`)
+	var resp strings.Builder
+	resp.WriteString(`{"items":[`)
+	for i := range 30 {
+		if i > 0 {
+			resp.WriteString(",\n")
+		}
+		resp.WriteString(`"item"`)
+	}
+	resp.WriteString(`]}`)
+	ev.ResponseBody = []byte(resp.String())
+	m := newTestModel(ev)
+	m.width = 120
+	m.height = 30
+	return press(m, "enter")
+}
+
+func TestSplitInspector_Layout(t *testing.T) {
+	t.Parallel()
+
+	m := press(splitTestEvent(), "v")
+	if !m.splitActive() {
+		t.Fatal("v did not switch to the split layout")
+	}
+	view := m.renderInspector()
+	lines := strings.Split(view, "\n")
+	if len(lines) != m.height {
+		t.Errorf("split view is %d lines tall, want %d", len(lines), m.height)
+	}
+	for i, line := range lines {
+		if w := lipgloss.Width(line); w != m.width-2 {
+			t.Errorf("line %d is %d wide, want %d: %q", i, w, m.width-2, line)
+		}
+	}
+	for _, line := range lines {
+		if strings.Contains(line, "x-request") && !strings.Contains(line, "x-response") {
+			t.Errorf("request and response headers are not side by side: %q", line)
+		}
+	}
+	if !strings.Contains(view, "▶ Request") {
+		t.Error("the request pane is not marked as focused")
+	}
+
+	m.width = splitMinWidth - 1
+	if m.splitActive() || strings.Contains(m.renderInspector(), "▶ Request") {
+		t.Error("a narrow terminal does not fall back to the stacked layout")
+	}
+}
+
+func TestSplitInspector_Scroll(t *testing.T) {
+	t.Parallel()
+
+	m := press(splitTestEvent(), "v")
+	m = press(m, "j")
+	if m.splitScroll != [2]int{0, 0} || m.inspectScroll != 0 {
+		t.Errorf("j scrolled a pane that has nothing below: %v", m.splitScroll)
+	}
+
+	m = press(m, "tab")
+	if m.splitFocus != paneResponse || !strings.Contains(m.renderInspector(), "▶ Response") {
+		t.Fatalf("tab did not focus the response pane")
+	}
+	m = press(m, "j")
+	m = press(m, "j")
+	if m.splitScroll != [2]int{0, 2} {
+		t.Errorf("scroll = %v, want only the response pane scrolled by 2", m.splitScroll)
+	}
+	m = press(m, "end")
+	if m.splitScroll[paneResponse] != m.paneMaxScroll(paneResponse) || m.paneMaxScroll(paneResponse) == 0 {
+		t.Errorf("G scrolled to %d, want %d", m.splitScroll[paneResponse], m.paneMaxScroll(paneResponse))
+	}
+	m = press(m, "home")
+	if m.splitScroll[paneResponse] != 0 {
+		t.Errorf("g scrolled to %d, want 0", m.splitScroll[paneResponse])
+	}
+
+	m = press(m, "v")
+	m = press(m, "j")
+	if m.inspectScroll != 1 {
+		t.Errorf("stacked layout: j scrolled to %d, want 1", m.inspectScroll)
+	}
+}
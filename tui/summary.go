@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// summarySlowest is how many of the slowest methods the exit summary lists.
+const summarySlowest = 3
+
+// WithSummaryOnExit makes the Model write a summary of the session on quit,
+// which ExitSummary returns for printing once the terminal is restored.
+func WithSummaryOnExit(enabled bool) Option {
+	return func(m *Model) {
+		m.summaryOnExit = enabled
+	}
+}
+
+// ExitSummary returns the summary of the session written on quit: its
+// length, the calls and errors captured and the slowest methods. It is empty
+// unless WithSummaryOnExit is set and the Model has quit.
+func (m Model) ExitSummary() string {
+	return m.exitSummary
+}
+
+// quit closes the connection to the daemon, writes the exit summary when
+// asked to, and quits.
+func (m Model) quit() (Model, tea.Cmd) {
+	if m.conn != nil {
+		_ = m.conn.Close()
+	}
+	if m.summaryOnExit {
+		m.exitSummary = m.summary(time.Now())
+	}
+	return m, tea.Quit
+}
+
+// summary summarizes the calls received up to now, aggregated as the
+// analytics view does: hidden internal methods are left out, as are calls
+// still in flight. Calls evicted or cleared since still count.
+func (m Model) summary(now time.Time) string {
+	type method struct {
+		name  string
+		count int
+		avg   time.Duration
+	}
+	var calls, errors int
+	var methods []method
+	for name, g := range m.sessionTotals {
+		if m.hiddenMethod(name) {
+			continue
+		}
+		calls += g.count
+		errors += g.errors
+		methods = append(methods, method{name: name, count: g.count, avg: g.totalDur / time.Duration(g.count)})
+	}
+	slices.SortFunc(methods, func(a, b method) int {
+		return cmp.Or(cmp.Compare(b.avg, a.avg), strings.Compare(a.name, b.name))
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "grpc-tap session: %s, %d calls, %d errors", now.Sub(m.sessionStart).Round(time.Second), calls, errors)
+	if calls > 0 {
+		fmt.Fprintf(&b, " (%.1f%%)", float64(errors)/float64(calls)*100)
+	}
+	b.WriteString("\n")
+	if len(methods) == 0 {
+		return b.String()
+	}
+	b.WriteString("Slowest methods (avg):\n")
+	for _, s := range methods[:min(len(methods), summarySlowest)] {
+		fmt.Fprintf(&b, "  %10s  %s (%d calls)\n", m.durationUnit.format(s.avg), s.name, s.count)
+	}
+	return b.String()
+}
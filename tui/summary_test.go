@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+func TestSummary(t *testing.T) {
+	t.Parallel()
+
+	inFlight := testEvent("8", "/pkg.Svc/Stream", 0, time.Hour)
+	inFlight.Phase = tapv1.EventPhase_EVENT_PHASE_START
+	m := newTestModel(
+		testEvent("1", "/pkg.Svc/Get", 0, 10*time.Millisecond),
+		testEvent("2", "/pkg.Svc/Get", 14, 30*time.Millisecond),
+		testEvent("3", "/pkg.Svc/Slow", 0, 2*time.Second),
+		testEvent("4", "/pkg.Svc/List", 0, 100*time.Millisecond),
+		testEvent("5", "/pkg.Svc/Fast", 0, time.Millisecond),
+		testEvent("6", "/pkg.Svc/Fast", 0, time.Millisecond),
+		testEvent("7", "/grpc.health.v1.Health/Check", 0, 5*time.Second), // hidden
+		inFlight,
+	)
+	m.sessionStart = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	want := "grpc-tap session: 1m30s, 6 calls, 1 errors (16.7%)\n" +
+		"Slowest methods (avg):\n" +
+		"       2.00s  /pkg.Svc/Slow (1 calls)\n" +
+		"     100.0ms  /pkg.Svc/List (1 calls)\n" +
+		"      20.0ms  /pkg.Svc/Get (2 calls)\n"
+	if got := m.summary(m.sessionStart.Add(90 * time.Second)); got != want {
+		t.Errorf("summary =\n%s\nwant\n%s", got, want)
+	}
+
+	empty := newTestModel()
+	empty.sessionStart = m.sessionStart
+	if got, want := empty.summary(m.sessionStart.Add(time.Second)), "grpc-tap session: 1s, 0 calls, 0 errors\n"; got != want {
+		t.Errorf("summary of no calls = %q, want %q", got, want)
+	}
+}
+
+func TestExitSummary(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel(testEvent("1", "/pkg.Svc/Get", 0, time.Millisecond))
+	if quit := press(m, "q"); quit.ExitSummary() != "" {
+		t.Errorf("exit summary = %q without WithSummaryOnExit", quit.ExitSummary())
+	}
+	WithSummaryOnExit(true)(&m)
+	if quit := press(m, "q"); quit.ExitSummary() == "" {
+		t.Error("no exit summary on quit")
+	}
+}
+
+func TestSummary_EvictedAndCleared(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel()
+	WithMaxEvents(2)(&m)
+	for _, id := range []string{"1", "2", "3"} {
+		m, _ = m.upsertEvent(testEvent(id, "/pkg.Svc/Get", 0, 10*time.Millisecond))
+	}
+	m, _ = m.upsertEvent(testEvent("4", "/pkg.Svc/Get", 14, 10*time.Millisecond))
+	m = press(press(m, "ctrl+l"), "y")
+	if len(m.events) != 0 {
+		t.Fatalf("%d events after clearing, want 0", len(m.events))
+	}
+	m.sessionStart = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	want := "grpc-tap session: 1s, 4 calls, 1 errors (25.0%)\n" +
+		"Slowest methods (avg):\n" +
+		"      10.0ms  /pkg.Svc/Get (4 calls)\n"
+	if got := m.summary(m.sessionStart.Add(time.Second)); got != want {
+		t.Errorf("summary =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,51 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+// WriteTable writes events to w as a plain-text table of cols, with the cells
+// the list view shows but unstyled and untruncated: each column is as wide as
+// its widest cell. A nil cols selects DefaultColumns.
+func WriteTable(w io.Writer, events []*tapv1.GRPCEvent, cols []Column, unit DurationUnit) error {
+	if cols == nil {
+		cols = DefaultColumns
+	}
+	m := Model{durationUnit: unit}
+
+	rows := make([][]string, 0, len(events)+1)
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = columnDefs[c].header
+	}
+	rows = append(rows, header)
+	for _, ev := range events {
+		row := make([]string, len(cols))
+		for i, c := range cols {
+			row[i] = m.cellText(c, ev)
+		}
+		rows = append(rows, row)
+	}
+
+	widths := make([]int, len(cols))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], lipgloss.Width(cell))
+		}
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			row[i] = alignCell(columnDefs[cols[i]], cell, widths[i])
+		}
+		if _, err := fmt.Fprintln(w, strings.TrimRight(strings.Join(row, "  "), " ")); err != nil {
+			return fmt.Errorf("write table: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+func TestWriteTable(t *testing.T) {
+	t.Parallel()
+
+	get := testEvent("1", "/pkg.Svc/Get", 0, 1500*time.Microsecond)
+	get.Protocol = tapv1.Protocol_PROTOCOL_CONNECT
+	get.ContentType = "application/json"
+	get.CallType = tapv1.CallType_CALL_TYPE_UNARY
+	watch := testEvent("2", "/pkg.Svc/WatchEverything", 14, 2*time.Second)
+	watch.Protocol = tapv1.Protocol_PROTOCOL_GRPC
+	watch.CallType = tapv1.CallType_CALL_TYPE_SERVER_STREAM
+
+	var sb strings.Builder
+	if err := WriteTable(&sb, []*tapv1.GRPCEvent{get, watch},
+		[]Column{ColumnProto, ColumnMethod, ColumnType, ColumnStatus, ColumnDuration}, DurationMillis); err != nil {
+		t.Fatal(err)
+	}
+	want := "" +
+		"Proto         Method                    Type          Status       Duration\n" +
+		"Connect/json  /pkg.Svc/Get              Unary         OK              1.5ms\n" +
+		"gRPC          /pkg.Svc/WatchEverything  ServerStream  Unavailable  2000.0ms\n"
+	if got := sb.String(); got != want {
+		t.Errorf("table =\n%s\nwant\n%s", got, want)
+	}
+
+	sb.Reset()
+	if err := WriteTable(&sb, nil, nil, DurationAuto); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sb.String(), "Proto  Method  Type  Status  Duration  Time\n"; got != want {
+		t.Errorf("empty table = %q, want the default header %q", got, want)
+	}
+}
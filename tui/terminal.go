@@ -0,0 +1,72 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/term"
+)
+
+// The smallest terminal the TUI draws its views in. Below it, View shows
+// only a request to enlarge the terminal.
+const (
+	minViewWidth  = 30
+	minViewHeight = 8
+)
+
+// errNotTerminal explains why the TUI cannot run when input or output is
+// not a terminal, as when piped.
+var errNotTerminal = errors.New("grpc-tap needs an interactive terminal")
+
+// CheckTerminal reports an error when in or out is not a terminal, so that
+// grpc-tap exits with a hint instead of drawing escape codes into a pipe or
+// waiting for keys that never come.
+func CheckTerminal(in, out *os.File) error {
+	for _, f := range []*os.File{in, out} {
+		if !term.IsTerminal(f.Fd()) {
+			return fmt.Errorf("%w: %s is not a terminal; "+
+				"to read captured calls without the TUI, use grpc-tapd's web API (-http), "+
+				"e.g. curl -sN http://localhost:8080/api/events", errNotTerminal, f.Name())
+		}
+	}
+	return nil
+}
+
+// innerWidth returns the width inside a box spanning the window, less its
+// borders and two spare columns, and never below 1.
+func (m Model) innerWidth() int {
+	return max(m.width-4, 1)
+}
+
+// tooSmall reports whether the window is too small to draw the views in.
+func (m Model) tooSmall() bool {
+	return m.width < minViewWidth || m.height < minViewHeight
+}
+
+// renderTooSmall asks for a larger terminal, in as much of the window as
+// there is.
+func (m Model) renderTooSmall() string {
+	lines := []string{
+		"Terminal too small.",
+		fmt.Sprintf("Need %d×%d, have %d×%d.", minViewWidth, minViewHeight, m.width, m.height),
+		m.keys.quit.key() + ": quit",
+	}
+	lines = lines[:min(len(lines), max(m.height, 1))]
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, max(m.width, 1), "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// updateTooSmall handles keys while the window is too small to show what
+// they would do: only quitting works.
+func (m Model) updateTooSmall(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.keys.quit.matches(msg) || m.keys.forceQuit.matches(msg) {
+		return m.quit()
+	}
+	return m, nil
+}
@@ -0,0 +1,116 @@
+package tui
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+func TestCheckTerminal_Pipe(t *testing.T) {
+	t.Parallel()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = r.Close(); _ = w.Close() }()
+
+	err = CheckTerminal(r, w)
+	if !errors.Is(err, errNotTerminal) {
+		t.Fatalf("CheckTerminal(pipe) = %v, want errNotTerminal", err)
+	}
+	if !strings.Contains(err.Error(), "/api/events") {
+		t.Errorf("error does not point to the web API: %v", err)
+	}
+}
+
+func TestView_TooSmall(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel(testEvent("1", "/pkg.Svc/A", 0, time.Millisecond))
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 16, Height: 2})
+	m = updated.(Model) //nolint:forcetypeassert // Update always returns Model
+
+	view := m.View()
+	lines := strings.Split(view, "\n")
+	if len(lines) > m.height || !strings.Contains(view, "Terminal too") {
+		t.Errorf("too-small view:\n%s", view)
+	}
+	for _, line := range lines {
+		if w := lipgloss.Width(line); w > m.width {
+			t.Errorf("line %q is %d wide, want at most %d", line, w, m.width)
+		}
+	}
+
+	if m = press(m, "/"); m.searchMode {
+		t.Error("a key other than quit acted on the hidden view")
+	}
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}); cmd == nil {
+		t.Error("q does not quit while the terminal is too small")
+	}
+
+	updated, _ = m.Update(tea.WindowSizeMsg{Width: -1, Height: -1})
+	if m = updated.(Model); m.width != 0 || m.height != 0 { //nolint:forcetypeassert // Update always returns Model
+		t.Errorf("size = %d×%d, want 0×0", m.width, m.height)
+	}
+}
+
+func TestView_FitsWindow(t *testing.T) {
+	t.Parallel()
+
+	var events []*tapv1.GRPCEvent
+	for i := range 40 {
+		ev := testEvent(strconv.Itoa(i), "/very.long.package.name.Service/SomeRatherLongMethodName", int32(i%3), time.Millisecond)
+		ev.Error = "upstream said no, at considerable length, for reasons of its own"
+		events = append(events, ev)
+	}
+	views := map[string]func(Model) Model{
+		"list":      func(m Model) Model { return m },
+		"inspector": func(m Model) Model { return press(m, "enter") },
+		"split":     func(m Model) Model { m = press(m, "enter"); m.splitView = true; return m },
+		"analytics": func(m Model) Model { return press(m, "a") },
+		"by code":   func(m Model) Model { return press(press(m, "a"), "c") },
+		"help":      func(m Model) Model { return press(m, "?") },
+		"alert":     func(m Model) Model { m, _ = m.showAlert(strings.Repeat("copied ", 20)); return m },
+	}
+	for _, size := range [][2]int{{1, 1}, {20, 5}, {40, 10}, {80, 24}} {
+		for name, open := range views {
+			m := newTestModel(events...)
+			m.width, m.height = size[0], size[1]
+			view := open(m).View()
+			lines := strings.Split(view, "\n")
+			if len(lines) > m.height {
+				t.Errorf("%s at %d×%d: %d lines, want at most %d:\n%s", name, m.width, m.height, len(lines), m.height, view)
+			}
+			for _, line := range lines {
+				if w := lipgloss.Width(line); w > m.width {
+					t.Errorf("%s at %d×%d: line %q is %d wide", name, m.width, m.height, line, w)
+				}
+			}
+		}
+	}
+}
+
+func TestRender_TinyNoPanic(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel(testEvent("1", "/pkg.Svc/A", 2, time.Millisecond))
+	m = m.refreshAnalytics()
+	for _, size := range [][2]int{{1, 1}, {20, 5}, {0, 0}} {
+		m.width, m.height = size[0], size[1]
+		// The renderers must cope below the minimum size View enforces.
+		_ = m.renderListView()
+		_ = m.renderInspector()
+		_ = m.renderSplitInspector(m.cursorEvent(), m.inspectHelp())
+		_ = m.renderAnalytics()
+		_ = m.renderHelp()
+	}
+}
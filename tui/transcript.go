@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"fmt"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+// transcriptEntry is one message of a bidi stream's transcript.
+type transcriptEntry struct {
+	response bool
+	n        int // number of the message in its direction, from 1
+	frame    *tapv1.Frame
+}
+
+// transcript merges the request and response messages of ev into the order
+// they passed the proxy. A request and a response that passed at the same
+// time are listed request first.
+func transcript(ev *tapv1.GRPCEvent) []transcriptEntry {
+	reqs, resps := ev.GetRequestFrames(), ev.GetResponseFrames()
+	entries := make([]transcriptEntry, 0, len(reqs)+len(resps))
+	i, j := 0, 0
+	for i < len(reqs) || j < len(resps) {
+		if j == len(resps) || i < len(reqs) &&
+			reqs[i].GetOffset().AsDuration() <= resps[j].GetOffset().AsDuration() {
+			entries = append(entries, transcriptEntry{n: i + 1, frame: reqs[i]})
+			i++
+		} else {
+			entries = append(entries, transcriptEntry{response: true, n: j + 1, frame: resps[j]})
+			j++
+		}
+	}
+	return entries
+}
+
+// transcriptIndent indents the decoded messages of a transcript under the
+// direction of their header line.
+const transcriptIndent = "             "
+
+// transcriptLines renders the transcript of ev for the inspector: a line
+// per message with when it passed the proxy, after the call started, and
+// its direction, followed by the decoded message.
+func (m Model) transcriptLines(ev *tapv1.GRPCEvent) []string {
+	var lines []string
+	for _, e := range transcript(ev) {
+		arrow, dir := "→", "request"
+		if e.response {
+			arrow, dir = "←", "response"
+		}
+		lines = append(lines, fmt.Sprintf("%10s %s %s %d",
+			"+"+m.durationUnit.formatProto(e.frame.GetOffset()), arrow, dir, e.n))
+		body, _ := m.renderMessage(ev.GetMethod(), e.response, e.frame.GetBody())
+		for _, line := range body {
+			lines = append(lines, transcriptIndent+line)
+		}
+	}
+	return lines
+}
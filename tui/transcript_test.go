@@ -0,0 +1,60 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+)
+
+func frame(offset time.Duration, body string) *tapv1.Frame {
+	return &tapv1.Frame{Offset: durationpb.New(offset), Body: []byte(body)}
+}
+
+func TestTranscript(t *testing.T) {
+	t.Parallel()
+
+	ev := testEvent("1", "/chat.v1.Chat/Talk", 0, time.Second)
+	ev.CallType = tapv1.CallType_CALL_TYPE_BIDI_STREAM
+	ev.RequestFrames = []*tapv1.Frame{
+		frame(0, "hello"),
+		frame(20*time.Millisecond, "how are you"),
+		frame(30*time.Millisecond, "bye"),
+	}
+	ev.ResponseFrames = []*tapv1.Frame{
+		frame(5*time.Millisecond, "hi"),
+		frame(6*time.Millisecond, "who is this"),
+		frame(30*time.Millisecond, "fine"), // as the third request: listed after it
+	}
+
+	m := newTestModel(ev)
+	WithDurationUnit(DurationMillis)(&m)
+	var got []string
+	for _, line := range m.transcriptLines(ev) {
+		if strings.ContainsAny(line, "→←") {
+			got = append(got, strings.TrimSpace(line))
+		}
+	}
+	want := []string{
+		"+0.0ms → request 1",
+		"+5.0ms ← response 1",
+		"+6.0ms ← response 2",
+		"+20.0ms → request 2",
+		"+30.0ms → request 3",
+		"+30.0ms ← response 3",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("transcript:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	lines := strings.Join(m.inspectLines(ev), "\n")
+	if !strings.Contains(lines, "── Transcript (6 messages) ──") {
+		t.Error("inspector has no transcript section")
+	}
+	if !strings.Contains(lines, transcriptIndent+"who is this") {
+		t.Errorf("transcript does not show the messages:\n%s", lines)
+	}
+}
@@ -0,0 +1,250 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxSavedViews is how many saved views fit the picker's 1–9 keys.
+const maxSavedViews = 9
+
+// State is what the TUI keeps across sessions in its state file.
+type State struct {
+	Views []SavedView `json:"views,omitempty"`
+}
+
+// SavedView is a named combination of search, sort and error filter.
+type SavedView struct {
+	Name       string `json:"name"`
+	Search     string `json:"search,omitempty"`
+	Sort       string `json:"sort,omitempty"` // e.g. "duration" or "method desc"; empty for chronological
+	ErrorsOnly bool   `json:"errors_only,omitempty"`
+}
+
+// viewsPrompt is the step of the saved-views footer prompt.
+type viewsPrompt int
+
+const (
+	viewsOff    viewsPrompt = iota
+	viewsPick               // choosing a view to apply
+	viewsName               // typing the name to save the current view as
+	viewsDelete             // choosing a view to delete
+)
+
+// DefaultStatePath returns the state file in the user's config directory,
+// or "" when there is none.
+func DefaultStatePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "grpc-tap", "state.json")
+}
+
+// LoadState reads the state file at path. A missing file is an empty State.
+func LoadState(path string) (State, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is user-provided config
+	if errors.Is(err, fs.ErrNotExist) {
+		return State{}, nil
+	}
+	if err != nil {
+		return State{}, fmt.Errorf("read state: %w", err)
+	}
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil {
+		return State{}, fmt.Errorf("parse state %s: %w", path, err)
+	}
+	return st, nil
+}
+
+// saveState writes st to path, creating its directory. The file is replaced
+// in one rename, so a crash never leaves it half written.
+func saveState(path string, st State) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("save state: %w", err)
+	}
+	// A temporary file of its own, so concurrent saves never write to the
+	// same file.
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("save state: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }() // gone after a successful rename
+	_, err = tmp.Write(append(data, '\n'))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("save state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("save state: %w", err)
+	}
+	return nil
+}
+
+// WithStateFile loads the saved views from st and saves changes to them to
+// path. Without it, or with an empty path, views can still be saved but last
+// only for the session.
+func WithStateFile(path string, st State) Option {
+	return func(m *Model) {
+		m.statePath = path
+		m.views = st.Views
+	}
+}
+
+type stateSavedMsg struct{ err error }
+
+// saveStateCmd writes the saved views to the state file off the UI
+// goroutine.
+func (m Model) saveStateCmd() tea.Cmd {
+	if m.statePath == "" {
+		return nil
+	}
+	path, st := m.statePath, State{Views: slices.Clone(m.views)}
+	return func() tea.Msg {
+		return stateSavedMsg{err: saveState(path, st)}
+	}
+}
+
+// currentView captures the current search, sort and error filter as name.
+func (m Model) currentView(name string) SavedView {
+	return SavedView{Name: name, Search: m.searchQuery, Sort: m.sortMode.String(), ErrorsOnly: m.filterErrors}
+}
+
+// saveView saves the current view as name, replacing a view of that name.
+// It fails when the picker is full.
+func (m Model) saveView(name string) (Model, error) {
+	v := m.currentView(name)
+	if i := slices.IndexFunc(m.views, func(s SavedView) bool { return s.Name == name }); i >= 0 {
+		m.views = slices.Clone(m.views)
+		m.views[i] = v
+		return m, nil
+	}
+	if len(m.views) >= maxSavedViews {
+		return m, fmt.Errorf("at most %d views; delete one first", maxSavedViews)
+	}
+	m.views = append(slices.Clone(m.views), v)
+	return m, nil
+}
+
+// applyView replaces the search, sort and error filter with v's.
+func (m Model) applyView(v SavedView) Model {
+	m.searchQuery = v.Search
+	m.filterErrors = v.ErrorsOnly
+	m.sortMode = parseSortMode(v.Sort)
+	if m.sortMode != sortChronological {
+		m.follow = false
+	}
+	m.displayRows = m.rebuildDisplayRows()
+	m.cursor = min(m.cursor, max(len(m.displayRows)-1, 0))
+	return m
+}
+
+// viewIndex returns the index of the view picked with key "1"…"9", or -1.
+func (m Model) viewIndex(key string) int {
+	n, err := strconv.Atoi(key)
+	if err != nil || n < 1 || n > len(m.views) {
+		return -1
+	}
+	return n - 1
+}
+
+// updateViews handles keys while the saved-views prompt is open.
+func (m Model) updateViews(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	if key == "ctrl+c" {
+		return m.quit()
+	}
+	switch m.viewsPrompt {
+	case viewsName:
+		return m.updateViewName(msg)
+	case viewsDelete:
+		m.viewsPrompt = viewsOff
+		if i := m.viewIndex(key); i >= 0 {
+			m.views = slices.Delete(slices.Clone(m.views), i, i+1)
+			return m, m.saveStateCmd()
+		}
+		return m, nil
+	}
+
+	m.viewsPrompt = viewsOff
+	switch key {
+	case "s":
+		m.viewsPrompt = viewsName
+		m.viewName = ""
+	case "d":
+		if len(m.views) > 0 {
+			m.viewsPrompt = viewsDelete
+		}
+	default:
+		if i := m.viewIndex(key); i >= 0 {
+			m = m.applyView(m.views[i])
+			return m.showAlert("view: " + m.views[i].Name)
+		}
+	}
+	return m, nil
+}
+
+// updateViewName handles keys while the name of a view to save is typed.
+// Enter saves it; an empty name or Esc cancels.
+func (m Model) updateViewName(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		m.viewsPrompt = viewsOff
+		name := strings.TrimSpace(m.viewName)
+		m.viewName = ""
+		if name == "" {
+			return m, nil
+		}
+		saved, err := m.saveView(name)
+		if err != nil {
+			return m.showAlert("save view: " + err.Error())
+		}
+		m, cmd := saved.showAlert("saved view: " + name)
+		return m, tea.Batch(cmd, m.saveStateCmd())
+	case "esc":
+		m.viewsPrompt = viewsOff
+		m.viewName = ""
+	case "backspace":
+		if len(m.viewName) > 0 {
+			_, size := utf8.DecodeLastRuneInString(m.viewName)
+			m.viewName = m.viewName[:len(m.viewName)-size]
+		}
+	default:
+		m.viewName += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// viewsFooter renders the list footer while the saved-views prompt is open.
+func (m Model) viewsFooter() string {
+	if m.viewsPrompt == viewsName {
+		return fmt.Sprintf("  save view as: %s█", m.viewName)
+	}
+	labels := make([]string, 0, len(m.views))
+	for i, v := range m.views {
+		labels = append(labels, fmt.Sprintf("[%d] %s", i+1, v.Name))
+	}
+	if m.viewsPrompt == viewsDelete {
+		return "  delete view: " + strings.Join(labels, "  ")
+	}
+	if len(labels) == 0 {
+		return "  views: none saved  [s]ave current"
+	}
+	return "  views: " + strings.Join(labels, "  ") + "  [s]ave current  [d]elete"
+}
@@ -0,0 +1,155 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSavedViews_SaveAndApply(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel(
+		testEvent("1", "/pkg.Svc/GetUser", 0, 50*time.Millisecond),
+		testEvent("2", "/pkg.Svc/GetUser", 14, 300*time.Millisecond),
+		testEvent("3", "/pkg.Svc/ListUsers", 13, 200*time.Millisecond),
+	)
+	m.searchQuery = "GetUser"
+	m.filterErrors = true
+	m = m.cycleSort()
+	want := m.displayRows
+
+	m = press(m, "V")
+	m = press(m, "s")
+	for _, key := range []string{"s", "l", "o", "w"} {
+		m = press(m, key)
+	}
+	m = press(m, "enter")
+	if len(m.views) != 1 || m.views[0] != (SavedView{Name: "slow", Search: "GetUser", Sort: "duration", ErrorsOnly: true}) {
+		t.Fatalf("views = %+v", m.views)
+	}
+
+	m = m.applyView(SavedView{})
+	if len(m.displayRows) != 3 {
+		t.Fatalf("empty view shows rows %v, want all", m.displayRows)
+	}
+
+	m = press(m, "V")
+	m = press(m, "1")
+	if m.searchQuery != "GetUser" || !m.filterErrors || m.sortMode != sortDuration {
+		t.Errorf("applied view: search %q, errors %v, sort %v", m.searchQuery, m.filterErrors, m.sortMode)
+	}
+	if len(m.displayRows) != len(want) || m.displayRows[0] != want[0] {
+		t.Errorf("applied view shows rows %v, want %v", m.displayRows, want)
+	}
+
+	m = press(m, "V")
+	m = press(m, "d")
+	m = press(m, "1")
+	if len(m.views) != 0 {
+		t.Errorf("views after delete = %+v, want none", m.views)
+	}
+}
+
+func TestSavedViews_ReplaceAndLimit(t *testing.T) {
+	t.Parallel()
+
+	m := newTestModel()
+	var err error
+	for i := range maxSavedViews {
+		m.searchQuery = string(rune('a' + i))
+		if m, err = m.saveView(m.searchQuery); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := m.saveView("one too many"); err == nil {
+		t.Error("saving beyond the limit succeeded")
+	}
+	m.searchQuery = "replaced"
+	if m, err = m.saveView("a"); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.views) != maxSavedViews || m.views[0].Search != "replaced" {
+		t.Errorf("views[0] = %+v, want the replaced view in place", m.views[0])
+	}
+}
+
+func TestSavedViews_Persist(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "grpc-tap", "state.json")
+	st, err := LoadState(path)
+	if err != nil || len(st.Views) != 0 {
+		t.Fatalf("LoadState(missing) = %+v, %v; want an empty state", st, err)
+	}
+
+	m := newTestModel()
+	WithStateFile(path, st)(&m)
+	m.searchQuery = "code:error dur>100ms"
+	if m, err = m.saveView("slow errors"); err != nil {
+		t.Fatal(err)
+	}
+	msg, ok := m.saveStateCmd()().(stateSavedMsg)
+	if !ok || msg.err != nil {
+		t.Fatalf("save: %+v", msg)
+	}
+
+	st, err = LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := newTestModel()
+	WithStateFile(path, st)(&next)
+	next = press(next, "V")
+	next = press(next, "1")
+	if next.searchQuery != "code:error dur>100ms" {
+		t.Errorf("reloaded view search = %q", next.searchQuery)
+	}
+}
+
+func TestLoadState_Invalid(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadState(path); err == nil {
+		t.Error("LoadState succeeded on invalid JSON")
+	}
+}
+
+func TestSaveState_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := range 8 {
+		wg.Go(func() {
+			errs <- saveState(path, State{Views: []SavedView{{Name: strconv.Itoa(i)}}})
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	if st, err := LoadState(path); err != nil || len(st.Views) != 1 {
+		t.Errorf("LoadState = %+v, %v; want one of the saved states", st, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("%d files in the state directory, want only the state file", len(entries))
+	}
+}
@@ -0,0 +1,92 @@
+package web_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/mickamy/grpc-tap/broker"
+	tapv1 "github.com/mickamy/grpc-tap/gen/tap/v1"
+	"github.com/mickamy/grpc-tap/proxy"
+	"github.com/mickamy/grpc-tap/server"
+	"github.com/mickamy/grpc-tap/web"
+)
+
+func TestWithGRPC(t *testing.T) {
+	t.Parallel()
+
+	b := broker.New(8, broker.WithBacklog(8))
+	b.Publish(proxy.Event{ID: "1", Method: "/test.Service/Hello"})
+	srv := server.New(b, &fakeProxy{})
+	t.Cleanup(srv.Stop)
+	ts := newTestServer(t, b, &fakeProxy{}, web.WithGRPC(srv.Handler()))
+
+	t.Run("grpc client", func(t *testing.T) {
+		t.Parallel()
+		conn, err := grpc.NewClient(
+			strings.TrimPrefix(ts.URL, "http://"),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = conn.Close() })
+
+		stream, err := tapv1.NewTapServiceClient(conn).Watch(t.Context(), &tapv1.WatchRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		done := make(chan *tapv1.WatchResponse, 1)
+		go func() {
+			resp, err := stream.Recv()
+			if err != nil {
+				t.Errorf("recv: %v", err)
+			}
+			done <- resp
+		}()
+		select {
+		case resp := <-done:
+			if resp.GetEvent().GetId() != "1" {
+				t.Errorf("got event %q, want the backlog event 1", resp.GetEvent().GetId())
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the backlog over gRPC")
+		}
+	})
+
+	t.Run("browser", func(t *testing.T) {
+		t.Parallel()
+		for _, path := range []string{"/", "/api/schema", "/api/events/history"} {
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, ts.URL+path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if resp.StatusCode != http.StatusOK || len(body) == 0 {
+				t.Errorf("GET %s = %d with %d bytes, want 200 with a body", path, resp.StatusCode, len(body))
+			}
+		}
+	})
+
+	t.Run("grpc-web is not routed to gRPC", func(t *testing.T) {
+		t.Parallel()
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/tap.v1.TapService/Watch", nil)
+		req.ProtoMajor = 2
+		req.Header.Set("Content-Type", "application/grpc-web")
+		rec := httptest.NewRecorder()
+		web.New(b, &fakeProxy{}, web.WithGRPC(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			t.Error("gRPC-Web request routed to the gRPC handler")
+		}))).Handler().ServeHTTP(rec, req)
+	})
+}
@@ -0,0 +1,127 @@
+package web
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// compressAPI compresses the responses of the /api/ endpoints, SSE streams
+// included, with gzip or deflate (zlib-wrapped, as HTTP defines it) when the
+// client accepts either. The web UI's static files are served as they are, so
+// that range requests keep working, and so are responses without a body.
+func compressAPI(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Accept-Encoding")
+		enc := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if enc == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, encoding: enc}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding picks gzip, else deflate, from an Accept-Encoding header,
+// or returns "" when the client accepts neither.
+func negotiateEncoding(accept string) string {
+	accepted := make(map[string]bool)
+	for part := range strings.SplitSeq(accept, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		accepted[name] = q > 0
+	}
+	for _, enc := range []string{"gzip", "deflate"} {
+		if ok, listed := accepted[enc]; ok || !listed && accepted["*"] {
+			return enc
+		}
+	}
+	return ""
+}
+
+// compressWriter compresses what a handler writes. Flush flushes the
+// compressor before the connection, so that each server-sent event reaches
+// the client as it is written.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	w           io.WriteCloser // nil until the body starts
+	wroteHeader bool
+	bodiless    bool // the status allows no body, so nothing is compressed
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+	if code == http.StatusNoContent || code == http.StatusNotModified {
+		cw.bodiless = true
+	} else {
+		h := cw.Header()
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", cw.encoding)
+	}
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	cw.WriteHeader(http.StatusOK)
+	if cw.bodiless {
+		return cw.ResponseWriter.Write(p) //nolint:wrapcheck // a ResponseWriter's errors are passed on as they are
+	}
+	cw.start()
+	return cw.w.Write(p) //nolint:wrapcheck // a ResponseWriter's errors are passed on as they are
+}
+
+// Flush sends what has been written so far to the client.
+func (cw *compressWriter) Flush() {
+	cw.WriteHeader(http.StatusOK)
+	if !cw.bodiless {
+		cw.start()
+		if f, ok := cw.w.(interface{ Flush() error }); ok {
+			_ = f.Flush()
+		}
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close ends the compressed body. A response whose header was written gets
+// a body even when empty, as clients fail to decode none, unless its status
+// allows no body.
+func (cw *compressWriter) Close() {
+	if !cw.wroteHeader || cw.bodiless {
+		return
+	}
+	cw.start()
+	_ = cw.w.Close()
+}
+
+// start creates the compressor on first use.
+func (cw *compressWriter) start() {
+	if cw.w != nil {
+		return
+	}
+	if cw.encoding == "gzip" {
+		cw.w = gzip.NewWriter(cw.ResponseWriter)
+		return
+	}
+	cw.w = zlib.NewWriter(cw.ResponseWriter)
+}
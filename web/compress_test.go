@@ -0,0 +1,155 @@
+package web_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mickamy/grpc-tap/broker"
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+// getEncoded sends a GET for path with the Accept-Encoding header set, which
+// keeps the client from decoding the response itself.
+func getEncoded(t *testing.T, url, path, accept string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, url+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept-Encoding", accept)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func TestCompression(t *testing.T) {
+	t.Parallel()
+
+	b := broker.New(8, broker.WithBacklog(8))
+	b.Publish(proxy.Event{ID: "1", Method: "/test.Service/Hello", RequestBody: helloWire()})
+	ts := newTestServer(t, b, &fakeProxy{})
+
+	t.Run("gzip", func(t *testing.T) {
+		t.Parallel()
+		resp := getEncoded(t, ts.URL, "/api/events/1", "br, gzip")
+		if got := resp.Header.Get("Content-Encoding"); got != "gzip" {
+			t.Fatalf("Content-Encoding = %q, want gzip", got)
+		}
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got map[string]any
+		if err := json.NewDecoder(zr).Decode(&got); err != nil {
+			t.Fatalf("decode gzipped response: %v", err)
+		}
+		if got["id"] != "1" {
+			t.Errorf("id = %v, want 1", got["id"])
+		}
+	})
+
+	t.Run("deflate", func(t *testing.T) {
+		t.Parallel()
+		resp := getEncoded(t, ts.URL, "/api/events/history", "gzip;q=0, deflate")
+		if got := resp.Header.Get("Content-Encoding"); got != "deflate" {
+			t.Fatalf("Content-Encoding = %q, want deflate", got)
+		}
+		// HTTP deflate is zlib-wrapped.
+		zr, err := zlib.NewReader(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []map[string]any
+		if err := json.NewDecoder(zr).Decode(&got); err != nil {
+			t.Fatalf("decode deflated response: %v", err)
+		}
+		if len(got) != 1 {
+			t.Errorf("history = %d events, want 1", len(got))
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		resp := getEncoded(t, ts.URL, "/api/events/missing", "gzip")
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusNotFound || !strings.Contains(string(body), "not found") {
+			t.Errorf("got %d %q, want 404 not found", resp.StatusCode, body)
+		}
+	})
+
+	t.Run("sse flushes each event", func(t *testing.T) {
+		t.Parallel()
+		resp := getEncoded(t, ts.URL, "/api/events", "gzip")
+		if got := resp.Header.Get("Content-Encoding"); got != "gzip" {
+			t.Fatalf("Content-Encoding = %q, want gzip", got)
+		}
+		// The stream stays open, so the backlog event is only read if it
+		// was flushed through the gzip writer.
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(zr)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				if !strings.Contains(data, `"id":"1"`) {
+					t.Errorf("event = %s, want id 1", data)
+				}
+				return
+			}
+		}
+		t.Fatalf("no SSE event: %v", scanner.Err())
+	})
+
+	t.Run("identity", func(t *testing.T) {
+		t.Parallel()
+		for _, accept := range []string{"identity", "gzip;q=0", ""} {
+			resp := getEncoded(t, ts.URL, "/api/events/1", accept)
+			if got := resp.Header.Get("Content-Encoding"); got != "" {
+				t.Errorf("Accept-Encoding %q: Content-Encoding = %q, want none", accept, got)
+			}
+		}
+	})
+
+	t.Run("head", func(t *testing.T) {
+		t.Parallel()
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodHead, ts.URL+"/api/events/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept-Encoding", "gzip")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if got := resp.Header.Get("Content-Encoding"); got != "" {
+			t.Errorf("Content-Encoding = %q, want none without a body", got)
+		}
+	})
+
+	t.Run("static files", func(t *testing.T) {
+		t.Parallel()
+		resp := getEncoded(t, ts.URL, "/", "gzip")
+		if got := resp.Header.Get("Content-Encoding"); got != "" {
+			t.Errorf("Content-Encoding = %q, want the web UI served as is", got)
+		}
+	})
+}
@@ -0,0 +1,43 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// readyTimeout bounds how long /readyz waits for the readiness check.
+const readyTimeout = 5 * time.Second
+
+// healthResponse is the body of /healthz and /readyz.
+type healthResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// WithReadiness serves /readyz from check, typically the proxy's CheckReady:
+// 200 when it succeeds, 503 Service Unavailable with its error otherwise.
+// Without it, /readyz reports ready whenever the server is up, like /healthz.
+func WithReadiness(check func(context.Context) error) Option {
+	return func(s *Server) {
+		s.ready = check
+	}
+}
+
+// handleHealthz reports that the daemon is running.
+func (s *Server) handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, &healthResponse{Status: "ok"})
+}
+
+// handleReadyz reports whether the daemon can serve calls.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if s.ready != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+		if err := s.ready(ctx); err != nil {
+			writeJSON(w, http.StatusServiceUnavailable, &healthResponse{Status: "unavailable", Error: err.Error()})
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, &healthResponse{Status: "ok"})
+}
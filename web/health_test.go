@@ -0,0 +1,86 @@
+package web_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mickamy/grpc-tap/broker"
+	"github.com/mickamy/grpc-tap/proxy"
+	"github.com/mickamy/grpc-tap/web"
+)
+
+// getHealth fetches a health endpoint and returns its status code and body.
+func getHealth(t *testing.T, ts *httptest.Server, path string) (int, map[string]string) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, ts.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var body map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, body
+}
+
+func TestHealthz(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t, broker.New(8), &fakeProxy{})
+	for _, path := range []string{"/healthz", "/readyz"} {
+		if code, body := getHealth(t, ts, path); code != http.StatusOK || body["status"] != "ok" {
+			t.Errorf("%s = %d %v, want 200 ok", path, code, body)
+		}
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	t.Parallel()
+
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	p, err := proxy.New("127.0.0.1:0", upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := newTestServer(t, broker.New(8), p, web.WithReadiness(p.CheckReady))
+
+	if code, body := getHealth(t, ts, "/readyz"); code != http.StatusServiceUnavailable || body["error"] == "" {
+		t.Errorf("before listening: /readyz = %d %v, want 503 with an error", code, body)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+	go func() { done <- p.ListenAndServe(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	select {
+	case <-p.Ready():
+	case err := <-done:
+		t.Fatalf("ListenAndServe: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("proxy never became ready")
+	}
+
+	if code, body := getHealth(t, ts, "/readyz"); code != http.StatusOK || body["status"] != "ok" {
+		t.Errorf("after startup: /readyz = %d %v, want 200 ok", code, body)
+	}
+
+	upstream.Close()
+	if code, body := getHealth(t, ts, "/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("upstream down: /readyz = %d %v, want 503", code, body)
+	}
+	if code, _ := getHealth(t, ts, "/healthz"); code != http.StatusOK {
+		t.Errorf("upstream down: /healthz = %d, want 200", code)
+	}
+}
@@ -0,0 +1,129 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mickamy/grpc-tap/filter"
+)
+
+const (
+	// defaultHistoryLimit is the page size of /api/events/history when the
+	// request sets none.
+	defaultHistoryLimit = 100
+	// maxHistoryLimit caps the page size of /api/events/history.
+	maxHistoryLimit = 1000
+)
+
+// historyEvent is an event of /api/events/history: the event as streamed over
+// SSE, plus its sequence number in the daemon's backlog.
+type historyEvent struct {
+	Seq uint64 `json:"seq"`
+	EventJSON
+}
+
+// eventDetail is the response of /api/events/{id}: the event as in
+// /api/events/history, plus its decoded bodies.
+type eventDetail struct {
+	historyEvent
+	decodedBodies
+}
+
+// historyQuery holds the parameters of /api/events/history.
+type historyQuery struct {
+	limit  int
+	offset int
+	after  uint64         // only events with a greater sequence number
+	method string         // lower-cased method substring
+	errors bool           // only events with a non-OK status
+	filter *filter.Filter // the q filter expression
+}
+
+func parseHistoryQuery(v url.Values) (historyQuery, error) {
+	q := historyQuery{
+		limit:  defaultHistoryLimit,
+		method: strings.ToLower(v.Get("method")),
+	}
+	var err error
+	if s := v.Get("limit"); s != "" {
+		if q.limit, err = strconv.Atoi(s); err != nil || q.limit < 1 || q.limit > maxHistoryLimit {
+			return q, fmt.Errorf("limit must be between 1 and %d, got %q", maxHistoryLimit, s)
+		}
+	}
+	if s := v.Get("offset"); s != "" {
+		if q.offset, err = strconv.Atoi(s); err != nil || q.offset < 0 {
+			return q, fmt.Errorf("offset must be a non-negative integer, got %q", s)
+		}
+	}
+	if s := v.Get("after"); s != "" {
+		if q.after, err = strconv.ParseUint(s, 10, 64); err != nil {
+			return q, fmt.Errorf("after must be a sequence number, got %q", s)
+		}
+	}
+	if s := v.Get("errors"); s != "" {
+		if q.errors, err = strconv.ParseBool(s); err != nil {
+			return q, fmt.Errorf("errors must be a boolean, got %q", s)
+		}
+	}
+	if q.filter, err = filter.Parse(v.Get("q")); err != nil {
+		return q, err //nolint:wrapcheck // the filter error names the bad term
+	}
+	return q, nil
+}
+
+// handleHistory returns a page of the daemon's backlog as a JSON array,
+// ordered by sequence number. Events are filtered like the TUI list — by a
+// method substring, to errors only and by a filter expression (see package
+// filter) — before offset and limit apply; after
+// skips events up to a sequence number, so that clients can page through the
+// backlog even while it moves.
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	q, err := parseHistoryQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page := []historyEvent{}
+	skipped := 0
+	for _, e := range s.broker.History() {
+		ev := e.Event
+		if e.Seq <= q.after ||
+			q.method != "" && !strings.Contains(strings.ToLower(ev.Method), q.method) ||
+			q.errors && ev.Status == 0 ||
+			!q.filter.Empty() && !q.filter.Evaluate(filter.FromEvent(ev)) {
+			continue
+		}
+		if skipped < q.offset {
+			skipped++
+			continue
+		}
+		page = append(page, historyEvent{Seq: e.Seq, EventJSON: EventToJSON(ev)})
+		if len(page) == q.limit {
+			break
+		}
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// handleEvent returns the backlog event with the given id, with its bodies
+// decoded, or 404 Not Found once it has left the backlog.
+func (s *Server) handleEvent(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	history := s.broker.History()
+	for i := len(history) - 1; i >= 0; i-- {
+		e := history[i]
+		if e.Event.ID != id {
+			continue
+		}
+		writeJSON(w, http.StatusOK, &eventDetail{
+			historyEvent:  historyEvent{Seq: e.Seq, EventJSON: EventToJSON(e.Event)},
+			decodedBodies: decodeBodies(e.Event),
+		})
+		return
+	}
+	http.Error(w, "event not found", http.StatusNotFound)
+}
@@ -0,0 +1,190 @@
+package web_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mickamy/grpc-tap/broker"
+	"github.com/mickamy/grpc-tap/proxy"
+)
+
+type historyEvent struct {
+	Seq    uint64 `json:"seq"`
+	ID     string `json:"id"`
+	Method string `json:"method"`
+	Status int32  `json:"status"`
+}
+
+// getHistory fetches /api/events/history with query and returns the status
+// code and, on success, the decoded page.
+func getHistory(t *testing.T, url, query string) (int, []historyEvent) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, url+"/api/events/history?"+query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+	var page []historyEvent
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, page
+}
+
+func historyIDs(page []historyEvent) string {
+	ids := make([]string, len(page))
+	for i, ev := range page {
+		ids[i] = ev.ID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	b := broker.New(8, broker.WithBacklog(10))
+	for i := 1; i <= 6; i++ {
+		ev := proxy.Event{ID: strconv.Itoa(i), Method: "/users.v1.UserService/GetUser"}
+		if i%2 == 0 {
+			ev.Method = "/orders.v1.OrderService/ListOrders"
+		}
+		if i >= 5 {
+			ev.Status = 14
+		}
+		b.Publish(ev)
+	}
+	b.Publish(proxy.Event{ID: "7", Phase: proxy.PhaseStart, Method: "/users.v1.UserService/GetUser"})
+	ts := newTestServer(t, b, &fakeProxy{})
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{name: "all", query: "", want: "1,2,3,4,5,6"},
+		{name: "limit", query: "limit=2", want: "1,2"},
+		{name: "offset", query: "limit=2&offset=2", want: "3,4"},
+		{name: "last page", query: "limit=4&offset=4", want: "5,6"},
+		{name: "offset past end", query: "offset=6", want: ""},
+		{name: "after", query: "after=4", want: "5,6"},
+		{name: "after and offset", query: "after=2&offset=1&limit=1", want: "4"},
+		{name: "method", query: "method=userservice", want: "1,3,5"},
+		{name: "errors", query: "errors=true", want: "5,6"},
+		{name: "method and errors", query: "method=OrderService&errors=1", want: "6"},
+		{name: "filter before offset", query: "method=GetUser&offset=1", want: "3,5"},
+		{name: "no match", query: "method=Nope", want: ""},
+		{name: "filter expression", query: "q=" + url.QueryEscape("orderservice code:unavailable"), want: "6"},
+		{name: "negated filter", query: "q=" + url.QueryEscape("-code:error"), want: "1,2,3,4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			status, page := getHistory(t, ts.URL, tt.query)
+			if status != http.StatusOK {
+				t.Fatalf("status = %d, want %d", status, http.StatusOK)
+			}
+			if page == nil {
+				t.Fatal("page is null, want an array")
+			}
+			if got := historyIDs(page); got != tt.want {
+				t.Errorf("ids = %s, want %s", got, tt.want)
+			}
+			for _, ev := range page {
+				if strconv.FormatUint(ev.Seq, 10) != ev.ID {
+					t.Errorf("event %s has seq %d", ev.ID, ev.Seq)
+				}
+			}
+		})
+	}
+
+	for _, query := range []string{
+		"limit=0", "limit=-1", "limit=1001", "limit=ten", "offset=-1", "offset=x", "after=-1", "errors=maybe",
+		"q=nope:x",
+	} {
+		t.Run("invalid "+query, func(t *testing.T) {
+			t.Parallel()
+			if status, _ := getHistory(t, ts.URL, query); status != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", status, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestHistory_MaxLimit(t *testing.T) {
+	t.Parallel()
+
+	b := broker.New(8, broker.WithBacklog(1500))
+	for i := range 1200 {
+		b.Publish(proxy.Event{ID: strconv.Itoa(i + 1), Method: "/test.Service/Hello"})
+	}
+	ts := newTestServer(t, b, &fakeProxy{})
+
+	if _, page := getHistory(t, ts.URL, ""); len(page) != 100 {
+		t.Errorf("default page = %d events, want 100", len(page))
+	}
+	_, page := getHistory(t, ts.URL, "limit=1000&offset=1000")
+	if len(page) != 200 || page[0].Seq != 1001 {
+		t.Errorf("last page = %d events from seq %d, want 200 from 1001", len(page), page[0].Seq)
+	}
+}
+
+func TestEvent(t *testing.T) {
+	t.Parallel()
+
+	b := broker.New(8, broker.WithBacklog(10))
+	b.Publish(proxy.Event{ID: "a", Method: "/test.Service/Hello", RequestBody: helloWire()})
+	b.Publish(proxy.Event{ID: "b", Method: "/test.Service/Hello", RequestBody: []byte("{}")})
+	ts := newTestServer(t, b, &fakeProxy{})
+
+	get := func(id string) (int, map[string]json.RawMessage) {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, ts.URL+"/api/events/"+id, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			return resp.StatusCode, nil
+		}
+		var got map[string]json.RawMessage
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, got
+	}
+
+	_, got := get("a")
+	if string(got["id"]) != `"a"` || string(got["seq"]) != "1" {
+		t.Errorf("event = id %s seq %s, want a, 1", got["id"], got["seq"])
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(got["decoded_request"], &decoded); err != nil || decoded["1"] != "hello" {
+		t.Errorf("decoded_request = %s, want field 1 = hello", got["decoded_request"])
+	}
+	if _, ok := got["decoded_response"]; ok {
+		t.Error("decoded_response present for an event without a response body")
+	}
+
+	if _, got := get("b"); got["decoded_request"] != nil {
+		t.Errorf("decoded_request = %s, want it left out for a JSON body", got["decoded_request"])
+	}
+	if status, _ := get("missing"); status != http.StatusNotFound {
+		t.Errorf("missing event: status = %d, want %d", status, http.StatusNotFound)
+	}
+}
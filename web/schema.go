@@ -0,0 +1,168 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// schemaTypes names the JSON shapes of the API, in the order they are
+// defined in the schema.
+var schemaTypes = []struct {
+	name string
+	typ  reflect.Type
+}{
+	{"Event", reflect.TypeFor[EventJSON]()},
+	{"HistoryEvent", reflect.TypeFor[historyEvent]()},
+	{"EventDetail", reflect.TypeFor[eventDetail]()},
+	{"ReplayRequest", reflect.TypeFor[replayRequest]()},
+	{"ReplayResponse", reflect.TypeFor[replayResponse]()},
+	{"ClearResponse", reflect.TypeFor[clearResponse]()},
+	{"Stats", reflect.TypeFor[statsJSON]()},
+	{"Summary", reflect.TypeFor[summaryJSON]()},
+}
+
+// schemaEndpoints describes which shapes each endpoint sends and receives.
+var schemaEndpoints = map[string]any{
+	"GET /api/events": map[string]any{
+		"description": "server-sent events stream; each data line is an Event, starting with the daemon's backlog; " +
+			"query: q (filter expression), fields (full, the default, or summary: without headers and bodies)",
+		"contentType": "text/event-stream",
+		"event":       map[string]any{"$ref": "#/$defs/Event"},
+	},
+	"GET /api/events/history": map[string]any{
+		"description": "page of the daemon's backlog ordered by seq; " +
+			"query: limit (1-1000, default 100), offset, after (seq), method (substring), errors (bool), " +
+			"q (filter expression)",
+		"response": map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/HistoryEvent"}},
+	},
+	"GET /api/events/{id}": map[string]any{
+		"description": "the backlog event with the given id, with its bodies decoded from protobuf; 404 once evicted",
+		"response":    map[string]any{"$ref": "#/$defs/EventDetail"},
+	},
+	"GET /api/stats": map[string]any{
+		"description": "server-sent events stream of totals over every method, sent right away and every interval; " +
+			"query: interval (duration, default 2s, at least 100ms), window (duration, default 1m)",
+		"contentType": "text/event-stream",
+		"event":       map[string]any{"$ref": "#/$defs/Stats"},
+	},
+	"GET /api/summary": map[string]any{
+		"description": "totals over the daemon's stats window and the calls in flight, for polling",
+		"response":    map[string]any{"$ref": "#/$defs/Summary"},
+	},
+	"POST /api/replay": map[string]any{
+		"request":  map[string]any{"$ref": "#/$defs/ReplayRequest"},
+		"response": map[string]any{"$ref": "#/$defs/ReplayResponse"},
+	},
+	"POST /api/clear": map[string]any{
+		"response": map[string]any{"$ref": "#/$defs/ClearResponse"},
+	},
+	"GET /api/schema": map[string]any{
+		"description": "this document",
+	},
+}
+
+// apiSchema returns the JSON Schema of the web API, derived from the structs
+// the handlers encode and decode so that it cannot drift from them.
+var apiSchema = sync.OnceValue(func() []byte {
+	defs := make(map[string]any, len(schemaTypes))
+	for _, st := range schemaTypes {
+		defs[st.name] = structSchema(st.typ)
+	}
+	b, err := json.MarshalIndent(map[string]any{
+		"$schema":   "https://json-schema.org/draft/2020-12/schema",
+		"title":     "grpc-tap web API",
+		"$defs":     defs,
+		"endpoints": schemaEndpoints,
+	}, "", "  ")
+	if err != nil {
+		panic(fmt.Sprintf("web: marshal schema: %v", err))
+	}
+	return b
+})
+
+// structSchema describes the JSON encoding of struct type t. Fields without
+// omitempty are required. A `schema:"base64"` tag marks strings holding
+// base64-encoded bytes.
+func structSchema(t reflect.Type) map[string]any {
+	props := make(map[string]any, t.NumField())
+	required := []string{}
+	addFields(t, props, &required)
+	return map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// addFields adds the properties of the fields of struct type t, including
+// those of embedded structs, which encoding/json flattens.
+func addFields(t reflect.Type, props map[string]any, required *[]string) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			addFields(f.Type, props, required)
+			continue
+		}
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		prop := typeSchema(f.Type)
+		if f.Tag.Get("schema") == "base64" {
+			prop["contentEncoding"] = "base64"
+		}
+		props[name] = prop
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// typeSchema describes the JSON encoding of t.
+func typeSchema(t reflect.Type) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	for _, st := range schemaTypes {
+		if st.typ == t {
+			return map[string]any{"$ref": "#/$defs/" + st.name}
+		}
+	}
+	if t == reflect.TypeFor[json.RawMessage]() {
+		// Only decoded bodies are raw JSON, and they are objects.
+		return map[string]any{"type": "object"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		panic(fmt.Sprintf("web: no schema for %s", t))
+	}
+}
+
+// handleSchema serves the JSON Schema of the web API.
+func (s *Server) handleSchema(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	_, _ = w.Write(apiSchema())
+}
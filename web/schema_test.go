@@ -0,0 +1,96 @@
+package web_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mickamy/grpc-tap/broker"
+	"github.com/mickamy/grpc-tap/web"
+)
+
+type schemaDoc struct {
+	Defs map[string]struct {
+		Properties map[string]map[string]any `json:"properties"`
+		Required   []string                  `json:"required"`
+	} `json:"$defs"`
+	Endpoints map[string]json.RawMessage `json:"endpoints"`
+}
+
+func TestSchema(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t, broker.New(8), &fakeProxy{})
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, ts.URL+"/api/schema", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	var doc schemaDoc
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+
+	t.Run("event matches EventJSON", func(t *testing.T) {
+		t.Parallel()
+		event, ok := doc.Defs["Event"]
+		if !ok {
+			t.Fatal("no Event definition")
+		}
+		typ := reflect.TypeFor[web.EventJSON]()
+		if len(event.Properties) != typ.NumField() {
+			t.Errorf("Event has %d properties, EventJSON has %d fields", len(event.Properties), typ.NumField())
+		}
+		for i := range typ.NumField() {
+			name, opts, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			if _, ok := event.Properties[name]; !ok {
+				t.Errorf("Event lacks property %q", name)
+			}
+			if required := slices.Contains(event.Required, name); required == strings.Contains(opts, "omitempty") {
+				t.Errorf("property %q required = %v, want the opposite of omitempty", name, required)
+			}
+		}
+		if got := event.Properties["request_body"]["contentEncoding"]; got != "base64" {
+			t.Errorf("request_body contentEncoding = %v, want base64", got)
+		}
+		if got := event.Properties["request_headers"]["type"]; got != "object" {
+			t.Errorf("request_headers type = %v, want object", got)
+		}
+	})
+
+	t.Run("replay shapes", func(t *testing.T) {
+		t.Parallel()
+		replay := doc.Defs["ReplayRequest"]
+		for _, name := range []string{"method", "request_body", "upstream"} {
+			if _, ok := replay.Properties[name]; !ok {
+				t.Errorf("ReplayRequest lacks property %q", name)
+			}
+		}
+		if !slices.Equal(replay.Required, []string{"method", "request_body"}) {
+			t.Errorf("ReplayRequest required = %v, want [method request_body]", replay.Required)
+		}
+		if got := doc.Defs["ReplayResponse"].Properties["event"]["$ref"]; got != "#/$defs/Event" {
+			t.Errorf("ReplayResponse event = %v, want a reference to Event", got)
+		}
+	})
+
+	t.Run("endpoints", func(t *testing.T) {
+		t.Parallel()
+		for _, ep := range []string{"GET /api/events", "GET /api/events/history", "GET /api/events/{id}", "POST /api/replay", "POST /api/clear", "GET /api/schema"} {
+			if _, ok := doc.Endpoints[ep]; !ok {
+				t.Errorf("endpoint %q not described", ep)
+			}
+		}
+	})
+}
@@ -98,6 +98,9 @@ function parseFilterTokens(input) {
       return {kind: 'duration', op, ms};
     }
     if (tok.toLowerCase() === 'error') return {kind: 'error'};
+    if (tok.toLowerCase().startsWith('peer:')) return {kind: 'peer', text: tok.slice(5).toLowerCase()};
+    if (tok.toLowerCase().startsWith('host:')) return {kind: 'host', text: tok.slice(5).toLowerCase()};
+    if (tok.toLowerCase().startsWith('trace:')) return {kind: 'trace', text: tok.slice(6).toLowerCase()};
     return {kind: 'text', text: tok.toLowerCase()};
   });
 }
@@ -108,6 +111,12 @@ function matchesFilter(ev, cond) {
       return cond.op === '>' ? ev.duration_ms > cond.ms : ev.duration_ms < cond.ms;
     case 'error':
       return ev.status !== 0;
+    case 'peer':
+      return (ev.peer_addr || '').toLowerCase().includes(cond.text);
+    case 'host':
+      return (ev.authority || '').toLowerCase().includes(cond.text);
+    case 'trace':
+      return (ev.correlation_id || '').toLowerCase().includes(cond.text);
     case 'text':
       return (ev.method || '').toLowerCase().includes(cond.text) ||
              (ev.call_type || '').toLowerCase().includes(cond.text) ||
@@ -132,6 +141,16 @@ function fmtDur(ms) {
   return (ms / 1000).toFixed(2) + 's';
 }
 
+// formatDeadline describes the client-set deadline of ev and how much of it
+// the call used, flagging calls at 90% or more.
+function formatDeadline(ev) {
+  const used = ev.duration_ms / ev.deadline_ms;
+  let s = fmtDur(ev.deadline_ms) + ' (used ' + (used * 100).toFixed(0) + '%)';
+  if (used >= 1) s += ' exceeded';
+  else if (used >= 0.9) s += ' near deadline';
+  return s;
+}
+
 function fmtTime(iso) {
   const d = new Date(iso);
   return d.toLocaleTimeString('en-GB', {hour12: false}) + '.' + String(d.getMilliseconds()).padStart(3, '0');
@@ -143,9 +162,17 @@ function escapeHTML(s) {
   return el.innerHTML;
 }
 
-function statusString(status) {
-  if (status === 0) return 'OK';
-  return 'ERR(' + status + ')';
+// isInFlight reports whether ev is a start or progress event of a call that
+// has not finished yet.
+function isInFlight(ev) {
+  return ev.phase === 'start' || ev.phase === 'progress';
+}
+
+// statusString names the status of ev, e.g. "OK" or "Unavailable(14)", as
+// sent by the daemon. Events from older daemons only carry the number.
+function statusString(ev) {
+  if (ev.status_name) return ev.status_name;
+  return ev.status === 0 ? 'OK' : 'Code(' + ev.status + ')';
 }
 
 function renderTable() {
@@ -166,12 +193,13 @@ function renderTable() {
     tr.dataset.idx = idx;
     tr.onclick = () => selectRow(idx);
     const statusClass = ev.status === 0 ? 'status-ok' : 'status-err';
+    const statusLabel = isInFlight(ev) ? '…' : statusString(ev);
     tr.innerHTML =
       `<td class="col-time">${escapeHTML(fmtTime(ev.start_time))}</td>` +
       `<td class="col-method" title="${escapeHTML(ev.method)}">${escapeHTML(ev.method)}</td>` +
       `<td class="col-type">${escapeHTML(ev.call_type)}</td>` +
       `<td class="col-dur">${escapeHTML(fmtDur(ev.duration_ms))}</td>` +
-      `<td class="col-status"><span class="${statusClass}">${escapeHTML(statusString(ev.status))}</span></td>`;
+      `<td class="col-status"><span class="${statusClass}">${escapeHTML(statusLabel)}</span></td>`;
     fragment.appendChild(tr);
   }
   tbody.replaceChildren(fragment);
@@ -188,7 +216,7 @@ function buildStats() {
   const textConds = parseFilterTokens(filterText).filter(c => c.kind === 'text');
   for (const ev of events) {
     const method = ev.method;
-    if (!method) continue;
+    if (!method || isInFlight(ev)) continue;
     if (textConds.length > 0 && !textConds.every(c => method.toLowerCase().includes(c.text))) continue;
     let group = groups.get(method);
     if (!group) {
@@ -288,14 +316,27 @@ function selectRow(idx) {
   const ev = events[idx];
   document.getElementById('d-method').textContent = ev.method;
   document.getElementById('d-time').textContent = fmtTime(ev.start_time);
-  document.getElementById('d-dur').textContent = fmtDur(ev.duration_ms);
+  document.getElementById('d-dur').textContent = ev.ttfb_ms != null
+    ? `TTFB: ${fmtDur(ev.ttfb_ms)}, Total: ${fmtDur(ev.duration_ms)}`
+    : fmtDur(ev.duration_ms);
   document.getElementById('d-protocol').textContent = ev.protocol;
   document.getElementById('d-calltype').textContent = ev.call_type;
 
   const statusEl = document.getElementById('d-status');
-  statusEl.textContent = statusString(ev.status);
+  statusEl.textContent = statusString(ev);
   statusEl.className = 'detail-value ' + (ev.status === 0 ? 'status-ok' : 'status-err');
 
+  document.getElementById('d-upstream').textContent = ev.upstream || '';
+  document.getElementById('d-upstream-row').style.display = ev.upstream ? '' : 'none';
+  document.getElementById('d-deadline').textContent = ev.deadline_ms ? formatDeadline(ev) : '';
+  document.getElementById('d-deadline-row').style.display = ev.deadline_ms ? '' : 'none';
+  document.getElementById('d-peer').textContent = ev.peer_addr || '';
+  document.getElementById('d-peer-row').style.display = ev.peer_addr ? '' : 'none';
+  document.getElementById('d-host').textContent = ev.authority || '';
+  document.getElementById('d-host-row').style.display = ev.authority ? '' : 'none';
+  document.getElementById('d-trace').textContent = ev.correlation_id || '';
+  document.getElementById('d-trace-row').style.display = ev.correlation_id ? '' : 'none';
+
   const errRow = document.getElementById('d-err-row');
   if (ev.error) {
     document.getElementById('d-err').textContent = ev.error;
@@ -307,18 +348,22 @@ function selectRow(idx) {
   // Headers
   const reqHeaders = ev.request_headers || {};
   const resHeaders = ev.response_headers || {};
+  const resTrailers = ev.response_trailers || {};
   document.getElementById('d-req-headers').textContent = formatHeaders(reqHeaders);
   document.getElementById('d-res-headers').textContent = formatHeaders(resHeaders);
+  document.getElementById('d-res-trailers').textContent = formatHeaders(resTrailers);
   document.getElementById('d-req-headers-section').style.display = Object.keys(reqHeaders).length > 0 ? '' : 'none';
   document.getElementById('d-res-headers-section').style.display = Object.keys(resHeaders).length > 0 ? '' : 'none';
+  document.getElementById('d-res-trailers-section').style.display = Object.keys(resTrailers).length > 0 ? '' : 'none';
 
   // Bodies
   const reqBody = ev.request_body || '';
   const resBody = ev.response_body || '';
-  document.getElementById('d-req-body').textContent = reqBody ? decodeBody(reqBody) : '';
-  document.getElementById('d-res-body').textContent = resBody ? decodeBody(resBody) : '';
-  document.getElementById('d-req-body-section').style.display = reqBody ? '' : 'none';
-  document.getElementById('d-res-body-section').style.display = resBody ? '' : 'none';
+  const noBody = ev.body_capture_disabled ? '(body capture disabled)' : '';
+  document.getElementById('d-req-body').textContent = reqBody ? decodeBody(reqBody) : noBody;
+  document.getElementById('d-res-body').textContent = resBody ? decodeBody(resBody) : noBody;
+  document.getElementById('d-req-body-section').style.display = reqBody || noBody ? '' : 'none';
+  document.getElementById('d-res-body-section').style.display = resBody || noBody ? '' : 'none';
 
   // Reset collapsed sections
   document.querySelectorAll('.detail-pre').forEach(el => el.classList.add('collapsed'));
@@ -331,7 +376,31 @@ function selectRow(idx) {
 
 function formatHeaders(headers) {
   const keys = Object.keys(headers).sort();
-  return keys.map(k => k + ': ' + headers[k]).join('\n');
+  return keys.map(k => {
+    const decoded = k.toLowerCase().endsWith('-bin') ? formatBinaryHeader(k, headers[k]) : null;
+    if (decoded === null) return k + ': ' + headers[k];
+    return k + ': ' + decoded + '\n  raw: ' + headers[k];
+  }).join('\n');
+}
+
+// formatBinaryHeader decodes the base64 values of a -bin header: status
+// details as protobuf, anything else as hex. Returns null if a value is not
+// base64.
+function formatBinaryHeader(name, value) {
+  try {
+    return value.split(',').map(v => {
+      const binary = atob(v.trim());
+      const bytes = new Uint8Array(binary.length);
+      for (let i = 0; i < binary.length; i++) bytes[i] = binary.charCodeAt(i);
+      if (name.toLowerCase() === 'grpc-status-details-bin') {
+        const decoded = decodeProtoWire(bytes, '    ');
+        if (decoded) return '\n' + decoded;
+      }
+      return toHex(bytes);
+    }).join(', ');
+  } catch (_) {
+    return null;
+  }
 }
 
 function toggleSection(name) {
@@ -547,7 +616,13 @@ async function replayRequest() {
     const resp = await fetch('/api/replay', {
       method: 'POST',
       headers: {'Content-Type': 'application/json'},
-      body: JSON.stringify({method: ev.method, request_body: ev.request_body || ''}),
+      // Replay with the original protocol and content type, e.g. Connect JSON.
+      body: JSON.stringify({
+        method: ev.method,
+        request_body: ev.request_body || '',
+        protocol: ev.protocol,
+        content_type: (ev.request_headers || {})['Content-Type'] || '',
+      }),
     });
     const data = await resp.json();
     if (data.error) {
@@ -555,9 +630,11 @@ async function replayRequest() {
       pre.className = 'replay-error';
     } else if (data.event) {
       const e = data.event;
-      let output = `Status: ${statusString(e.status)}\nDuration: ${fmtDur(e.duration_ms)}`;
+      let output = `Status: ${statusString(e)}\nDuration: ${fmtDur(e.duration_ms)}`;
       if (e.error) output += `\nError: ${e.error}`;
-      if (e.response_body) {
+      if (data.decoded_response) {
+        output += '\n\nResponse Body:\n' + JSON.stringify(data.decoded_response, null, 2);
+      } else if (e.response_body) {
         output += '\n\nResponse Body:\n' + decodeBody(e.response_body);
       }
       pre.textContent = output;
@@ -631,6 +708,7 @@ function buildExportData() {
     protocol: ev.protocol,
     duration_ms: ev.duration_ms,
     status: ev.status,
+    status_name: statusString(ev),
     error: ev.error || '',
   }));
 
@@ -704,7 +782,7 @@ function renderExportMarkdown(data) {
   md += '| # | Time | Method | Type | Protocol | Duration | Status | Error |\n';
   md += '|---|------|--------|------|----------|----------|--------|-------|\n';
   data.calls.forEach((c, i) => {
-    md += `| ${i + 1} | ${c.time} | ${escPipe(c.method)} | ${c.call_type} | ${c.protocol} | ${fmtDurExport(c.duration_ms)} | ${statusString(c.status)} | ${escPipe(c.error)} |\n`;
+    md += `| ${i + 1} | ${c.time} | ${escPipe(c.method)} | ${c.call_type} | ${c.protocol} | ${fmtDurExport(c.duration_ms)} | ${statusString(c)} | ${escPipe(c.error)} |\n`;
   });
 
   if (data.analytics.length > 0) {
@@ -747,7 +825,14 @@ function connectSSE() {
   es.onmessage = (e) => {
     if (paused) return;
     const ev = JSON.parse(e.data);
-    events.push(ev);
+    // A call's start, progress and final events share an id; keep only the
+    // latest one.
+    const idx = events.findLastIndex(x => x.id === ev.id);
+    if (idx >= 0) {
+      events[idx] = ev;
+    } else {
+      events.push(ev);
+    }
     render();
   };
   es.onerror = () => {
@@ -758,4 +843,25 @@ function connectSSE() {
   };
 }
 
+// connectStatsSSE keeps the footer showing the daemon's rolling totals, so
+// they cover calls from before the page was opened and survive Pause.
+function connectStatsSSE() {
+  const totalsEl = document.getElementById('totals-text');
+  const es = new EventSource('/api/stats');
+  es.onmessage = (e) => {
+    const st = JSON.parse(e.data);
+    const span = st.window_s >= 60 ? (st.window_s / 60) + 'm' : st.window_s + 's';
+    const errors = st.errors > 0
+      ? `<span class="totals-errors">${st.error_rate.toFixed(1)}% errors</span>`
+      : '0% errors';
+    totalsEl.innerHTML = `last ${span}: ${st.count} calls \u00b7 ${st.rps.toFixed(1)} rps \u00b7 ${errors}` +
+      ` \u00b7 avg ${fmtDur(st.avg_ms)} \u00b7 p95 ${fmtDur(st.p95_ms)}`;
+  };
+  es.onerror = () => {
+    es.close();
+    setTimeout(connectStatsSSE, 5000);
+  };
+}
+
 connectSSE();
+connectStatsSSE();
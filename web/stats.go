@@ -0,0 +1,146 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// defaultStatsInterval is how often /api/stats sends an update.
+	defaultStatsInterval = 2 * time.Second
+	// minStatsInterval keeps clients from asking for a busy loop.
+	minStatsInterval = 100 * time.Millisecond
+	// defaultStatsWindow is how far back /api/stats totals calls.
+	defaultStatsWindow = time.Minute
+)
+
+// statsJSON is one update of /api/stats: totals over every method in the
+// last window seconds.
+type statsJSON struct {
+	WindowS   float64 `json:"window_s"`
+	Count     int     `json:"count"`
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"error_rate"` // percent
+	RPS       float64 `json:"rps"`
+	AvgMs     float64 `json:"avg_ms"`
+	P50Ms     float64 `json:"p50_ms"`
+	P95Ms     float64 `json:"p95_ms"`
+	P99Ms     float64 `json:"p99_ms"`
+}
+
+// parseStatsQuery reads the interval and window of /api/stats, both Go
+// durations such as 5s.
+func parseStatsQuery(v url.Values) (interval, window time.Duration, err error) {
+	interval, window = defaultStatsInterval, defaultStatsWindow
+	if s := v.Get("interval"); s != "" {
+		if interval, err = time.ParseDuration(s); err != nil || interval < minStatsInterval {
+			return 0, 0, fmt.Errorf("interval must be a duration of at least %s, got %q", minStatsInterval, s)
+		}
+	}
+	if s := v.Get("window"); s != "" {
+		if window, err = time.ParseDuration(s); err != nil || window <= 0 {
+			return 0, 0, fmt.Errorf("window must be a positive duration, got %q", s)
+		}
+	}
+	return interval, window, nil
+}
+
+// handleStats streams the daemon's rolling totals as server-sent events: one
+// update right away and one every interval, so dashboards need not
+// aggregate every event themselves. The window is capped at the stats
+// window the daemon keeps.
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if s.stats == nil {
+		http.Error(w, "stats are not enabled", http.StatusNotFound)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	interval, window, err := parseStatsQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	window = min(window, s.stats.Window())
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.writeStats(w, window)
+		flusher.Flush()
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeStats writes the totals of the last window as a server-sent event.
+func (s *Server) writeStats(w http.ResponseWriter, window time.Duration) {
+	t := s.stats.Totals(window)
+	st := statsJSON{
+		WindowS:   window.Seconds(),
+		Count:     t.Count,
+		Errors:    t.Errors,
+		ErrorRate: t.ErrorRate(),
+		RPS:       float64(t.Count) / window.Seconds(),
+		P50Ms:     durationMs(t.P50),
+		P95Ms:     durationMs(t.P95),
+		P99Ms:     durationMs(t.P99),
+	}
+	if t.Count > 0 {
+		st.AvgMs = durationMs(t.Total / time.Duration(t.Count))
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		slog.Warn("web: marshal stats", "error", err)
+		return
+	}
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
+
+// summaryJSON is the response of /api/summary: the totals over the stats
+// window the daemon keeps and the calls in flight.
+type summaryJSON struct {
+	WindowS float64 `json:"window_s"`
+	Count   int     `json:"count"`
+	Errors  int     `json:"errors"`
+	Active  int     `json:"active"` // calls in flight long enough to be shown, e.g. open streams
+}
+
+// handleSummary returns the current totals as one JSON document, for clients
+// that poll, e.g. to show counts in a browser tab title, without following
+// /api/events or /api/stats.
+func (s *Server) handleSummary(w http.ResponseWriter, _ *http.Request) {
+	if s.stats == nil {
+		http.Error(w, "stats are not enabled", http.StatusNotFound)
+		return
+	}
+	count, errs := s.stats.Counts()
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	writeJSON(w, http.StatusOK, &summaryJSON{
+		WindowS: s.stats.Window().Seconds(),
+		Count:   count,
+		Errors:  errs,
+		Active:  s.stats.Active(),
+	})
+}
+
+// durationMs returns d in milliseconds, to the microsecond as in EventJSON.
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,161 @@
+package web_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mickamy/grpc-tap/broker"
+	"github.com/mickamy/grpc-tap/proxy"
+	"github.com/mickamy/grpc-tap/stats"
+	"github.com/mickamy/grpc-tap/web"
+)
+
+type statsUpdate struct {
+	WindowS   float64 `json:"window_s"`
+	Count     int     `json:"count"`
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+	RPS       float64 `json:"rps"`
+	AvgMs     float64 `json:"avg_ms"`
+	P95Ms     float64 `json:"p95_ms"`
+}
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	b := broker.New(8)
+	st := stats.New(time.Minute)
+	ch, unsub := b.Subscribe()
+	t.Cleanup(unsub)
+	go st.Run(ch)
+	ts := newTestServer(t, b, &fakeProxy{}, web.WithStats(st))
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, ts.URL+"/api/stats?interval=100ms&window=10s", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	for i, status := range []int32{0, 0, 0, 14} {
+		b.Publish(proxy.Event{
+			Method:   "/test.Service/Hello",
+			Phase:    proxy.PhaseComplete,
+			Status:   status,
+			Duration: time.Duration(i+1) * 10 * time.Millisecond,
+		})
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() && time.Now().Before(deadline) {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var got statsUpdate
+		if err := json.Unmarshal([]byte(data), &got); err != nil {
+			t.Fatalf("invalid JSON in stats update %q: %v", data, err)
+		}
+		if got.Count < 4 {
+			continue
+		}
+		want := statsUpdate{WindowS: 10, Count: 4, Errors: 1, ErrorRate: 25, RPS: 0.4, AvgMs: 25, P95Ms: 40}
+		if got != want {
+			t.Errorf("stats update = %+v, want %+v", got, want)
+		}
+		return
+	}
+	t.Fatalf("no stats update counted the published calls: %v", scanner.Err())
+}
+
+func TestStats_Errors(t *testing.T) {
+	t.Parallel()
+
+	get := func(t *testing.T, url string) int {
+		t.Helper()
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	ts := newTestServer(t, broker.New(8), &fakeProxy{})
+	for _, path := range []string{"/api/stats", "/api/summary"} {
+		if code := get(t, ts.URL+path); code != http.StatusNotFound {
+			t.Errorf("%s without stats: status = %d, want %d", path, code, http.StatusNotFound)
+		}
+	}
+
+	ts = newTestServer(t, broker.New(8), &fakeProxy{}, web.WithStats(stats.New(time.Minute)))
+	for _, query := range []string{"interval=1ms", "interval=soon", "window=0s", "window=-1m"} {
+		if code := get(t, ts.URL+"/api/stats?"+query); code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", query, code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	t.Parallel()
+
+	b := broker.New(8)
+	st := stats.New(time.Minute)
+	ch, unsub := b.Subscribe()
+	t.Cleanup(unsub)
+	go st.Run(ch)
+	ts := newTestServer(t, b, &fakeProxy{}, web.WithStats(st))
+
+	b.Publish(proxy.Event{ID: "stream", Method: "/test.Service/Watch", Phase: proxy.PhaseStart})
+	for i, status := range []int32{0, 0, 14} {
+		b.Publish(proxy.Event{ID: fmt.Sprint(i), Method: "/test.Service/Hello", Phase: proxy.PhaseComplete, Status: status})
+	}
+
+	type summary struct {
+		WindowS float64 `json:"window_s"`
+		Count   int     `json:"count"`
+		Errors  int     `json:"errors"`
+		Active  int     `json:"active"`
+	}
+	want := summary{WindowS: 60, Count: 3, Errors: 1, Active: 1}
+	var got summary
+	// The stats take the events from the broker asynchronously.
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, ts.URL+"/api/summary", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = json.NewDecoder(resp.Body).Decode(&got)
+		_ = resp.Body.Close()
+		if err != nil {
+			t.Fatalf("invalid summary: %v", err)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+			t.Fatalf("Content-Type = %q, want application/json", ct)
+		}
+		if got == want {
+			return
+		}
+	}
+	t.Errorf("summary = %+v, want %+v", got, want)
+}
@@ -5,15 +5,22 @@ import (
 	"embed"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
+	"log/slog"
 	"net"
 	"net/http"
 	"strings"
 	"time"
 
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
 	"github.com/mickamy/grpc-tap/broker"
+	"github.com/mickamy/grpc-tap/filter"
 	"github.com/mickamy/grpc-tap/proxy"
+	"github.com/mickamy/grpc-tap/stats"
 )
 
 //go:embed static
@@ -24,13 +31,63 @@ type Server struct {
 	httpServer *http.Server
 	broker     *broker.Broker
 	proxy      proxy.Proxy
+	stats      *stats.Aggregator
+	readOnly   bool
+	maxBody    int                         // largest replay body accepted
+	grpc       http.Handler                // set by WithGRPC
+	ready      func(context.Context) error // set by WithReadiness
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithStats gives the server the daemon's stats, so that clearing resets them
+// too.
+func WithStats(a *stats.Aggregator) Option {
+	return func(s *Server) {
+		s.stats = a
+	}
+}
+
+// WithReadOnly rejects the endpoints that act on the daemon, replay and
+// clear, with 403 Forbidden.
+func WithReadOnly() Option {
+	return func(s *Server) {
+		s.readOnly = true
+	}
+}
+
+// WithMaxCaptureSize tells the server the proxy's capture size, set with
+// proxy.WithMaxCaptureSize, so that every captured request can be replayed.
+// Larger replay bodies are rejected. Values below one keep
+// proxy.MaxCaptureSize.
+func WithMaxCaptureSize(n int) Option {
+	return func(s *Server) {
+		if n > 0 {
+			s.maxBody = n
+		}
+	}
+}
+
+// WithGRPC also serves gRPC on the web server's port by handing gRPC requests
+// to h, typically the daemon's TapService, so that TUI clients and browsers
+// can share one port. The server then accepts HTTP/2 over cleartext (h2c),
+// which gRPC requires.
+func WithGRPC(h http.Handler) Option {
+	return func(s *Server) {
+		s.grpc = h
+	}
 }
 
 // New creates a new web Server backed by the given Broker and Proxy.
-func New(b *broker.Broker, p proxy.Proxy) *Server {
+func New(b *broker.Broker, p proxy.Proxy, opts ...Option) *Server {
 	s := &Server{
-		broker: b,
-		proxy:  p,
+		broker:  b,
+		proxy:   p,
+		maxBody: proxy.MaxCaptureSize,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
 
 	mux := http.NewServeMux()
@@ -41,15 +98,46 @@ func New(b *broker.Broker, p proxy.Proxy) *Server {
 	}
 	mux.Handle("GET /", http.FileServer(http.FS(sub)))
 	mux.HandleFunc("GET /api/events", s.handleSSE)
+	mux.HandleFunc("GET /api/events/history", s.handleHistory)
+	mux.HandleFunc("GET /api/events/{id}", s.handleEvent)
+	mux.HandleFunc("GET /api/stats", s.handleStats)
+	mux.HandleFunc("GET /api/summary", s.handleSummary)
 	mux.HandleFunc("POST /api/replay", s.handleReplay)
+	mux.HandleFunc("POST /api/clear", s.handleClear)
+	mux.HandleFunc("GET /api/schema", s.handleSchema)
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
+
+	api := compressAPI(mux)
+	handler := api
+	if s.grpc != nil {
+		grpcHandler := s.grpc
+		handler = h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isGRPCRequest(r) {
+				grpcHandler.ServeHTTP(w, r)
+				return
+			}
+			api.ServeHTTP(w, r)
+		}), &http2.Server{})
+	}
 
 	s.httpServer = &http.Server{
-		Handler:           mux,
+		Handler:           handler,
 		ReadHeaderTimeout: 10 * time.Second,
 	}
 	return s
 }
 
+// isGRPCRequest reports whether r is a native gRPC call. gRPC-Web and Connect
+// requests are left to the web handlers.
+func isGRPCRequest(r *http.Request) bool {
+	if r.ProtoMajor != 2 {
+		return false
+	}
+	ct := r.Header.Get("Content-Type")
+	return ct == "application/grpc" || strings.HasPrefix(ct, "application/grpc+")
+}
+
 // Serve starts the HTTP server on the given listener.
 func (s *Server) Serve(lis net.Listener) error {
 	if err := s.httpServer.Serve(lis); err != nil && err != http.ErrServerClosed {
@@ -71,35 +159,66 @@ func (s *Server) Handler() http.Handler {
 	return s.httpServer.Handler
 }
 
-type eventJSON struct {
-	ID              string            `json:"id"`
-	Method          string            `json:"method"`
-	CallType        string            `json:"call_type"`
-	Protocol        string            `json:"protocol"`
-	StartTime       string            `json:"start_time"`
-	DurationMs      float64           `json:"duration_ms"`
-	Status          int32             `json:"status"`
-	Error           string            `json:"error,omitempty"`
-	RequestHeaders  map[string]string `json:"request_headers,omitempty"`
-	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
-	RequestBody     string            `json:"request_body,omitempty"`
-	ResponseBody    string            `json:"response_body,omitempty"`
-}
-
-func eventToJSON(ev proxy.Event) eventJSON {
-	return eventJSON{
-		ID:              ev.ID,
-		Method:          ev.Method,
-		CallType:        ev.CallType.String(),
-		Protocol:        ev.Protocol.String(),
-		StartTime:       ev.StartTime.Format(time.RFC3339Nano),
-		DurationMs:      float64(ev.Duration.Microseconds()) / 1000,
-		Status:          ev.Status,
-		Error:           ev.Error,
-		RequestHeaders:  flattenHeaders(ev.RequestHeaders),
-		ResponseHeaders: flattenHeaders(ev.ResponseHeaders),
-		RequestBody:     encodeBody(ev.RequestBody),
-		ResponseBody:    encodeBody(ev.ResponseBody),
+// EventJSON is the JSON representation of a proxy.Event used by the web API
+// and by external integrations such as webhooks.
+type EventJSON struct {
+	ID               string            `json:"id"`
+	Phase            string            `json:"phase"`
+	Method           string            `json:"method"`
+	CallType         string            `json:"call_type"`
+	Protocol         string            `json:"protocol"`
+	StartTime        string            `json:"start_time"`
+	DurationMs       float64           `json:"duration_ms"`
+	Status           int32             `json:"status"`
+	StatusName       string            `json:"status_name"`
+	Error            string            `json:"error,omitempty"`
+	RequestHeaders   map[string]string `json:"request_headers,omitempty"`
+	ResponseHeaders  map[string]string `json:"response_headers,omitempty"`
+	ResponseTrailers map[string]string `json:"response_trailers,omitempty"`
+	RequestBody      string            `json:"request_body,omitempty" schema:"base64"`
+	ResponseBody     string            `json:"response_body,omitempty" schema:"base64"`
+	RequestEncoding  string            `json:"request_encoding,omitempty"`
+	ResponseEncoding string            `json:"response_encoding,omitempty"`
+
+	BodyCaptureDisabled bool    `json:"body_capture_disabled,omitempty"`
+	BodyTruncated       bool    `json:"body_truncated,omitempty"`
+	Upstream            string  `json:"upstream,omitempty"`
+	DeadlineMs          float64 `json:"deadline_ms,omitempty"`
+	PeerAddr            string  `json:"peer_addr,omitempty"`
+	Authority           string  `json:"authority,omitempty"`
+	CorrelationID       string  `json:"correlation_id,omitempty"`
+	TTFBMs              float64 `json:"ttfb_ms,omitempty"` // time to the first response bytes
+}
+
+// EventToJSON converts ev to its JSON representation.
+func EventToJSON(ev proxy.Event) EventJSON {
+	return EventJSON{
+		ID:               ev.ID,
+		Phase:            ev.Phase.String(),
+		Method:           ev.Method,
+		CallType:         ev.CallType.String(),
+		Protocol:         ev.Protocol.String(),
+		StartTime:        ev.StartTime.Format(time.RFC3339Nano),
+		DurationMs:       float64(ev.Duration.Microseconds()) / 1000,
+		Status:           ev.Status,
+		StatusName:       proxy.FormatStatus(ev.Status),
+		Error:            ev.Error,
+		RequestHeaders:   flattenHeaders(ev.RequestHeaders),
+		ResponseHeaders:  flattenHeaders(ev.ResponseHeaders),
+		ResponseTrailers: flattenHeaders(ev.ResponseTrailers),
+		RequestBody:      encodeBody(ev.RequestBody),
+		ResponseBody:     encodeBody(ev.ResponseBody),
+		RequestEncoding:  ev.RequestEncoding,
+		ResponseEncoding: ev.ResponseEncoding,
+
+		BodyCaptureDisabled: ev.BodyCaptureDisabled,
+		BodyTruncated:       ev.BodyTruncated,
+		Upstream:            ev.Upstream,
+		DeadlineMs:          float64(ev.Deadline.Microseconds()) / 1000,
+		PeerAddr:            ev.PeerAddr,
+		Authority:           ev.Authority,
+		CorrelationID:       ev.CorrelationID,
+		TTFBMs:              float64(ev.TimeToFirstByte.Microseconds()) / 1000,
 	}
 }
 
@@ -121,12 +240,74 @@ func encodeBody(data []byte) string {
 	return base64.StdEncoding.EncodeToString(data)
 }
 
+// decodedBodies holds the bodies of an event decoded from protobuf wire
+// format as by proxy.ProtoWireToJSON, so that clients need not decode them
+// themselves. A body that is empty or not protobuf is left out.
+type decodedBodies struct {
+	DecodedRequest  json.RawMessage `json:"decoded_request,omitempty"`
+	DecodedResponse json.RawMessage `json:"decoded_response,omitempty"`
+}
+
+func decodeBodies(ev proxy.Event) decodedBodies {
+	return decodedBodies{
+		DecodedRequest:  decodeBody(ev.RequestBody),
+		DecodedResponse: decodeBody(ev.ResponseBody),
+	}
+}
+
+func decodeBody(data []byte) json.RawMessage {
+	if len(data) == 0 {
+		return nil
+	}
+	j, err := proxy.ProtoWireToJSON(data)
+	if err != nil {
+		return nil
+	}
+	return j
+}
+
+// summarize strips ev down to what a list of calls shows, leaving out its
+// headers and bodies, which clients can fetch from /api/events/{id}.
+func summarize(ev proxy.Event) proxy.Event {
+	ev.RequestHeaders, ev.ResponseHeaders, ev.ResponseTrailers = nil, nil, nil
+	ev.RequestBody, ev.ResponseBody = nil, nil
+	return ev
+}
+
+// parseFields reads the fields query parameter of /api/events: "full", the
+// default, or "summary".
+func parseFields(s string) (summary bool, err error) {
+	switch s {
+	case "", "full":
+		return false, nil
+	case "summary":
+		return true, nil
+	}
+	return false, fmt.Errorf("fields must be full or summary, got %q", s)
+}
+
+// handleSSE streams the backlog and then new events. A q filter expression
+// (see package filter) streams only the events it matches, and fields=summary
+// streams them without headers and bodies.
 func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
 	flusher, ok := w.(http.Flusher)
 	if !ok {
 		http.Error(w, "streaming not supported", http.StatusInternalServerError)
 		return
 	}
+	f, err := filter.Parse(r.URL.Query().Get("q"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	summary, err := parseFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	matches := func(ev proxy.Event) bool {
+		return f.Empty() || f.Evaluate(filter.FromEvent(ev))
+	}
 
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
@@ -134,9 +315,16 @@ func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	flusher.Flush()
 
-	ch, unsub := s.broker.Subscribe()
+	backlog, ch, unsub := s.broker.SubscribeWithBacklog()
 	defer unsub()
 
+	for _, ev := range backlog {
+		if matches(ev) {
+			writeSSE(w, ev, summary)
+		}
+	}
+	flusher.Flush()
+
 	ctx := r.Context()
 	for {
 		select {
@@ -146,28 +334,68 @@ func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
 			if !ok {
 				return
 			}
-			data, err := json.Marshal(eventToJSON(ev))
-			if err != nil {
+			if !matches(ev) {
 				continue
 			}
-			fmt.Fprintf(w, "data: %s\n\n", data)
+			writeSSE(w, ev, summary)
 			flusher.Flush()
 		}
 	}
 }
 
+// writeSSE writes ev, summarized when summary is set, as a server-sent event.
+func writeSSE(w http.ResponseWriter, ev proxy.Event, summary bool) {
+	if summary {
+		ev = summarize(ev)
+	}
+	data, err := json.Marshal(EventToJSON(ev))
+	if err != nil {
+		slog.Warn("web: marshal event", "id", ev.ID, "error", err)
+		return
+	}
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
+
+type clearResponse struct {
+	Cleared int    `json:"cleared"`
+	Error   string `json:"error,omitempty"`
+}
+
+// handleClear drops the daemon's event backlog and stats for every client.
+func (s *Server) handleClear(w http.ResponseWriter, _ *http.Request) {
+	if s.readOnly {
+		writeJSON(w, http.StatusForbidden, &clearResponse{Error: "daemon is read-only"})
+		return
+	}
+	n := s.broker.Clear()
+	if s.stats != nil {
+		s.stats.Reset()
+	}
+	slog.Info("web: cleared backlog and stats", "events", n)
+	writeJSON(w, http.StatusOK, &clearResponse{Cleared: n})
+}
+
 type replayRequest struct {
 	Method      string `json:"method"`
-	RequestBody string `json:"request_body"`
+	RequestBody string `json:"request_body" schema:"base64"`
+	Upstream    string `json:"upstream,omitempty"`
+	Protocol    string `json:"protocol,omitempty"`     // e.g. "Connect"; empty for gRPC
+	ContentType string `json:"content_type,omitempty"` // empty for the protocol's protobuf type
 }
 
 type replayResponse struct {
-	Event *eventJSON `json:"event,omitempty"`
-	Error string     `json:"error,omitempty"`
+	Event *EventJSON `json:"event,omitempty"`
+	decodedBodies
+	Error string `json:"error,omitempty"`
 }
 
 func (s *Server) handleReplay(w http.ResponseWriter, r *http.Request) {
-	r.Body = http.MaxBytesReader(w, r.Body, 2*proxy.MaxCaptureSize)
+	if s.readOnly {
+		writeJSON(w, http.StatusForbidden, &replayResponse{Error: "daemon is read-only"})
+		return
+	}
+	// The body travels base64-encoded in JSON, a third larger than itself.
+	r.Body = http.MaxBytesReader(w, r.Body, int64(2*s.maxBody))
 
 	var req replayRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -177,9 +405,9 @@ func (s *Server) handleReplay(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Method == "" || !strings.HasPrefix(req.Method, "/") {
+	if err := proxy.ValidateReplayMethod(s.proxy, req.Method); err != nil {
 		writeJSON(w, http.StatusBadRequest, &replayResponse{
-			Error: "invalid method: must be a non-empty path starting with '/'",
+			Error: err.Error(),
 		})
 		return
 	}
@@ -192,28 +420,60 @@ func (s *Server) handleReplay(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(body) > proxy.MaxCaptureSize {
+	if len(body) > s.maxBody {
 		writeJSON(w, http.StatusBadRequest, &replayResponse{
 			Error: "request body too large",
 		})
 		return
 	}
 
-	ev, err := s.proxy.Replay(r.Context(), req.Method, body)
+	protocol := proxy.ProtocolGRPC
+	if req.Protocol != "" {
+		if protocol, err = proxy.ParseProtocol(req.Protocol); err != nil {
+			writeJSON(w, http.StatusBadRequest, &replayResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+	}
+
+	ev, err := s.proxy.Replay(r.Context(), proxy.ReplayRequest{
+		Method:      req.Method,
+		Body:        body,
+		Upstream:    req.Upstream,
+		Protocol:    protocol,
+		ContentType: req.ContentType,
+	})
+	if errors.Is(err, proxy.ErrUnknownUpstream) || errors.Is(err, proxy.ErrInvalidMethod) ||
+		errors.Is(err, proxy.ErrInvalidContentType) {
+		writeJSON(w, http.StatusBadRequest, &replayResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, proxy.ErrRedirect) {
+		slog.Warn("web: replay", "method", req.Method, "error", err)
+		writeJSON(w, http.StatusBadGateway, &replayResponse{
+			Error: err.Error(),
+		})
+		return
+	}
 	if err != nil {
+		slog.Warn("web: replay", "method", req.Method, "error", err)
 		writeJSON(w, http.StatusInternalServerError, &replayResponse{
 			Error: err.Error(),
 		})
 		return
 	}
 
-	ej := eventToJSON(ev)
-	writeJSON(w, http.StatusOK, &replayResponse{Event: &ej})
+	ej := EventToJSON(ev)
+	writeJSON(w, http.StatusOK, &replayResponse{Event: &ej, decodedBodies: decodeBodies(ev)})
 }
 
-func writeJSON(w http.ResponseWriter, status int, v *replayResponse) {
+func writeJSON(w http.ResponseWriter, status int, v any) {
 	b, err := json.Marshal(v)
 	if err != nil {
+		slog.Error("web: marshal response", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
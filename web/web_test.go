@@ -11,28 +11,31 @@ import (
 	"testing"
 	"time"
 
+	"google.golang.org/protobuf/encoding/protowire"
+
 	"github.com/mickamy/grpc-tap/broker"
 	"github.com/mickamy/grpc-tap/proxy"
+	"github.com/mickamy/grpc-tap/stats"
 	"github.com/mickamy/grpc-tap/web"
 )
 
 type fakeProxy struct {
-	replayFunc func(ctx context.Context, method string, body []byte) (proxy.Event, error)
+	replayFunc func(ctx context.Context, req proxy.ReplayRequest) (proxy.Event, error)
 }
 
 func (f *fakeProxy) ListenAndServe(context.Context) error { return nil }
 func (f *fakeProxy) Events() <-chan proxy.Event           { return nil }
 func (f *fakeProxy) Close() error                         { return nil }
-func (f *fakeProxy) Replay(ctx context.Context, method string, body []byte) (proxy.Event, error) {
+func (f *fakeProxy) Replay(ctx context.Context, req proxy.ReplayRequest) (proxy.Event, error) {
 	if f.replayFunc != nil {
-		return f.replayFunc(ctx, method, body)
+		return f.replayFunc(ctx, req)
 	}
 	return proxy.Event{}, nil
 }
 
-func newTestServer(t *testing.T, b *broker.Broker, p proxy.Proxy) *httptest.Server {
+func newTestServer(t *testing.T, b *broker.Broker, p proxy.Proxy, opts ...web.Option) *httptest.Server {
 	t.Helper()
-	srv := web.New(b, p)
+	srv := web.New(b, p, opts...)
 	ts := httptest.NewServer(srv.Handler())
 	t.Cleanup(ts.Close)
 	return ts
@@ -41,11 +44,19 @@ func newTestServer(t *testing.T, b *broker.Broker, p proxy.Proxy) *httptest.Serv
 // doPost sends a POST to /api/replay and returns the response.
 func doPost(
 	t *testing.T, ts *httptest.Server, body string,
+) *http.Response {
+	t.Helper()
+	return doPostTo(t, ts, "/api/replay", body)
+}
+
+// doPostTo sends a POST to path and returns the response.
+func doPostTo(
+	t *testing.T, ts *httptest.Server, path, body string,
 ) *http.Response {
 	t.Helper()
 	req, err := http.NewRequestWithContext(
 		t.Context(), http.MethodPost,
-		ts.URL+"/api/replay", strings.NewReader(body),
+		ts.URL+path, strings.NewReader(body),
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -153,16 +164,16 @@ func TestReplay(t *testing.T) {
 
 	b := broker.New(8)
 	fp := &fakeProxy{
-		replayFunc: func(_ context.Context, method string, body []byte) (proxy.Event, error) {
+		replayFunc: func(_ context.Context, req proxy.ReplayRequest) (proxy.Event, error) {
 			return proxy.Event{
 				ID:           "replay-1",
-				Method:       method,
+				Method:       req.Method,
 				CallType:     proxy.Unary,
 				Protocol:     proxy.ProtocolGRPC,
 				StartTime:    time.Now(),
 				Duration:     5 * time.Millisecond,
 				Status:       0,
-				RequestBody:  body,
+				RequestBody:  req.Body,
 				ResponseBody: []byte("resp"),
 			}, nil
 		},
@@ -202,6 +213,47 @@ func TestReplay(t *testing.T) {
 	}
 }
 
+// helloWire is field 1 = "hello" in protobuf wire format.
+func helloWire() []byte {
+	b := protowire.AppendTag(nil, 1, protowire.BytesType)
+	return protowire.AppendString(b, "hello")
+}
+
+func TestReplay_DecodedBodies(t *testing.T) {
+	t.Parallel()
+
+	fp := &fakeProxy{
+		replayFunc: func(_ context.Context, req proxy.ReplayRequest) (proxy.Event, error) {
+			return proxy.Event{
+				ID:           "replay-1",
+				Method:       req.Method,
+				RequestBody:  req.Body,
+				ResponseBody: []byte{0xff}, // not protobuf
+			}, nil
+		},
+	}
+	ts := newTestServer(t, broker.New(8), fp)
+
+	payload := `{"method":"/test.Service/Hello","request_body":"` + base64.StdEncoding.EncodeToString(helloWire()) + `"}`
+	resp := doPost(t, ts, payload)
+	defer func() { _ = resp.Body.Close() }()
+
+	var result map[string]json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(result["decoded_request"], &decoded); err != nil {
+		t.Fatalf("decoded_request = %s: %v", result["decoded_request"], err)
+	}
+	if decoded["1"] != "hello" {
+		t.Errorf("decoded_request = %v, want field 1 = hello", decoded)
+	}
+	if got, ok := result["decoded_response"]; ok {
+		t.Errorf("decoded_response = %s, want it left out for a body that is not protobuf", got)
+	}
+}
+
 func TestReplay_InvalidJSON(t *testing.T) {
 	t.Parallel()
 
@@ -266,3 +318,309 @@ func TestReplay_BodyTooLarge(t *testing.T) {
 		t.Fatalf("status = %d, want 400 or 413", resp.StatusCode)
 	}
 }
+
+func TestReplay_MaxCaptureSize(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t, broker.New(8), &fakeProxy{}, web.WithMaxCaptureSize(4*proxy.MaxCaptureSize))
+
+	for size, want := range map[int]int{
+		4 * proxy.MaxCaptureSize:   http.StatusOK,
+		4*proxy.MaxCaptureSize + 1: http.StatusBadRequest,
+	} {
+		body := base64.StdEncoding.EncodeToString(make([]byte, size))
+		resp := doPost(t, ts, `{"method":"/test.Service/Hello","request_body":"`+body+`"}`)
+		_ = resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("%d byte body: status = %d, want %d", size, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestReplay_UnknownUpstream(t *testing.T) {
+	t.Parallel()
+
+	p, err := proxy.New(":0", "http://localhost:1", proxy.WithReplayUpstream("staging", "http://localhost:2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := newTestServer(t, broker.New(8), p)
+	resp := doPost(t, ts, `{"method":"/test.Service/Hello","request_body":"","upstream":"prod"}`)
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestReplay_Protocol(t *testing.T) {
+	t.Parallel()
+
+	got := make(chan proxy.ReplayRequest, 1)
+	fp := &fakeProxy{
+		replayFunc: func(_ context.Context, req proxy.ReplayRequest) (proxy.Event, error) {
+			got <- req
+			return proxy.Event{Method: req.Method, Protocol: req.Protocol}, nil
+		},
+	}
+	ts := newTestServer(t, broker.New(8), fp)
+
+	resp := doPost(t, ts, `{"method":"/test.Service/Hello","request_body":"","protocol":"Connect","content_type":"application/json"}`)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if req := <-got; req.Protocol != proxy.ProtocolConnect || req.ContentType != "application/json" {
+		t.Errorf("replayed with %v/%q, want Connect/application/json", req.Protocol, req.ContentType)
+	}
+
+	resp = doPost(t, ts, `{"method":"/test.Service/Hello","request_body":""}`)
+	_ = resp.Body.Close()
+	if req := <-got; req.Protocol != proxy.ProtocolGRPC || req.ContentType != "" {
+		t.Errorf("replayed with %v/%q by default, want gRPC", req.Protocol, req.ContentType)
+	}
+
+	resp = doPost(t, ts, `{"method":"/test.Service/Hello","request_body":"","protocol":"thrift"}`)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unknown protocol: status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	p, err := proxy.New(":0", "http://localhost:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp = doPost(t, newTestServer(t, broker.New(8), p),
+		`{"method":"/test.Service/Hello","request_body":"","protocol":"gRPC","content_type":"application/json"}`)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("mismatched content type: status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestReplay_MaliciousMethod(t *testing.T) {
+	t.Parallel()
+
+	p, err := proxy.New(":0", "http://localhost:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := newTestServer(t, broker.New(8), p)
+
+	for _, method := range []string{"/../admin", "/http://169.254.169.254/latest", "//evil.example.com/x"} {
+		resp := doPost(t, ts, `{"method":"`+method+`","request_body":""}`)
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("method %q: status = %d, want %d", method, resp.StatusCode, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestSSE_Backlog(t *testing.T) {
+	t.Parallel()
+
+	b := broker.New(8, broker.WithBacklog(8))
+	b.Publish(proxy.Event{ID: "old-1", Method: "/test.Service/Hello"})
+	ts := newTestServer(t, b, &fakeProxy{})
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, ts.URL+"/api/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var got map[string]any
+		if err := json.Unmarshal([]byte(data), &got); err != nil {
+			t.Fatalf("invalid JSON in SSE event: %v", err)
+		}
+		if got["id"] != "old-1" {
+			t.Errorf("first event id = %v, want old-1", got["id"])
+		}
+		return
+	}
+	t.Fatalf("no SSE event: %v", scanner.Err())
+}
+
+func TestSSE_Filter(t *testing.T) {
+	t.Parallel()
+
+	b := broker.New(8, broker.WithBacklog(8))
+	b.Publish(proxy.Event{ID: "ok-1", Method: "/test.Service/Hello"})
+	b.Publish(proxy.Event{ID: "err-1", Method: "/test.Service/Hello", Status: 14})
+	ts := newTestServer(t, b, &fakeProxy{})
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, ts.URL+"/api/events?q=code:unavailable", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var got map[string]any
+		if err := json.Unmarshal([]byte(data), &got); err != nil {
+			t.Fatalf("invalid JSON in SSE event: %v", err)
+		}
+		if got["id"] != "err-1" {
+			t.Errorf("first event id = %v, want err-1", got["id"])
+		}
+		return
+	}
+	t.Fatalf("no SSE event: %v", scanner.Err())
+}
+
+func TestSSE_InvalidFilter(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer(t, broker.New(8), &fakeProxy{})
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, ts.URL+"/api/events?q=nope:x", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestSSE_Summary(t *testing.T) {
+	t.Parallel()
+
+	b := broker.New(8, broker.WithBacklog(8))
+	b.Publish(proxy.Event{
+		ID:               "1",
+		Method:           "/test.Service/Hello",
+		Status:           14,
+		Duration:         5 * time.Millisecond,
+		RequestHeaders:   http.Header{"X-Req": {"1"}},
+		ResponseHeaders:  http.Header{"X-Resp": {"1"}},
+		ResponseTrailers: http.Header{"Grpc-Status": {"14"}},
+		RequestBody:      helloWire(),
+		ResponseBody:     []byte("resp"),
+	})
+	ts := newTestServer(t, b, &fakeProxy{})
+
+	for _, query := range []string{"?fields=bodies", "?fields=SUMMARY"} {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, ts.URL+"/api/events"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", query, resp.StatusCode, http.StatusBadRequest)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, ts.URL+"/api/events?fields=summary", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var got map[string]any
+		if err := json.Unmarshal([]byte(data), &got); err != nil {
+			t.Fatalf("invalid JSON in SSE event: %v", err)
+		}
+		if got["id"] != "1" || got["method"] != "/test.Service/Hello" || got["status"] != float64(14) ||
+			got["duration_ms"] != float64(5) || got["start_time"] == nil || got["protocol"] == nil {
+			t.Errorf("summary = %v, want id, method, protocol, status, duration and time", got)
+		}
+		for _, field := range []string{
+			"request_body", "response_body", "request_headers", "response_headers", "response_trailers",
+		} {
+			if _, ok := got[field]; ok {
+				t.Errorf("summary has %s", field)
+			}
+		}
+		return
+	}
+	t.Fatalf("no SSE event: %v", scanner.Err())
+}
+
+func TestClear(t *testing.T) {
+	t.Parallel()
+
+	b := broker.New(8, broker.WithBacklog(8))
+	st := stats.New(time.Minute)
+	ev := proxy.Event{ID: "1", Method: "/test.Service/Hello"}
+	b.Publish(ev)
+	st.Add(ev)
+	ts := newTestServer(t, b, &fakeProxy{}, web.WithStats(st))
+
+	resp := doPostTo(t, ts, "/api/clear", "")
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	var result struct {
+		Cleared int `json:"cleared"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Cleared != 1 {
+		t.Errorf("cleared = %d, want 1", result.Cleared)
+	}
+	if n := len(b.Backlog()); n != 0 {
+		t.Errorf("backlog = %d events, want 0", n)
+	}
+	if n := len(st.Snapshot(0)); n != 0 {
+		t.Errorf("stats methods = %d, want 0", n)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	t.Parallel()
+
+	b := broker.New(8, broker.WithBacklog(8))
+	b.Publish(proxy.Event{ID: "1", Method: "/test.Service/Hello"})
+	ts := newTestServer(t, b, &fakeProxy{}, web.WithReadOnly())
+
+	for _, path := range []string{"/api/clear", "/api/replay"} {
+		resp := doPostTo(t, ts, path, `{"method":"/test.Service/Hello","request_body":""}`)
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("%s: status = %d, want %d", path, resp.StatusCode, http.StatusForbidden)
+		}
+	}
+	if n := len(b.Backlog()); n != 1 {
+		t.Errorf("backlog = %d events, want 1", n)
+	}
+}